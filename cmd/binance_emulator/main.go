@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"os"
+	"time"
+
+	"market-maker-go/gateway"
+	"market-maker-go/test/emulator"
+)
+
+// 启动本地 Binance USDⓈ-M 模拟交易所：REST + combined stream WS + WS 交易 API（/ws-fapi/v1），
+// 并用随机游走驱动盘口与成交，便于 runner 离线联调（gateway.baseURL/wsEndpoint 指向本地地址）。
+func main() {
+	addr := flag.String("addr", "127.0.0.1:18080", "监听地址")
+	symbol := flag.String("symbol", "ETHUSDC", "合约代码")
+	mid := flag.Float64("mid", 3000, "初始中间价")
+	tick := flag.Float64("tick", 0.01, "价格最小变动")
+	step := flag.Float64("step", 0.001, "数量最小变动")
+	levels := flag.Int("levels", 20, "每侧外部挂单档数")
+	levelQty := flag.Float64("levelQty", 2, "每档外部挂单数量")
+	interval := flag.Duration("interval", 200*time.Millisecond, "盘口刷新间隔")
+	volTicks := flag.Float64("volTicks", 3, "每次刷新中间价波动（tick 标准差）")
+	tradeQty := flag.Float64("tradeQty", 0.05, "每次刷新打印的外部成交量（0 关闭）")
+	makerFee := flag.Float64("makerFee", 0, "maker 费率")
+	takerFee := flag.Float64("takerFee", 0.0004, "taker 费率")
+	flag.Parse()
+
+	apiKey := os.Getenv("MM_GATEWAY_API_KEY")
+	secret := os.Getenv("MM_GATEWAY_API_SECRET")
+	if apiKey == "" || secret == "" {
+		log.Fatalf("请设置 MM_GATEWAY_API_KEY/MM_GATEWAY_API_SECRET（与 runner 配置一致）")
+	}
+
+	ex := emulator.New(emulator.Config{
+		APIKey:       apiKey,
+		Secret:       secret,
+		MakerFeeRate: *makerFee,
+		TakerFeeRate: *takerFee,
+		Symbols: []emulator.SymbolSpec{{
+			Symbol:   *symbol,
+			TickSize: *tick,
+			StepSize: *step,
+		}},
+	})
+
+	go func() {
+		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+		price := *mid
+		ticker := time.NewTicker(*interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			price += rng.NormFloat64() * *volTicks * *tick
+			price = math.Max(price, *tick*float64(*levels+1))
+			bids, asks := ladder(price, *tick, *levels, *levelQty)
+			ex.SetBook(*symbol, bids, asks)
+			if *tradeQty > 0 {
+				px := bids[0].Price
+				if rng.Intn(2) == 0 {
+					px = asks[0].Price
+				}
+				ex.Trade(*symbol, px, *tradeQty)
+			}
+		}
+	}()
+
+	log.Printf("binance emulator listening on http://%s (ws://%s) symbol=%s", *addr, *addr, *symbol)
+	if err := http.ListenAndServe(*addr, ex.Handler()); err != nil {
+		log.Fatalf("emulator exit: %v", err)
+	}
+}
+
+// ladder 以 mid 为中心生成对称的外部挂单，最优价距 mid 至少 1 tick。
+func ladder(mid, tick float64, n int, qty float64) ([]gateway.DepthLevel, []gateway.DepthLevel) {
+	bestBid := math.Floor(mid/tick-0.5) * tick
+	bestAsk := bestBid + 2*tick
+	bids := make([]gateway.DepthLevel, 0, n)
+	asks := make([]gateway.DepthLevel, 0, n)
+	for i := 0; i < n; i++ {
+		bids = append(bids, gateway.DepthLevel{Price: round(bestBid-float64(i)*tick, tick), Qty: qty})
+		asks = append(asks, gateway.DepthLevel{Price: round(bestAsk+float64(i)*tick, tick), Qty: qty})
+	}
+	return bids, asks
+}
+
+func round(v, tick float64) float64 {
+	return math.Round(v/tick) * tick
+}
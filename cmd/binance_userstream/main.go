@@ -1,7 +1,6 @@
 package main
 
 import (
-	"context"
 	"flag"
 	"fmt"
 	"log"
@@ -9,7 +8,6 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
-	"time"
 
 	"market-maker-go/config"
 	"market-maker-go/gateway"
@@ -27,9 +25,6 @@ func main() {
 		log.Fatalf("加载配置失败: %v", err)
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
 	// 初始化 order manager 和 inventory
 	mgr := order.NewManager(nil)
 	symbolConstraints := make(map[string]order.SymbolConstraints)
@@ -50,16 +45,25 @@ func main() {
 		APIKey:     cfg.Gateway.APIKey,
 		HTTPClient: gateway.NewListenKeyHTTPClient(),
 	}
-	listenKey, err := lkClient.NewListenKey()
+	ws := gateway.NewBinanceWSReal()
+	// listenKey 续期失败或过期时自动换 key 并重订阅用户流
+	lkManager := gateway.NewListenKeyManager(lkClient)
+	lkManager.OnRotate = func(oldKey, newKey string) {
+		log.Printf("listenKey 轮换 %s -> %s", oldKey, newKey)
+		if err := ws.SubscribeUserData(newKey); err != nil {
+			log.Printf("重新订阅用户流失败: %v", err)
+		}
+	}
+	lkManager.OnResync = func(reason string) {
+		log.Printf("用户流曾中断（%s），期间的订单/仓位变化需自行核对", reason)
+	}
+	listenKey, err := lkManager.Start()
 	if err != nil {
 		log.Fatalf("创建 listenKey 失败: %v", err)
 	}
 	log.Printf("listenKey=%s", listenKey)
-	defer lkClient.CloseListenKey(listenKey)
-
-	go keepAliveLoop(ctx, lkClient, listenKey)
+	defer lkManager.Close()
 
-	ws := gateway.NewBinanceWSReal()
 	if *depthSymbol != "" {
 		if err := ws.SubscribeDepth(strings.ToUpper(*depthSymbol)); err != nil {
 			log.Fatalf("订阅 depth 失败: %v", err)
@@ -86,42 +90,25 @@ func main() {
 		OnAccountUpdate: func(a gateway.AccountUpdate) {
 			for _, p := range a.Positions {
 				if _, ok := symbolConstraints[p.Symbol]; ok {
-					inv.SetExposure(p.PositionAmt, p.EntryPrice)
+					inv.ApplyPosition(p.PositionSide, p.PositionAmt, p.EntryPrice)
 				}
 			}
 			log.Printf("ACCOUNT event: %+v", a)
 		},
+		OnListenKeyExpired: lkManager.HandleExpired,
 	}
 	handler := &streamHandler{user: userHandler}
 
 	go func() {
 		if err := ws.Run(handler); err != nil {
 			log.Printf("WS 运行失败: %v", err)
-			cancel()
 		}
 	}()
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	cancel()
-}
-
-func keepAliveLoop(ctx context.Context, cli *gateway.ListenKeyClient, key string) {
-	ticker := time.NewTicker(30 * time.Minute)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			if err := cli.KeepAlive(key); err != nil {
-				log.Printf("listenKey keepalive err: %v", err)
-			} else {
-				log.Printf("listenKey keepalive 成功")
-			}
-		}
-	}
+	ws.Stop()
 }
 
 type streamHandler struct {
@@ -6,9 +6,14 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+
+	"market-maker-go/config"
+	"market-maker-go/gateway"
+	"market-maker-go/posttrade"
 )
 
 type stats struct {
@@ -37,7 +42,11 @@ func (s *stats) add(side string, price, qty, pnl float64) {
 }
 
 func main() {
+	mode := flag.String("mode", "log", "统计来源：log（解析 runner 日志）或 ledger（资金流水账本，含资金费与手续费）")
 	logPath := flag.String("log", "/var/log/market-maker/runner.log", "runner 日志路径")
+	ledgerPath := flag.String("ledger", "data/income_ledger.jsonl", "资金流水账本路径（mode=ledger）")
+	syncLedger := flag.Bool("sync", false, "统计前从 /fapi/v1/income 增量同步账本（mode=ledger）")
+	cfgPath := flag.String("config", "configs/config.yaml", "配置文件路径（-sync 时读取 API Key）")
 	symbol := flag.String("symbol", "", "仅统计指定交易对 (默认全量)")
 	sinceStr := flag.String("since", "", "仅统计此时间之后的记录 (RFC3339，例如 2025-11-22T00:00:00Z)")
 	flag.Parse()
@@ -52,6 +61,18 @@ func main() {
 		}
 	}
 
+	if *mode == "ledger" {
+		if err := ledgerReport(*ledgerPath, *cfgPath, *syncLedger, strings.ToUpper(*symbol), since); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *mode != "log" {
+		fmt.Fprintf(os.Stderr, "未知 mode: %s（可选 log/ledger）\n", *mode)
+		os.Exit(1)
+	}
+
 	f, err := os.Open(*logPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "无法读取日志: %v\n", err)
@@ -132,6 +153,90 @@ func main() {
 	fmt.Printf("Realized PnL (来自 Binance 回报): %.6f USDC\n", st.realizedPnL)
 }
 
+// ledgerReport 按资金流水账本输出每个交易对每天、每种资产的真实净盈亏（已实现盈亏 + 手续费 + 资金费），合计按资产分列。
+func ledgerReport(path, cfgPath string, sync bool, symbol string, since time.Time) error {
+	ledger, err := posttrade.OpenIncomeLedger(path)
+	if err != nil {
+		return err
+	}
+	if sync {
+		added, err := syncIncome(ledger, cfgPath, since)
+		if err != nil {
+			return fmt.Errorf("同步资金流水失败: %w", err)
+		}
+		fmt.Printf("同步资金流水: 新增 %d 条\n", added)
+	}
+
+	days := posttrade.SummarizeDaily(ledger.Entries(), symbol, since)
+	fmt.Printf("账本: %s\n", path)
+	fmt.Printf("%-10s  %-10s  %-6s  %14s  %12s  %12s  %14s  %12s\n", "日期", "交易对", "资产", "已实现盈亏", "手续费", "资金费", "净盈亏", "划转")
+	totals := make(map[string]*posttrade.DailyPnL)
+	var assets []string
+	for _, d := range days {
+		sym := d.Symbol
+		if sym == "" {
+			sym = "-"
+		}
+		fmt.Printf("%-10s  %-10s  %-6s  %14.6f  %12.6f  %12.6f  %14.6f  %12.6f\n", d.Date, sym, d.Asset, d.RealizedPnL, d.Commission, d.Funding, d.Net, d.Transfer)
+		total, ok := totals[d.Asset]
+		if !ok {
+			total = &posttrade.DailyPnL{Asset: d.Asset}
+			totals[d.Asset] = total
+			assets = append(assets, d.Asset)
+		}
+		total.RealizedPnL += d.RealizedPnL
+		total.Commission += d.Commission
+		total.Funding += d.Funding
+		total.Net += d.Net
+		total.Transfer += d.Transfer
+	}
+	sort.Strings(assets)
+	for _, asset := range assets {
+		total := totals[asset]
+		fmt.Printf("%-10s  %-10s  %-6s  %14.6f  %12.6f  %12.6f  %14.6f  %12.6f\n", "合计", "", asset, total.RealizedPnL, total.Commission, total.Funding, total.Net, total.Transfer)
+	}
+	return nil
+}
+
+// syncIncome 从账本最新一条的时间（空账本时从 since，均为空时取交易所默认的最近 7 天）拉取全部类型的流水。
+func syncIncome(ledger *posttrade.IncomeLedger, cfgPath string, since time.Time) (int, error) {
+	cfg, err := config.LoadWithEnvOverrides(cfgPath)
+	if err != nil {
+		return 0, fmt.Errorf("加载配置失败: %w", err)
+	}
+	client := &gateway.BinanceRESTClient{
+		BaseURL:      cfg.Gateway.BaseURL,
+		APIKey:       cfg.Gateway.APIKey,
+		Secret:       cfg.Gateway.APISecret,
+		HTTPClient:   gateway.NewDefaultHTTPClient(),
+		RecvWindowMs: 5000,
+	}
+	start := ledger.LastTime()
+	if start == 0 && !since.IsZero() {
+		start = since.UnixMilli()
+	}
+	// 翻页中途失败时已取回的部分照常入账，下次从账本末尾续传
+	records, fetchErr := client.IncomeHistory(gateway.IncomeQuery{StartTime: start})
+	entries := make([]posttrade.IncomeEntry, 0, len(records))
+	for _, r := range records {
+		entries = append(entries, posttrade.IncomeEntry{
+			TranID:  r.TranID,
+			Symbol:  r.Symbol,
+			Type:    r.IncomeType,
+			Amount:  r.Income,
+			Asset:   r.Asset,
+			Time:    r.Time,
+			TradeID: r.TradeID,
+			Info:    r.Info,
+		})
+	}
+	added, err := ledger.Append(entries)
+	if err != nil {
+		return added, err
+	}
+	return added, fetchErr
+}
+
 func toFloat(v interface{}) float64 {
 	switch val := v.(type) {
 	case float64:
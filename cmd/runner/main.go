@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -10,6 +11,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -35,7 +37,19 @@ func main() {
 	restRate := flag.Float64("restRate", 5, "REST 限流：每秒令牌数")
 	restBurst := flag.Int("restBurst", 10, "REST 限流：最大突发令牌数")
 	metricsAddr := flag.String("metricsAddr", ":8080", "address for prometheus metrics endpoint")
+	capturePath := flag.String("capture", "", "抓包文件：记录全部 WS 入站帧与 REST 请求/响应（脱敏），按大小轮转")
+	captureMaxMB := flag.Int("captureMaxMB", 64, "抓包单文件上限（MB）")
+	captureFiles := flag.Int("captureFiles", 10, "抓包保留的历史文件数")
+	replayPath := flag.String("replay", "", "回放抓包文件：以录制的 WS 帧与 REST 响应代替交易所连接")
+	replaySpeed := flag.Float64("replaySpeed", 1, "回放倍速：1 为原速，<=0 为尽快回放")
+	runID := flag.String("runId", "", "clientOrderId 中的 run 标识（最多 5 位字母数字），为空时随机生成")
+	asyncOrders := flag.Bool("asyncOrders", false, "动态挂单/撤单走异步管道，不等待交易所响应")
+	journalPath := flag.String("journal", "", "订单日志路径，默认 data/journal/<SYMBOL>.jsonl；回放时不记录")
+	recoveryMode := flag.String("recovery", "adopt", "启动恢复：adopt 接管日志中有记录的挂单，cancel 撤销本系统全部挂单，off 不处理")
 	flag.Parse()
+	replaying := *replayPath != ""
+	// 回放时行情与查询响应来自抓包，dryRun 只决定是否把订单交给（录制的）下单接口
+	connected := !*dryRun || replaying
 	if *configPath == "" {
 		// Try to find config in common locations
 		possiblePaths := []string{
@@ -76,6 +90,10 @@ func main() {
 	if !ok {
 		log.Fatalf("symbol %s not found in config", symbolUpper)
 	}
+	if strings.EqualFold(symConf.Risk.OrphanPolicy, order.OrphanAdopt) {
+		// 对账接管的订单只登记到 Manager，不进报价槽位也没有交易所订单号映射，Runner 无法继续管理
+		log.Fatalf("symbol %s risk.orphanPolicy=adopt is not supported by the runner, use cancel or report", symbolUpper)
+	}
 	
 	// 创建策略工厂
 	factory := strategy.NewStrategyFactory()
@@ -130,6 +148,9 @@ func main() {
 		if symConf.Strategy.HighVolSpreadMultiplier > 0 {
 			asmmConfig.HighVolSpreadMultiplier = symConf.Strategy.HighVolSpreadMultiplier
 		}
+		if symConf.Strategy.VPINToxicThreshold > 0 {
+			asmmConfig.VPINToxicThreshold = symConf.Strategy.VPINToxicThreshold
+		}
 		
 		engine, err = factory.CreateStrategy("asmm", asmmConfig)
 		if err != nil {
@@ -154,14 +175,84 @@ func main() {
 		stratParams = symConf.Strategy
 	}
 
+	// 限流：按接口权重与响应头中的已用额度记账，令牌桶仅用于平滑突发
+	weightLimiter := gateway.NewWeightLimiter(0, 0, 0)
+	weightLimiter.Pacer = gateway.NewTokenBucketLimiter(*restRate, *restBurst)
+	weightLimiter.OnObserve = func(b gateway.RateBudget) {
+		metrics.UpdateRateLimitRemaining(b.Weight1M, b.Orders10S, b.Orders1M)
+	}
 	restClient := &gateway.BinanceRESTClient{
 		BaseURL:      cfg.Gateway.BaseURL,
 		APIKey:       cfg.Gateway.APIKey,
 		Secret:       cfg.Gateway.APISecret,
 		HTTPClient:   gateway.NewDefaultHTTPClient(),
 		RecvWindowMs: 5000,
-		Limiter:      gateway.NewTokenBucketLimiter(*restRate, *restBurst),
+		Limiter:      weightLimiter,
+		Precision:    gateway.NewSymbolPrecision(),
+	}
+	// 抓包：原始 WS 帧与 REST 往返写入轮转文件；回放：REST 由录制响应应答，WS 帧按录制节奏重放
+	var capture *gateway.CaptureWriter
+	var replayFiles []string
+	if replaying {
+		if replayFiles, err = gateway.CaptureFiles(*replayPath); err != nil {
+			log.Fatalf("打开回放文件失败: %v", err)
+		}
+		recs, err := gateway.ReadCapture(gateway.CaptureREST, replayFiles...)
+		if err != nil {
+			log.Fatalf("读取回放文件失败: %v", err)
+		}
+		restClient.HTTPClient = gateway.NewReplayRESTClient(recs).HTTPClient
+		restClient.Limiter = nil
+		logEvent("replay_start", map[string]interface{}{"files": replayFiles, "restRecords": len(recs), "speed": *replaySpeed})
+	} else if *capturePath != "" {
+		if capture, err = gateway.NewCaptureWriter(*capturePath, int64(*captureMaxMB)<<20, *captureFiles); err != nil {
+			log.Fatalf("打开抓包文件失败: %v", err)
+		}
+		defer capture.Close()
+		restClient.HTTPClient = gateway.WithCapture(restClient.HTTPClient, capture)
+		logEvent("capture_start", map[string]interface{}{"path": *capturePath, "maxMB": *captureMaxMB})
+	}
+	// 校时：签名 timestamp 按交易所时间补偿，收到 -1021 时自动重新校时
+	timeSync := gateway.NewTimeSync(cfg.Gateway.BaseURL, gateway.NewDefaultHTTPClient())
+	timeSync.Interval = time.Duration(cfg.Gateway.TimeSyncIntervalSec) * time.Second
+	timeSync.OnSync = func(offset, rtt time.Duration) {
+		metrics.UpdateClockSync(float64(offset.Milliseconds()), float64(rtt.Microseconds())/1000)
+	}
+	restClient.TimeSync = timeSync
+	timeSyncCtx, stopTimeSync := context.WithCancel(context.Background())
+	defer stopTimeSync()
+	if !*dryRun && !replaying {
+		if err := timeSync.Start(timeSyncCtx); err != nil {
+			logEvent("time_sync_error", map[string]interface{}{"error": err.Error()})
+		} else {
+			logEvent("time_sync", map[string]interface{}{"offsetMs": timeSync.Offset().Milliseconds(), "rttMs": timeSync.RTT().Milliseconds()})
+		}
+	}
+	// 下单通道：orderTransport=ws 时走 WebSocket 交易 API，socket 不可用时回退 REST
+	var orderClient gateway.BinanceREST = restClient
+	// 批量下单/撤单走 REST /fapi/v1/batchOrders；WS 通道下逐笔发送以保持低延迟
+	var batchClient gateway.BinanceBatchREST = restClient
+	if strings.EqualFold(cfg.Gateway.OrderTransport, "ws") && !*dryRun && !replaying {
+		wsAPI := gateway.NewBinanceWSAPIClient(cfg.Gateway.APIKey, cfg.Gateway.APISecret, restClient)
+		if cfg.Gateway.WSAPIEndpoint != "" {
+			wsAPI.Endpoint = cfg.Gateway.WSAPIEndpoint
+		}
+		wsAPI.RecvWindowMs = 5000
+		wsAPI.TimeSync = timeSync
+		wsAPI.Precision = restClient.Precision
+		if err := wsAPI.Connect(); err != nil {
+			// 首次连接失败不致命：请求先走 REST，后台自动重连
+			logEvent("ws_api_connect_error", map[string]interface{}{"endpoint": wsAPI.Endpoint, "error": err.Error()})
+		} else {
+			logEvent("ws_api_connected", map[string]interface{}{"endpoint": wsAPI.Endpoint})
+		}
+		defer wsAPI.Close()
+		orderClient = wsAPI
+		batchClient = nil
 	}
+	// 交易所无关的查询/对账走 Venue；批量下单与改单仍直接使用 Binance 客户端
+	venue := gateway.NewBinanceVenue(restClient)
+	venue.Orders = orderClient
 	// 初始化指标收集器
 	mc := &metricsCollector{
 		quotesGenerated: promauto.NewCounterVec(prometheus.CounterOpts{
@@ -196,6 +287,14 @@ func main() {
 			Name: "mm_runner_ws_failures_total",
 			Help: "Total number of WebSocket failures",
 		}),
+		wsGaps: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "mm_runner_ws_gaps_total",
+			Help: "Total number of WebSocket data gaps (reconnect or silent stream)",
+		}),
+		lkRotations: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "mm_runner_listenkey_rotations_total",
+			Help: "Total number of listenKey rotations after expiry or keepalive failure",
+		}),
 		midPrice: promauto.NewGauge(prometheus.GaugeOpts{
 			Name: "mm_runner_mid_price",
 			Help: "Current mid price",
@@ -220,7 +319,8 @@ func main() {
 	
 	// 初始化订单网关
 	gw := &restOrderGateway{
-		client:           restClient,
+		client:           orderClient,
+		batch:            batchClient,
 		dryRun:           *dryRun,
 		symbolByID:       map[string]string{symbolUpper: symbolUpper},
 		exchangeByClient: map[string]string{symbolUpper: "binance"},
@@ -228,6 +328,13 @@ func main() {
 		metrics:          mc, // 注入 metricsCollector
 	}
 	mgr := order.NewManager(gw)
+	// clientOrderId 由 Manager 统一生成并原样用于下单、撤单、对账与成交匹配
+	mgr.IDs = order.NewClientIDGenerator(*runID)
+	logEvent("client_id_run", map[string]interface{}{"symbol": symbolUpper, "runId": mgr.IDs.RunID})
+	if connected && !*dryRun {
+		// 下单超时/5xx 后按 clientOrderId 查询确认，查无此单才以同一 ID 重发
+		mgr.Lookup = mappingLookup{OrderLookup: gateway.NewOrderQueryAdapter(venue, symbolUpper), gw: gw}
+	}
 	symbolConstraints := make(map[string]order.SymbolConstraints)
 	for sym, sc := range cfg.Symbols {
 		symbolConstraints[strings.ToUpper(sym)] = order.SymbolConstraints{
@@ -238,7 +345,42 @@ func main() {
 			MinNotional: sc.MinNotional,
 		}
 	}
+	// 以 exchangeInfo 的 tick/step 为准，配置值仅作兜底；下单价格/数量按此精度序列化
+	if connected {
+		if infos, err := venue.Instruments(symbolUpper); err != nil {
+			logEvent("exchange_info_error", map[string]interface{}{"symbol": symbolUpper, "error": err.Error()})
+		} else {
+			for _, info := range infos {
+				if !strings.EqualFold(info.Symbol, symbolUpper) {
+					continue
+				}
+				sc := symbolConstraints[symbolUpper]
+				if info.TickSize <= 0 || info.StepSize <= 0 {
+					continue
+				}
+				if sc.TickSize != info.TickSize || sc.StepSize != info.StepSize {
+					logEvent("symbol_filters_override", map[string]interface{}{
+						"symbol":   symbolUpper,
+						"tickSize": info.TickSize,
+						"stepSize": info.StepSize,
+						"cfgTick":  sc.TickSize,
+						"cfgStep":  sc.StepSize,
+					})
+				}
+				sc.TickSize, sc.StepSize = info.TickSize, info.StepSize
+				symbolConstraints[symbolUpper] = sc
+			}
+		}
+	}
+	for sym, sc := range symbolConstraints {
+		restClient.Precision.Set(sym, sc)
+	}
 	mgr.SetConstraints(symbolConstraints)
+	// 多档梯子与静态腿在快速重定价时可能互相穿越，按配置让交易所拦截自成交
+	if stp := strings.ToUpper(symConf.Strategy.SelfTradePrevention); stp != "" {
+		mgr.SetSelfTradePrevention(map[string]string{symbolUpper: stp})
+		logEvent("self_trade_prevention", map[string]interface{}{"symbol": symbolUpper, "mode": stp})
+	}
 
 	inv := &inventory.Tracker{}
 	book := market.NewOrderBook()
@@ -260,10 +402,118 @@ func main() {
 		Inv:      inv,
 		OrderMgr: mgr,
 		Book:     book,
+		RateBudget: weightLimiter,
 	}
 	if sc, ok := symbolConstraints[symbolUpper]; ok {
 		runner.Constraints = sc
 	}
+	if *asyncOrders {
+		runner.AsyncOrders = true
+		mgr.OnComplete = func(c order.Completion) {
+			if c.Err == nil {
+				return
+			}
+			logEvent("async_order_error", map[string]interface{}{
+				"symbol":  c.Order.Symbol,
+				"op":      c.Op,
+				"orderId": c.Order.ID,
+				"side":    c.Order.Side,
+				"status":  c.Order.Status,
+				"error":   c.Err.Error(),
+			})
+		}
+	}
+	// 成交统一经 Manager.ApplyExecution 去重后驱动库存、FillTracker 与 PostTrade
+	mgr.OnFill = func(f order.Fill) {
+		// 本地未登记的订单（如上次进程遗留挂单）同样计入本交易对库存
+		if f.Symbol == symbolUpper {
+			runner.HandleFill(f)
+		}
+		logEvent("fill", map[string]interface{}{
+			"symbol":       f.Symbol,
+			"orderId":      f.OrderID,
+			"tradeId":      f.TradeID,
+			"side":         f.Side,
+			"positionSide": f.PositionSide,
+			"price":        f.Price,
+			"qty":          f.Qty,
+			"filledQty":    f.FilledQty,
+			"remaining":    f.Remaining,
+			"avgPrice":     f.AvgPrice,
+			"fee":          f.Fee,
+			"feeAsset":     f.FeeAsset,
+			"maker":        f.Maker,
+			"known":        f.Known,
+		})
+	}
+	// 账户为双向持仓时多空两腿独立记账、独立报价
+	if connected {
+		if dual, err := restClient.GetDualPosition(); err != nil {
+			logEvent("position_mode_error", map[string]interface{}{"error": err.Error()})
+		} else if dual {
+			runner.HedgeMode = true
+			inv.SetHedgeMode(true)
+			logEvent("position_mode", map[string]interface{}{"dualSidePosition": true})
+		}
+	}
+	// 订单日志：上次进程的挂单在报价开始前接管或撤销，本进程的订单事件逐条落盘
+	if !replaying {
+		if *journalPath == "" {
+			*journalPath = filepath.Join("data", "journal", symbolUpper+".jsonl")
+		}
+		mode := *recoveryMode
+		if !connected || *dryRun {
+			// dry-run 订单不在交易所，不做挂单对账
+			mode = "off"
+		}
+		journal, err := recoverOrders(*journalPath, mode, restClient, venue, gw, mgr, &runner, symbolUpper, inv)
+		if err != nil {
+			log.Fatalf("订单日志恢复失败: %v", err)
+		}
+		defer journal.Close()
+	}
+	// 成交流驱动 VPIN 与 1m Kline；同步回调避免慢消费丢成交
+	vpinBucket := symConf.Strategy.VPINBucketSize
+	if vpinBucket <= 0 {
+		vpinBucket = 100 * symConf.Strategy.BaseSize
+	}
+	vpinBuckets := symConf.Strategy.VPINBuckets
+	if vpinBuckets <= 0 {
+		vpinBuckets = 50
+	}
+	vpinThreshold := symConf.Strategy.VPINToxicThreshold
+	if vpinThreshold <= 0 {
+		vpinThreshold = 0.4
+	}
+	marketPub := market.NewPublisher()
+	marketSvc := market.NewService(marketPub)
+	tradeFlow := &market.TradeFlow{
+		Symbol: symbolUpper,
+		Klines: market.NewKlineAggregator(time.Minute),
+		OnKline: func(k market.Kline) {
+			logEvent("kline_closed", map[string]interface{}{
+				"symbol": symbolUpper, "open": k.Open, "high": k.High, "low": k.Low,
+				"close": k.Close, "volume": k.Volume, "ts": k.Ts.Format(time.RFC3339),
+			})
+		},
+	}
+	if vpinBucket > 0 {
+		vpin := market.NewVPINCalculator(vpinBucket, vpinBuckets, vpinThreshold)
+		tradeFlow.VPIN = vpin
+		runner.VPIN = vpin
+	}
+	marketPub.SubscribeTradeFunc(tradeFlow.OnTrade)
+	// 估值基准：mid（默认）或交易所标记价（与强平/未实现盈亏口径一致）
+	valuationBasis, err := market.ParsePriceBasis(symConf.Risk.ValuationPrice)
+	if err != nil {
+		log.Fatalf("risk.valuationPrice 配置错误: %v", err)
+	}
+	runner.Valuation = marketSvc
+	runner.ValuationBasis = valuationBasis
+	markFn := func() float64 {
+		mark, _ := marketSvc.MarkPrice(symbolUpper)
+		return mark
+	}
 	runner.StopLoss = symConf.Risk.StopLoss
 	runner.ShockThreshold = symConf.Risk.ShockPct
 	runner.ReduceOnlyThreshold = symConf.Risk.ReduceOnlyThreshold
@@ -290,6 +540,8 @@ func main() {
 			Source: risk.InventoryPnL{
 				Tracker: inv,
 				MidFn:   book.Mid,
+				MarkFn:  markFn,
+				Basis:   valuationBasis,
 			},
 		})
 	}
@@ -302,13 +554,16 @@ func main() {
 			Fractions: symConf.Risk.ReduceFractions,
 			Mode:      symConf.Risk.ReduceMode,
 			Cooldown:  time.Duration(symConf.Risk.ReduceCooldownSeconds) * time.Second,
-			PnL:       &risk.InventoryPnL{Tracker: inv, MidFn: book.Mid},
+			PnL:       &risk.InventoryPnL{Tracker: inv, MidFn: book.Mid, MarkFn: markFn, Basis: valuationBasis},
 			Pos:       &trackerInventory{tr: inv},
 			NetMax:    riskConf.NetMax,
 			Base:      stratParams.BaseSize,
 		}
 	}
+	// 风控停机标记供倒计时心跳（独立 goroutine）读取
+	var riskHalted atomic.Bool
 	runner.SetRiskStateListener(func(state sim.RiskState, reason string) {
+		riskHalted.Store(state == sim.RiskStateHalted)
 		fields := map[string]interface{}{
 			"symbol": symbolUpper,
 			"state":  state.String(),
@@ -352,84 +607,92 @@ func main() {
 		logEvent("strategy_adjust", fields)
 	})
 
-	if bid, ask, err := restClient.GetBestBidAsk(symbolUpper, 50); err != nil {
-		logEvent("depth_snapshot_error", map[string]interface{}{"symbol": symbolUpper, "error": err.Error()})
-	} else {
-		book.ApplyDelta(map[float64]float64{bid: 1}, map[float64]float64{ask: 1})
-		logEvent("depth_snapshot", map[string]interface{}{"symbol": symbolUpper, "bid": bid, "ask": ask})
-	}
-
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	// 初始化 listenKey + WS
-	var ws *gateway.BinanceWSReal
-	var listenKey string
+	var ws gateway.WSSession
+	var lkManager *gateway.ListenKeyManager
+	var depthSync *gateway.DepthSynchronizer
+	var heartbeat *gateway.CountdownHeartbeat
 	
-	if !*dryRun {
-		lkClient := &gateway.ListenKeyClient{
-			BaseURL:    cfg.Gateway.BaseURL,
-			APIKey:     cfg.Gateway.APIKey,
-			HTTPClient: gateway.NewListenKeyHTTPClient(),
-		}
-		var err error
-		listenKey, err = lkClient.NewListenKey()
-		if err != nil {
-			log.Fatalf("创建 listenKey 失败: %v", err)
+	if connected {
+		// 回放时用户流帧已在抓包中，无需 listenKey
+		if !replaying {
+			lkClient := &gateway.ListenKeyClient{
+				BaseURL:    cfg.Gateway.BaseURL,
+				APIKey:     cfg.Gateway.APIKey,
+				HTTPClient: gateway.NewListenKeyHTTPClient(),
+			}
+			// listenKey 过期或续期失败时换新 key、重订阅用户流，并用 REST 补齐盲区内的订单与仓位
+			lkManager = gateway.NewListenKeyManager(lkClient)
+			lkManager.OnError = func(err error) {
+				logEvent("listenkey_error", map[string]interface{}{"error": err.Error()})
+			}
+			lkManager.OnRotate = func(oldKey, newKey string) {
+				mc.lkRotations.Inc()
+				logEvent("listenkey_rotated", map[string]interface{}{"old": oldKey, "new": newKey})
+				if err := ws.SubscribeUserData(newKey); err != nil {
+					logEvent("user_stream_resubscribe_error", map[string]interface{}{"listenKey": newKey, "error": err.Error()})
+				}
+			}
+			lkManager.OnResync = func(reason string) {
+				logEvent("user_stream_resync", map[string]interface{}{"symbol": symbolUpper, "reason": reason})
+				resyncUserState(venue, mgr, symbolUpper, inv)
+			}
 		}
-		logEvent("listenkey_created", map[string]interface{}{"listenKey": listenKey})
-		defer lkClient.CloseListenKey(listenKey)
-		go keepAliveLoop(ctx, lkClient, listenKey)
 
-		depthHandler := &gateway.BinanceWSHandler{Book: book}
+		depthSync = gateway.NewDepthSynchronizer(symbolUpper, book, restClient)
+		defer depthSync.Stop()
+		depthSync.OnResync = func(reason string) {
+			logEvent("depth_resync", map[string]interface{}{"symbol": symbolUpper, "reason": reason})
+		}
+		depthSync.OnSynced = func(bid, ask float64) {
+			logEvent("depth_snapshot", map[string]interface{}{"symbol": symbolUpper, "bid": bid, "ask": ask})
+		}
 		userHandler := &gateway.BinanceUserHandler{
 			OnOrderUpdate: func(o gateway.OrderUpdate) {
-				switch o.Status {
-				case "FILLED":
-					_ = mgr.Update(o.ClientOrderID, order.StatusFilled)
-					// 记录成交
-					side := "buy"
-					if o.Side == "SELL" {
-						side = "sell"
-					}
-					metrics.IncrementFill(symbolUpper, side)
-				case "PARTIALLY_FILLED":
-					_ = mgr.Update(o.ClientOrderID, order.StatusPartial)
-					// 部分成交也计数
-					side := "buy"
-					if o.Side == "SELL" {
-						side = "sell"
-					}
-					metrics.IncrementFill(symbolUpper, side)
-				case "CANCELED":
-					_ = mgr.Update(o.ClientOrderID, order.StatusCanceled)
-					metrics.IncrementOrderCanceled(symbolUpper)
-				case "REJECTED":
-					_ = mgr.Update(o.ClientOrderID, order.StatusRejected)
-				case "EXPIRED", "EXPIRED_IN_MATCH", "EXPIRED_IN_CANCEL":
-					_ = mgr.Update(o.ClientOrderID, order.StatusExpired)
-				}
-				logEvent("order_update", map[string]interface{}{
-					"symbol":        o.Symbol,
-					"status":        o.Status,
-					"clientOrderId": o.ClientOrderID,
-					"orderId":       o.OrderID,
-					"lastQty":       o.LastFilledQty,
-					"lastPrice":     o.LastFilledPrice,
-					"pnl":           o.RealizedPnL,
-				})
+				applyOrderEvent(mgr, symbolUpper, gateway.BinanceOrderEvent(o))
 			},
 			OnAccountUpdate: func(a gateway.AccountUpdate) {
+				// ORDER 引起的仓位变化已由成交回报计入库存，这里只应用强平/ADL/资金费等非成交变化
 				for _, p := range a.Positions {
+					if a.Reason == "ORDER" {
+						break
+					}
 					if strings.ToUpper(p.Symbol) == symbolUpper {
-						inv.SetExposure(p.PositionAmt, p.EntryPrice)
+						inv.ApplyPosition(p.PositionSide, p.PositionAmt, p.EntryPrice)
 					}
 				}
 				logEvent("account_update", map[string]interface{}{"reason": a.Reason})
 			},
+			OnListenKeyExpired: func(key string) {
+				logEvent("listenkey_expired", map[string]interface{}{"listenKey": key})
+				if lkManager != nil {
+					lkManager.HandleExpired(key)
+				}
+			},
+		}
+		// 成交流：aggTrade -> market.Service -> VPIN/Kline
+		tradeHandler := &gateway.BinanceTradeHandler{Svc: marketSvc}
+		// 标记价/资金费率：markPrice@1s -> market.Service
+		markHandler := &gateway.BinanceMarkPriceHandler{
+			Svc: marketSvc,
+			OnUpdate: func(st market.FundingState) {
+				metrics.UpdateFunding(st.Symbol, st.MarkPrice, st.IndexPrice, st.FundingRate, st.UntilFunding(time.Now()).Seconds())
+			},
+		}
+		wsMux := &wsMultiplexer{depth: depthSync, user: userHandler, trade: tradeHandler, mark: markHandler}
+		if replaying {
+			ws = gateway.NewReplayWS(*replaySpeed, replayFiles...)
+		} else {
+			live := gateway.NewBinanceWSReal()
+			if cfg.Gateway.WSEndpoint != "" {
+				live.BaseEndpoint = cfg.Gateway.WSEndpoint
+			}
+			live.Capture = capture
+			ws = live
 		}
-		wsMux := &wsMultiplexer{depth: depthHandler, user: userHandler}
-		ws = gateway.NewBinanceWSReal()
 		ws.OnConnect(func() {
 			mc.wsConnects.Inc()
 			logEvent("ws_connect", map[string]interface{}{"symbol": symbolUpper})
@@ -438,18 +701,110 @@ func main() {
 			mc.wsFailures.Inc()
 			logEvent("ws_disconnect", map[string]interface{}{"error": err.Error()})
 		})
+		// 数据缺口：深度重新拉快照；用户流缺口期间的成交/仓位变化通过 REST 对账补齐
+		ws.OnGap(func(g gateway.WSGap) {
+			mc.wsGaps.Inc()
+			logEvent("ws_gap", map[string]interface{}{
+				"stream": g.Stream,
+				"kind":   string(g.Kind),
+				"reason": g.Reason,
+				"since":  g.Since.Format(time.RFC3339Nano),
+			})
+			switch g.Kind {
+			case gateway.StreamDepth:
+				depthSync.Resync("ws_" + g.Reason)
+			case gateway.StreamUserData:
+				go resyncPosition(venue, symbolUpper, inv)
+			}
+		})
 		if err := ws.SubscribeDepth(symbolUpper); err != nil {
 			log.Fatalf("订阅 depth 失败: %v", err)
 		}
-		if err := ws.SubscribeUserData(listenKey); err != nil {
-			log.Fatalf("订阅用户流失败: %v", err)
+		if err := ws.SubscribeTrade(symbolUpper); err != nil {
+			log.Fatalf("订阅成交流失败: %v", err)
 		}
+		if err := ws.SubscribeMarkPrice(symbolUpper); err != nil {
+			log.Fatalf("订阅标记价失败: %v", err)
+		}
+		if lkManager != nil {
+			listenKey, err := lkManager.Start()
+			if err != nil {
+				log.Fatalf("创建 listenKey 失败: %v", err)
+			}
+			logEvent("listenkey_created", map[string]interface{}{"listenKey": listenKey})
+			defer lkManager.Close()
+			if err := ws.SubscribeUserData(listenKey); err != nil {
+				log.Fatalf("订阅用户流失败: %v", err)
+			}
+		}
+		// 会话层断线自动重连并重放订阅；仅在首次建连失败时返回错误。回放结束后退出
 		go func() {
-			if err := ws.Run(wsMux); err != nil {
+			err := ws.Run(wsMux)
+			if err != nil {
 				logEvent("ws_exit", map[string]interface{}{"error": err.Error()})
 				cancel()
+			} else if replaying {
+				logEvent("replay_done", map[string]interface{}{"symbol": symbolUpper, "frames": ws.(*gateway.ReplayWS).Frames()})
+				cancel()
 			}
 		}()
+		// 定时核对挂单与仓位：补齐用户流漏掉的终态，处置孤儿挂单，仓位偏差以交易所为准修正；回放时终态已在录制的用户流中
+		if !replaying {
+			// 孤儿挂单只认本 run 的 clientOrderId，避免处置同账户其它实例的挂单
+			reconciler := order.NewReconciler(gateway.NewOrderQueryAdapter(venue, symbolUpper), mgr, order.ReconcilerConfig{
+				Symbol:        symbolUpper,
+				OrphanPolicy:  strings.ToLower(symConf.Risk.OrphanPolicy),
+				Owns:          mgr.IDs.Owns,
+				LocalPosition: inv.NetExposure,
+				RemotePosition: func() (float64, error) {
+					positions, err := venue.Positions(symbolUpper)
+					if err != nil {
+						return 0, err
+					}
+					net := 0.0
+					for _, p := range positions {
+						if strings.EqualFold(p.Symbol, symbolUpper) {
+							net += p.PositionAmt
+						}
+					}
+					metrics.UpdatePositionDrift(symbolUpper, inv.NetExposure()-net)
+					return net, nil
+				},
+				SyncPosition:      func() { resyncPosition(venue, symbolUpper, inv) },
+				PositionTolerance: symbolConstraints[symbolUpper].StepSize / 2,
+				HaltDrift:         symConf.Risk.PositionDriftHalt,
+				OnHalt: func(reason string) {
+					logEvent("reconcile_halt", map[string]interface{}{"symbol": symbolUpper, "reason": reason})
+					runner.RequestHalt(reason)
+				},
+				OnDiscrepancy: logDiscrepancy,
+			})
+			if err := reconciler.Start(ctx); err == nil {
+				defer reconciler.Stop()
+			}
+		}
+		// 死人开关：主循环停滞或风控停机时停止续期，倒计时到期由交易所撤掉全部挂单
+		if symConf.Risk.CancelCountdownSec > 0 && !replaying {
+			hb := gateway.NewCountdownHeartbeat(restClient, symbolUpper, time.Duration(symConf.Risk.CancelCountdownSec)*time.Second)
+			hb.Healthy = func() bool { return !riskHalted.Load() }
+			hb.OnError = func(err error) {
+				logEvent("countdown_error", map[string]interface{}{"symbol": symbolUpper, "error": err.Error()})
+			}
+			hb.OnPause = func(reason string) {
+				logEvent("countdown_paused", map[string]interface{}{"symbol": symbolUpper, "reason": reason})
+			}
+			if err := hb.Start(ctx); err != nil {
+				logEvent("countdown_error", map[string]interface{}{"symbol": symbolUpper, "error": err.Error()})
+			} else {
+				heartbeat = hb
+				logEvent("countdown_armed", map[string]interface{}{"symbol": symbolUpper, "countdownSec": symConf.Risk.CancelCountdownSec})
+				defer func() {
+					if err := hb.Stop(); err != nil {
+						logEvent("countdown_error", map[string]interface{}{"symbol": symbolUpper, "error": err.Error()})
+					}
+				}()
+			}
+		}
 	} else {
 		// 在dryRun模式下，使用模拟数据填充订单簿
 		log.Println("Dry-run mode: using simulated order book data")
@@ -509,24 +864,35 @@ func main() {
 					staleThreshold = 500 * time.Millisecond
 				}
 				// 只有当book数据过期或无效时才回退到REST API
-				if mid == 0 || time.Since(book.LastUpdate()) > staleThreshold {
-					if restClient != nil {
-						if bid, ask, err := restClient.GetBestBidAsk(symbolUpper, 5); err == nil {
-							book.SetBest(bid, ask)
-							mid = book.Mid()
-						} else {
-							logEvent("depth_refresh_error", map[string]interface{}{"symbol": symbolUpper, "error": err.Error()})
-						}
+				stale := mid == 0 || time.Since(book.LastUpdate()) > staleThreshold
+				if depthSync != nil {
+					// 增量簿由快照重建，避免 SetBest 覆盖掉完整深度；重建完成前不报价，也不续期死人开关
+					if stale {
+						depthSync.Resync("stale_book")
+					}
+					if stale || !depthSync.Synced() {
+						continue
+					}
+				} else if stale && venue != nil {
+					if bid, ask, err := venue.BestBidAsk(symbolUpper); err == nil {
+						book.SetBest(bid, ask)
+						mid = book.Mid()
+					} else {
+						logEvent("depth_refresh_error", map[string]interface{}{"symbol": symbolUpper, "error": err.Error()})
 					}
 				}
 				if mid == 0 {
 					continue
 				}
+				// 行情有效即视为主循环存活（节流跳过报价不算停滞）
+				if heartbeat != nil {
+					heartbeat.Beat()
+				}
 				if !runner.ReadyForNext(mid) {
 					continue
 				}
 				mc.midPrice.Set(mid)
-				net, pnl := inv.Valuation(mid)
+				net, pnl := inv.Valuation(runner.ValuationPrice(mid))
 				mc.position.Set(net)
 				mc.pnl.Set(pnl)
 
@@ -586,13 +952,22 @@ func main() {
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	select {
+	case <-quit:
+	case <-ctx.Done():
+	}
 	cancel()
+	// 等待在途的异步下单/撤单完成
+	mgr.Close()
+	if ws != nil {
+		ws.Stop()
+	}
 	logEvent("runner_exit", map[string]interface{}{"symbol": symbolUpper})
 }
 
 type restOrderGateway struct {
-	client           *gateway.BinanceRESTClient
+	client           gateway.BinanceREST // BinanceRESTClient 或 BinanceWSAPIClient
+	batch            gateway.BinanceBatchREST // 可为 nil，此时批量请求逐笔下发
 	dryRun           bool
 	symbolByID       map[string]string
 	exchangeByClient map[string]string
@@ -618,8 +993,13 @@ func (g *restOrderGateway) Place(o order.Order) (string, error) {
 	}
 
 	// Real mode: place order via Binance REST API
-	clientOrderID := fmt.Sprintf("mm_%d", time.Now().UnixNano())
-	exchangeOrderID, err := g.client.PlaceLimit(g.symbolByID[g.symbol], string(o.Side), "GTC", o.Price, o.Quantity, false, o.PostOnly, clientOrderID)
+	// newClientOrderId 取本地订单 ID（与批量下单一致），对账时按 origClientOrderId 查询；
+	// timeInForce/reduceOnly 与 PlaceBatch 一致，单笔与批量下发的同一档位行为相同
+	tif := o.TimeInForce
+	if tif == "" {
+		tif = "GTC"
+	}
+	exchangeOrderID, err := gateway.PlaceLimitOrder(g.client, g.symbolByID[g.symbol], string(o.Side), tif, o.Price, o.Quantity, o.ReduceOnly, o.PostOnly, o.ID, gateway.OrderOptionsOf(o))
 	if err != nil {
 		g.metrics.restErrors.WithLabelValues("place").Inc()
 		g.metrics.restLatency.WithLabelValues("place").Observe(time.Since(start).Seconds())
@@ -638,9 +1018,135 @@ func (g *restOrderGateway) Place(o order.Order) (string, error) {
 		side = "sell"
 	}
 	metrics.IncrementOrderPlaced(g.symbol, side)
+	g.storeMapping(o.ID, exchangeOrderID, g.symbolByID[g.symbol])
 	return exchangeOrderID, nil
 }
 
+// PlaceBatch 实现 order.BatchGateway：一次 /fapi/v1/batchOrders 请求下发整条报价梯。
+func (g *restOrderGateway) PlaceBatch(orders []order.Order) ([]order.BatchResult, error) {
+	if g.dryRun || g.batch == nil {
+		results := make([]order.BatchResult, len(orders))
+		for i, o := range orders {
+			results[i].ExchangeID, results[i].Err = g.Place(o)
+		}
+		return results, nil
+	}
+	start := time.Now()
+	g.metrics.restRequests.WithLabelValues("place_batch").Inc()
+	symbol := g.symbolByID[g.symbol]
+	reqs := make([]gateway.BatchLimitOrder, len(orders))
+	for i, o := range orders {
+		tif := o.TimeInForce
+		if tif == "" {
+			tif = "GTC"
+		}
+		reqs[i] = gateway.BatchLimitOrder{
+			Symbol:              symbol,
+			Side:                o.Side,
+			PositionSide:        o.PositionSide,
+			SelfTradePrevention: o.SelfTradePrevention,
+			PriceMatch:          o.PriceMatch,
+			TimeInForce:         tif,
+			Price:               o.Price,
+			Qty:                 o.Quantity,
+			ReduceOnly:          o.ReduceOnly,
+			PostOnly:            o.PostOnly,
+			ClientID:            o.ID,
+		}
+	}
+	res, err := g.batch.PlaceBatch(reqs)
+	g.metrics.restLatency.WithLabelValues("place_batch").Observe(time.Since(start).Seconds())
+	if err != nil {
+		g.metrics.restErrors.WithLabelValues("place_batch").Inc()
+	}
+	results := make([]order.BatchResult, len(res))
+	for i, r := range res {
+		results[i] = order.BatchResult{ExchangeID: r.OrderID, Err: r.Err}
+		if r.Err != nil || i >= len(orders) {
+			continue
+		}
+		g.storeMapping(orders[i].ID, r.OrderID, symbol)
+		g.metrics.incOrdersPlaced(orders[i].Side)
+		metrics.IncrementOrderPlaced(g.symbol, strings.ToLower(orders[i].Side))
+	}
+	return results, err
+}
+
+// CancelBatch 实现 order.BatchGateway：按 orderIdList 批量撤单。
+func (g *restOrderGateway) CancelBatch(orderIDs []string) ([]order.BatchResult, error) {
+	results := make([]order.BatchResult, len(orderIDs))
+	if g.dryRun || g.batch == nil {
+		for i, id := range orderIDs {
+			results[i].Err = g.Cancel(id)
+		}
+		return results, nil
+	}
+	start := time.Now()
+	g.metrics.restRequests.WithLabelValues("cancel_batch").Inc()
+	exchangeIDs := make([]string, 0, len(orderIDs))
+	idx := make([]int, 0, len(orderIDs))
+	for i, id := range orderIDs {
+		exchangeID, _ := g.lookupMapping(id)
+		if exchangeID == "" {
+			results[i].Err = fmt.Errorf("无法找到订单映射: %s", id)
+			continue
+		}
+		exchangeIDs = append(exchangeIDs, exchangeID)
+		idx = append(idx, i)
+	}
+	if len(exchangeIDs) == 0 {
+		return results, nil
+	}
+	res, err := g.batch.CancelBatch(g.symbolByID[g.symbol], exchangeIDs)
+	g.metrics.restLatency.WithLabelValues("cancel_batch").Observe(time.Since(start).Seconds())
+	if err != nil {
+		g.metrics.restErrors.WithLabelValues("cancel_batch").Inc()
+	}
+	for k, i := range idx {
+		if k < len(res) {
+			results[i].Err = res[k].Err
+		} else {
+			results[i].Err = err
+		}
+	}
+	return results, err
+}
+
+// Amend 实现 order.AmendGateway：PUT /fapi/v1/order（或 WS API order.modify）原地改价/改量。
+func (g *restOrderGateway) Amend(o order.Order) error {
+	start := time.Now()
+	g.metrics.restRequests.WithLabelValues("amend").Inc()
+	if g.dryRun {
+		g.metrics.restLatency.WithLabelValues("amend").Observe(time.Since(start).Seconds())
+		return nil
+	}
+	modifier, ok := g.client.(gateway.BinanceOrderModifier)
+	if !ok {
+		return order.ErrAmendUnsupported
+	}
+	exchangeID, _ := g.lookupMapping(o.ID)
+	if exchangeID == "" {
+		return fmt.Errorf("无法找到订单映射: %s", o.ID)
+	}
+	var err error
+	if o.PriceMatch != "" {
+		// priceMatch 单改单时由交易所重新按盘口定价
+		om, ok := g.client.(gateway.BinanceOptionsModifier)
+		if !ok {
+			return order.ErrAmendUnsupported
+		}
+		_, err = om.ModifyOrderWithOptions(g.symbolByID[g.symbol], exchangeID, o.Side, o.Price, o.Quantity, gateway.OrderOptionsOf(o))
+	} else {
+		_, err = modifier.ModifyOrder(g.symbolByID[g.symbol], exchangeID, o.Side, o.Price, o.Quantity)
+	}
+	g.metrics.restLatency.WithLabelValues("amend").Observe(time.Since(start).Seconds())
+	if err != nil {
+		g.metrics.restErrors.WithLabelValues("amend").Inc()
+		return err
+	}
+	return nil
+}
+
 func (g *restOrderGateway) Cancel(clientOrderID string) error {
 	start := time.Now()
 	g.metrics.restRequests.WithLabelValues("cancel").Inc()
@@ -674,8 +1180,10 @@ func (g *restOrderGateway) Cancel(clientOrderID string) error {
 }
 
 type wsMultiplexer struct {
-	depth *gateway.BinanceWSHandler
+	depth *gateway.DepthSynchronizer
 	user  *gateway.BinanceUserHandler
+	trade *gateway.BinanceTradeHandler
+	mark  *gateway.BinanceMarkPriceHandler
 }
 
 func (m *wsMultiplexer) OnDepth(symbol string, bid, ask float64) {}
@@ -689,29 +1197,250 @@ func (m *wsMultiplexer) OnRawMessage(msg []byte) {
 	if m.depth != nil {
 		m.depth.OnRawMessage(msg)
 	}
+	if m.trade != nil {
+		m.trade.OnRawMessage(msg)
+	}
+	if m.mark != nil {
+		m.mark.OnRawMessage(msg)
+	}
 }
 
-func keepAliveLoop(ctx context.Context, cli *gateway.ListenKeyClient, key string) {
-	ticker := time.NewTicker(30 * time.Minute)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			if err := cli.KeepAlive(key); err != nil {
-				logEvent("listenkey_keepalive_error", map[string]interface{}{"listenKey": key, "error": err.Error()})
-				time.Sleep(5 * time.Second)
-				if err = cli.KeepAlive(key); err != nil {
-					logEvent("listenkey_keepalive_retry_failed", map[string]interface{}{"listenKey": key, "error": err.Error()})
-				} else {
-					logEvent("listenkey_keepalive_retry_ok", map[string]interface{}{"listenKey": key})
-				}
-			} else {
-				logEvent("listenkey_keepalive_ok", map[string]interface{}{"listenKey": key})
-			}
+// applyOrderEvent 把归一化的订单推送交给 Manager.ApplyExecution（按成交 ID 去重、累计成交与手续费），
+// 新计入的成交经 Manager.OnFill 驱动库存，并打点。
+func applyOrderEvent(mgr *order.Manager, symbol string, ev gateway.VenueOrderEvent) {
+	side := "buy"
+	if ev.Side == "SELL" {
+		side = "sell"
+	}
+	report := order.ExecutionReport{
+		OrderID:      ev.ClientOrderID,
+		ExchangeID:   ev.ExchangeID,
+		Symbol:       strings.ToUpper(ev.Symbol),
+		Side:         ev.Side,
+		PositionSide: ev.PositionSide,
+		Status:       ev.Status,
+		TradeID:      ev.TradeID,
+		LastQty:      ev.LastQty,
+		LastPrice:    ev.LastPrice,
+		CumQty:       ev.CumQty,
+		Fee:          ev.Fee,
+		FeeAsset:     ev.FeeAsset,
+		RealizedPnL:  ev.RealizedPnL,
+		Maker:        ev.Maker,
+	}
+	if ev.Time > 0 {
+		report.Time = time.UnixMilli(ev.Time)
+	}
+	fill, _ := mgr.ApplyExecution(report)
+	if ev.Status == order.StatusCanceled {
+		metrics.IncrementOrderCanceled(symbol)
+	}
+	fields := map[string]interface{}{
+		"symbol":        ev.Symbol,
+		"status":        ev.RawStatus,
+		"clientOrderId": ev.ClientOrderID,
+		"orderId":       ev.ExchangeID,
+		"tradeId":       ev.TradeID,
+		"lastQty":       ev.LastQty,
+		"lastPrice":     ev.LastPrice,
+		"pnl":           ev.RealizedPnL,
+	}
+	if fill != nil {
+		// 部分成交也计数，重复推送不计
+		metrics.IncrementFill(symbol, side)
+		fields["filledQty"] = fill.FilledQty
+		fields["avgPrice"] = fill.AvgPrice
+		fields["fee"] = ev.Fee
+	} else if ev.LastQty > 0 {
+		fields["duplicate"] = true
+	}
+	logEvent("order_update", fields)
+}
+
+// logDiscrepancy 输出对账差异事件并计入指标。
+func logDiscrepancy(d order.Discrepancy) {
+	fields := map[string]interface{}{
+		"symbol": d.Symbol,
+		"kind":   d.Kind,
+		"action": d.Action,
+	}
+	if d.OrderID != "" {
+		fields["orderId"] = d.OrderID
+		fields["localStatus"] = d.LocalStatus
+		fields["remoteStatus"] = d.RemoteStatus
+	}
+	if d.Kind == order.DiscrepancyPosition {
+		fields["localQty"] = d.LocalQty
+		fields["remoteQty"] = d.RemoteQty
+	}
+	if d.Err != nil {
+		fields["error"] = d.Err.Error()
+	}
+	logEvent("reconcile_discrepancy", fields)
+	metrics.IncrementReconcileDiscrepancy(d.Symbol, d.Kind, d.Action)
+}
+
+// resyncPosition 用户流出现缺口后按 REST 仓位对齐本地库存。
+func resyncPosition(venue gateway.Venue, symbol string, inv *inventory.Tracker) {
+	positions, err := venue.Positions(symbol)
+	if err != nil {
+		logEvent("position_resync_error", map[string]interface{}{"symbol": symbol, "error": err.Error()})
+		return
+	}
+	for _, p := range positions {
+		if strings.EqualFold(p.Symbol, symbol) {
+			inv.ApplyPosition(p.PositionSide, p.PositionAmt, p.EntryPrice)
+			logEvent("position_resync", map[string]interface{}{"symbol": symbol, "side": p.PositionSide, "amt": p.PositionAmt, "entry": p.EntryPrice})
+		}
+	}
+}
+
+// resyncUserState 在用户流中断后做 REST 对账：盲区内本地活跃单的状态按 openOrders 与逐笔 clientOrderId 查询校正，
+// 交易所上的其它挂单不受影响；查询失败时才退回撤掉交易对全部挂单并把本地活跃单置为已撤。仓位以 positionRisk 为准。
+func resyncUserState(venue gateway.Venue, mgr *order.Manager, symbol string, inv *inventory.Tracker) {
+	if err := reconcileUserState(venue, mgr, symbol); err != nil {
+		logEvent("order_resync_error", map[string]interface{}{"symbol": symbol, "error": err.Error(), "fallback": "cancel_all"})
+		cancelAllOrders(venue, mgr, symbol)
+	}
+	resyncPosition(venue, symbol, inv)
+}
+
+// reconcileUserState 以交易所状态校正本地活跃单：在挂单列表中的取列表状态，不在的按 clientOrderId 查询，
+// 查无此单记为过期；请求在途（PENDING_*）的订单等异步结果。
+func reconcileUserState(venue gateway.Venue, mgr *order.Manager, symbol string) error {
+	open, err := venue.OpenOrders(symbol)
+	if err != nil {
+		return fmt.Errorf("open orders: %w", err)
+	}
+	remote := make(map[string]*order.Order, len(open))
+	for _, o := range open {
+		remote[o.ID] = o
+	}
+	updated := 0
+	for _, local := range mgr.GetActiveOrdersBySymbol(symbol) {
+		// 下单/撤单请求尚未返回（同步 Submit 同样登记为 PENDING_NEW），交易所可能还没看到该订单
+		cur, _ := mgr.Status(local.ID)
+		if cur == order.StatusPendingNew || cur == order.StatusPendingCancel {
+			continue
+		}
+		st := order.StatusExpired
+		if r, ok := remote[local.ID]; ok {
+			st = r.Status
+		} else if r, err := venue.GetOrder(symbol, local.ID); err == nil {
+			st = r.Status
+		} else if !errors.Is(err, gateway.ErrOrderNotFound) {
+			return fmt.Errorf("get order %s: %w", local.ID, err)
+		}
+		if st == "" || st == order.StatusNew {
+			st = order.StatusAck
+		}
+		if st != cur && mgr.Update(local.ID, st) == nil {
+			updated++
+		}
+	}
+	logEvent("order_resync", map[string]interface{}{"symbol": symbol, "open": len(open), "updated": updated})
+	return nil
+}
+
+// cancelAllOrders 撤销交易对全部挂单并把本地活跃单置为已撤，由策略下一轮重新报价。
+func cancelAllOrders(venue gateway.Venue, mgr *order.Manager, symbol string) {
+	if err := venue.CancelAll(symbol); err != nil {
+		logEvent("order_resync_error", map[string]interface{}{"symbol": symbol, "error": err.Error()})
+		return
+	}
+	canceled := 0
+	for _, o := range mgr.GetActiveOrdersBySymbol(symbol) {
+		if err := mgr.Update(o.ID, order.StatusCanceled); err == nil {
+			canceled++
+		}
+	}
+	logEvent("order_resync", map[string]interface{}{"symbol": symbol, "canceled": canceled})
+}
+
+// recoverOrders 启动恢复：回放上次进程的订单日志，对照交易所挂单接管（mode=adopt）或撤销本系统挂单，
+// 按 positionRisk 对齐库存；旧日志移到 .prev，本进程从新日志开始记录（接管的订单先记一条 adopt）。
+// 查询挂单失败时撤销交易对全部挂单，从空白状态开始报价。
+func recoverOrders(path, mode string, rest *gateway.BinanceRESTClient, venue gateway.Venue, gw *restOrderGateway, mgr *order.Manager, runner *sim.Runner, symbol string, inv *inventory.Tracker) (*order.FileJournal, error) {
+	entries, skipped, err := order.ReadJournal(path)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]order.Order)
+	for id, o := range order.ReplayJournal(entries) {
+		if o.Symbol == symbol {
+			known[id] = o
+		}
+	}
+	// 沿用同一 runId 时接着上次的序号编号，避免 clientOrderId 重复
+	if seq := order.LastSeq(known, mgr.IDs.RunID); seq > mgr.IDs.Seq() {
+		mgr.IDs.SetSeq(seq)
+	}
+	if err := order.RotateJournal(path); err != nil {
+		return nil, err
+	}
+	journal, err := order.OpenJournal(path)
+	if err != nil {
+		return nil, err
+	}
+	journal.OnError = func(err error) {
+		logEvent("journal_error", map[string]interface{}{"symbol": symbol, "error": err.Error()})
+	}
+	mgr.Journal = journal
+	fields := map[string]interface{}{"symbol": symbol, "path": path, "mode": mode, "entries": len(entries), "skipped": skipped, "orders": len(known)}
+	if mode == "off" {
+		logEvent("order_recovery", fields)
+		return journal, nil
+	}
+
+	open, err := rest.OpenOrders(symbol)
+	if err != nil {
+		logEvent("order_recovery_error", map[string]interface{}{"symbol": symbol, "error": err.Error()})
+		if err := venue.CancelAll(symbol); err != nil {
+			return nil, fmt.Errorf("cancel all after open orders query failed: %w", err)
+		}
+		fields["canceledAll"] = true
+		logEvent("order_recovery", fields)
+		resyncPosition(venue, symbol, inv)
+		return journal, nil
+	}
+	remote := make([]*order.Order, 0, len(open))
+	exchangeIDs := make(map[string]string, len(open))
+	for _, fo := range open {
+		remote = append(remote, fo.Order())
+		exchangeIDs[fo.ClientOrderID] = fo.OrderID
+		gw.storeMapping(fo.ClientOrderID, fo.OrderID, symbol)
+	}
+	// 只处置本实例（日志中的 run 与当前 run）的挂单，同账户其它实例的挂单记为 foreign
+	plan := order.PlanRecovery(known, remote, mode == "adopt", order.JournalOwner(known, mgr.IDs))
+	mgr.Restore(plan.Adopt)
+	cancelIDs := runner.AdoptOrders(plan.Adopt)
+	adopted := len(plan.Adopt) - len(cancelIDs)
+	canceled := 0
+	for _, id := range cancelIDs {
+		if err := mgr.Cancel(id); err != nil {
+			logEvent("order_recovery_cancel_error", map[string]interface{}{"symbol": symbol, "orderId": id, "error": err.Error()})
+			continue
+		}
+		canceled++
+	}
+	for _, o := range plan.Cancel {
+		if err := venue.CancelOrder(symbol, exchangeIDs[o.ID]); err != nil {
+			logEvent("order_recovery_cancel_error", map[string]interface{}{"symbol": symbol, "orderId": o.ID, "error": err.Error()})
+			continue
 		}
+		canceled++
 	}
+	for _, o := range plan.Closed {
+		logEvent("order_recovery_closed", map[string]interface{}{"symbol": symbol, "orderId": o.ID, "side": o.Side, "price": o.Price, "status": o.Status, "filledQty": o.FilledQty})
+	}
+	fields["adopted"] = adopted
+	fields["canceled"] = canceled
+	fields["closed"] = len(plan.Closed)
+	fields["foreign"] = len(plan.Foreign)
+	logEvent("order_recovery", fields)
+	// 停机期间的成交只体现在仓位上
+	resyncPosition(venue, symbol, inv)
+	return journal, nil
 }
 
 func (g *restOrderGateway) storeMapping(clientID, exchangeID, symbol string) {
@@ -725,6 +1454,20 @@ func (g *restOrderGateway) storeMapping(clientID, exchangeID, symbol string) {
 	}
 }
 
+// mappingLookup 确认结果未知的下单时顺带登记交易所订单号，使恢复出的订单可以照常撤单/改单。
+type mappingLookup struct {
+	order.OrderLookup
+	gw *restOrderGateway
+}
+
+func (l mappingLookup) GetOrder(orderID string) (*order.Order, error) {
+	o, err := l.OrderLookup.GetOrder(orderID)
+	if err == nil && o != nil && o.ExchangeID != "" {
+		l.gw.storeMapping(orderID, o.ExchangeID, l.gw.symbolByID[l.gw.symbol])
+	}
+	return o, err
+}
+
 func (g *restOrderGateway) lookupMapping(id string) (exchangeID, symbol string) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
@@ -805,6 +1548,8 @@ type metricsCollector struct {
 	restLatency     *prometheus.HistogramVec
 	wsConnects      prometheus.Counter
 	wsFailures      prometheus.Counter
+	wsGaps          prometheus.Counter
+	lkRotations     prometheus.Counter
 	midPrice        prometheus.Gauge
 	position        prometheus.Gauge
 	pnl             prometheus.Gauge
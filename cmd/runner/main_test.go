@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"market-maker-go/gateway"
+	"market-maker-go/order"
+)
+
+// fakeVenue 只实现对账用到的查询/撤单，其余方法未实现（调用即 panic）。
+type fakeVenue struct {
+	gateway.Venue
+	open      []*order.Order
+	orders    map[string]*order.Order
+	openErr   error
+	canceled  []string
+	cancelAll int
+}
+
+func (v *fakeVenue) OpenOrders(symbol string) ([]*order.Order, error) {
+	return v.open, v.openErr
+}
+
+func (v *fakeVenue) GetOrder(symbol, clientOrderID string) (*order.Order, error) {
+	if o, ok := v.orders[clientOrderID]; ok {
+		return o, nil
+	}
+	return nil, gateway.ErrOrderNotFound
+}
+
+func (v *fakeVenue) CancelOrder(symbol, exchangeID string) error {
+	v.canceled = append(v.canceled, exchangeID)
+	return nil
+}
+
+func (v *fakeVenue) CancelAll(symbol string) error {
+	v.cancelAll++
+	return nil
+}
+
+func TestReconcileUserStateKeepsBook(t *testing.T) {
+	mgr := order.NewManager(nil)
+	for _, id := range []string{"open", "filled", "lost"} {
+		mgr.Restore([]order.Order{{ID: id, Symbol: "ETHUSDC", Side: "BUY", Price: 100, Quantity: 1, Status: order.StatusAck}})
+	}
+	venue := &fakeVenue{
+		open: []*order.Order{
+			{ID: "open", Status: order.StatusPartial},
+			{ID: "web_manual", Status: order.StatusAck},
+		},
+		orders: map[string]*order.Order{"filled": {ID: "filled", Status: order.StatusFilled}},
+	}
+	if err := reconcileUserState(venue, mgr, "ETHUSDC"); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if venue.cancelAll != 0 || len(venue.canceled) != 0 {
+		t.Fatalf("resync must not cancel resting orders")
+	}
+	for id, want := range map[string]order.Status{"open": order.StatusPartial, "filled": order.StatusFilled, "lost": order.StatusExpired} {
+		if st, _ := mgr.Status(id); st != want {
+			t.Fatalf("%s status %s want %s", id, st, want)
+		}
+	}
+
+	// 查询失败才退回 CancelAll
+	venue.openErr = errors.New("503")
+	mgr.Restore([]order.Order{{ID: "next", Symbol: "ETHUSDC", Side: "SELL", Price: 101, Quantity: 1, Status: order.StatusAck}})
+	if err := reconcileUserState(venue, mgr, "ETHUSDC"); err == nil {
+		t.Fatalf("expected query error")
+	}
+	cancelAllOrders(venue, mgr, "ETHUSDC")
+	if st, _ := mgr.Status("next"); venue.cancelAll != 1 || st != order.StatusCanceled {
+		t.Fatalf("fallback should cancel all, status %s", st)
+	}
+}
+
+// timeoutREST 下单总是超时（结果未知），记录撤单时使用的交易所订单号。
+type timeoutREST struct {
+	canceled []string
+}
+
+func (c *timeoutREST) PlaceLimit(symbol, side, tif string, price, qty float64, reduceOnly, postOnly bool, clientID string) (string, error) {
+	return "", fmt.Errorf("%w: read timeout", gateway.ErrExecutionUnknown)
+}
+
+func (c *timeoutREST) CancelOrder(symbol, orderID string) error {
+	c.canceled = append(c.canceled, orderID)
+	return nil
+}
+
+func testMetrics() *metricsCollector {
+	return &metricsCollector{
+		ordersPlaced: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_orders_placed"}, []string{"side"}),
+		restRequests: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_rest_requests"}, []string{"method"}),
+		restErrors:   prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_rest_errors"}, []string{"method"}),
+		restLatency:  prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "test_rest_latency"}, []string{"method"}),
+	}
+}
+
+func TestRecoveredPlaceCanBeCanceled(t *testing.T) {
+	rest := &timeoutREST{}
+	gw := &restOrderGateway{
+		client:           rest,
+		symbolByID:       map[string]string{"ETHUSDC": "ETHUSDC"},
+		exchangeByClient: map[string]string{"ETHUSDC": "binance"},
+		symbol:           "ETHUSDC",
+		metrics:          testMetrics(),
+	}
+	venue := &fakeVenue{orders: map[string]*order.Order{}}
+	mgr := order.NewManager(gw)
+	mgr.Lookup = mappingLookup{OrderLookup: gateway.NewOrderQueryAdapter(venue, "ETHUSDC"), gw: gw}
+
+	// 下单超时，但交易所已受理：按 clientOrderId 查到后登记交易所订单号
+	id := mgr.IDs.Next(order.Order{Symbol: "ETHUSDC", Side: "BUY"})
+	venue.orders[id] = &order.Order{ID: id, ExchangeID: "777", Status: order.StatusAck}
+	o, err := mgr.Submit(order.Order{ID: id, Symbol: "ETHUSDC", Side: "BUY", Price: 100, Quantity: 1})
+	if err != nil {
+		t.Fatalf("recovered submit: %v", err)
+	}
+	if err := mgr.Cancel(o.ID); err != nil {
+		t.Fatalf("cancel recovered order: %v", err)
+	}
+	if len(rest.canceled) != 1 || rest.canceled[0] != "777" {
+		t.Fatalf("cancel should use exchange id 777, got %v", rest.canceled)
+	}
+}
+
+// recordingREST 记录单笔下单的 timeInForce 与 reduceOnly。
+type recordingREST struct {
+	tif        []string
+	reduceOnly []bool
+}
+
+func (c *recordingREST) PlaceLimit(symbol, side, tif string, price, qty float64, reduceOnly, postOnly bool, clientID string) (string, error) {
+	c.tif = append(c.tif, tif)
+	c.reduceOnly = append(c.reduceOnly, reduceOnly)
+	return "1", nil
+}
+
+func (c *recordingREST) CancelOrder(symbol, orderID string) error { return nil }
+
+func TestPlaceHonorsTimeInForceAndReduceOnly(t *testing.T) {
+	rest := &recordingREST{}
+	gw := &restOrderGateway{
+		client:           rest,
+		symbolByID:       map[string]string{"ETHUSDC": "ETHUSDC"},
+		exchangeByClient: map[string]string{"ETHUSDC": "binance"},
+		symbol:           "ETHUSDC",
+		metrics:          testMetrics(),
+	}
+	// 单档变化时批量下单退化为单笔 Place，参数须与 PlaceBatch 一致
+	if _, err := gw.PlaceBatch([]order.Order{{ID: "a", Symbol: "ETHUSDC", Side: "SELL", Price: 100, Quantity: 1, TimeInForce: "IOC", ReduceOnly: true}}); err != nil {
+		t.Fatalf("place batch: %v", err)
+	}
+	if _, err := gw.Place(order.Order{ID: "b", Symbol: "ETHUSDC", Side: "BUY", Price: 99, Quantity: 1}); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if len(rest.tif) != 2 || rest.tif[0] != "IOC" || !rest.reduceOnly[0] || rest.tif[1] != "GTC" || rest.reduceOnly[1] {
+		t.Fatalf("unexpected tif %v reduceOnly %v", rest.tif, rest.reduceOnly)
+	}
+}
+
+// blockingGateway 在 release 关闭前阻塞下单。
+type blockingGateway struct {
+	placing chan struct{}
+	release chan struct{}
+}
+
+func (g *blockingGateway) Place(o order.Order) (string, error) {
+	close(g.placing)
+	<-g.release
+	return "1", nil
+}
+
+func (g *blockingGateway) Cancel(id string) error { return nil }
+
+func TestReconcileUserStateSkipsPlaceInFlight(t *testing.T) {
+	gw := &blockingGateway{placing: make(chan struct{}), release: make(chan struct{})}
+	mgr := order.NewManager(gw)
+	done := make(chan error)
+	go func() {
+		_, err := mgr.Submit(order.Order{ID: "slow", Symbol: "ETHUSDC", Side: "BUY", Price: 100, Quantity: 1})
+		done <- err
+	}()
+	<-gw.placing
+	// 下单请求未返回：挂单列表与逐笔查询都查不到，但不能记为过期
+	venue := &fakeVenue{orders: map[string]*order.Order{}}
+	if err := reconcileUserState(venue, mgr, "ETHUSDC"); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if st, _ := mgr.Status("slow"); st != order.StatusPendingNew {
+		t.Fatalf("in-flight place should be left alone, got %s", st)
+	}
+	close(gw.release)
+	if err := <-done; err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if st, _ := mgr.Status("slow"); st != order.StatusAck {
+		t.Fatalf("expected ACK after place returns, got %s", st)
+	}
+}
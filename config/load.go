@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -26,6 +27,14 @@ type GatewayConfig struct {
 	APIKey    string `yaml:"apiKey"`
 	APISecret string `yaml:"apiSecret"`
 	BaseURL   string `yaml:"baseURL"`
+	// WSEndpoint 行情/用户流 WS 地址，留空使用 wss://fstream.binance.com（可指向本地模拟交易所 ws://host:port）。
+	WSEndpoint string `yaml:"wsEndpoint"`
+	// OrderTransport 下单通道：rest（默认）或 ws（WebSocket 交易 API，断线自动回退 REST）。
+	OrderTransport string `yaml:"orderTransport"`
+	// WSAPIEndpoint WS 交易 API 地址，留空使用 wss://ws-fapi.binance.com/ws-fapi/v1。
+	WSAPIEndpoint string `yaml:"wsAPIEndpoint"`
+	// TimeSyncIntervalSec 与交易所校时（/fapi/v1/time）的周期（秒），0 使用默认 60s。
+	TimeSyncIntervalSec int `yaml:"timeSyncIntervalSec"`
 }
 
 type InventoryConfig struct {
@@ -85,6 +94,10 @@ type StrategyParams struct {
 	TrendSpreadMultiplier      float64 `yaml:"trendSpreadMultiplier"`    // 趋势市场价差乘数
 	HighVolSpreadMultiplier    float64 `yaml:"highVolSpreadMultiplier"`  // 高波动市场价差乘数
 	AvoidToxic                 bool    `yaml:"avoidToxic"`               // 是否避免有毒订单流
+	VPINBucketSize             float64 `yaml:"vpinBucketSize"`           // VPIN 每个成交量桶的成交量（基础币），默认 100×baseSize
+	VPINBuckets                int     `yaml:"vpinBuckets"`              // VPIN 滚动桶数，默认 50
+	VPINToxicThreshold         float64 `yaml:"vpinToxicThreshold"`       // VPIN 毒性阈值，默认 0.4
+	SelfTradePrevention        string  `yaml:"selfTradePrevention"`      // 默认自成交保护：NONE/EXPIRE_TAKER/EXPIRE_MAKER/EXPIRE_BOTH，留空沿用账户设置
 }
 
 type SymbolRisk struct {
@@ -100,6 +113,10 @@ type SymbolRisk struct {
 	StopLoss                   float64   `yaml:"stopLoss"`
 	HaltSeconds                int       `yaml:"haltSeconds"`
 	ShockPct                   float64   `yaml:"shockPct"`
+	ValuationPrice             string    `yaml:"valuationPrice"` // 止损/浮亏估值价格：mid（默认）或 mark（交易所标记价）
+	CancelCountdownSec         int       `yaml:"cancelCountdownSec"` // 交易所自动撤单倒计时（死人开关），0 表示关闭
+	OrphanPolicy               string    `yaml:"orphanPolicy"`       // 对账发现本系统孤儿挂单：report（默认）/cancel/adopt（Runner 不支持 adopt）
+	PositionDriftHalt          float64   `yaml:"positionDriftHalt"`  // 对账仓位偏差达到该值时停机，0 表示只修正不停机
 	// 浮亏分层减仓
 	DrawdownBands           []float64 `yaml:"drawdownBands"`
 	ReduceFractions         []float64 `yaml:"reduceFractions"`
@@ -158,6 +175,14 @@ func Validate(cfg AppConfig) error {
 	if cfg.Gateway.APIKey == "" || cfg.Gateway.APISecret == "" {
 		return errors.New("gateway.apiKey/apiSecret is required (or env overrides)")
 	}
+	switch strings.ToLower(cfg.Gateway.OrderTransport) {
+	case "", "rest", "ws":
+	default:
+		return fmt.Errorf("gateway.orderTransport must be rest or ws, got %q", cfg.Gateway.OrderTransport)
+	}
+	if cfg.Gateway.TimeSyncIntervalSec < 0 {
+		return errors.New("gateway.timeSyncIntervalSec must be >= 0")
+	}
 	if len(cfg.Symbols) == 0 {
 		return errors.New("symbols config is required")
 	}
@@ -213,9 +238,30 @@ func Validate(cfg AppConfig) error {
 		if sc.Risk.HaltSeconds < 0 {
 			return fmt.Errorf("symbol %s risk.haltSeconds must be >= 0", sym)
 		}
+		if sc.Risk.CancelCountdownSec < 0 {
+			return fmt.Errorf("symbol %s risk.cancelCountdownSec must be >= 0", sym)
+		}
+		if sc.Risk.PositionDriftHalt < 0 {
+			return fmt.Errorf("symbol %s risk.positionDriftHalt must be >= 0", sym)
+		}
+		switch strings.ToLower(sc.Risk.OrphanPolicy) {
+		case "", "cancel", "adopt", "report":
+		default:
+			return fmt.Errorf("symbol %s risk.orphanPolicy must be cancel/adopt/report, got %q", sym, sc.Risk.OrphanPolicy)
+		}
 		if sc.Risk.ShockPct < 0 {
 			return fmt.Errorf("symbol %s risk.shockPct must be >= 0", sym)
 		}
+		switch strings.ToLower(sc.Risk.ValuationPrice) {
+		case "", "mid", "mark":
+		default:
+			return fmt.Errorf("symbol %s risk.valuationPrice must be mid or mark, got %q", sym, sc.Risk.ValuationPrice)
+		}
+		switch strings.ToUpper(sc.Strategy.SelfTradePrevention) {
+		case "", "NONE", "EXPIRE_TAKER", "EXPIRE_MAKER", "EXPIRE_BOTH":
+		default:
+			return fmt.Errorf("symbol %s strategy.selfTradePrevention must be NONE/EXPIRE_TAKER/EXPIRE_MAKER/EXPIRE_BOTH, got %q", sym, sc.Strategy.SelfTradePrevention)
+		}
 	}
 	return nil
 }
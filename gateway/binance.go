@@ -1,9 +1,16 @@
 package gateway
 
+import (
+	"fmt"
+
+	"market-maker-go/order"
+)
+
 // Binance endpoints (USDC-M perpetual)
 const (
-	BinanceFuturesWSEndpoint   = "wss://fstream.binance.com"
-	BinanceFuturesRestEndpoint = "https://fapi.binance.com"
+	BinanceFuturesWSEndpoint    = "wss://fstream.binance.com"
+	BinanceFuturesRestEndpoint  = "https://fapi.binance.com"
+	BinanceFuturesWSAPIEndpoint = "wss://ws-fapi.binance.com/ws-fapi/v1"
 )
 
 // BinanceREST is a minimal REST client interface; real实现需签名、时间戳等。
@@ -12,6 +19,55 @@ type BinanceREST interface {
 	CancelOrder(symbol, orderID string) error
 }
 
+// BinanceOrderModifier 可选的改单能力（PUT /fapi/v1/order 或 WS API order.modify），
+// 返回改单后的 orderId（与原单相同）。
+type BinanceOrderModifier interface {
+	ModifyOrder(symbol, orderID, side string, price, qty float64) (string, error)
+}
+
+// OrderOptions 下单的可选参数，零值即单向持仓、账户默认自成交保护、按 price 定价。
+type OrderOptions struct {
+	// PositionSide 双向持仓（Hedge Mode）下的腿：LONG/SHORT。双向模式下 Binance 不接受 reduceOnly 参数，
+	// reduceOnly=true 映射为平该腿（SELL+LONG 平多，BUY+SHORT 平空）。
+	PositionSide string
+	// SelfTradePrevention 即 selfTradePreventionMode：NONE/EXPIRE_TAKER/EXPIRE_MAKER/EXPIRE_BOTH。
+	SelfTradePrevention string
+	// PriceMatch 按盘口定价（OPPONENT[_5/10/20]、QUEUE[_5/10/20]）；设置后不发送 price。
+	PriceMatch string
+}
+
+// IsZero 是否未设置任何选项（可走普通 PlaceLimit）。
+func (o OrderOptions) IsZero() bool {
+	return !order.IsHedgeSide(o.PositionSide) && o.SelfTradePrevention == "" && o.PriceMatch == ""
+}
+
+// OrderOptionsOf 取 order.Order 上的下单选项。
+func OrderOptionsOf(o order.Order) OrderOptions {
+	return OrderOptions{PositionSide: o.PositionSide, SelfTradePrevention: o.SelfTradePrevention, PriceMatch: o.PriceMatch}
+}
+
+// BinanceOptionsREST 可选的带 OrderOptions 下单能力（双向持仓、自成交保护、priceMatch）。
+type BinanceOptionsREST interface {
+	PlaceLimitWithOptions(symbol, side, tif string, price, qty float64, reduceOnly, postOnly bool, clientID string, opts OrderOptions) (string, error)
+}
+
+// BinanceOptionsModifier 可选的带 priceMatch 改单能力；opts 中仅 PriceMatch 生效（持仓腿与 STP 不可修改）。
+type BinanceOptionsModifier interface {
+	ModifyOrderWithOptions(symbol, orderID, side string, price, qty float64, opts OrderOptions) (string, error)
+}
+
+// PlaceLimitOrder 按 opts 选择下单方式；opts 为零值时等同 PlaceLimit。
+func PlaceLimitOrder(c BinanceREST, symbol, side, tif string, price, qty float64, reduceOnly, postOnly bool, clientID string, opts OrderOptions) (string, error) {
+	if opts.IsZero() {
+		return c.PlaceLimit(symbol, side, tif, price, qty, reduceOnly, postOnly, clientID)
+	}
+	oc, ok := c.(BinanceOptionsREST)
+	if !ok {
+		return "", fmt.Errorf("order options %+v not supported by %T", opts, c)
+	}
+	return oc.PlaceLimitWithOptions(symbol, side, tif, price, qty, reduceOnly, postOnly, clientID, opts)
+}
+
 // BinanceWS is一个极简抽象，供后续对接 binance ws 客户端。
 type BinanceWS interface {
 	SubscribeDepth(symbol string) error
@@ -0,0 +1,174 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CountdownCancelAll 调用 POST /fapi/v1/countdownCancelAll：countdown 到期前未再次调用时，
+// 交易所撤销该交易对的全部挂单；countdown 为 0 时取消倒计时。
+func (c *BinanceRESTClient) CountdownCancelAll(symbol string, countdown time.Duration) error {
+	if c == nil || c.HTTPClient == nil {
+		return fmt.Errorf("http client not set")
+	}
+	if symbol == "" {
+		return fmt.Errorf("symbol required")
+	}
+	if countdown < 0 {
+		return fmt.Errorf("countdown must be >= 0")
+	}
+	params := map[string]string{
+		"symbol":        symbol,
+		"countdownTime": strconv.FormatInt(countdown.Milliseconds(), 10),
+	}
+	query, sig := c.signParams(params)
+	endpoint := c.BaseURL + "/fapi/v1/countdownCancelAll?" + query + "&signature=" + url.QueryEscape(sig)
+	headers := map[string]string{"X-MBX-APIKEY": c.APIKey}
+	resp, err := c.sendWithRetry(http.MethodPost, endpoint, headers)
+	if err != nil {
+		return err
+	}
+	body, _ := io.ReadAll(resp.Body)
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return newAPIError("countdown cancel all", endpoint, resp.StatusCode, body)
+	}
+	return nil
+}
+
+// CountdownCanceler 自动撤单倒计时能力，由 BinanceRESTClient 实现。
+type CountdownCanceler interface {
+	CountdownCancelAll(symbol string, countdown time.Duration) error
+}
+
+// CountdownHeartbeat 基于 countdownCancelAll 的“死人开关”：主循环每轮调用 Beat，
+// 后台按 Refresh 周期续期交易所倒计时。主循环停滞超过 StallAfter 或 Healthy 返回 false（如风控停机）时停止续期，
+// 倒计时到期后由交易所撤掉全部挂单；进程卡死或断网时同理。恢复后下一轮续期重新布防。
+type CountdownHeartbeat struct {
+	Client CountdownCanceler
+	Symbol string
+	// Countdown 交易所倒计时时长，默认 60s。
+	Countdown time.Duration
+	// Refresh 续期周期，默认 Countdown/4。
+	Refresh time.Duration
+	// StallAfter 距上次 Beat 超过该时长视为主循环停滞，默认 Countdown/2。
+	StallAfter time.Duration
+	// Healthy 可选：返回 false 时暂停续期。
+	Healthy func() bool
+	// OnError 续期请求失败时回调；OnPause 首次因停滞/不健康暂停续期时回调（reason 为 stalled/unhealthy）。
+	OnError func(error)
+	OnPause func(reason string)
+
+	lastBeat atomic.Int64 // UnixNano
+	paused   atomic.Bool
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// 暂停续期的原因。
+const (
+	HeartbeatPauseStalled   = "stalled"
+	HeartbeatPauseUnhealthy = "unhealthy"
+)
+
+// NewCountdownHeartbeat 创建倒计时心跳；countdown<=0 时使用默认 60s。
+func NewCountdownHeartbeat(client CountdownCanceler, symbol string, countdown time.Duration) *CountdownHeartbeat {
+	if countdown <= 0 {
+		countdown = 60 * time.Second
+	}
+	return &CountdownHeartbeat{Client: client, Symbol: symbol, Countdown: countdown}
+}
+
+// Beat 标记主循环仍在推进，每个 tick 调用一次。
+func (h *CountdownHeartbeat) Beat() {
+	h.lastBeat.Store(time.Now().UnixNano())
+}
+
+// Paused 返回当前是否处于暂停续期状态。
+func (h *CountdownHeartbeat) Paused() bool {
+	return h.paused.Load()
+}
+
+// Start 立即布防一次并启动续期循环；首次布防失败时返回错误且不启动。
+func (h *CountdownHeartbeat) Start(ctx context.Context) error {
+	if h.Countdown <= 0 {
+		h.Countdown = 60 * time.Second
+	}
+	if h.Refresh <= 0 {
+		h.Refresh = h.Countdown / 4
+	}
+	if h.StallAfter <= 0 {
+		h.StallAfter = h.Countdown / 2
+	}
+	h.Beat()
+	if err := h.Client.CountdownCancelAll(h.Symbol, h.Countdown); err != nil {
+		return err
+	}
+	loopCtx, cancel := context.WithCancel(ctx)
+	h.mu.Lock()
+	h.cancel = cancel
+	h.done = make(chan struct{})
+	done := h.done
+	h.mu.Unlock()
+	go h.loop(loopCtx, done)
+	return nil
+}
+
+// Stop 停止续期并取消交易所倒计时（正常退出时挂单由调用方自行处理）。
+func (h *CountdownHeartbeat) Stop() error {
+	h.mu.Lock()
+	cancel, done := h.cancel, h.done
+	h.cancel, h.done = nil, nil
+	h.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	<-done
+	return h.Client.CountdownCancelAll(h.Symbol, 0)
+}
+
+func (h *CountdownHeartbeat) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(h.Refresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.refresh()
+		}
+	}
+}
+
+// refresh 主循环健康时续期倒计时；否则跳过，任由倒计时到期。
+func (h *CountdownHeartbeat) refresh() {
+	reason := ""
+	if time.Since(time.Unix(0, h.lastBeat.Load())) > h.StallAfter {
+		reason = HeartbeatPauseStalled
+	} else if h.Healthy != nil && !h.Healthy() {
+		reason = HeartbeatPauseUnhealthy
+	}
+	if reason != "" {
+		if !h.paused.Swap(true) && h.OnPause != nil {
+			h.OnPause(reason)
+		}
+		return
+	}
+	if err := h.Client.CountdownCancelAll(h.Symbol, h.Countdown); err != nil {
+		if h.OnError != nil {
+			h.OnError(err)
+		}
+		return
+	}
+	h.paused.Store(false)
+}
@@ -0,0 +1,144 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countdownStub 记录 countdownCancelAll 请求的 countdownTime 序列。
+type countdownStub struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (s *countdownStub) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.Method != http.MethodPost || r.URL.Path != "/fapi/v1/countdownCancelAll" || q.Get("symbol") != "ETHUSDC" || q.Get("signature") == "" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		s.mu.Lock()
+		s.calls = append(s.calls, q.Get("countdownTime"))
+		s.mu.Unlock()
+		w.Write([]byte(`{"symbol":"ETHUSDC","countdownTime":"` + q.Get("countdownTime") + `"}`))
+	}
+}
+
+func (s *countdownStub) snapshot() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.calls...)
+}
+
+func newCountdownHeartbeatForTest(t *testing.T) (*CountdownHeartbeat, *countdownStub) {
+	stub := &countdownStub{}
+	ts := httptest.NewServer(stub.handler(t))
+	t.Cleanup(ts.Close)
+	cli := &BinanceRESTClient{BaseURL: ts.URL, APIKey: "key", Secret: "secret", HTTPClient: ts.Client(), Limiter: &mockLimiter{}}
+	hb := NewCountdownHeartbeat(cli, "ETHUSDC", 2*time.Second)
+	hb.Refresh = 10 * time.Millisecond
+	hb.StallAfter = 50 * time.Millisecond
+	return hb, stub
+}
+
+func TestCountdownHeartbeatRefreshesAndDisarms(t *testing.T) {
+	hb, stub := newCountdownHeartbeatForTest(t)
+	if err := hb.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(5 * time.Millisecond):
+				hb.Beat()
+			}
+		}
+	}()
+	eventually(t, func() bool { return len(stub.snapshot()) >= 4 })
+	close(stop)
+	if err := hb.Stop(); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	calls := stub.snapshot()
+	for _, c := range calls[:len(calls)-1] {
+		if c != "2000" {
+			t.Fatalf("expected countdownTime=2000 while armed, got %v", calls)
+		}
+	}
+	if calls[len(calls)-1] != "0" {
+		t.Fatalf("expected disarm with countdownTime=0, got %v", calls)
+	}
+}
+
+func TestCountdownHeartbeatStopsRefreshingWhenStalled(t *testing.T) {
+	hb, stub := newCountdownHeartbeatForTest(t)
+	var reason atomic.Value
+	hb.OnPause = func(r string) { reason.Store(r) }
+	if err := hb.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer hb.Stop()
+	// 不再 Beat：超过 StallAfter 后停止续期
+	eventually(t, hb.Paused)
+	if r, _ := reason.Load().(string); r != HeartbeatPauseStalled {
+		t.Fatalf("expected stalled pause, got %q", r)
+	}
+	n := len(stub.snapshot())
+	time.Sleep(60 * time.Millisecond)
+	if got := len(stub.snapshot()); got != n {
+		t.Fatalf("expected no refresh while stalled, calls %d -> %d", n, got)
+	}
+	// 主循环恢复后重新续期
+	hb.Beat()
+	eventually(t, func() bool { return !hb.Paused() && len(stub.snapshot()) > n })
+}
+
+func TestCountdownHeartbeatStopsRefreshingWhenUnhealthy(t *testing.T) {
+	hb, stub := newCountdownHeartbeatForTest(t)
+	var halted atomic.Bool
+	hb.Healthy = func() bool { return !halted.Load() }
+	var reason atomic.Value
+	hb.OnPause = func(r string) { reason.Store(r) }
+	if err := hb.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer hb.Stop()
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(5 * time.Millisecond):
+				hb.Beat()
+			}
+		}
+	}()
+	eventually(t, func() bool { return len(stub.snapshot()) >= 2 })
+	halted.Store(true)
+	eventually(t, hb.Paused)
+	if r, _ := reason.Load().(string); r != HeartbeatPauseUnhealthy {
+		t.Fatalf("expected unhealthy pause, got %q", r)
+	}
+	n := len(stub.snapshot())
+	time.Sleep(50 * time.Millisecond)
+	if got := len(stub.snapshot()); got != n {
+		t.Fatalf("expected no refresh while halted, calls %d -> %d", n, got)
+	}
+}
+
+func TestCountdownCancelAllRejectsNegative(t *testing.T) {
+	cli := &BinanceRESTClient{HTTPClient: http.DefaultClient}
+	if err := cli.CountdownCancelAll("ETHUSDC", -time.Second); err == nil {
+		t.Fatalf("expected error for negative countdown")
+	}
+}
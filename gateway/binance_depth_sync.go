@@ -0,0 +1,327 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"market-maker-go/market"
+)
+
+// DepthSnapshotSource 提供全量深度快照（BinanceRESTClient 已实现）。
+type DepthSnapshotSource interface {
+	DepthSnapshot(symbol string, limit int) (DepthSnapshot, error)
+}
+
+// DepthSynchronizer 消费 <symbol>@depth@100ms 增量流，按 Binance 规范维护完整 L2 订单簿：
+//  1. 未同步时缓存增量事件，并异步拉取 REST 快照；
+//  2. 丢弃 u < lastUpdateId 的事件，首条事件需满足 U <= lastUpdateId <= u；
+//  3. 之后每条事件的 pu 必须等于上一条的 u，否则判定缺口并自动重新同步。
+type DepthSynchronizer struct {
+	Symbol    string
+	Book      *market.OrderBook
+	Svc       *market.Service // 可选，同步后推送 best bid/ask
+	Snapshots DepthSnapshotSource
+	// SnapshotLimit 快照档数，默认 1000。
+	SnapshotLimit int
+	// MaxBuffer 未同步期间最多缓存的事件数，默认 1000。
+	MaxBuffer int
+	// RetryDelay 快照失败或回放仍有缺口时的首次重试间隔，之后按指数退避，默认 1s。
+	RetryDelay time.Duration
+	// MaxRetryDelay 退避上限，默认 30s；距上次拉取快照超过该值时退避清零。
+	MaxRetryDelay time.Duration
+	// MinResyncInterval 外部主动 Resync 的最小间隔，默认 5s（避免行情静默时反复拉快照）。
+	MinResyncInterval time.Duration
+	// OnResync 在每次开始重新同步时回调（reason 说明触发原因）。
+	OnResync func(reason string)
+	// OnSynced 快照重建并回放完缓存事件后回调，参数为此时的最优买卖价。
+	OnSynced func(bid, ask float64)
+
+	mu           sync.Mutex
+	synced       bool
+	syncing      bool
+	awaitFirst   bool
+	lastUpdateID int64
+	buffer       []DepthDiff
+	lastSnapshot time.Time
+	snapshots    int
+	lastFetch    time.Time
+	backoff      time.Duration
+	stop         chan struct{}
+	stopOnce     sync.Once
+}
+
+// NewDepthSynchronizer 创建增量深度同步器。
+func NewDepthSynchronizer(symbol string, book *market.OrderBook, src DepthSnapshotSource) *DepthSynchronizer {
+	return &DepthSynchronizer{
+		Symbol:    strings.ToUpper(symbol),
+		Book:      book,
+		Snapshots: src,
+		stop:      make(chan struct{}),
+	}
+}
+
+// Stop 停止快照拉取与重试等待，用于退出时不被退避阻塞。
+func (s *DepthSynchronizer) Stop() {
+	s.stopOnce.Do(func() {
+		if s.stop != nil {
+			close(s.stop)
+		}
+	})
+}
+
+// OnRawMessage 满足 interface { OnRawMessage([]byte) }，可直接交给 BinanceWSReal。
+func (s *DepthSynchronizer) OnRawMessage(msg []byte) {
+	if s == nil {
+		return
+	}
+	diff, err := ParseDepthDiff(msg)
+	if err != nil {
+		if errors.Is(err, ErrNonDepthUpdate) {
+			return
+		}
+		log.Printf("parse depth diff err: %v", err)
+		return
+	}
+	s.Apply(diff)
+}
+
+// Apply 处理一条增量事件：未同步则缓存，已同步则按序号校验后写入 OrderBook。
+func (s *DepthSynchronizer) Apply(d DepthDiff) {
+	if d.Symbol != "" && !strings.EqualFold(d.Symbol, s.Symbol) {
+		return
+	}
+	s.mu.Lock()
+	if !s.synced {
+		s.bufferLocked(d)
+		start := s.startSyncLocked()
+		s.mu.Unlock()
+		if start {
+			go s.fetchSnapshot()
+		}
+		return
+	}
+	if !s.processLocked(d) {
+		reason := fmt.Sprintf("gap pu=%d last=%d U=%d", d.PrevFinalUpdateID, s.lastUpdateID, d.FirstUpdateID)
+		s.synced = false
+		s.buffer = []DepthDiff{d}
+		start := s.startSyncLocked()
+		s.mu.Unlock()
+		s.notifyResync(reason)
+		if start {
+			go s.fetchSnapshot()
+		}
+		return
+	}
+	s.mu.Unlock()
+	s.publish()
+}
+
+// Resync 主动丢弃当前状态并重新拉取快照（例如 WS 重连或行情陈旧时）。
+// 距离上次快照不足 MinResyncInterval 或已在同步中时忽略。
+func (s *DepthSynchronizer) Resync(reason string) {
+	s.mu.Lock()
+	minInterval := s.MinResyncInterval
+	if minInterval <= 0 {
+		minInterval = 5 * time.Second
+	}
+	if s.syncing || (!s.lastSnapshot.IsZero() && time.Since(s.lastSnapshot) < minInterval) {
+		s.mu.Unlock()
+		return
+	}
+	s.synced = false
+	s.buffer = nil
+	s.startSyncLocked()
+	s.mu.Unlock()
+	s.notifyResync(reason)
+	go s.fetchSnapshot()
+}
+
+// Synced 返回订单簿当前是否与交易所连续同步。
+func (s *DepthSynchronizer) Synced() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.synced
+}
+
+// LastUpdateID 返回最近一次应用到订单簿的 update ID。
+func (s *DepthSynchronizer) LastUpdateID() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastUpdateID
+}
+
+// SnapshotCount 返回累计拉取快照的次数（含初始化）。
+func (s *DepthSynchronizer) SnapshotCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshots
+}
+
+func (s *DepthSynchronizer) startSyncLocked() bool {
+	if s.syncing {
+		return false
+	}
+	s.syncing = true
+	return true
+}
+
+func (s *DepthSynchronizer) bufferLocked(d DepthDiff) {
+	max := s.MaxBuffer
+	if max <= 0 {
+		max = 1000
+	}
+	s.buffer = append(s.buffer, d)
+	if len(s.buffer) > max {
+		s.buffer = s.buffer[len(s.buffer)-max:]
+	}
+}
+
+func (s *DepthSynchronizer) fetchSnapshot() {
+	for {
+		if s.Snapshots == nil {
+			log.Printf("depth sync %s: snapshot source not set", s.Symbol)
+			s.stopSyncing()
+			return
+		}
+		if !s.waitBackoff() {
+			s.stopSyncing()
+			return
+		}
+		snap, err := s.Snapshots.DepthSnapshot(s.Symbol, s.SnapshotLimit)
+		if err != nil {
+			log.Printf("depth sync %s: snapshot err: %v", s.Symbol, err)
+			continue
+		}
+		if s.applySnapshot(snap) {
+			s.publish()
+			if s.OnSynced != nil && s.Book != nil {
+				s.OnSynced(s.Book.Best())
+			}
+			return
+		}
+	}
+}
+
+// waitBackoff 在两次拉取快照之间按退避间隔等待（快照失败、回放缺口与同步后很快又出现缺口都会累加退避），
+// 期间 Stop 返回 false。
+func (s *DepthSynchronizer) waitBackoff() bool {
+	base, max := s.RetryDelay, s.MaxRetryDelay
+	if base <= 0 {
+		base = time.Second
+	}
+	if max <= 0 {
+		max = 30 * time.Second
+	}
+	s.mu.Lock()
+	since := time.Since(s.lastFetch)
+	if s.lastFetch.IsZero() || since >= max {
+		s.backoff = 0
+	}
+	wait := s.backoff - since
+	if s.backoff == 0 {
+		s.backoff = base
+	} else if s.backoff *= 2; s.backoff > max {
+		s.backoff = max
+	}
+	s.mu.Unlock()
+	if wait > 0 {
+		t := time.NewTimer(wait)
+		defer t.Stop()
+		select {
+		case <-s.stop:
+			return false
+		case <-t.C:
+		}
+	} else {
+		select {
+		case <-s.stop:
+			return false
+		default:
+		}
+	}
+	s.mu.Lock()
+	s.lastFetch = time.Now()
+	s.mu.Unlock()
+	return true
+}
+
+func (s *DepthSynchronizer) stopSyncing() {
+	s.mu.Lock()
+	s.syncing = false
+	s.mu.Unlock()
+}
+
+// applySnapshot 用快照重建订单簿并回放缓存事件；返回 false 表示缓存中仍有缺口需要重新拉取。
+func (s *DepthSynchronizer) applySnapshot(snap DepthSnapshot) bool {
+	s.mu.Lock()
+	s.snapshots++
+	s.lastSnapshot = time.Now()
+	if s.Book != nil {
+		s.Book.Reset(levelsToMap(snap.Bids), levelsToMap(snap.Asks))
+	}
+	s.lastUpdateID = snap.LastUpdateID
+	s.awaitFirst = true
+	pending := s.buffer
+	s.buffer = nil
+	for i, d := range pending {
+		if !s.processLocked(d) {
+			s.buffer = append(s.buffer, pending[i:]...)
+			reason := fmt.Sprintf("gap after snapshot lastUpdateId=%d U=%d", snap.LastUpdateID, d.FirstUpdateID)
+			s.mu.Unlock()
+			s.notifyResync(reason)
+			return false
+		}
+	}
+	s.synced = true
+	s.syncing = false
+	s.mu.Unlock()
+	return true
+}
+
+// processLocked 按 U/u/pu 规则应用单条增量；返回 false 表示检测到缺口。
+func (s *DepthSynchronizer) processLocked(d DepthDiff) bool {
+	if d.FinalUpdateID < s.lastUpdateID || (!s.awaitFirst && d.FinalUpdateID == s.lastUpdateID) {
+		// 早于快照或重复的事件直接丢弃
+		return true
+	}
+	if s.awaitFirst {
+		if d.FirstUpdateID > s.lastUpdateID {
+			return false
+		}
+		s.awaitFirst = false
+	} else if d.PrevFinalUpdateID != s.lastUpdateID {
+		return false
+	}
+	if s.Book != nil {
+		s.Book.ApplyDelta(levelsToMap(d.Bids), levelsToMap(d.Asks))
+	}
+	s.lastUpdateID = d.FinalUpdateID
+	return true
+}
+
+func (s *DepthSynchronizer) publish() {
+	if s.Svc == nil || s.Book == nil {
+		return
+	}
+	bid, ask := s.Book.Best()
+	s.Svc.OnDepth(s.Symbol, bid, ask, time.Now().UTC())
+}
+
+func (s *DepthSynchronizer) notifyResync(reason string) {
+	log.Printf("depth sync %s: resync (%s)", s.Symbol, reason)
+	if s.OnResync != nil {
+		s.OnResync(reason)
+	}
+}
+
+func levelsToMap(levels []DepthLevel) map[float64]float64 {
+	out := make(map[float64]float64, len(levels))
+	for _, lv := range levels {
+		out[lv.Price] = lv.Qty
+	}
+	return out
+}
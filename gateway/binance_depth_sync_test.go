@@ -0,0 +1,244 @@
+package gateway
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"market-maker-go/market"
+)
+
+type fixtureSnapshotSource struct {
+	mu    sync.Mutex
+	snaps []DepthSnapshot
+	calls int
+}
+
+func (f *fixtureSnapshotSource) DepthSnapshot(symbol string, limit int) (DepthSnapshot, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	idx := f.calls
+	if idx >= len(f.snaps) {
+		idx = len(f.snaps) - 1
+	}
+	f.calls++
+	return f.snaps[idx], nil
+}
+
+func loadSnapshotFixture(t *testing.T, name string) DepthSnapshot {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture %s: %v", name, err)
+	}
+	var dr depthResp
+	if err := json.Unmarshal(raw, &dr); err != nil {
+		t.Fatalf("decode fixture %s: %v", name, err)
+	}
+	snap := DepthSnapshot{LastUpdateID: dr.LastUpdateID}
+	if snap.Bids, err = parseDepthLevels(dr.Bids); err != nil {
+		t.Fatalf("parse bids: %v", err)
+	}
+	if snap.Asks, err = parseDepthLevels(dr.Asks); err != nil {
+		t.Fatalf("parse asks: %v", err)
+	}
+	return snap
+}
+
+func loadStreamFixture(t *testing.T, name string) [][]byte {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("open fixture %s: %v", name, err)
+	}
+	defer f.Close()
+	var msgs [][]byte
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		msgs = append(msgs, append([]byte(nil), line...))
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("scan fixture %s: %v", name, err)
+	}
+	return msgs
+}
+
+type failingSnapshotSource struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (f *failingSnapshotSource) DepthSnapshot(symbol string, limit int) (DepthSnapshot, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return DepthSnapshot{}, errors.New("503 service unavailable")
+}
+
+func (f *failingSnapshotSource) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func waitForUpdateID(t *testing.T, s *DepthSynchronizer, id int64) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if s.Synced() && s.LastUpdateID() == id {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("depth sync not reached update %d (synced=%v last=%d)", id, s.Synced(), s.LastUpdateID())
+}
+
+func TestDepthSynchronizerSnapshotAndReplay(t *testing.T) {
+	src := &fixtureSnapshotSource{snaps: []DepthSnapshot{loadSnapshotFixture(t, "depth_snapshot_btcusdt.json")}}
+	book := market.NewOrderBook()
+	pub := market.NewPublisher()
+	depthCh := pub.SubscribeDepth()
+	s := NewDepthSynchronizer("BTCUSDT", book, src)
+	s.Svc = market.NewService(pub)
+	syncedBid := make(chan float64, 1)
+	s.OnSynced = func(bid, ask float64) { syncedBid <- bid }
+
+	for _, msg := range loadStreamFixture(t, "depth_stream_btcusdt.jsonl") {
+		s.OnRawMessage(msg)
+	}
+	waitForUpdateID(t, s, 1012)
+	select {
+	case bid := <-syncedBid:
+		if bid <= 0 {
+			t.Fatalf("unexpected synced bid %.2f", bid)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("OnSynced not called")
+	}
+
+	bid, ask := book.Best()
+	if bid != 100.05 || ask != 100.15 {
+		t.Fatalf("unexpected best bid/ask %.2f/%.2f", bid, ask)
+	}
+	if got := book.BidVolume(100.00); got != 2.5 {
+		t.Fatalf("stale pre-snapshot event applied, bid 100.00 qty=%.3f", got)
+	}
+	if got := book.BidVolume(99.90); got != 0 {
+		t.Fatalf("expected 99.90 removed, qty=%.3f", got)
+	}
+	if got := book.BidVolume(99.70); got != 8 {
+		t.Fatalf("unexpected 99.70 qty %.3f", got)
+	}
+	if got := book.AskVolume(100.30); got != 0 {
+		t.Fatalf("expected 100.30 removed, qty=%.3f", got)
+	}
+	if n := len(book.BidPrices()) + len(book.AskPrices()); n != 6 {
+		t.Fatalf("expected 6 levels, got %d", n)
+	}
+	if s.SnapshotCount() != 1 {
+		t.Fatalf("expected single snapshot, got %d", s.SnapshotCount())
+	}
+	select {
+	case d := <-depthCh:
+		if d.Bid == 0 || d.Ask == 0 {
+			t.Fatalf("unexpected published depth %+v", d)
+		}
+	default:
+		t.Fatalf("expected depth published to service")
+	}
+}
+
+func TestDepthSynchronizerResyncOnGap(t *testing.T) {
+	src := &fixtureSnapshotSource{snaps: []DepthSnapshot{
+		loadSnapshotFixture(t, "depth_snapshot_btcusdt.json"),
+		loadSnapshotFixture(t, "depth_snapshot_btcusdt_resync.json"),
+	}}
+	book := market.NewOrderBook()
+	s := NewDepthSynchronizer("BTCUSDT", book, src)
+	s.RetryDelay = 10 * time.Millisecond
+	var mu sync.Mutex
+	var reasons []string
+	s.OnResync = func(reason string) {
+		mu.Lock()
+		reasons = append(reasons, reason)
+		mu.Unlock()
+	}
+
+	for _, msg := range loadStreamFixture(t, "depth_stream_btcusdt_gap.jsonl") {
+		s.OnRawMessage(msg)
+	}
+	waitForUpdateID(t, s, 1025)
+
+	if s.SnapshotCount() != 2 {
+		t.Fatalf("expected 2 snapshots after gap, got %d", s.SnapshotCount())
+	}
+	mu.Lock()
+	if len(reasons) == 0 {
+		t.Fatalf("expected resync callback on gap")
+	}
+	mu.Unlock()
+	bid, ask := book.Best()
+	if bid != 99.95 || ask != 100.20 {
+		t.Fatalf("unexpected best bid/ask after resync %.2f/%.2f", bid, ask)
+	}
+	if got := book.BidVolume(100.05); got != 0 {
+		t.Fatalf("pre-gap level should be dropped by resync, qty=%.3f", got)
+	}
+	if got := book.AskVolume(100.25); got != 5 {
+		t.Fatalf("unexpected 100.25 qty %.3f", got)
+	}
+}
+
+func TestDepthSynchronizerIgnoresOtherSymbols(t *testing.T) {
+	src := &fixtureSnapshotSource{snaps: []DepthSnapshot{loadSnapshotFixture(t, "depth_snapshot_btcusdt.json")}}
+	s := NewDepthSynchronizer("ETHUSDC", market.NewOrderBook(), src)
+	for _, msg := range loadStreamFixture(t, "depth_stream_btcusdt.jsonl") {
+		s.OnRawMessage(msg)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if s.SnapshotCount() != 0 || s.Synced() {
+		t.Fatalf("foreign symbol events should be ignored")
+	}
+}
+
+func TestDepthSynchronizerBackoffAndStop(t *testing.T) {
+	src := &failingSnapshotSource{}
+	s := NewDepthSynchronizer("BTCUSDT", market.NewOrderBook(), src)
+	s.RetryDelay = 20 * time.Millisecond
+	s.MaxRetryDelay = 80 * time.Millisecond
+	s.Apply(DepthDiff{Symbol: "BTCUSDT", FirstUpdateID: 1, FinalUpdateID: 2})
+	time.Sleep(150 * time.Millisecond)
+	// 退避 0/20/40/80ms：150ms 内最多 4 次，立即重试会打出上千次
+	if n := src.Calls(); n < 2 || n > 5 {
+		t.Fatalf("expected backed-off snapshot retries, got %d calls", n)
+	}
+	s.Stop()
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.mu.Lock()
+		syncing := s.syncing
+		s.mu.Unlock()
+		if !syncing {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("fetch loop did not exit after Stop")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	n := src.Calls()
+	time.Sleep(100 * time.Millisecond)
+	if src.Calls() != n {
+		t.Fatalf("snapshot fetched after Stop")
+	}
+}
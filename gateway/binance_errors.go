@@ -0,0 +1,273 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"market-maker-go/order"
+)
+
+// Binance 合约常见错误码（完整列表见官方 Error Codes 文档）。
+const (
+	CodeUnknown               = -1000
+	CodeDisconnected          = -1001
+	CodeTooManyRequests       = -1003
+	CodeUnexpectedResponse    = -1006
+	CodeTimeout               = -1007
+	CodeTooManyOrders         = -1015
+	CodeInvalidTimestamp      = -1021
+	CodeInvalidSignature      = -1022
+	CodeBadPrecision          = -1111
+	CodeListenKeyNotExist     = -1125
+	CodeNewOrderRejected      = -2010
+	CodeCancelRejected        = -2011
+	CodeNoSuchOrder           = -2013
+	CodeBadAPIKeyFormat       = -2014
+	CodeRejectedAPIKey        = -2015
+	CodeMarginInsufficient    = -2019
+	CodeWouldImmediateTrigger = -2021
+	CodeReduceOnlyReject      = -2022
+	CodeMaxOpenOrders         = -2025
+	CodeInvalidPrice          = -4013
+	CodePriceTickSize         = -4014
+	CodeNoNeedMarginType      = -4046
+	CodeNoNeedPositionSide    = -4059
+	CodePositionSideMismatch  = -4061
+	CodePercentPrice          = -4131
+	CodeMinNotional           = -4164
+	CodePostOnlyReject        = -5022
+	CodeNoNeedToModify        = -5027
+)
+
+// 错误哨兵：BinanceAPIError 通过 Unwrap 指向对应哨兵，调用方用 errors.Is 按类别处理。
+var (
+	ErrRateLimited          = errors.New("binance: rate limited")
+	ErrIPBanned             = errors.New("binance: ip banned")
+	ErrServerBusy           = errors.New("binance: server busy")
+	ErrTimestamp            = errors.New("binance: timestamp outside recvWindow")
+	ErrAuth                 = errors.New("binance: api key or signature rejected")
+	ErrFilterViolation      = errors.New("binance: order violates symbol filter")
+	ErrMinNotional          = errors.New("binance: order notional below minimum")
+	ErrNewOrderRejected     = errors.New("binance: new order rejected")
+	ErrCancelRejected       = errors.New("binance: cancel rejected")
+	ErrMarginInsufficient   = errors.New("binance: margin insufficient")
+	ErrReduceOnlyRejected   = errors.New("binance: reduce-only order rejected")
+	ErrPostOnlyReject       = errors.New("binance: post-only order would take liquidity")
+	ErrMaxOpenOrders        = errors.New("binance: max open orders exceeded")
+	ErrPositionSideMismatch = errors.New("binance: position side does not match account mode")
+	ErrNoChange             = errors.New("binance: no change needed")
+	ErrListenKeyExpired     = errors.New("binance: listenKey does not exist")
+	// ErrOrderNotFound 与 order.ErrRemoteOrderNotFound 为同一哨兵，供对账器在不依赖 gateway 的情况下识别。
+	ErrOrderNotFound = order.ErrRemoteOrderNotFound
+	// ErrExecutionUnknown 与 order.ErrExecutionUnknown 为同一哨兵，order.Manager 据此按 clientOrderId 确认下单结果。
+	ErrExecutionUnknown = order.ErrExecutionUnknown
+)
+
+// ErrorSemantics 描述调用方对某个错误码应采取的动作。
+type ErrorSemantics int
+
+const (
+	// SemanticReject 业务拒单：不重试，调整价格/数量/参数后再发。
+	SemanticReject ErrorSemantics = iota
+	// SemanticRetry 可立即重试（例如 -1021 校时后重新签名）。
+	SemanticRetry
+	// SemanticBackoff 退避后重试（限流、服务繁忙、保证金不足）。
+	SemanticBackoff
+	// SemanticFatal 配置或权限问题，需人工介入，不应自动重试。
+	SemanticFatal
+	// SemanticBenign 无害：状态已是目标值（如重复切换持仓模式）。
+	SemanticBenign
+)
+
+func (s ErrorSemantics) String() string {
+	switch s {
+	case SemanticRetry:
+		return "retry"
+	case SemanticBackoff:
+		return "backoff"
+	case SemanticFatal:
+		return "fatal"
+	case SemanticBenign:
+		return "benign"
+	default:
+		return "reject"
+	}
+}
+
+// CodeInfo 为错误码目录中的一项。
+type CodeInfo struct {
+	Name      string
+	Sentinel  error
+	Semantics ErrorSemantics
+	Type      ErrorType
+}
+
+var codeCatalogue = map[int]CodeInfo{
+	CodeUnknown:               {"UNKNOWN", ErrServerBusy, SemanticBackoff, ErrorTypeServer},
+	CodeDisconnected:          {"DISCONNECTED", ErrServerBusy, SemanticBackoff, ErrorTypeServer},
+	CodeTooManyRequests:       {"TOO_MANY_REQUESTS", ErrRateLimited, SemanticBackoff, ErrorTypeRateLimit},
+	CodeUnexpectedResponse:    {"UNEXPECTED_RESP", ErrExecutionUnknown, SemanticBackoff, ErrorTypeServer},
+	CodeTimeout:               {"TIMEOUT", ErrExecutionUnknown, SemanticBackoff, ErrorTypeServer},
+	CodeTooManyOrders:         {"TOO_MANY_ORDERS", ErrRateLimited, SemanticBackoff, ErrorTypeRateLimit},
+	CodeInvalidTimestamp:      {"INVALID_TIMESTAMP", ErrTimestamp, SemanticRetry, ErrorTypeTimestamp},
+	CodeInvalidSignature:      {"INVALID_SIGNATURE", ErrAuth, SemanticFatal, ErrorTypeAuth},
+	CodeBadPrecision:          {"BAD_PRECISION", ErrFilterViolation, SemanticReject, ErrorTypeClient},
+	CodeListenKeyNotExist:     {"INVALID_LISTEN_KEY", ErrListenKeyExpired, SemanticRetry, ErrorTypeClient},
+	CodeNewOrderRejected:      {"NEW_ORDER_REJECTED", ErrNewOrderRejected, SemanticReject, ErrorTypeInsufficientBalance},
+	CodeCancelRejected:        {"CANCEL_REJECTED", ErrCancelRejected, SemanticReject, ErrorTypeClient},
+	CodeNoSuchOrder:           {"NO_SUCH_ORDER", ErrOrderNotFound, SemanticReject, ErrorTypeClient},
+	CodeBadAPIKeyFormat:       {"BAD_API_KEY_FMT", ErrAuth, SemanticFatal, ErrorTypeAuth},
+	CodeRejectedAPIKey:        {"REJECTED_MBX_KEY", ErrAuth, SemanticFatal, ErrorTypeAuth},
+	CodeMarginInsufficient:    {"MARGIN_NOT_SUFFICIENT", ErrMarginInsufficient, SemanticBackoff, ErrorTypeInsufficientBalance},
+	CodeWouldImmediateTrigger: {"ORDER_WOULD_IMMEDIATELY_TRIGGER", ErrNewOrderRejected, SemanticReject, ErrorTypeClient},
+	CodeReduceOnlyReject:      {"REDUCE_ONLY_REJECT", ErrReduceOnlyRejected, SemanticReject, ErrorTypeClient},
+	CodeMaxOpenOrders:         {"MAX_OPEN_ORDER_EXCEEDED", ErrMaxOpenOrders, SemanticBackoff, ErrorTypeClient},
+	CodeInvalidPrice:          {"PRICE_LESS_THAN_MIN_PRICE", ErrFilterViolation, SemanticReject, ErrorTypeClient},
+	CodePriceTickSize:         {"PRICE_NOT_INCREASED_BY_TICK_SIZE", ErrFilterViolation, SemanticReject, ErrorTypeClient},
+	CodeNoNeedMarginType:      {"NO_NEED_TO_CHANGE_MARGIN_TYPE", ErrNoChange, SemanticBenign, ErrorTypeClient},
+	CodeNoNeedPositionSide:    {"NO_NEED_TO_CHANGE_POSITION_SIDE", ErrNoChange, SemanticBenign, ErrorTypeClient},
+	CodePositionSideMismatch:  {"POSITION_SIDE_NOT_MATCH", ErrPositionSideMismatch, SemanticFatal, ErrorTypeClient},
+	CodePercentPrice:          {"PERCENT_PRICE", ErrFilterViolation, SemanticReject, ErrorTypeClient},
+	CodeMinNotional:           {"MIN_NOTIONAL", ErrMinNotional, SemanticReject, ErrorTypeClient},
+	CodePostOnlyReject:        {"GTX_ORDER_REJECT", ErrPostOnlyReject, SemanticReject, ErrorTypePostOnlyReject},
+	CodeNoNeedToModify:        {"NO_NEED_TO_MODIFY_ORDER", ErrNoChange, SemanticBenign, ErrorTypeClient},
+}
+
+// LookupCode 查询错误码目录；未收录的错误码返回 false。
+func LookupCode(code int) (CodeInfo, bool) {
+	info, ok := codeCatalogue[code]
+	return info, ok
+}
+
+// BinanceAPIError 为交易所返回的结构化错误：HTTP 状态 + {"code":..,"msg":..}。
+// Op 为调用动作（如 "place limit"），Endpoint 为接口路径（如 /fapi/v1/order）或 WS API 方法名。
+type BinanceAPIError struct {
+	Op         string
+	Endpoint   string
+	HTTPStatus int
+	Code       int
+	Msg        string
+}
+
+// Error 保留 "<op> status <http>: {"code":..,"msg":..}" 格式，与此前的错误文本兼容。
+func (e *BinanceAPIError) Error() string {
+	op := e.Op
+	if op == "" {
+		op = e.Endpoint
+	}
+	if e.Code == 0 {
+		if e.Msg == "" {
+			return fmt.Sprintf("%s status %d", op, e.HTTPStatus)
+		}
+		return fmt.Sprintf("%s status %d: %s", op, e.HTTPStatus, e.Msg)
+	}
+	body, _ := json.Marshal(struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}{e.Code, e.Msg})
+	return fmt.Sprintf("%s status %d: %s", op, e.HTTPStatus, body)
+}
+
+// Unwrap 返回错误码对应的哨兵；未收录的错误码按 HTTP 状态归类（429/418/5xx）。
+func (e *BinanceAPIError) Unwrap() error {
+	if info, ok := codeCatalogue[e.Code]; ok {
+		return info.Sentinel
+	}
+	switch {
+	case e.HTTPStatus == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.HTTPStatus == 418:
+		return ErrIPBanned
+	case e.HTTPStatus == http.StatusUnauthorized || e.HTTPStatus == http.StatusForbidden:
+		return ErrAuth
+	case e.HTTPStatus >= 500:
+		// 5xx 时订单可能已被受理，需查询确认
+		return ErrExecutionUnknown
+	}
+	return nil
+}
+
+// Semantics 返回该错误的处理语义。
+func (e *BinanceAPIError) Semantics() ErrorSemantics {
+	if info, ok := codeCatalogue[e.Code]; ok {
+		return info.Semantics
+	}
+	switch {
+	case e.HTTPStatus == http.StatusTooManyRequests || e.HTTPStatus == 418 || e.HTTPStatus >= 500:
+		return SemanticBackoff
+	case e.HTTPStatus == http.StatusUnauthorized || e.HTTPStatus == http.StatusForbidden:
+		return SemanticFatal
+	}
+	return SemanticReject
+}
+
+// Type 返回兼容 ClassifyError 的错误类型。
+func (e *BinanceAPIError) Type() ErrorType {
+	if e.HTTPStatus == 401 || e.HTTPStatus == 403 || e.HTTPStatus == 429 || e.HTTPStatus == 418 {
+		return classifyStatus(e.HTTPStatus)
+	}
+	if info, ok := codeCatalogue[e.Code]; ok && e.HTTPStatus < 500 {
+		return info.Type
+	}
+	return classifyStatus(e.HTTPStatus)
+}
+
+// ParseAPIError 从响应体解析结构化错误；响应体不是 {"code","msg"} 时 Msg 保留原文。
+func ParseAPIError(op, endpoint string, status int, body []byte) *BinanceAPIError {
+	apiErr := &BinanceAPIError{Op: op, Endpoint: endpoint, HTTPStatus: status}
+	body = bytes.TrimSpace(body)
+	var payload struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	raw := body
+	if i := bytes.IndexByte(raw, '{'); i > 0 {
+		// 兼容 "<op> status 400: {...}" 形式的错误文本
+		raw = raw[i:]
+	}
+	if err := json.Unmarshal(raw, &payload); err == nil && payload.Code != 0 {
+		apiErr.Code = payload.Code
+		apiErr.Msg = payload.Msg
+		return apiErr
+	}
+	apiErr.Msg = string(body)
+	return apiErr
+}
+
+// newAPIError 由完整请求 URL 提取接口路径并解析错误体。
+func newAPIError(op, endpoint string, status int, body []byte) error {
+	path := endpoint
+	if u, err := url.Parse(endpoint); err == nil {
+		path = u.Path
+	}
+	return ParseAPIError(op, path, status, body)
+}
+
+// AsAPIError 从错误链中提取 BinanceAPIError（包括 WS API 错误）。
+func AsAPIError(err error) (*BinanceAPIError, bool) {
+	var apiErr *BinanceAPIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+// ErrorCode 返回错误链中的交易所错误码，非交易所错误返回 0。
+func ErrorCode(err error) int {
+	if apiErr, ok := AsAPIError(err); ok {
+		return apiErr.Code
+	}
+	return 0
+}
+
+// SemanticsOf 返回任意错误的处理语义；非交易所错误（网络错误等）视为需退避。
+func SemanticsOf(err error) ErrorSemantics {
+	if apiErr, ok := AsAPIError(err); ok {
+		return apiErr.Semantics()
+	}
+	return SemanticBackoff
+}
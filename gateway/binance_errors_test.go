@@ -0,0 +1,88 @@
+package gateway
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"market-maker-go/order"
+)
+
+func TestParseAPIError(t *testing.T) {
+	err := ParseAPIError("place limit", "/fapi/v1/order", 400, []byte(`{"code":-2019,"msg":"Margin is insufficient."}`))
+	if err.Code != CodeMarginInsufficient || err.Msg != "Margin is insufficient." || err.HTTPStatus != 400 {
+		t.Fatalf("unexpected parse %+v", err)
+	}
+	if !errors.Is(err, ErrMarginInsufficient) || errors.Is(err, ErrPostOnlyReject) {
+		t.Fatalf("sentinel mismatch for %v", err)
+	}
+	if err.Semantics() != SemanticBackoff || err.Type() != ErrorTypeInsufficientBalance {
+		t.Fatalf("unexpected semantics %s type %s", err.Semantics(), err.Type())
+	}
+	if got := err.Error(); got != `place limit status 400: {"code":-2019,"msg":"Margin is insufficient."}` {
+		t.Fatalf("error text changed: %s", got)
+	}
+
+	// 非 JSON 响应体：保留原文，按 HTTP 状态归类
+	raw := ParseAPIError("cancel", "/fapi/v1/order", 503, []byte("Service Unavailable"))
+	if raw.Code != 0 || raw.Msg != "Service Unavailable" || !errors.Is(raw, ErrExecutionUnknown) || raw.Semantics() != SemanticBackoff {
+		t.Fatalf("unexpected raw error %+v", raw)
+	}
+	if banned := ParseAPIError("request", "", 418, nil); !errors.Is(banned, ErrIPBanned) || banned.Type() != ErrorTypeRateLimit {
+		t.Fatalf("418 should map to ip ban, got %v", banned)
+	}
+	if nf := ParseAPIError("query order", "", 400, []byte(`{"code":-2013,"msg":"Order does not exist."}`)); !errors.Is(nf, order.ErrRemoteOrderNotFound) {
+		t.Fatalf("-2013 should unwrap to order.ErrRemoteOrderNotFound")
+	}
+	if fatal := ParseAPIError("place limit", "", 400, []byte(`{"code":-4061,"msg":"Order's position side does not match user's setting."}`)); fatal.Semantics() != SemanticFatal {
+		t.Fatalf("-4061 should be fatal")
+	}
+}
+
+func TestClassifyErrorUsesCatalogue(t *testing.T) {
+	cases := []struct {
+		status int
+		body   string
+		want   ErrorType
+	}{
+		{400, `{"code":-1021,"msg":"Timestamp for this request is outside of the recvWindow."}`, ErrorTypeTimestamp},
+		{400, `place limit status 400: {"code":-5022,"msg":"Post Only"}`, ErrorTypePostOnlyReject},
+		{400, `{"code":-2010,"msg":"Account has insufficient balance"}`, ErrorTypeInsufficientBalance},
+		{400, `{"code":-1102,"msg":"Mandatory parameter missing"}`, ErrorTypeClient},
+		{401, `{"code":-2015,"msg":"Invalid API-key"}`, ErrorTypeAuth},
+		{429, `{"code":-1003,"msg":"Too many requests"}`, ErrorTypeRateLimit},
+		{502, `Bad Gateway`, ErrorTypeServer},
+	}
+	for _, c := range cases {
+		if got := ClassifyError(c.status, c.body); got != c.want {
+			t.Errorf("ClassifyError(%d, %s) = %s, want %s", c.status, c.body, got, c.want)
+		}
+	}
+}
+
+func TestRESTAndWSErrorsAreTyped(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"code":-2022,"msg":"ReduceOnly Order is rejected."}`)
+	}))
+	defer ts.Close()
+	cli := &BinanceRESTClient{BaseURL: ts.URL, APIKey: "key", Secret: "secret", HTTPClient: ts.Client()}
+	_, err := cli.PlaceMarket("BTCUSDT", "SELL", 1, true, "")
+	apiErr, ok := AsAPIError(err)
+	if !ok || apiErr.Endpoint != "/fapi/v1/order" || apiErr.Code != CodeReduceOnlyReject || !errors.Is(err, ErrReduceOnlyRejected) {
+		t.Fatalf("expected typed -2022 error, got %#v", err)
+	}
+	if err := cli.CancelOrder("BTCUSDT", "1"); ErrorCode(err) != CodeReduceOnlyReject {
+		t.Fatalf("cancel should surface exchange code, got %v", err)
+	}
+
+	var wsErr error = &WSAPIError{Method: "order.place", Status: 400, Code: CodePostOnlyReject, Msg: "Post Only"}
+	if !errors.Is(wsErr, ErrPostOnlyReject) || ErrorCode(wsErr) != CodePostOnlyReject {
+		t.Fatalf("ws api error should unwrap to catalogue sentinel")
+	}
+	if SemanticsOf(errors.New("dial tcp: connection refused")) != SemanticBackoff {
+		t.Fatalf("transport errors should back off")
+	}
+}
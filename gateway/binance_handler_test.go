@@ -1,7 +1,9 @@
 package gateway
 
 import (
+	"math"
 	"testing"
+	"time"
 
 	"market-maker-go/market"
 )
@@ -19,3 +21,46 @@ func TestMarketDataHandler(t *testing.T) {
 	<-depthCh
 	<-tradeCh
 }
+
+func TestBinanceTradeHandlerFeedsVPIN(t *testing.T) {
+	pub := market.NewPublisher()
+	svc := market.NewService(pub)
+	vpin := market.NewVPINCalculator(1, 4, 0.4)
+	flow := &market.TradeFlow{Symbol: "BTCUSDT", VPIN: vpin}
+	pub.SubscribeTradeFunc(flow.OnTrade)
+	h := &BinanceTradeHandler{Svc: svc}
+	for _, msg := range loadStreamFixture(t, "aggtrade_btcusdt.jsonl") {
+		h.OnRawMessage(msg)
+	}
+	// 三个 1.0 成交量桶：全买、全卖、0.6/0.4 → (1+1+0.2)/3
+	if !vpin.IsReady() || math.Abs(vpin.GetVPIN()-2.2/3) > 1e-9 {
+		t.Fatalf("unexpected vpin %.6f ready=%v", vpin.GetVPIN(), vpin.IsReady())
+	}
+	last, ok := svc.LastTrade("BTCUSDT")
+	if !ok || last.ID != 106 || last.IsBuy || !last.Ts.Equal(time.UnixMilli(1700000000600)) {
+		t.Fatalf("unexpected last trade %+v", last)
+	}
+}
+
+func TestBinanceMarkPriceHandlerTracksFunding(t *testing.T) {
+	svc := market.NewService(nil)
+	var updates []market.FundingState
+	h := &BinanceMarkPriceHandler{Svc: svc, OnUpdate: func(st market.FundingState) { updates = append(updates, st) }}
+	for _, msg := range loadStreamFixture(t, "markprice_btcusdt.jsonl") {
+		h.OnRawMessage(msg)
+	}
+	if len(updates) != 3 {
+		t.Fatalf("expected 3 mark price updates, got %d", len(updates))
+	}
+	st, ok := svc.Funding("BTCUSDT")
+	if !ok || st.MarkPrice != 36490 || st.IndexPrice != 36492.5 || st.FundingRate != -0.00003 {
+		t.Fatalf("unexpected funding state %+v", st)
+	}
+	// 结算时间前移：上一期费率 0.000125 记为 LastFundingRate
+	if st.LastFundingRate != 0.000125 || !st.NextFundingTime.Equal(time.UnixMilli(1700035200000)) {
+		t.Fatalf("funding roll not tracked: %+v", st)
+	}
+	if mark, ok := svc.MarkPrice("BTCUSDT"); !ok || mark != 36490 {
+		t.Fatalf("expected fresh mark price, got %.2f ok=%v", mark, ok)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"time"
@@ -34,7 +35,8 @@ func (c *ListenKeyClient) NewListenKey() (string, error) {
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
-		return "", fmt.Errorf("new listenKey status %d", resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		return "", newAPIError("new listenKey", endpoint, resp.StatusCode, body)
 	}
 	var lr listenKeyResp
 	if err := json.NewDecoder(resp.Body).Decode(&lr); err != nil {
@@ -62,12 +64,10 @@ func (c *ListenKeyClient) KeepAlive(listenKey string) error {
 		return err
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusTooManyRequests+29 {
-		// Binance 429 / 418
-		return fmt.Errorf("keepalive rate limited: %d", resp.StatusCode)
-	}
 	if resp.StatusCode >= 300 {
-		return fmt.Errorf("keepalive status %d", resp.StatusCode)
+		// 429/418 归为限流；-1125 表示 listenKey 已失效，需要重新创建
+		body, _ := io.ReadAll(resp.Body)
+		return newAPIError("keepalive", endpoint, resp.StatusCode, body)
 	}
 	return nil
 }
@@ -89,7 +89,8 @@ func (c *ListenKeyClient) CloseListenKey(listenKey string) error {
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
-		return fmt.Errorf("close status %d", resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		return newAPIError("close listenKey", endpoint, resp.StatusCode, body)
 	}
 	return nil
 }
@@ -0,0 +1,208 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ListenKeyManager 负责 listenKey 的完整生命周期：创建、定时续期、失效后轮换、关闭。
+// 续期失败（含 -1125）或收到 listenKeyExpired 时重新创建 key：先回调 OnRotate 让调用方
+// 重新订阅用户流，再回调 OnResync，由调用方通过 REST 补齐失效期间漏掉的订单与仓位变化。
+type ListenKeyManager struct {
+	Client     *ListenKeyClient
+	Interval   time.Duration // 续期间隔，默认 30m（listenKey 有效期 60m）
+	RetryDelay time.Duration // 续期/创建失败后的重试间隔，默认 5s
+
+	OnRotate func(oldKey, newKey string)
+	OnResync func(reason string)
+	OnError  func(err error)
+
+	mu       sync.Mutex
+	key      string
+	expired  chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+	started  bool
+	stopOnce sync.Once
+}
+
+// 轮换原因，随 OnResync 透传给调用方。
+const (
+	ListenKeyReasonExpired   = "listenkey_expired"
+	ListenKeyReasonKeepAlive = "keepalive_failed"
+)
+
+// NewListenKeyManager 使用默认续期参数创建管理器。
+func NewListenKeyManager(client *ListenKeyClient) *ListenKeyManager {
+	return &ListenKeyManager{
+		Client:     client,
+		Interval:   30 * time.Minute,
+		RetryDelay: 5 * time.Second,
+		expired:    make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Start 创建 listenKey 并启动后台续期；只能调用一次。
+func (m *ListenKeyManager) Start() (string, error) {
+	if m.Client == nil {
+		return "", fmt.Errorf("listenKey client not set")
+	}
+	m.mu.Lock()
+	if m.started {
+		m.mu.Unlock()
+		return "", fmt.Errorf("listenKey manager already started")
+	}
+	m.started = true
+	m.mu.Unlock()
+
+	key, err := m.Client.NewListenKey()
+	if err != nil {
+		close(m.done)
+		return "", err
+	}
+	m.mu.Lock()
+	m.key = key
+	m.mu.Unlock()
+	go m.loop()
+	return key, nil
+}
+
+// Key 返回当前有效的 listenKey。
+func (m *ListenKeyManager) Key() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.key
+}
+
+// HandleExpired 处理用户流推送的 listenKeyExpired；旧 key 的迟到事件会被忽略。
+// 非阻塞，可直接在 WS 读循环中调用，实际轮换在后台协程完成。
+func (m *ListenKeyManager) HandleExpired(listenKey string) {
+	if listenKey != "" && listenKey != m.Key() {
+		return
+	}
+	select {
+	case m.expired <- struct{}{}:
+	default:
+	}
+}
+
+// Rotate 立即创建新 listenKey 并依次回调 OnRotate、OnResync。
+func (m *ListenKeyManager) Rotate(reason string) error {
+	key, err := m.Client.NewListenKey()
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	old := m.key
+	m.key = key
+	m.mu.Unlock()
+	if m.OnRotate != nil {
+		m.OnRotate(old, key)
+	}
+	if m.OnResync != nil {
+		m.OnResync(reason)
+	}
+	return nil
+}
+
+// Close 停止续期并关闭当前 listenKey。
+func (m *ListenKeyManager) Close() error {
+	m.stopOnce.Do(func() { close(m.stop) })
+	m.mu.Lock()
+	started := m.started
+	m.mu.Unlock()
+	if !started {
+		return nil
+	}
+	<-m.done
+	key := m.Key()
+	if key == "" {
+		return nil
+	}
+	return m.Client.CloseListenKey(key)
+}
+
+func (m *ListenKeyManager) loop() {
+	defer close(m.done)
+	interval := m.Interval
+	if interval <= 0 {
+		interval = 30 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-m.expired:
+			m.rotateUntilOK(ListenKeyReasonExpired)
+		case <-ticker.C:
+			m.keepAlive()
+		}
+	}
+}
+
+// keepAlive 续期当前 key：-1125 直接轮换，其他错误先重试一次，仍失败再轮换。
+func (m *ListenKeyManager) keepAlive() {
+	key := m.Key()
+	err := m.Client.KeepAlive(key)
+	if err == nil {
+		return
+	}
+	m.reportError(err)
+	if !errors.Is(err, ErrListenKeyExpired) {
+		if !m.wait(m.retryDelay()) {
+			return
+		}
+		if err = m.Client.KeepAlive(key); err == nil {
+			return
+		}
+		m.reportError(err)
+	}
+	m.rotateUntilOK(ListenKeyReasonKeepAlive)
+}
+
+// rotateUntilOK 持续重试创建 listenKey，直到成功或管理器关闭。
+func (m *ListenKeyManager) rotateUntilOK(reason string) {
+	for {
+		err := m.Rotate(reason)
+		if err == nil {
+			return
+		}
+		m.reportError(err)
+		if !m.wait(m.retryDelay()) {
+			return
+		}
+	}
+}
+
+func (m *ListenKeyManager) wait(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-m.stop:
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+func (m *ListenKeyManager) retryDelay() time.Duration {
+	if m.RetryDelay > 0 {
+		return m.RetryDelay
+	}
+	return 5 * time.Second
+}
+
+func (m *ListenKeyManager) reportError(err error) {
+	if m.OnError != nil {
+		m.OnError(err)
+		return
+	}
+	log.Printf("listenKey manager err: %v", err)
+}
@@ -0,0 +1,144 @@
+package gateway
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeListenKeyServer 按序发放 lk-N；PUT 时若 key 已被标记失效返回 -1125。
+type fakeListenKeyServer struct {
+	mu      sync.Mutex
+	next    int
+	dead    map[string]bool
+	closed  []string
+	renewed int
+}
+
+func (s *fakeListenKeyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := r.URL.Query().Get("listenKey")
+	switch r.Method {
+	case http.MethodPost:
+		s.next++
+		fmt.Fprintf(w, `{"listenKey":"lk-%d"}`, s.next)
+	case http.MethodPut:
+		if s.dead[key] {
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"code":-1125,"msg":"This listenKey does not exist."}`)
+			return
+		}
+		s.renewed++
+		io.WriteString(w, `{}`)
+	case http.MethodDelete:
+		s.closed = append(s.closed, key)
+		io.WriteString(w, `{}`)
+	}
+}
+
+type rotateRecorder struct {
+	mu      sync.Mutex
+	rotated [][2]string
+	reasons []string
+}
+
+func (r *rotateRecorder) snapshot() ([][2]string, []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][2]string(nil), r.rotated...), append([]string(nil), r.reasons...)
+}
+
+func newTestListenKeyManager(t *testing.T, srv *fakeListenKeyServer, interval time.Duration) (*ListenKeyManager, *rotateRecorder) {
+	t.Helper()
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	m := NewListenKeyManager(&ListenKeyClient{BaseURL: ts.URL, APIKey: "key", HTTPClient: ts.Client()})
+	m.Interval = interval
+	m.RetryDelay = 10 * time.Millisecond
+	rec := &rotateRecorder{}
+	m.OnRotate = func(oldKey, newKey string) {
+		rec.mu.Lock()
+		rec.rotated = append(rec.rotated, [2]string{oldKey, newKey})
+		rec.mu.Unlock()
+	}
+	m.OnResync = func(reason string) {
+		rec.mu.Lock()
+		rec.reasons = append(rec.reasons, reason)
+		rec.mu.Unlock()
+	}
+	m.OnError = func(error) {}
+	return m, rec
+}
+
+func TestListenKeyManagerRotatesOnKeepAliveError(t *testing.T) {
+	srv := &fakeListenKeyServer{dead: map[string]bool{"lk-1": true}}
+	m, rec := newTestListenKeyManager(t, srv, 20*time.Millisecond)
+	key, err := m.Start()
+	if err != nil || key != "lk-1" {
+		t.Fatalf("start: %s %v", key, err)
+	}
+	eventually(t, func() bool {
+		rotated, _ := rec.snapshot()
+		return len(rotated) == 1
+	})
+	rotated, reasons := rec.snapshot()
+	if rotated[0] != [2]string{"lk-1", "lk-2"} || reasons[0] != ListenKeyReasonKeepAlive {
+		t.Fatalf("unexpected rotation %v reasons %v", rotated, reasons)
+	}
+	if m.Key() != "lk-2" {
+		t.Fatalf("manager should hold the new key, got %s", m.Key())
+	}
+	// 新 key 正常续期，不再轮换
+	eventually(t, func() bool {
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+		return srv.renewed > 0
+	})
+	if err := m.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if rotated, _ := rec.snapshot(); len(rotated) != 1 {
+		t.Fatalf("healthy key should not rotate again: %v", rotated)
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.closed) != 1 || srv.closed[0] != "lk-2" {
+		t.Fatalf("close should release the current key, got %v", srv.closed)
+	}
+}
+
+func TestListenKeyManagerRotatesOnExpiredEvent(t *testing.T) {
+	srv := &fakeListenKeyServer{dead: map[string]bool{}}
+	m, rec := newTestListenKeyManager(t, srv, time.Hour)
+	if _, err := m.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer m.Close()
+
+	var expired []string
+	h := &BinanceUserHandler{OnListenKeyExpired: func(k string) {
+		expired = append(expired, k)
+		m.HandleExpired(k)
+	}}
+	h.OnRawMessage([]byte(`{"stream":"lk-1","data":{"e":"listenKeyExpired","E":"1736996475556","listenKey":"lk-1"}}`))
+	eventually(t, func() bool {
+		rotated, _ := rec.snapshot()
+		return len(rotated) == 1
+	})
+	// 旧 key 的迟到事件（仅带 stream 名）不应再次轮换
+	h.OnRawMessage([]byte(`{"stream":"lk-1","data":{"e":"listenKeyExpired","E":1736996475600}}`))
+	time.Sleep(50 * time.Millisecond)
+
+	rotated, reasons := rec.snapshot()
+	if len(rotated) != 1 || rotated[0] != [2]string{"lk-1", "lk-2"} || reasons[0] != ListenKeyReasonExpired {
+		t.Fatalf("unexpected rotation %v reasons %v", rotated, reasons)
+	}
+	if len(expired) != 2 || expired[0] != "lk-1" || expired[1] != "lk-1" {
+		t.Fatalf("handler should surface the expired key, got %v", expired)
+	}
+}
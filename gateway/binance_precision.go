@@ -0,0 +1,98 @@
+package gateway
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"market-maker-go/order"
+)
+
+// SymbolPrecision 保存各交易对的 tickSize/stepSize，下单序列化时据此输出
+// 恰为 tick/step 整数倍、且小数位不超过其精度的价格/数量字符串。
+// 为 nil 或未登记的交易对按 formatDecimal 输出（最多 8 位小数，去掉末尾的 0）。
+type SymbolPrecision struct {
+	mu      sync.RWMutex
+	symbols map[string]order.SymbolConstraints
+}
+
+// NewSymbolPrecision 创建空的精度表。
+func NewSymbolPrecision() *SymbolPrecision {
+	return &SymbolPrecision{symbols: make(map[string]order.SymbolConstraints)}
+}
+
+// Set 登记交易对的精度约束。
+func (p *SymbolPrecision) Set(symbol string, c order.SymbolConstraints) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.symbols[strings.ToUpper(symbol)] = c
+}
+
+// SetExchangeInfo 以 exchangeInfo 的过滤器登记（覆盖配置中的值）。
+func (p *SymbolPrecision) SetExchangeInfo(infos []ExchangeSymbolInfo) {
+	for _, info := range infos {
+		p.Set(info.Symbol, info.Constraints())
+	}
+}
+
+// Constraints 查询已登记的精度约束。
+func (p *SymbolPrecision) Constraints(symbol string) (order.SymbolConstraints, bool) {
+	if p == nil {
+		return order.SymbolConstraints{}, false
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	c, ok := p.symbols[strings.ToUpper(symbol)]
+	return c, ok
+}
+
+// FormatPrice 输出下单用的价格字符串；价格偏离 tick 网格时返回 ErrFilterViolation，不发往交易所。
+func (p *SymbolPrecision) FormatPrice(symbol string, price float64) (string, error) {
+	c, ok := p.Constraints(symbol)
+	if !ok || c.TickSize <= 0 {
+		return formatDecimal(price), nil
+	}
+	d := c.QuantizePrice(price, order.RoundNearest)
+	if !onGrid(price, d.Float64(), c.TickSize) {
+		return "", fmt.Errorf("%w: price %s not aligned to tickSize %s", ErrFilterViolation, formatDecimal(price), c.PriceTick())
+	}
+	return d.String(), nil
+}
+
+// FormatQty 输出下单用的数量字符串；数量偏离 step 网格时返回 ErrFilterViolation。
+func (p *SymbolPrecision) FormatQty(symbol string, qty float64) (string, error) {
+	c, ok := p.Constraints(symbol)
+	if !ok || c.StepSize <= 0 {
+		return formatDecimal(qty), nil
+	}
+	d := c.QuantizeQty(qty, order.RoundNearest)
+	if !onGrid(qty, d.Float64(), c.StepSize) {
+		return "", fmt.Errorf("%w: qty %s not aligned to stepSize %s", ErrFilterViolation, formatDecimal(qty), c.QtyStep())
+	}
+	return d.String(), nil
+}
+
+// onGrid 判断 v 与网格点 snapped 的差是否仅为浮点误差（远小于一个 step）。
+func onGrid(v, snapped, step float64) bool {
+	return math.Abs(v-snapped) <= step*1e-6
+}
+
+// formatDecimal 无精度信息时的兜底格式：四舍五入到 8 位小数并去掉末尾的 0。
+func formatDecimal(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 8, 64)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// Constraints 把 exchangeInfo 过滤器转换为 order.SymbolConstraints。
+func (i ExchangeSymbolInfo) Constraints() order.SymbolConstraints {
+	return order.SymbolConstraints{
+		TickSize:    i.TickSize,
+		StepSize:    i.StepSize,
+		MinQty:      i.MinQty,
+		MaxQty:      i.MaxQty,
+		MinNotional: i.MinNotional,
+	}
+}
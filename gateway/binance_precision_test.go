@@ -0,0 +1,131 @@
+package gateway
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"market-maker-go/order"
+)
+
+// filterCase 随机生成的 exchangeInfo 过滤器与一笔待下单的价格/数量。
+type filterCase struct {
+	Info  ExchangeSymbolInfo
+	Price float64
+	Qty   float64
+}
+
+// Generate 实现 quick.Generator：tick/step 取 {1,5,25}×10^-k，覆盖 Binance 上常见的精度组合。
+func (filterCase) Generate(r *rand.Rand, _ int) reflect.Value {
+	grid := func(maxExp int) float64 {
+		mant := []float64{1, 5, 25}[r.Intn(3)]
+		return mant * math.Pow10(-r.Intn(maxExp+1))
+	}
+	info := ExchangeSymbolInfo{Symbol: "TESTUSDT", TickSize: grid(8), StepSize: grid(6)}
+	info.MinPrice, info.MaxPrice = info.TickSize, 1e6
+	info.MinQty, info.MaxQty = info.StepSize, 1e4
+	return reflect.ValueOf(filterCase{
+		Info:  info,
+		Price: info.MinPrice + r.Float64()*r.Float64()*(info.MaxPrice-info.MinPrice),
+		Qty:   info.MinQty + r.Float64()*r.Float64()*(info.MaxQty-info.MinQty),
+	})
+}
+
+// exactMultiple 判断十进制字符串 s 是否恰为 step 的整数倍，且小数位不超过 step 的精度。
+func exactMultiple(s string, step float64) bool {
+	v, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return false
+	}
+	stepDec := order.DecimalFromFloat(step)
+	st, _ := new(big.Rat).SetString(stepDec.String())
+	if !new(big.Rat).Quo(v, st).IsInt() {
+		return false
+	}
+	if i := strings.IndexByte(s, '.'); i >= 0 && len(s)-i-1 > stepDec.Scale() {
+		return false
+	}
+	return true
+}
+
+func TestSymbolPrecisionMatchesExchangeFilters(t *testing.T) {
+	prop := func(fc filterCase) bool {
+		prec := NewSymbolPrecision()
+		prec.SetExchangeInfo([]ExchangeSymbolInfo{fc.Info})
+		c := fc.Info.Constraints()
+
+		// 上游按 tick/step 对齐后的 float（可能带尾差）必须序列化为精确倍数
+		price := c.QuantizePrice(fc.Price, order.RoundDown).Float64()
+		qty := c.QuantizeQty(fc.Qty, order.RoundDown).Float64()
+		params, err := limitOrderParams(prec, fc.Info.Symbol, "BUY", "GTC", price, qty, false, true, "", OrderOptions{})
+		if err != nil {
+			t.Logf("case %+v: %v", fc, err)
+			return false
+		}
+		if !exactMultiple(params["price"], fc.Info.TickSize) || !exactMultiple(params["quantity"], fc.Info.StepSize) {
+			t.Logf("case %+v: price=%s qty=%s", fc, params["price"], params["quantity"])
+			return false
+		}
+		// 容差取 tick 的 1e-6 与输入 float 的 2 个 ulp 中较大者：1e6 量级价格配 1e-8 tick 已超出 float64 分辨率
+		tol := math.Max(fc.Info.TickSize*1e-6, 2*(math.Nextafter(fc.Price, math.Inf(1))-fc.Price))
+		if p, _ := new(big.Rat).SetString(params["price"]); p.Cmp(new(big.Rat).SetFloat64(fc.Price+tol)) > 0 {
+			t.Logf("round down moved price above input: %s > %v", params["price"], fc.Price)
+			return false
+		}
+
+		// 偏离网格半个 tick 的价格在本地拒绝，不发往交易所
+		_, err = prec.FormatPrice(fc.Info.Symbol, price+fc.Info.TickSize/2)
+		return errors.Is(err, ErrFilterViolation)
+	}
+	if err := quick.Check(prop, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFormatWithoutPrecisionFallsBack(t *testing.T) {
+	var prec *SymbolPrecision
+	params, err := limitOrderParams(prec, "ETHUSDC", "SELL", "GTC", 0.1+0.2, 0.000123456, false, false, "", OrderOptions{})
+	if err != nil {
+		t.Fatalf("params: %v", err)
+	}
+	if params["price"] != "0.3" || params["quantity"] != "0.00012346" {
+		t.Fatalf("unexpected fallback formatting price=%s qty=%s", params["price"], params["quantity"])
+	}
+}
+
+func TestLimitOrderParamsMatchOptions(t *testing.T) {
+	params, err := limitOrderParams(nil, "ETHUSDC", "BUY", "GTC", 2000.5, 0.1, false, true, "c1", OrderOptions{SelfTradePrevention: "expire_maker", PriceMatch: "QUEUE"})
+	if err != nil {
+		t.Fatalf("params: %v", err)
+	}
+	if params["selfTradePreventionMode"] != "EXPIRE_MAKER" || params["priceMatch"] != "QUEUE" || params["timeInForce"] != "GTX" {
+		t.Fatalf("unexpected params %v", params)
+	}
+	// priceMatch 与 price 互斥
+	if _, ok := params["price"]; ok || params["quantity"] != "0.1" {
+		t.Fatalf("price must be omitted with priceMatch: %v", params)
+	}
+	// 对手价定价必然吃单，不能是 postOnly
+	if _, err := limitOrderParams(nil, "ETHUSDC", "BUY", "GTC", 2000.5, 0.1, false, true, "c2", OrderOptions{PriceMatch: "OPPONENT_5"}); err == nil {
+		t.Fatalf("expected error for OPPONENT with postOnly")
+	}
+	if _, err := limitOrderParams(nil, "ETHUSDC", "BUY", "GTC", 2000.5, 0.1, false, false, "c3", OrderOptions{SelfTradePrevention: "EXPIRE_ALL"}); err == nil {
+		t.Fatalf("expected error for unknown STP mode")
+	}
+	if _, err := limitOrderParams(nil, "ETHUSDC", "BUY", "GTC", 2000.5, 0.1, false, false, "c4", OrderOptions{PriceMatch: "BEST"}); err == nil {
+		t.Fatalf("expected error for unknown priceMatch")
+	}
+
+	mod, err := modifyOrderParams(nil, "ETHUSDC", "1001", "SELL", 0, 0.2, OrderOptions{PriceMatch: "QUEUE_5"})
+	if err != nil {
+		t.Fatalf("modify params: %v", err)
+	}
+	if _, ok := mod["price"]; ok || mod["priceMatch"] != "QUEUE_5" || mod["quantity"] != "0.2" {
+		t.Fatalf("unexpected modify params %v", mod)
+	}
+}
@@ -0,0 +1,180 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Binance /fapi/v1/batchOrders 单次请求上限。
+const (
+	MaxBatchPlaceOrders  = 5
+	MaxBatchCancelOrders = 10
+)
+
+// BatchLimitOrder 为批量下单中的一笔 LIMIT 单，字段语义同 PlaceLimit。
+type BatchLimitOrder struct {
+	Symbol      string
+	Side        string
+	TimeInForce string
+	Price       float64
+	Qty         float64
+	ReduceOnly  bool
+	PostOnly    bool
+	ClientID    string
+	// PositionSide 双向持仓下的腿（LONG/SHORT），单向持仓留空。
+	PositionSide string
+	// SelfTradePrevention/PriceMatch 见 OrderOptions。
+	SelfTradePrevention string
+	PriceMatch          string
+}
+
+// Options 返回该笔的下单选项。
+func (o BatchLimitOrder) Options() OrderOptions {
+	return OrderOptions{PositionSide: o.PositionSide, SelfTradePrevention: o.SelfTradePrevention, PriceMatch: o.PriceMatch}
+}
+
+// BatchOrderResult 为批量请求中单笔的结果，顺序与请求一致；Err 非空表示该笔失败。
+// Err 文本保留交易所返回的 {"code":..,"msg":..}，与单笔接口的错误识别方式一致。
+type BatchOrderResult struct {
+	OrderID       string
+	ClientOrderID string
+	Err           error
+}
+
+// BinanceBatchREST 批量下单/撤单接口，由 BinanceRESTClient 实现。
+type BinanceBatchREST interface {
+	PlaceBatch(orders []BatchLimitOrder) ([]BatchOrderResult, error)
+	CancelBatch(symbol string, orderIDs []string) ([]BatchOrderResult, error)
+}
+
+type batchItemResp struct {
+	OrderID       json.Number `json:"orderId"`
+	ClientOrderID string      `json:"clientOrderId"`
+	Code          int         `json:"code"`
+	Msg           string      `json:"msg"`
+}
+
+// PlaceBatch 调用 POST /fapi/v1/batchOrders 批量下 LIMIT 单，超过 5 笔时自动分批。
+// 单笔失败见 BatchOrderResult.Err；某一批请求整体失败（网络/签名等）时返回 error，
+// 该批及其后未发送的各笔 Err 均置为该错误，results 长度始终与 orders 一致。
+// 请求可能已送达的传输错误（连接重置、读响应 EOF、响应无法解析）包装为 ErrExecutionUnknown，由调用方按 clientOrderId 确认。
+func (c *BinanceRESTClient) PlaceBatch(orders []BatchLimitOrder) ([]BatchOrderResult, error) {
+	if c == nil || c.HTTPClient == nil {
+		return nil, fmt.Errorf("http client not set")
+	}
+	results := make([]BatchOrderResult, 0, len(orders))
+	for start := 0; start < len(orders); start += MaxBatchPlaceOrders {
+		end := start + MaxBatchPlaceOrders
+		if end > len(orders) {
+			end = len(orders)
+		}
+		items := make([]map[string]string, 0, end-start)
+		for _, o := range orders[start:end] {
+			params, err := limitOrderParams(c.Precision, o.Symbol, o.Side, o.TimeInForce, o.Price, o.Qty, o.ReduceOnly, o.PostOnly, o.ClientID, o.Options())
+			if err != nil {
+				return failRemaining(results, len(orders), err), err
+			}
+			items = append(items, params)
+		}
+		raw, _ := json.Marshal(items)
+		chunk, err := c.sendBatch(http.MethodPost, map[string]string{"batchOrders": string(raw)}, "place batch", len(items))
+		if err != nil {
+			err = placeOutcomeUnknown(err)
+			return failRemaining(results, len(orders), err), err
+		}
+		results = append(results, chunk...)
+	}
+	return results, nil
+}
+
+// CancelBatch 调用 DELETE /fapi/v1/batchOrders（orderIdList）批量撤单，超过 10 笔时自动分批；
+// 错误语义同 PlaceBatch。
+func (c *BinanceRESTClient) CancelBatch(symbol string, orderIDs []string) ([]BatchOrderResult, error) {
+	if c == nil || c.HTTPClient == nil {
+		return nil, fmt.Errorf("http client not set")
+	}
+	if symbol == "" {
+		return nil, fmt.Errorf("symbol required")
+	}
+	results := make([]BatchOrderResult, 0, len(orderIDs))
+	for start := 0; start < len(orderIDs); start += MaxBatchCancelOrders {
+		end := start + MaxBatchCancelOrders
+		if end > len(orderIDs) {
+			end = len(orderIDs)
+		}
+		params := map[string]string{
+			"symbol":      symbol,
+			"orderIdList": "[" + strings.Join(orderIDs[start:end], ",") + "]",
+		}
+		chunk, err := c.sendBatch(http.MethodDelete, params, "cancel batch", end-start)
+		if err != nil {
+			return failRemaining(results, len(orderIDs), err), err
+		}
+		results = append(results, chunk...)
+	}
+	return results, nil
+}
+
+func (c *BinanceRESTClient) sendBatch(method string, params map[string]string, action string, n int) ([]BatchOrderResult, error) {
+	query, sig := c.signParams(params)
+	endpoint := c.BaseURL + "/fapi/v1/batchOrders?" + query + "&signature=" + url.QueryEscape(sig)
+	headers := map[string]string{"X-MBX-APIKEY": c.APIKey}
+	resp, err := c.sendWithRetry(method, endpoint, headers)
+	if err != nil {
+		return nil, err
+	}
+	body, _ := io.ReadAll(resp.Body)
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, newAPIError(action, endpoint, resp.StatusCode, body)
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil {
+		return nil, err
+	}
+	if len(items) != n {
+		return nil, fmt.Errorf("%s: expected %d results, got %d", action, n, len(items))
+	}
+	out := make([]BatchOrderResult, len(items))
+	for i, raw := range items {
+		var item batchItemResp
+		if err := json.Unmarshal(raw, &item); err != nil {
+			out[i].Err = err
+			continue
+		}
+		if item.Code != 0 && item.Code != 200 {
+			out[i].Err = newAPIError(action+" item", endpoint, resp.StatusCode, raw)
+			continue
+		}
+		out[i].OrderID = item.OrderID.String()
+		out[i].ClientOrderID = item.ClientOrderID
+	}
+	return out, nil
+}
+
+// placeOutcomeUnknown 把下单请求的传输层错误标记为结果未知；交易所明确返回的 API 错误与建连失败（请求未发出）保持原样。
+func placeOutcomeUnknown(err error) error {
+	var apiErr *BinanceAPIError
+	if errors.As(err, &apiErr) || errors.Is(err, ErrExecutionUnknown) {
+		return err
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrExecutionUnknown, err)
+}
+
+// failRemaining 将尚未得到结果的各笔标记为 err。
+func failRemaining(results []BatchOrderResult, n int, err error) []BatchOrderResult {
+	for len(results) < n {
+		results = append(results, BatchOrderResult{Err: err})
+	}
+	return results
+}
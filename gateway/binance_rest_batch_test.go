@@ -0,0 +1,140 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBinanceRESTClientPlaceBatch(t *testing.T) {
+	var batches [][]map[string]string
+	nextID := 100
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/fapi/v1/batchOrders" {
+			t.Fatalf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+		var items []map[string]string
+		if err := json.Unmarshal([]byte(r.URL.Query().Get("batchOrders")), &items); err != nil {
+			t.Fatalf("decode batchOrders: %v", err)
+		}
+		batches = append(batches, items)
+		out := make([]string, len(items))
+		for i, it := range items {
+			if it["newClientOrderId"] == "c2" {
+				out[i] = `{"code":-5022,"msg":"Due to the order could not be executed as maker, the Post Only order will be rejected."}`
+				continue
+			}
+			out[i] = fmt.Sprintf(`{"orderId":%d,"clientOrderId":"%s"}`, nextID, it["newClientOrderId"])
+			nextID++
+		}
+		io.WriteString(w, "["+strings.Join(out, ",")+"]")
+	}))
+	defer ts.Close()
+
+	cli := &BinanceRESTClient{BaseURL: ts.URL, APIKey: "key", Secret: "secret", HTTPClient: ts.Client(), Limiter: &mockLimiter{}}
+	orders := make([]BatchLimitOrder, 7)
+	for i := range orders {
+		orders[i] = BatchLimitOrder{Symbol: "ETHUSDC", Side: "BUY", TimeInForce: "GTC", Price: float64(i + 1), Qty: 1, PostOnly: i == 2, ClientID: "c" + string(rune('0'+i))}
+	}
+	res, err := cli.PlaceBatch(orders)
+	if err != nil {
+		t.Fatalf("place batch: %v", err)
+	}
+	if len(batches) != 2 || len(batches[0]) != MaxBatchPlaceOrders || len(batches[1]) != 2 {
+		t.Fatalf("expected chunks of 5+2, got %d batches", len(batches))
+	}
+	if batches[0][2]["timeInForce"] != "GTX" {
+		t.Fatalf("postOnly should map to GTX, got %+v", batches[0][2])
+	}
+	if len(res) != 7 {
+		t.Fatalf("expected 7 results, got %d", len(res))
+	}
+	if res[0].OrderID != "100" || res[0].ClientOrderID != "c0" || res[0].Err != nil {
+		t.Fatalf("unexpected first result %+v", res[0])
+	}
+	if res[2].Err == nil || !strings.Contains(res[2].Err.Error(), `code":-5022`) {
+		t.Fatalf("expected per-item post-only reject, got %+v", res[2])
+	}
+	if res[6].OrderID != "105" {
+		t.Fatalf("unexpected last result %+v", res[6])
+	}
+}
+
+func TestBinanceRESTClientCancelBatch(t *testing.T) {
+	var lists []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Fatalf("unexpected method %s", r.Method)
+		}
+		if r.URL.Query().Get("symbol") != "ETHUSDC" {
+			t.Fatalf("missing symbol")
+		}
+		lists = append(lists, r.URL.Query().Get("orderIdList"))
+		if len(lists) == 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"code":-1022,"msg":"Signature for this request is not valid."}`)
+			return
+		}
+		var ids []int64
+		_ = json.Unmarshal([]byte(lists[len(lists)-1]), &ids)
+		out := make([]string, len(ids))
+		for i := range ids {
+			out[i] = `{"orderId":1,"status":"CANCELED"}`
+		}
+		out[0] = `{"code":-2011,"msg":"Unknown order sent."}`
+		io.WriteString(w, "["+strings.Join(out, ",")+"]")
+	}))
+	defer ts.Close()
+
+	cli := &BinanceRESTClient{BaseURL: ts.URL, APIKey: "key", Secret: "secret", HTTPClient: ts.Client(), Limiter: &mockLimiter{}}
+	ids := make([]string, 12)
+	for i := range ids {
+		ids[i] = string(rune('1' + i%9))
+	}
+	res, err := cli.CancelBatch("ETHUSDC", ids)
+	if err == nil || !strings.Contains(err.Error(), "-1022") {
+		t.Fatalf("expected second chunk to fail, got %v", err)
+	}
+	if lists[0] != "[1,2,3,4,5,6,7,8,9,1]" {
+		t.Fatalf("unexpected orderIdList %s", lists[0])
+	}
+	if len(res) != 12 {
+		t.Fatalf("results must cover every id, got %d", len(res))
+	}
+	if res[0].Err == nil || res[1].Err != nil {
+		t.Fatalf("unexpected per-item results %+v %+v", res[0], res[1])
+	}
+	if res[10].Err == nil || res[11].Err == nil {
+		t.Fatalf("failed chunk items should carry error")
+	}
+}
+
+func TestBinanceRESTClientPlaceBatchConnectionResetIsUnknown(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 请求已到达交易所，响应前断开连接
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Fatalf("hijack: %v", err)
+		}
+		conn.Close()
+	}))
+	defer ts.Close()
+
+	cli := &BinanceRESTClient{BaseURL: ts.URL, APIKey: "key", Secret: "secret", HTTPClient: ts.Client(), Limiter: &mockLimiter{}, MaxRetries: 1, RetryDelay: time.Millisecond}
+	res, err := cli.PlaceBatch([]BatchLimitOrder{
+		{Symbol: "ETHUSDC", Side: "BUY", TimeInForce: "GTC", Price: 100, Qty: 1, ClientID: "c0"},
+		{Symbol: "ETHUSDC", Side: "SELL", TimeInForce: "GTC", Price: 101, Qty: 1, ClientID: "c1"},
+	})
+	if !errors.Is(err, ErrExecutionUnknown) {
+		t.Fatalf("expected unknown outcome, got %v", err)
+	}
+	if len(res) != 2 || !errors.Is(res[1].Err, ErrExecutionUnknown) {
+		t.Fatalf("every item should carry unknown outcome: %+v", res)
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"market-maker-go/order"
 )
 
 // BinanceRESTClient 一个可签名的简化客户端；默认不发起真实网络调用，HTTPClient 可注入 httptest。
@@ -23,6 +25,10 @@ type BinanceRESTClient struct {
 	Limiter      RateLimiter
 	MaxRetries   int
 	RetryDelay   time.Duration
+	// TimeSync 可选：按交易所时间补偿签名 timestamp，收到 -1021 时自动触发重新同步。
+	TimeSync *TimeSync
+	// Precision 可选：按交易对 tick/step 序列化价格与数量。
+	Precision *SymbolPrecision
 }
 
 type placeResp struct {
@@ -48,32 +54,19 @@ type depthResp struct {
 
 // PlaceLimit 调用 /fapi/v1/order 下单（LIMIT）。
 func (c *BinanceRESTClient) PlaceLimit(symbol, side, tif string, price, qty float64, reduceOnly, postOnly bool, clientID string) (string, error) {
+	return c.PlaceLimitWithOptions(symbol, side, tif, price, qty, reduceOnly, postOnly, clientID, OrderOptions{})
+}
+
+// PlaceLimitWithOptions 同 PlaceLimit，附带双向持仓腿、自成交保护与 priceMatch 选项。
+func (c *BinanceRESTClient) PlaceLimitWithOptions(symbol, side, tif string, price, qty float64, reduceOnly, postOnly bool, clientID string, opts OrderOptions) (string, error) {
 	if c == nil || c.HTTPClient == nil {
 		return "", fmt.Errorf("http client not set")
 	}
-	if err := validateTimeInForce(tif, postOnly); err != nil {
+	params, err := limitOrderParams(c.Precision, symbol, side, tif, price, qty, reduceOnly, postOnly, clientID, opts)
+	if err != nil {
 		return "", err
 	}
-	params := map[string]string{
-		"symbol":   symbol,
-		"side":     side,
-		"type":     "LIMIT",
-		"price":    fmt.Sprintf("%f", price),
-		"quantity": fmt.Sprintf("%f", qty),
-	}
-	if reduceOnly {
-		params["reduceOnly"] = "true"
-	}
-	if postOnly {
-		params["timeInForce"] = "GTX"
-	} else {
-		params["timeInForce"] = tif
-	}
-	if clientID != "" {
-		params["newClientOrderId"] = clientID
-	}
-	c.applyRecvWindow(params)
-	query, sig := SignParams(params, c.Secret)
+	query, sig := c.signParams(params)
 	endpoint := c.BaseURL + "/fapi/v1/order?" + query + "&signature=" + url.QueryEscape(sig)
 	headers := map[string]string{"X-MBX-APIKEY": c.APIKey}
 	resp, err := c.sendWithRetry(http.MethodPost, endpoint, headers)
@@ -83,7 +76,7 @@ func (c *BinanceRESTClient) PlaceLimit(symbol, side, tif string, price, qty floa
 	body, _ := io.ReadAll(resp.Body)
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
-		return "", fmt.Errorf("place limit status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+		return "", newAPIError("place limit", endpoint, resp.StatusCode, body)
 	}
 	var pr placeResp
 	if err := json.Unmarshal(body, &pr); err != nil {
@@ -97,26 +90,34 @@ func (c *BinanceRESTClient) PlaceLimit(symbol, side, tif string, price, qty floa
 
 // PlaceMarket 调用 /fapi/v1/order 下市价单（MARKET）。
 func (c *BinanceRESTClient) PlaceMarket(symbol, side string, qty float64, reduceOnly bool, clientID string) (string, error) {
+	return c.PlaceMarketSide(symbol, side, "", qty, reduceOnly, clientID)
+}
+
+// PlaceMarketSide 同 PlaceMarket，positionSide 为 LONG/SHORT 时按双向持仓的对应腿下单。
+func (c *BinanceRESTClient) PlaceMarketSide(symbol, side, positionSide string, qty float64, reduceOnly bool, clientID string) (string, error) {
 	if c == nil || c.HTTPClient == nil {
 		return "", fmt.Errorf("http client not set")
 	}
 	if qty <= 0 {
 		return "", fmt.Errorf("qty must be > 0")
 	}
+	qtyStr, err := c.Precision.FormatQty(symbol, qty)
+	if err != nil {
+		return "", err
+	}
 	params := map[string]string{
 		"symbol":   symbol,
 		"side":     side,
 		"type":     "MARKET",
-		"quantity": fmt.Sprintf("%f", qty),
+		"quantity": qtyStr,
 	}
-	if reduceOnly {
-		params["reduceOnly"] = "true"
+	if err := applyPositionSide(params, side, positionSide, reduceOnly); err != nil {
+		return "", err
 	}
 	if clientID != "" {
 		params["newClientOrderId"] = clientID
 	}
-	c.applyRecvWindow(params)
-	query, sig := SignParams(params, c.Secret)
+	query, sig := c.signParams(params)
 	endpoint := c.BaseURL + "/fapi/v1/order?" + query + "&signature=" + url.QueryEscape(sig)
 	headers := map[string]string{"X-MBX-APIKEY": c.APIKey}
 	resp, err := c.sendWithRetry(http.MethodPost, endpoint, headers)
@@ -126,7 +127,7 @@ func (c *BinanceRESTClient) PlaceMarket(symbol, side string, qty float64, reduce
 	body, _ := io.ReadAll(resp.Body)
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
-		return "", fmt.Errorf("place market status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+		return "", newAPIError("place market", endpoint, resp.StatusCode, body)
 	}
 	var pr placeResp
 	if err := json.Unmarshal(body, &pr); err != nil {
@@ -146,8 +147,7 @@ func (c *BinanceRESTClient) SetDualPosition(enable bool) error {
 	params := map[string]string{
 		"dualSidePosition": strconv.FormatBool(enable),
 	}
-	c.applyRecvWindow(params)
-	query, sig := SignParams(params, c.Secret)
+	query, sig := c.signParams(params)
 	endpoint := c.BaseURL + "/fapi/v1/positionSide/dual?" + query + "&signature=" + url.QueryEscape(sig)
 	headers := map[string]string{"X-MBX-APIKEY": c.APIKey}
 	resp, err := c.sendWithRetry(http.MethodPost, endpoint, headers)
@@ -157,7 +157,7 @@ func (c *BinanceRESTClient) SetDualPosition(enable bool) error {
 	body, _ := io.ReadAll(resp.Body)
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
-		return fmt.Errorf("set position mode status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+		return newAPIError("set position mode", endpoint, resp.StatusCode, body)
 	}
 	var pr dualPositionResp
 	if err := json.Unmarshal(body, &pr); err != nil {
@@ -175,8 +175,7 @@ func (c *BinanceRESTClient) GetDualPosition() (bool, error) {
 		return false, fmt.Errorf("http client not set")
 	}
 	params := map[string]string{}
-	c.applyRecvWindow(params)
-	query, sig := SignParams(params, c.Secret)
+	query, sig := c.signParams(params)
 	endpoint := c.BaseURL + "/fapi/v1/positionSide/dual?" + query + "&signature=" + url.QueryEscape(sig)
 	headers := map[string]string{"X-MBX-APIKEY": c.APIKey}
 	resp, err := c.sendWithRetry(http.MethodGet, endpoint, headers)
@@ -186,7 +185,7 @@ func (c *BinanceRESTClient) GetDualPosition() (bool, error) {
 	body, _ := io.ReadAll(resp.Body)
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
-		return false, fmt.Errorf("get position mode status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+		return false, newAPIError("get position mode", endpoint, resp.StatusCode, body)
 	}
 	var pr dualPositionResp
 	if err := json.Unmarshal(body, &pr); err != nil {
@@ -204,8 +203,7 @@ func (c *BinanceRESTClient) SetMarginType(symbol, marginType string) error {
 		"symbol":     symbol,
 		"marginType": strings.ToUpper(marginType),
 	}
-	c.applyRecvWindow(params)
-	query, sig := SignParams(params, c.Secret)
+	query, sig := c.signParams(params)
 	endpoint := c.BaseURL + "/fapi/v1/marginType?" + query + "&signature=" + url.QueryEscape(sig)
 	headers := map[string]string{"X-MBX-APIKEY": c.APIKey}
 	resp, err := c.sendWithRetry(http.MethodPost, endpoint, headers)
@@ -215,7 +213,7 @@ func (c *BinanceRESTClient) SetMarginType(symbol, marginType string) error {
 	body, _ := io.ReadAll(resp.Body)
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
-		return fmt.Errorf("set margin type status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+		return newAPIError("set margin type", endpoint, resp.StatusCode, body)
 	}
 	var mr marginTypeResp
 	if err := json.Unmarshal(body, &mr); err != nil {
@@ -236,8 +234,7 @@ func (c *BinanceRESTClient) SetLeverage(symbol string, leverage int) error {
 		"symbol":   symbol,
 		"leverage": strconv.Itoa(leverage),
 	}
-	c.applyRecvWindow(params)
-	query, sig := SignParams(params, c.Secret)
+	query, sig := c.signParams(params)
 	endpoint := c.BaseURL + "/fapi/v1/leverage?" + query + "&signature=" + url.QueryEscape(sig)
 	headers := map[string]string{"X-MBX-APIKEY": c.APIKey}
 	resp, err := c.sendWithRetry(http.MethodPost, endpoint, headers)
@@ -247,7 +244,7 @@ func (c *BinanceRESTClient) SetLeverage(symbol string, leverage int) error {
 	body, _ := io.ReadAll(resp.Body)
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
-		return fmt.Errorf("set leverage status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+		return newAPIError("set leverage", endpoint, resp.StatusCode, body)
 	}
 	return nil
 }
@@ -271,7 +268,7 @@ func (c *BinanceRESTClient) GetBestBidAsk(symbol string, limit int) (float64, fl
 	body, _ := io.ReadAll(resp.Body)
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
-		return 0, 0, fmt.Errorf("get depth status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+		return 0, 0, newAPIError("get depth", endpoint, resp.StatusCode, body)
 	}
 	var dr depthResp
 	if err := json.Unmarshal(body, &dr); err != nil {
@@ -284,6 +281,49 @@ func (c *BinanceRESTClient) GetBestBidAsk(symbol string, limit int) (float64, fl
 	return bestBid, bestAsk, nil
 }
 
+// DepthSnapshot 是 /fapi/v1/depth 的全量快照，用于 diff depth 流的初始化与重同步。
+type DepthSnapshot struct {
+	LastUpdateID int64
+	Bids         []DepthLevel
+	Asks         []DepthLevel
+}
+
+// DepthSnapshot 调用 /fapi/v1/depth 获取指定档数的全量深度（默认 1000 档）。
+func (c *BinanceRESTClient) DepthSnapshot(symbol string, limit int) (DepthSnapshot, error) {
+	var snap DepthSnapshot
+	if c == nil || c.HTTPClient == nil {
+		return snap, fmt.Errorf("http client not set")
+	}
+	if limit <= 0 {
+		limit = 1000
+	}
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("limit", strconv.Itoa(limit))
+	endpoint := c.BaseURL + "/fapi/v1/depth?" + params.Encode()
+	resp, err := c.sendWithRetry(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return snap, err
+	}
+	body, _ := io.ReadAll(resp.Body)
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return snap, newAPIError("get depth snapshot", endpoint, resp.StatusCode, body)
+	}
+	var dr depthResp
+	if err := json.Unmarshal(body, &dr); err != nil {
+		return snap, err
+	}
+	snap.LastUpdateID = dr.LastUpdateID
+	if snap.Bids, err = parseDepthLevels(dr.Bids); err != nil {
+		return snap, err
+	}
+	if snap.Asks, err = parseDepthLevels(dr.Asks); err != nil {
+		return snap, err
+	}
+	return snap, nil
+}
+
 func parseDepthLevel(levels [][]string) float64 {
 	if len(levels) == 0 || len(levels[0]) == 0 {
 		return 0
@@ -304,8 +344,7 @@ func (c *BinanceRESTClient) CancelOrder(symbol, orderID string) error {
 		"symbol":  symbol,
 		"orderId": orderID,
 	}
-	c.applyRecvWindow(params)
-	query, sig := SignParams(params, c.Secret)
+	query, sig := c.signParams(params)
 	endpoint := c.BaseURL + "/fapi/v1/order?" + query + "&signature=" + url.QueryEscape(sig)
 	headers := map[string]string{"X-MBX-APIKEY": c.APIKey}
 	resp, err := c.sendWithRetry(http.MethodDelete, endpoint, headers)
@@ -314,11 +353,52 @@ func (c *BinanceRESTClient) CancelOrder(symbol, orderID string) error {
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
-		return fmt.Errorf("cancel status %d", resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		return newAPIError("cancel", endpoint, resp.StatusCode, body)
 	}
 	return nil
 }
 
+// ModifyOrder 调用 PUT /fapi/v1/order 修改挂单价格/数量，orderId 保持不变；
+// 仅支持 LIMIT 单，side 须与原单一致。
+func (c *BinanceRESTClient) ModifyOrder(symbol, orderID, side string, price, qty float64) (string, error) {
+	return c.ModifyOrderWithOptions(symbol, orderID, side, price, qty, OrderOptions{})
+}
+
+// ModifyOrderWithOptions 同 ModifyOrder；opts.PriceMatch 非空时按盘口重新定价，不发送 price。
+func (c *BinanceRESTClient) ModifyOrderWithOptions(symbol, orderID, side string, price, qty float64, opts OrderOptions) (string, error) {
+	if c == nil || c.HTTPClient == nil {
+		return "", fmt.Errorf("http client not set")
+	}
+	if (price <= 0 && !priceMatched(opts)) || qty <= 0 {
+		return "", fmt.Errorf("price and qty must be > 0")
+	}
+	params, err := modifyOrderParams(c.Precision, symbol, orderID, side, price, qty, opts)
+	if err != nil {
+		return "", err
+	}
+	query, sig := c.signParams(params)
+	endpoint := c.BaseURL + "/fapi/v1/order?" + query + "&signature=" + url.QueryEscape(sig)
+	headers := map[string]string{"X-MBX-APIKEY": c.APIKey}
+	resp, err := c.sendWithRetry(http.MethodPut, endpoint, headers)
+	if err != nil {
+		return "", err
+	}
+	body, _ := io.ReadAll(resp.Body)
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return "", newAPIError("modify order", endpoint, resp.StatusCode, body)
+	}
+	var pr placeResp
+	if err := json.Unmarshal(body, &pr); err != nil {
+		return "", err
+	}
+	if pr.OrderID == "" {
+		return "", fmt.Errorf("empty orderId")
+	}
+	return pr.OrderID.String(), nil
+}
+
 // CancelAll 调用 /fapi/v1/allOpenOrders 取消指定合约的所有挂单。
 func (c *BinanceRESTClient) CancelAll(symbol string) error {
 	if c == nil || c.HTTPClient == nil {
@@ -330,8 +410,7 @@ func (c *BinanceRESTClient) CancelAll(symbol string) error {
 	params := map[string]string{
 		"symbol": symbol,
 	}
-	c.applyRecvWindow(params)
-	query, sig := SignParams(params, c.Secret)
+	query, sig := c.signParams(params)
 	endpoint := c.BaseURL + "/fapi/v1/allOpenOrders?" + query + "&signature=" + url.QueryEscape(sig)
 	headers := map[string]string{"X-MBX-APIKEY": c.APIKey}
 	resp, err := c.sendWithRetry(http.MethodDelete, endpoint, headers)
@@ -341,7 +420,7 @@ func (c *BinanceRESTClient) CancelAll(symbol string) error {
 	body, _ := io.ReadAll(resp.Body)
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
-		return fmt.Errorf("cancel all status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+		return newAPIError("cancel all", endpoint, resp.StatusCode, body)
 	}
 	return nil
 }
@@ -435,8 +514,7 @@ func (c *BinanceRESTClient) AccountBalances() ([]FuturesBalance, error) {
 		return nil, fmt.Errorf("http client not set")
 	}
 	params := map[string]string{}
-	c.applyRecvWindow(params)
-	query, sig := SignParams(params, c.Secret)
+	query, sig := c.signParams(params)
 	endpoint := c.BaseURL + "/fapi/v2/balance?" + query + "&signature=" + url.QueryEscape(sig)
 	headers := map[string]string{"X-MBX-APIKEY": c.APIKey}
 	resp, err := c.sendWithRetry(http.MethodGet, endpoint, headers)
@@ -445,7 +523,8 @@ func (c *BinanceRESTClient) AccountBalances() ([]FuturesBalance, error) {
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("balance status %d", resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newAPIError("balance", endpoint, resp.StatusCode, body)
 	}
 	var raw []struct {
 		Asset            string `json:"asset"`
@@ -481,8 +560,7 @@ func (c *BinanceRESTClient) AccountInfo() (FuturesAccount, error) {
 		return result, fmt.Errorf("http client not set")
 	}
 	params := map[string]string{}
-	c.applyRecvWindow(params)
-	query, sig := SignParams(params, c.Secret)
+	query, sig := c.signParams(params)
 	endpoint := c.BaseURL + "/fapi/v2/account?" + query + "&signature=" + url.QueryEscape(sig)
 	headers := map[string]string{"X-MBX-APIKEY": c.APIKey}
 	resp, err := c.sendWithRetry(http.MethodGet, endpoint, headers)
@@ -491,7 +569,8 @@ func (c *BinanceRESTClient) AccountInfo() (FuturesAccount, error) {
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
-		return result, fmt.Errorf("account status %d", resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		return result, newAPIError("account", endpoint, resp.StatusCode, body)
 	}
 	var raw struct {
 		TotalWalletBalance    string `json:"totalWalletBalance"`
@@ -588,8 +667,7 @@ func (c *BinanceRESTClient) PositionRisk(symbol string) ([]FuturesPosition, erro
 	if symbol != "" {
 		params["symbol"] = symbol
 	}
-	c.applyRecvWindow(params)
-	query, sig := SignParams(params, c.Secret)
+	query, sig := c.signParams(params)
 	endpoint := c.BaseURL + "/fapi/v2/positionRisk?" + query + "&signature=" + url.QueryEscape(sig)
 	headers := map[string]string{"X-MBX-APIKEY": c.APIKey}
 	resp, err := c.sendWithRetry(http.MethodGet, endpoint, headers)
@@ -598,7 +676,8 @@ func (c *BinanceRESTClient) PositionRisk(symbol string) ([]FuturesPosition, erro
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("position status %d", resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newAPIError("position", endpoint, resp.StatusCode, body)
 	}
 	var raw []struct {
 		Symbol           string `json:"symbol"`
@@ -652,8 +731,7 @@ func (c *BinanceRESTClient) LeverageBrackets(symbol string) ([]LeverageBracket,
 	if symbol != "" {
 		params["symbol"] = symbol
 	}
-	c.applyRecvWindow(params)
-	query, sig := SignParams(params, c.Secret)
+	query, sig := c.signParams(params)
 	endpoint := c.BaseURL + "/fapi/v1/leverageBracket?" + query + "&signature=" + url.QueryEscape(sig)
 	headers := map[string]string{"X-MBX-APIKEY": c.APIKey}
 	resp, err := c.sendWithRetry(http.MethodGet, endpoint, headers)
@@ -662,7 +740,8 @@ func (c *BinanceRESTClient) LeverageBrackets(symbol string) ([]LeverageBracket,
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("leverage bracket status %d", resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newAPIError("leverage bracket", endpoint, resp.StatusCode, body)
 	}
 	var raw []struct {
 		Symbol   string `json:"symbol"`
@@ -712,7 +791,8 @@ func (c *BinanceRESTClient) ExchangeInfo(symbol string) ([]ExchangeSymbolInfo, e
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("exchangeInfo status %d", resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newAPIError("exchangeInfo", endpoint, resp.StatusCode, body)
 	}
 	var raw struct {
 		Symbols []struct {
@@ -777,9 +857,47 @@ func (c *BinanceRESTClient) applyRecvWindow(params map[string]string) {
 	}
 }
 
-func (c *BinanceRESTClient) waitLimit() {
-	if c != nil && c.Limiter != nil {
-		c.Limiter.Wait()
+// signParams 补齐 recvWindow 与（经 TimeSync 补偿的）timestamp 后签名。
+func (c *BinanceRESTClient) signParams(params map[string]string) (string, string) {
+	c.applyRecvWindow(params)
+	if c.TimeSync != nil {
+		params["timestamp"] = strconv.FormatInt(c.TimeSync.NowMillis(), 10)
+	}
+	return SignParams(params, c.Secret)
+}
+
+// checkTimestampError 在响应为 -1021 时触发 TimeSync 重新同步；响应体读取后原样放回。
+func (c *BinanceRESTClient) checkTimestampError(resp *http.Response) {
+	if c.TimeSync == nil || resp.StatusCode < 400 || resp.StatusCode >= 500 {
+		return
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if ClassifyError(resp.StatusCode, string(body)) == ErrorTypeTimestamp {
+		c.TimeSync.Resync("-1021")
+	}
+}
+
+// waitLimit 在发送前排队；CostLimiter 按接口权重计费，其它限流器按请求数计。
+func (c *BinanceRESTClient) waitLimit(method, endpoint string) {
+	if c == nil || c.Limiter == nil {
+		return
+	}
+	if cl, ok := c.Limiter.(CostLimiter); ok {
+		cl.WaitCost(requestCost(method, endpoint))
+		return
+	}
+	c.Limiter.Wait()
+}
+
+// observeLimit 将响应头中的已用权重/下单数回灌给 CostLimiter。
+func (c *BinanceRESTClient) observeLimit(resp *http.Response) {
+	if c == nil || c.Limiter == nil {
+		return
+	}
+	if cl, ok := c.Limiter.(CostLimiter); ok {
+		cl.ObserveResponse(resp)
 	}
 }
 
@@ -798,15 +916,18 @@ func (c *BinanceRESTClient) sendWithRetry(method, endpoint string, headers map[s
 		for k, v := range headers {
 			req.Header.Set(k, v)
 		}
-		c.waitLimit()
+		c.waitLimit(method, endpoint)
 		resp, err := c.HTTPClient.Do(req)
 		if err != nil {
 			lastErr = err
 		} else {
+			c.observeLimit(resp)
 			if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == 418 {
-				lastErr = fmt.Errorf("status %d", resp.StatusCode)
+				body, _ := io.ReadAll(resp.Body)
 				resp.Body.Close()
+				lastErr = newAPIError("request", endpoint, resp.StatusCode, body)
 			} else {
+				c.checkTimestampError(resp)
 				return resp, nil
 			}
 		}
@@ -815,6 +936,130 @@ func (c *BinanceRESTClient) sendWithRetry(method, endpoint string, headers map[s
 	return nil, fmt.Errorf("request failed after %d attempts: %w", maxAttempts, lastErr)
 }
 
+// limitOrderParams 构造 LIMIT 下单参数（REST 与 WS API 共用）；postOnly 映射为 GTX。
+// 价格/数量经 prec 按交易对精度序列化，prec 可为 nil；positionSide 见 applyPositionSide，
+// STP/priceMatch 见 applyMatchOptions。
+func limitOrderParams(prec *SymbolPrecision, symbol, side, tif string, price, qty float64, reduceOnly, postOnly bool, clientID string, opts OrderOptions) (map[string]string, error) {
+	if err := validateTimeInForce(tif, postOnly); err != nil {
+		return nil, err
+	}
+	if err := validatePriceMatch(opts.PriceMatch, postOnly); err != nil {
+		return nil, err
+	}
+	params := map[string]string{
+		"symbol": symbol,
+		"side":   side,
+		"type":   "LIMIT",
+	}
+	if err := applyPriceQty(params, prec, symbol, price, qty, opts); err != nil {
+		return nil, err
+	}
+	if err := applyPositionSide(params, side, opts.PositionSide, reduceOnly); err != nil {
+		return nil, err
+	}
+	if err := applyMatchOptions(params, opts); err != nil {
+		return nil, err
+	}
+	if postOnly {
+		params["timeInForce"] = "GTX"
+	} else {
+		params["timeInForce"] = tif
+	}
+	if clientID != "" {
+		params["newClientOrderId"] = clientID
+	}
+	return params, nil
+}
+
+// applyPositionSide 写入 positionSide/reduceOnly。单向持仓（空或 BOTH）沿用 reduceOnly；
+// 双向持仓下 Binance 不接受 reduceOnly，平仓由 side 与腿的组合表达，方向不符时本地拒绝。
+func applyPositionSide(params map[string]string, side, positionSide string, reduceOnly bool) error {
+	ps := strings.ToUpper(positionSide)
+	switch ps {
+	case "", order.PositionSideBoth:
+		if reduceOnly {
+			params["reduceOnly"] = "true"
+		}
+		return nil
+	case order.PositionSideLong, order.PositionSideShort:
+		if reduceOnly && order.ClosingPositionSide(side) != ps {
+			return fmt.Errorf("reduceOnly %s cannot close %s position", side, ps)
+		}
+		params["positionSide"] = ps
+		return nil
+	default:
+		return fmt.Errorf("unsupported positionSide %s", positionSide)
+	}
+}
+
+// modifyOrderParams 构造改单参数（REST 与 WS API 共用）；opts 中仅 PriceMatch 生效。
+func modifyOrderParams(prec *SymbolPrecision, symbol, orderID, side string, price, qty float64, opts OrderOptions) (map[string]string, error) {
+	if err := validatePriceMatch(opts.PriceMatch, false); err != nil {
+		return nil, err
+	}
+	params := map[string]string{
+		"symbol":  symbol,
+		"orderId": orderID,
+		"side":    side,
+	}
+	if err := applyPriceQty(params, prec, symbol, price, qty, opts); err != nil {
+		return nil, err
+	}
+	if priceMatched(opts) {
+		params["priceMatch"] = strings.ToUpper(opts.PriceMatch)
+	}
+	return params, nil
+}
+
+// applyPriceQty 写入 price/quantity；priceMatch 生效时 Binance 不允许同时传 price，只写数量。
+func applyPriceQty(params map[string]string, prec *SymbolPrecision, symbol string, price, qty float64, opts OrderOptions) error {
+	if priceMatched(opts) {
+		qtyStr, err := prec.FormatQty(symbol, qty)
+		if err != nil {
+			return err
+		}
+		params["quantity"] = qtyStr
+		return nil
+	}
+	priceStr, qtyStr, err := formatPriceQty(prec, symbol, price, qty)
+	if err != nil {
+		return err
+	}
+	params["price"] = priceStr
+	params["quantity"] = qtyStr
+	return nil
+}
+
+// applyMatchOptions 写入 selfTradePreventionMode/priceMatch。
+func applyMatchOptions(params map[string]string, opts OrderOptions) error {
+	if opts.SelfTradePrevention != "" {
+		if err := validateSelfTradePrevention(opts.SelfTradePrevention); err != nil {
+			return err
+		}
+		params["selfTradePreventionMode"] = strings.ToUpper(opts.SelfTradePrevention)
+	}
+	if priceMatched(opts) {
+		params["priceMatch"] = strings.ToUpper(opts.PriceMatch)
+	}
+	return nil
+}
+
+func priceMatched(opts OrderOptions) bool {
+	return opts.PriceMatch != "" && !strings.EqualFold(opts.PriceMatch, order.PriceMatchNone)
+}
+
+func formatPriceQty(prec *SymbolPrecision, symbol string, price, qty float64) (string, string, error) {
+	priceStr, err := prec.FormatPrice(symbol, price)
+	if err != nil {
+		return "", "", err
+	}
+	qtyStr, err := prec.FormatQty(symbol, qty)
+	if err != nil {
+		return "", "", err
+	}
+	return priceStr, qtyStr, nil
+}
+
 func validateTimeInForce(tif string, postOnly bool) error {
 	if postOnly {
 		return nil
@@ -828,3 +1073,27 @@ func validateTimeInForce(tif string, postOnly bool) error {
 		return fmt.Errorf("unsupported timeInForce %s", tif)
 	}
 }
+
+func validateSelfTradePrevention(mode string) error {
+	switch strings.ToUpper(mode) {
+	case "", order.STPNone, order.STPExpireTaker, order.STPExpireMaker, order.STPExpireBoth:
+		return nil
+	default:
+		return fmt.Errorf("unsupported selfTradePreventionMode %s", mode)
+	}
+}
+
+// validatePriceMatch 校验 priceMatch；OPPONENT 系列按对手价成交，与 postOnly(GTX) 矛盾，本地拒绝。
+func validatePriceMatch(pm string, postOnly bool) error {
+	switch strings.ToUpper(pm) {
+	case "", order.PriceMatchNone, order.PriceMatchQueue, order.PriceMatchQueue5, order.PriceMatchQueue10, order.PriceMatchQueue20:
+		return nil
+	case order.PriceMatchOpponent, order.PriceMatchOpponent5, order.PriceMatchOpponent10, order.PriceMatchOpponent20:
+		if postOnly {
+			return fmt.Errorf("priceMatch %s crosses the book and cannot be postOnly", pm)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported priceMatch %s", pm)
+	}
+}
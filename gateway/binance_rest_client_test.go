@@ -4,6 +4,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
 	"time"
@@ -48,6 +49,64 @@ func TestBinanceRESTClientPlaceCancel(t *testing.T) {
 	}
 }
 
+func TestBinanceRESTClientModifyOrder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/fapi/v1/order" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("orderId") != "1001" || q.Get("side") != "SELL" || q.Get("price") != "101.5" || q.Get("quantity") != "2" {
+			t.Fatalf("unexpected params %v", q)
+		}
+		if q.Get("signature") == "" {
+			t.Fatalf("missing signature")
+		}
+		io.WriteString(w, `{"orderId":1001,"status":"NEW"}`)
+	}))
+	defer ts.Close()
+
+	cli := &BinanceRESTClient{BaseURL: ts.URL, APIKey: "key", Secret: "secret", HTTPClient: ts.Client(), Limiter: &mockLimiter{}}
+	id, err := cli.ModifyOrder("BTCUSDT", "1001", "SELL", 101.5, 2)
+	if err != nil {
+		t.Fatalf("modify err: %v", err)
+	}
+	if id != "1001" {
+		t.Fatalf("unexpected order id %s", id)
+	}
+	if _, err := cli.ModifyOrder("BTCUSDT", "1001", "SELL", 0, 2); err == nil {
+		t.Fatalf("expected error for zero price")
+	}
+}
+
+func TestBinanceRESTClientPlaceLimitHedgeSide(t *testing.T) {
+	var got []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if _, ok := q["reduceOnly"]; ok {
+			t.Fatalf("reduceOnly must not be sent with positionSide: %v", q)
+		}
+		got = append(got, q.Get("side")+"/"+q.Get("positionSide"))
+		io.WriteString(w, `{"orderId":1}`)
+	}))
+	defer ts.Close()
+
+	cli := &BinanceRESTClient{BaseURL: ts.URL, APIKey: "key", Secret: "secret", HTTPClient: ts.Client(), Limiter: &mockLimiter{}}
+	if _, err := PlaceLimitOrder(cli, "BTCUSDT", "BUY", "GTC", 100, 1, false, true, "open", OrderOptions{PositionSide: "LONG"}); err != nil {
+		t.Fatalf("open long: %v", err)
+	}
+	// reduce-only 卖单映射为平多：只发 positionSide=LONG
+	if _, err := PlaceLimitOrder(cli, "BTCUSDT", "SELL", "GTC", 101, 1, true, false, "close", OrderOptions{PositionSide: "LONG"}); err != nil {
+		t.Fatalf("close long: %v", err)
+	}
+	// reduce-only 买单不能平多头腿，本地拒绝
+	if _, err := PlaceLimitOrder(cli, "BTCUSDT", "BUY", "GTC", 99, 1, true, false, "bad", OrderOptions{PositionSide: "LONG"}); err == nil {
+		t.Fatalf("expected error for reduce-only on the wrong leg")
+	}
+	if len(got) != 2 || got[0] != "BUY/LONG" || got[1] != "SELL/LONG" {
+		t.Fatalf("unexpected requests %v", got)
+	}
+}
+
 func TestBinanceRESTClientAccountBalances(t *testing.T) {
 	timeNowMillis = func() int64 { return 1234567890000 }
 	defer func() { timeNowMillis = func() int64 { return time.Now().UnixMilli() } }()
@@ -274,6 +333,36 @@ func TestBinanceRESTClientGetBestBidAsk(t *testing.T) {
 	}
 }
 
+func TestBinanceRESTClientDepthSnapshot(t *testing.T) {
+	raw, err := os.ReadFile("testdata/depth_snapshot_btcusdt.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/fapi/v1/depth" || r.URL.Query().Get("limit") != "1000" {
+			t.Fatalf("unexpected request %s", r.URL.String())
+		}
+		w.Write(raw)
+	}))
+	defer ts.Close()
+
+	cli := &BinanceRESTClient{
+		BaseURL:    ts.URL,
+		HTTPClient: ts.Client(),
+		Limiter:    &mockLimiter{},
+	}
+	snap, err := cli.DepthSnapshot("BTCUSDT", 0)
+	if err != nil {
+		t.Fatalf("snapshot err: %v", err)
+	}
+	if snap.LastUpdateID != 1000 || len(snap.Bids) != 3 || len(snap.Asks) != 3 {
+		t.Fatalf("unexpected snapshot %+v", snap)
+	}
+	if snap.Bids[0].Price != 100 || snap.Asks[2].Qty != 6 {
+		t.Fatalf("unexpected levels %+v %+v", snap.Bids, snap.Asks)
+	}
+}
+
 func TestBinanceRESTClientGetBestBidAskError(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "oops", http.StatusBadRequest)
@@ -0,0 +1,127 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// MaxIncomeLimit 为 /fapi/v1/income 单页上限。
+const MaxIncomeLimit = 1000
+
+// IncomeRecord 为 /fapi/v1/income 返回的一条资金流水。
+// IncomeType 常见值：REALIZED_PNL、FUNDING_FEE、COMMISSION、TRANSFER 等；Income 为带符号金额。
+type IncomeRecord struct {
+	TranID     int64
+	Symbol     string
+	IncomeType string
+	Income     float64
+	Asset      string
+	Info       string
+	TradeID    string
+	Time       int64
+}
+
+// IncomeQuery 为 /fapi/v1/income 的查询条件；零值字段不发送（交易所默认最近 7 天、100 条）。
+type IncomeQuery struct {
+	Symbol     string
+	IncomeType string
+	StartTime  int64
+	EndTime    int64
+	Limit      int
+}
+
+// Income 调用 GET /fapi/v1/income 查询一页资金流水，按时间升序返回。
+func (c *BinanceRESTClient) Income(q IncomeQuery) ([]IncomeRecord, error) {
+	if c == nil || c.HTTPClient == nil {
+		return nil, fmt.Errorf("http client not set")
+	}
+	params := map[string]string{}
+	if q.Symbol != "" {
+		params["symbol"] = q.Symbol
+	}
+	if q.IncomeType != "" {
+		params["incomeType"] = q.IncomeType
+	}
+	if q.StartTime > 0 {
+		params["startTime"] = strconv.FormatInt(q.StartTime, 10)
+	}
+	if q.EndTime > 0 {
+		params["endTime"] = strconv.FormatInt(q.EndTime, 10)
+	}
+	if q.Limit > 0 {
+		params["limit"] = strconv.Itoa(q.Limit)
+	}
+	body, err := c.signedGet("/fapi/v1/income", "income", params)
+	if err != nil {
+		return nil, err
+	}
+	var raw []struct {
+		Symbol     string      `json:"symbol"`
+		IncomeType string      `json:"incomeType"`
+		Income     string      `json:"income"`
+		Asset      string      `json:"asset"`
+		Info       string      `json:"info"`
+		Time       int64       `json:"time"`
+		TranID     json.Number `json:"tranId"`
+		TradeID    string      `json:"tradeId"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	out := make([]IncomeRecord, 0, len(raw))
+	for _, r := range raw {
+		tranID, err := r.TranID.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("parse tranId %q: %w", r.TranID, err)
+		}
+		out = append(out, IncomeRecord{
+			TranID:     tranID,
+			Symbol:     r.Symbol,
+			IncomeType: r.IncomeType,
+			Income:     parseFloat(r.Income),
+			Asset:      r.Asset,
+			Info:       r.Info,
+			TradeID:    r.TradeID,
+			Time:       r.Time,
+		})
+	}
+	return out, nil
+}
+
+// IncomeHistory 从 q.StartTime 起按时间向后翻页，取回截至 q.EndTime（0 表示当前）的全部流水。
+// 翻页以上一页最后一条的时间为下一页起点（含该毫秒），按（incomeType, tranId, asset）去重，因此同一毫秒内的多条记录不会丢失；
+// tranId 只在同一 incomeType 内唯一，同一成交的 COMMISSION 与 REALIZED_PNL 可能共用。
+func (c *BinanceRESTClient) IncomeHistory(q IncomeQuery) ([]IncomeRecord, error) {
+	q.Limit = MaxIncomeLimit
+	type key struct {
+		incomeType string
+		tranID     int64
+		asset      string
+	}
+	seen := make(map[key]bool)
+	var out []IncomeRecord
+	for {
+		page, err := c.Income(q)
+		if err != nil {
+			return out, err
+		}
+		for _, r := range page {
+			k := key{r.IncomeType, r.TranID, r.Asset}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			out = append(out, r)
+		}
+		if len(page) < q.Limit {
+			return out, nil
+		}
+		next := page[len(page)-1].Time
+		if next <= q.StartTime {
+			// 整页都落在同一毫秒：跳过该毫秒，避免死循环
+			next = q.StartTime + 1
+		}
+		q.StartTime = next
+	}
+}
@@ -0,0 +1,79 @@
+package gateway
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestBinanceRESTClientIncomeHistoryPages(t *testing.T) {
+	// 2500 条流水，每 3 条共用一个毫秒，跨页边界落在同一毫秒内
+	type rec struct {
+		Symbol     string `json:"symbol"`
+		IncomeType string `json:"incomeType"`
+		Income     string `json:"income"`
+		Asset      string `json:"asset"`
+		Time       int64  `json:"time"`
+		TranID     int64  `json:"tranId"`
+	}
+	all := make([]rec, 2500)
+	for i := range all {
+		all[i] = rec{Symbol: "ETHUSDC", IncomeType: "COMMISSION", Income: "-0.01", Asset: "USDC", Time: 1000 + int64(i/3), TranID: int64(i + 1)}
+	}
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		q := r.URL.Query()
+		if r.URL.Path != "/fapi/v1/income" || q.Get("limit") != "1000" {
+			t.Fatalf("unexpected request %s", r.URL)
+		}
+		start, _ := strconv.ParseInt(q.Get("startTime"), 10, 64)
+		page := []rec{}
+		for _, x := range all {
+			if x.Time >= start && len(page) < 1000 {
+				page = append(page, x)
+			}
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer ts.Close()
+
+	cli := &BinanceRESTClient{BaseURL: ts.URL, APIKey: "key", Secret: "secret", HTTPClient: ts.Client(), Limiter: &mockLimiter{}}
+	got, err := cli.IncomeHistory(IncomeQuery{StartTime: 1000})
+	if err != nil {
+		t.Fatalf("income history: %v", err)
+	}
+	if len(got) != len(all) || calls != 3 {
+		t.Fatalf("expected %d records in 3 pages, got %d in %d", len(all), len(got), calls)
+	}
+	for i, r := range got {
+		if r.TranID != int64(i+1) || r.Income != -0.01 || r.IncomeType != "COMMISSION" {
+			t.Fatalf("record %d unexpected %+v", i, r)
+		}
+	}
+}
+
+func TestBinanceRESTClientIncomeHistoryKeepsSharedTranID(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 同一成交的已实现盈亏与手续费共用 tranId，BNB 抵扣的手续费另记一条
+		io.WriteString(w, `[
+			{"symbol":"ETHUSDC","incomeType":"REALIZED_PNL","income":"1.5","asset":"USDC","time":1000,"tranId":7},
+			{"symbol":"ETHUSDC","incomeType":"COMMISSION","income":"-0.02","asset":"USDC","time":1000,"tranId":7},
+			{"symbol":"ETHUSDC","incomeType":"COMMISSION","income":"-0.0001","asset":"BNB","time":1000,"tranId":7},
+			{"symbol":"ETHUSDC","incomeType":"COMMISSION","income":"-0.02","asset":"USDC","time":1000,"tranId":7}
+		]`)
+	}))
+	defer ts.Close()
+
+	cli := &BinanceRESTClient{BaseURL: ts.URL, APIKey: "key", Secret: "secret", HTTPClient: ts.Client(), Limiter: &mockLimiter{}}
+	got, err := cli.IncomeHistory(IncomeQuery{StartTime: 1000})
+	if err != nil {
+		t.Fatalf("income history: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 distinct records, got %+v", got)
+	}
+}
@@ -0,0 +1,252 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"market-maker-go/order"
+)
+
+// FuturesOrder 为 /fapi/v1/order、/fapi/v1/openOrders 返回的订单。
+type FuturesOrder struct {
+	Symbol        string
+	OrderID       string
+	ClientOrderID string
+	Side          string
+	PositionSide  string
+	Type          string
+	TimeInForce   string
+	Status        string // Binance 原始状态，见 MapOrderStatus
+	Price         float64
+	OrigQty       float64
+	ExecutedQty   float64
+	AvgPrice      float64
+	ReduceOnly    bool
+	UpdateTime    int64
+}
+
+// FuturesTrade 为 /fapi/v1/userTrades 返回的一笔成交。
+type FuturesTrade struct {
+	ID              int64
+	OrderID         string
+	Symbol          string
+	Side            string
+	PositionSide    string
+	Price           float64
+	Qty             float64
+	QuoteQty        float64
+	RealizedPnL     float64
+	Commission      float64
+	CommissionAsset string
+	Maker           bool
+	Time            int64
+}
+
+type futuresOrderResp struct {
+	Symbol        string      `json:"symbol"`
+	OrderID       json.Number `json:"orderId"`
+	ClientOrderID string      `json:"clientOrderId"`
+	Side          string      `json:"side"`
+	PositionSide  string      `json:"positionSide"`
+	Type          string      `json:"type"`
+	TimeInForce   string      `json:"timeInForce"`
+	Status        string      `json:"status"`
+	Price         string      `json:"price"`
+	OrigQty       string      `json:"origQty"`
+	ExecutedQty   string      `json:"executedQty"`
+	AvgPrice      string      `json:"avgPrice"`
+	ReduceOnly    bool        `json:"reduceOnly"`
+	UpdateTime    int64       `json:"updateTime"`
+}
+
+func (r futuresOrderResp) toOrder() FuturesOrder {
+	return FuturesOrder{
+		Symbol:        r.Symbol,
+		OrderID:       r.OrderID.String(),
+		ClientOrderID: r.ClientOrderID,
+		Side:          r.Side,
+		PositionSide:  r.PositionSide,
+		Type:          r.Type,
+		TimeInForce:   r.TimeInForce,
+		Status:        r.Status,
+		Price:         parseFloat(r.Price),
+		OrigQty:       parseFloat(r.OrigQty),
+		ExecutedQty:   parseFloat(r.ExecutedQty),
+		AvgPrice:      parseFloat(r.AvgPrice),
+		ReduceOnly:    r.ReduceOnly,
+		UpdateTime:    r.UpdateTime,
+	}
+}
+
+// MapOrderStatus 把 Binance 订单状态映射为 order.Status。
+// 交易所侧 NEW 表示已被撮合引擎接受，对应本地的 StatusAck；未知状态返回空串。
+func MapOrderStatus(s string) order.Status {
+	switch s {
+	case "NEW":
+		return order.StatusAck
+	case "PARTIALLY_FILLED":
+		return order.StatusPartial
+	case "FILLED":
+		return order.StatusFilled
+	case "CANCELED":
+		return order.StatusCanceled
+	case "REJECTED":
+		return order.StatusRejected
+	case "EXPIRED", "EXPIRED_IN_MATCH", "EXPIRED_IN_CANCEL":
+		return order.StatusExpired
+	}
+	return ""
+}
+
+// Order 转为 order.Order；ID 取 clientOrderId（即本地下单时的订单 ID）。
+func (o FuturesOrder) Order() *order.Order {
+	return &order.Order{
+		ID:           o.ClientOrderID,
+		ExchangeID:   o.OrderID,
+		Symbol:       o.Symbol,
+		Side:         o.Side,
+		Type:         o.Type,
+		Price:        o.Price,
+		Quantity:     o.OrigQty,
+		Status:       MapOrderStatus(o.Status),
+		ClientID:     o.ClientOrderID,
+		ReduceOnly:   o.ReduceOnly,
+		PostOnly:     o.TimeInForce == "GTX",
+		TimeInForce:  o.TimeInForce,
+		PositionSide: o.PositionSide,
+		FilledQty:    o.ExecutedQty,
+		AvgFillPrice: o.AvgPrice,
+	}
+}
+
+// QueryOrder 调用 GET /fapi/v1/order 查询单笔订单，orderID 与 clientOrderID 至少提供一个（优先 orderID）。
+// 查无此单时返回的错误满足 errors.Is(err, ErrOrderNotFound)。
+func (c *BinanceRESTClient) QueryOrder(symbol, orderID, clientOrderID string) (FuturesOrder, error) {
+	if c == nil || c.HTTPClient == nil {
+		return FuturesOrder{}, fmt.Errorf("http client not set")
+	}
+	if symbol == "" {
+		return FuturesOrder{}, fmt.Errorf("symbol required")
+	}
+	params := map[string]string{"symbol": symbol}
+	switch {
+	case orderID != "":
+		params["orderId"] = orderID
+	case clientOrderID != "":
+		params["origClientOrderId"] = clientOrderID
+	default:
+		return FuturesOrder{}, fmt.Errorf("orderId or clientOrderId required")
+	}
+	body, err := c.signedGet("/fapi/v1/order", "query order", params)
+	if err != nil {
+		return FuturesOrder{}, err
+	}
+	var raw futuresOrderResp
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return FuturesOrder{}, err
+	}
+	return raw.toOrder(), nil
+}
+
+// OpenOrders 调用 GET /fapi/v1/openOrders 查询当前挂单；symbol 为空时返回全部交易对（权重较高）。
+func (c *BinanceRESTClient) OpenOrders(symbol string) ([]FuturesOrder, error) {
+	if c == nil || c.HTTPClient == nil {
+		return nil, fmt.Errorf("http client not set")
+	}
+	params := map[string]string{}
+	if symbol != "" {
+		params["symbol"] = symbol
+	}
+	body, err := c.signedGet("/fapi/v1/openOrders", "open orders", params)
+	if err != nil {
+		return nil, err
+	}
+	var raw []futuresOrderResp
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	out := make([]FuturesOrder, 0, len(raw))
+	for _, r := range raw {
+		out = append(out, r.toOrder())
+	}
+	return out, nil
+}
+
+// UserTrades 调用 GET /fapi/v1/userTrades 查询成交历史；startTime 为 0 时由交易所取最近 7 天，limit 为 0 时取默认 500。
+func (c *BinanceRESTClient) UserTrades(symbol string, startTime int64, limit int) ([]FuturesTrade, error) {
+	if c == nil || c.HTTPClient == nil {
+		return nil, fmt.Errorf("http client not set")
+	}
+	if symbol == "" {
+		return nil, fmt.Errorf("symbol required")
+	}
+	params := map[string]string{"symbol": symbol}
+	if startTime > 0 {
+		params["startTime"] = strconv.FormatInt(startTime, 10)
+	}
+	if limit > 0 {
+		params["limit"] = strconv.Itoa(limit)
+	}
+	body, err := c.signedGet("/fapi/v1/userTrades", "user trades", params)
+	if err != nil {
+		return nil, err
+	}
+	var raw []struct {
+		ID              int64       `json:"id"`
+		OrderID         json.Number `json:"orderId"`
+		Symbol          string      `json:"symbol"`
+		Side            string      `json:"side"`
+		PositionSide    string      `json:"positionSide"`
+		Price           string      `json:"price"`
+		Qty             string      `json:"qty"`
+		QuoteQty        string      `json:"quoteQty"`
+		RealizedPnl     string      `json:"realizedPnl"`
+		Commission      string      `json:"commission"`
+		CommissionAsset string      `json:"commissionAsset"`
+		Maker           bool        `json:"maker"`
+		Time            int64       `json:"time"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	out := make([]FuturesTrade, 0, len(raw))
+	for _, r := range raw {
+		out = append(out, FuturesTrade{
+			ID:              r.ID,
+			OrderID:         r.OrderID.String(),
+			Symbol:          r.Symbol,
+			Side:            r.Side,
+			PositionSide:    r.PositionSide,
+			Price:           parseFloat(r.Price),
+			Qty:             parseFloat(r.Qty),
+			QuoteQty:        parseFloat(r.QuoteQty),
+			RealizedPnL:     parseFloat(r.RealizedPnl),
+			Commission:      parseFloat(r.Commission),
+			CommissionAsset: r.CommissionAsset,
+			Maker:           r.Maker,
+			Time:            r.Time,
+		})
+	}
+	return out, nil
+}
+
+// signedGet 发送签名 GET 请求并返回响应体；非 2xx 时返回 newAPIError。
+func (c *BinanceRESTClient) signedGet(path, op string, params map[string]string) ([]byte, error) {
+	query, sig := c.signParams(params)
+	endpoint := c.BaseURL + path + "?" + query + "&signature=" + url.QueryEscape(sig)
+	headers := map[string]string{"X-MBX-APIKEY": c.APIKey}
+	resp, err := c.sendWithRetry(http.MethodGet, endpoint, headers)
+	if err != nil {
+		return nil, err
+	}
+	body, _ := io.ReadAll(resp.Body)
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, newAPIError(op, endpoint, resp.StatusCode, body)
+	}
+	return body, nil
+}
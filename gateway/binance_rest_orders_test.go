@@ -0,0 +1,110 @@
+package gateway
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"market-maker-go/order"
+)
+
+func TestMapOrderStatus(t *testing.T) {
+	cases := map[string]order.Status{
+		"NEW":              order.StatusAck,
+		"PARTIALLY_FILLED": order.StatusPartial,
+		"FILLED":           order.StatusFilled,
+		"CANCELED":         order.StatusCanceled,
+		"REJECTED":         order.StatusRejected,
+		"EXPIRED":          order.StatusExpired,
+		"EXPIRED_IN_MATCH": order.StatusExpired,
+		"NEW_INSURANCE":    "",
+	}
+	for in, want := range cases {
+		if got := MapOrderStatus(in); got != want {
+			t.Errorf("MapOrderStatus(%s) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestReconcilerAgainstRESTClient(t *testing.T) {
+	remote := map[string]string{
+		"c-filled": `{"symbol":"ETHUSDC","orderId":11,"clientOrderId":"c-filled","side":"BUY","type":"LIMIT","timeInForce":"GTX","status":"FILLED","price":"2000.10","origQty":"0.5","executedQty":"0.5","avgPrice":"2000.10","updateTime":1}`,
+		"c-open":   `{"symbol":"ETHUSDC","orderId":12,"clientOrderId":"c-open","side":"SELL","type":"LIMIT","timeInForce":"GTC","status":"NEW","price":"2001","origQty":"0.5","executedQty":"0","avgPrice":"0","updateTime":1}`,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.Method != http.MethodGet || q.Get("signature") == "" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		switch r.URL.Path {
+		case "/fapi/v1/order":
+			body, ok := remote[q.Get("origClientOrderId")]
+			if !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				io.WriteString(w, `{"code":-2013,"msg":"Order does not exist."}`)
+				return
+			}
+			io.WriteString(w, body)
+		case "/fapi/v1/openOrders":
+			io.WriteString(w, "["+remote["c-open"]+"]")
+		default:
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	cli := &BinanceRESTClient{BaseURL: ts.URL, APIKey: "key", Secret: "secret", HTTPClient: ts.Client(), Limiter: &mockLimiter{}}
+	mgr := order.NewManager(nil)
+	for _, id := range []string{"c-filled", "c-open", "c-lost"} {
+		if _, err := mgr.Submit(order.Order{ID: id, Symbol: "ETHUSDC", Side: "BUY", Price: 2000, Quantity: 0.5}); err != nil {
+			t.Fatalf("submit %s: %v", id, err)
+		}
+	}
+	rec := order.NewReconciler(NewOrderQueryAdapter(NewBinanceVenue(cli), "ETHUSDC"), mgr, order.ReconcilerConfig{})
+	if err := rec.Reconcile(); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	want := map[string]order.Status{
+		"c-filled": order.StatusFilled,
+		"c-open":   order.StatusAck,
+		"c-lost":   order.StatusExpired, // -2013 视为交易所已无此单，标记过期
+	}
+	for id, st := range want {
+		if got, _ := mgr.Status(id); got != st {
+			t.Errorf("%s status = %s, want %s", id, got, st)
+		}
+	}
+
+	open, err := NewOrderQueryAdapter(NewBinanceVenue(cli), "ETHUSDC").GetOpenOrders("")
+	if err != nil {
+		t.Fatalf("open orders: %v", err)
+	}
+	if len(open) != 1 || open[0].ID != "c-open" || open[0].Price != 2001 || open[0].Status != order.StatusAck {
+		t.Fatalf("unexpected open orders %+v", open)
+	}
+}
+
+func TestBinanceRESTClientUserTrades(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/fapi/v1/userTrades" || q.Get("symbol") != "ETHUSDC" || q.Get("startTime") != "1700000000000" || q.Get("limit") != "100" {
+			t.Fatalf("unexpected request %s", r.URL)
+		}
+		io.WriteString(w, `[{"buyer":false,"commission":"-0.0781901","commissionAsset":"USDC","id":698759,"maker":true,"orderId":25851813,"price":"2001.5","qty":"0.002","quoteQty":"4.003","realizedPnl":"-0.9154","side":"SELL","positionSide":"BOTH","symbol":"ETHUSDC","time":1700000000123}]`)
+	}))
+	defer ts.Close()
+
+	cli := &BinanceRESTClient{BaseURL: ts.URL, APIKey: "key", Secret: "secret", HTTPClient: ts.Client(), Limiter: &mockLimiter{}}
+	trades, err := cli.UserTrades("ETHUSDC", 1700000000000, 100)
+	if err != nil {
+		t.Fatalf("user trades: %v", err)
+	}
+	if len(trades) != 1 {
+		t.Fatalf("expected 1 trade, got %d", len(trades))
+	}
+	tr := trades[0]
+	if tr.OrderID != "25851813" || tr.Price != 2001.5 || tr.Qty != 0.002 || tr.RealizedPnL != -0.9154 || tr.Commission != -0.0781901 || !tr.Maker || tr.Time != 1700000000123 {
+		t.Fatalf("unexpected trade %+v", tr)
+	}
+}
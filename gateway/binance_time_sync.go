@@ -0,0 +1,196 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TimeSync 周期性采样 /fapi/v1/time，估计本地时钟与交易所的偏差（offset）与往返时延（RTT），
+// 为签名请求提供补偿后的 timestamp，避免本地时钟漂移导致 -1021 Timestamp outside recvWindow。
+// 每轮取 Samples 次采样中 RTT 最小的一次：offset = serverTime - (发送时刻+接收时刻)/2。
+type TimeSync struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	// Interval 周期同步间隔，默认 60s。
+	Interval time.Duration
+	// Samples 每轮采样次数，默认 3。
+	Samples int
+	// MinResyncInterval 两次同步的最小间隔，默认 2s；-1021 突发时多次 Resync 合并为一次并延后到间隔满足。
+	MinResyncInterval time.Duration
+	// OnSync 每轮同步成功后回调，用于上报漂移指标。
+	OnSync func(offset, rtt time.Duration)
+
+	offsetMs atomic.Int64
+	rttNs    atomic.Int64
+
+	mu       sync.Mutex
+	lastSync time.Time
+	syncs    int
+	resync   chan string
+}
+
+type serverTimeResp struct {
+	ServerTime int64 `json:"serverTime"`
+}
+
+// NewTimeSync 创建时间同步器；需调用 Sync 或 Start 后 offset 才生效（之前为 0，即使用本地时钟）。
+func NewTimeSync(baseURL string, httpClient *http.Client) *TimeSync {
+	return &TimeSync{
+		BaseURL:    baseURL,
+		HTTPClient: httpClient,
+		resync:     make(chan string, 1),
+	}
+}
+
+// NowMillis 返回按交易所时间补偿后的毫秒时间戳，可直接用于签名参数 timestamp。
+func (s *TimeSync) NowMillis() int64 {
+	if s == nil {
+		return timeNowMillis()
+	}
+	return timeNowMillis() + s.offsetMs.Load()
+}
+
+// Offset 返回当前估计的时钟偏差（交易所时间 - 本地时间）。
+func (s *TimeSync) Offset() time.Duration {
+	return time.Duration(s.offsetMs.Load()) * time.Millisecond
+}
+
+// RTT 返回最近一轮同步选用样本的往返时延。
+func (s *TimeSync) RTT() time.Duration {
+	return time.Duration(s.rttNs.Load())
+}
+
+// SyncCount 返回累计成功同步的次数。
+func (s *TimeSync) SyncCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.syncs
+}
+
+// Sync 立即执行一轮采样并更新 offset；全部采样失败时返回最后一个错误，offset 保持不变。
+func (s *TimeSync) Sync() error {
+	samples := s.Samples
+	if samples <= 0 {
+		samples = 3
+	}
+	var (
+		bestOffset int64
+		bestRTT    time.Duration = -1
+		lastErr    error
+	)
+	for i := 0; i < samples; i++ {
+		offset, rtt, err := s.sample()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if bestRTT < 0 || rtt < bestRTT {
+			bestOffset, bestRTT = offset, rtt
+		}
+	}
+	if bestRTT < 0 {
+		return lastErr
+	}
+	s.offsetMs.Store(bestOffset)
+	s.rttNs.Store(int64(bestRTT))
+	s.mu.Lock()
+	s.lastSync = time.Now()
+	s.syncs++
+	s.mu.Unlock()
+	if s.OnSync != nil {
+		s.OnSync(time.Duration(bestOffset)*time.Millisecond, bestRTT)
+	}
+	return nil
+}
+
+func (s *TimeSync) sample() (int64, time.Duration, error) {
+	if s.HTTPClient == nil {
+		return 0, 0, fmt.Errorf("http client not set")
+	}
+	start := time.Now()
+	sent := timeNowMillis()
+	resp, err := s.HTTPClient.Get(s.BaseURL + "/fapi/v1/time")
+	if err != nil {
+		return 0, 0, err
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	received := timeNowMillis()
+	rtt := time.Since(start)
+	if resp.StatusCode >= 300 {
+		return 0, 0, fmt.Errorf("server time status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	var tr serverTimeResp
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return 0, 0, err
+	}
+	if tr.ServerTime <= 0 {
+		return 0, 0, fmt.Errorf("empty serverTime")
+	}
+	return tr.ServerTime - (sent+received)/2, rtt, nil
+}
+
+// Resync 请求尽快重新同步（例如收到 -1021）；非阻塞，由 Start 启动的循环执行。
+func (s *TimeSync) Resync(reason string) {
+	if s == nil || s.resync == nil {
+		return
+	}
+	select {
+	case s.resync <- reason:
+	default:
+	}
+}
+
+// Start 先同步一次（失败仅记录日志），随后在后台按 Interval 周期同步并响应 Resync，直到 ctx 取消。
+func (s *TimeSync) Start(ctx context.Context) error {
+	err := s.Sync()
+	if err != nil {
+		log.Printf("time sync: initial sync err: %v", err)
+	}
+	go s.run(ctx)
+	return err
+}
+
+func (s *TimeSync) run(ctx context.Context) {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = 60 * time.Second
+	}
+	minResync := s.MinResyncInterval
+	if minResync <= 0 {
+		minResync = 2 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case reason := <-s.resync:
+			// 距上次同步不足 minResync 时延后执行，而不是丢弃
+			s.mu.Lock()
+			wait := minResync - time.Since(s.lastSync)
+			s.mu.Unlock()
+			if wait > 0 {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(wait):
+				}
+			}
+			log.Printf("time sync: resync (%s)", reason)
+		}
+		if err := s.Sync(); err != nil {
+			log.Printf("time sync: sync err: %v", err)
+		}
+	}
+}
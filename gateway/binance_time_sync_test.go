@@ -0,0 +1,96 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTimeServer 模拟交易所：服务器时间 = 本地时间 + skew；/fapi/v1/order 按 1s recvWindow 校验 timestamp。
+func newTimeServer(t *testing.T, skew *atomic.Int64) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server := time.Now().UnixMilli() + skew.Load()
+		switch r.URL.Path {
+		case "/fapi/v1/time":
+			fmt.Fprintf(w, `{"serverTime":%d}`, server)
+		case "/fapi/v1/order":
+			stamp, _ := strconv.ParseInt(r.URL.Query().Get("timestamp"), 10, 64)
+			if d := server - stamp; d > 1000 || d < -1000 {
+				w.WriteHeader(http.StatusBadRequest)
+				io.WriteString(w, `{"code":-1021,"msg":"Timestamp for this request is outside of the recvWindow."}`)
+				return
+			}
+			io.WriteString(w, `{"orderId":1}`)
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestTimeSyncEstimatesOffset(t *testing.T) {
+	var skew atomic.Int64
+	skew.Store(3000)
+	ts := newTimeServer(t, &skew)
+
+	var reported time.Duration
+	clock := NewTimeSync(ts.URL, ts.Client())
+	clock.OnSync = func(offset, rtt time.Duration) { reported = offset }
+	if err := clock.Sync(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if off := clock.Offset(); off < 2900*time.Millisecond || off > 3100*time.Millisecond {
+		t.Fatalf("unexpected offset %s", off)
+	}
+	if reported != clock.Offset() || clock.RTT() <= 0 || clock.SyncCount() != 1 {
+		t.Fatalf("unexpected sync state reported=%s rtt=%s count=%d", reported, clock.RTT(), clock.SyncCount())
+	}
+	if d := clock.NowMillis() - time.Now().UnixMilli(); d < 2900 || d > 3100 {
+		t.Fatalf("NowMillis not compensated: %d", d)
+	}
+
+	bad := NewTimeSync("http://127.0.0.1:1", &http.Client{Timeout: 100 * time.Millisecond})
+	if err := bad.Sync(); err == nil || bad.Offset() != 0 {
+		t.Fatalf("expected error and zero offset, got err=%v offset=%s", err, bad.Offset())
+	}
+}
+
+func TestBinanceRESTClientResyncOnTimestampError(t *testing.T) {
+	var skew atomic.Int64
+	ts := newTimeServer(t, &skew)
+
+	clock := NewTimeSync(ts.URL, ts.Client())
+	clock.MinResyncInterval = time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := clock.Start(ctx); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	cli := &BinanceRESTClient{BaseURL: ts.URL, APIKey: "key", Secret: "secret", HTTPClient: ts.Client(), TimeSync: clock}
+	if _, err := cli.PlaceLimit("BTCUSDT", "BUY", "GTC", 100, 1, false, false, ""); err != nil {
+		t.Fatalf("place before drift: %v", err)
+	}
+
+	// 本地时钟落后交易所 5s：首单 -1021，并自动触发重新校时
+	skew.Store(5000)
+	if _, err := cli.PlaceLimit("BTCUSDT", "BUY", "GTC", 100, 1, false, false, ""); err == nil || ClassifyError(http.StatusBadRequest, err.Error()) != ErrorTypeTimestamp {
+		t.Fatalf("expected -1021, got %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for clock.SyncCount() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if clock.SyncCount() < 2 {
+		t.Fatalf("resync not triggered")
+	}
+	if _, err := cli.PlaceLimit("BTCUSDT", "BUY", "GTC", 100, 1, false, false, ""); err != nil {
+		t.Fatalf("place after resync: %v", err)
+	}
+}
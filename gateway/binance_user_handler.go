@@ -9,6 +9,8 @@ import (
 type BinanceUserHandler struct {
 	OnOrderUpdate   func(OrderUpdate)
 	OnAccountUpdate func(AccountUpdate)
+	// OnListenKeyExpired 收到 listenKeyExpired 时回调，通常交给 ListenKeyManager.HandleExpired。
+	OnListenKeyExpired func(listenKey string)
 }
 
 // OnRawMessage 满足 interface { OnRawMessage([]byte) }，可直接交给 BinanceWSReal。
@@ -33,6 +35,10 @@ func (h *BinanceUserHandler) OnRawMessage(msg []byte) {
 		if h.OnAccountUpdate != nil && ev.Account != nil {
 			h.OnAccountUpdate(*ev.Account)
 		}
+	case "listenKeyExpired":
+		if h.OnListenKeyExpired != nil {
+			h.OnListenKeyExpired(ev.ListenKey)
+		}
 	default:
 		// 忽略其他事件
 	}
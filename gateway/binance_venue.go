@@ -0,0 +1,245 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"market-maker-go/market"
+	"market-maker-go/order"
+)
+
+// VenueBinance Binance USDⓈ-M/USDC-M 永续。
+const VenueBinance = "binance"
+
+// BinanceVenue 把 Binance 的 REST/WS 客户端适配为 Venue。
+type BinanceVenue struct {
+	REST *BinanceRESTClient
+	// Orders 下单/撤单通道，默认 REST；可替换为 BinanceWSAPIClient。
+	Orders BinanceREST
+	// WSEndpoint 行情/用户流 WS 地址，默认 BinanceFuturesWSEndpoint。
+	WSEndpoint string
+	// ListenKeys 非空时 Stream 订阅用户流；Stream 接管其 OnRotate/OnResync 回调，并在返回时关闭。
+	ListenKeys *ListenKeyManager
+}
+
+// NewBinanceVenue 创建以 REST 下单的 Binance 适配器（不订阅用户流）。
+func NewBinanceVenue(rest *BinanceRESTClient) *BinanceVenue {
+	return &BinanceVenue{REST: rest, Orders: rest}
+}
+
+func (v *BinanceVenue) Name() string { return VenueBinance }
+
+func (v *BinanceVenue) orders() BinanceREST {
+	if v.Orders != nil {
+		return v.Orders
+	}
+	return v.REST
+}
+
+func (v *BinanceVenue) Instruments(symbol string) ([]ExchangeSymbolInfo, error) {
+	return v.REST.ExchangeInfo(symbol)
+}
+
+func (v *BinanceVenue) BestBidAsk(symbol string) (float64, float64, error) {
+	return v.REST.GetBestBidAsk(symbol, 5)
+}
+
+// PlaceOrder 限价单按 OrderOptionsOf(o) 下发 positionSide/STP/priceMatch；MARKET 单走 REST。
+func (v *BinanceVenue) PlaceOrder(o order.Order) (string, error) {
+	if strings.EqualFold(o.Type, "MARKET") {
+		return v.REST.PlaceMarketSide(o.Symbol, o.Side, o.PositionSide, o.Quantity, o.ReduceOnly, o.ID)
+	}
+	tif := o.TimeInForce
+	if tif == "" {
+		tif = "GTC"
+	}
+	return PlaceLimitOrder(v.orders(), o.Symbol, o.Side, tif, o.Price, o.Quantity, o.ReduceOnly, o.PostOnly, o.ID, OrderOptionsOf(o))
+}
+
+func (v *BinanceVenue) CancelOrder(symbol, exchangeID string) error {
+	return v.orders().CancelOrder(symbol, exchangeID)
+}
+
+func (v *BinanceVenue) CancelAll(symbol string) error {
+	return v.REST.CancelAll(symbol)
+}
+
+// GetOrder 按 origClientOrderId 查询；交易所状态无法映射时返回错误。
+func (v *BinanceVenue) GetOrder(symbol, clientOrderID string) (*order.Order, error) {
+	fo, err := v.REST.QueryOrder(symbol, "", clientOrderID)
+	if err != nil {
+		return nil, err
+	}
+	o := fo.Order()
+	if o.Status == "" {
+		return nil, fmt.Errorf("unknown order status %q for %s", fo.Status, clientOrderID)
+	}
+	return o, nil
+}
+
+func (v *BinanceVenue) OpenOrders(symbol string) ([]*order.Order, error) {
+	orders, err := v.REST.OpenOrders(symbol)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*order.Order, 0, len(orders))
+	for _, fo := range orders {
+		out = append(out, fo.Order())
+	}
+	return out, nil
+}
+
+func (v *BinanceVenue) Positions(symbol string) ([]FuturesPosition, error) {
+	return v.REST.PositionRisk(symbol)
+}
+
+func (v *BinanceVenue) Balances() ([]FuturesBalance, error) {
+	return v.REST.AccountBalances()
+}
+
+// Stream 在一条 combined stream 上订阅 depth（经 DepthSynchronizer 维护本地簿）、aggTrade 与用户流。
+func (v *BinanceVenue) Stream(ctx context.Context, symbol string, h VenueHandler) error {
+	symbol = strings.ToUpper(symbol)
+	ws := NewBinanceWSReal()
+	if v.WSEndpoint != "" {
+		ws.BaseEndpoint = v.WSEndpoint
+	}
+	depth := NewDepthSynchronizer(symbol, market.NewOrderBook(), v.REST)
+	mux := &binanceVenueMux{symbol: symbol, depth: depth, h: h}
+	ws.OnGap(func(g WSGap) {
+		if g.Kind == StreamDepth {
+			depth.Resync("ws_" + g.Reason)
+		}
+		h.gap(g)
+	})
+	if err := ws.SubscribeDepth(symbol); err != nil {
+		return err
+	}
+	if err := ws.SubscribeTrade(symbol); err != nil {
+		return err
+	}
+	if lk := v.ListenKeys; lk != nil {
+		lk.OnRotate = func(_, newKey string) {
+			if err := ws.SubscribeUserData(newKey); err != nil {
+				log.Printf("binance venue: resubscribe user stream: %v", err)
+			}
+		}
+		lk.OnResync = func(reason string) {
+			h.gap(WSGap{Stream: "listenKey", Kind: StreamUserData, Reason: reason, Since: time.Now()})
+		}
+		key, err := lk.Start()
+		if err != nil {
+			return fmt.Errorf("start listenKey: %w", err)
+		}
+		defer lk.Close()
+		mux.user = &BinanceUserHandler{
+			OnOrderUpdate: func(o OrderUpdate) { h.order(BinanceOrderEvent(o)) },
+			OnAccountUpdate: func(a AccountUpdate) {
+				for _, p := range a.Positions {
+					h.position(FuturesPosition{
+						Symbol:           p.Symbol,
+						PositionAmt:      p.PositionAmt,
+						EntryPrice:       p.EntryPrice,
+						UnrealizedProfit: p.PnL,
+						MarginType:       p.MarginType,
+						PositionSide:     p.PositionSide,
+					})
+				}
+			},
+			OnListenKeyExpired: lk.HandleExpired,
+		}
+		if err := ws.SubscribeUserData(key); err != nil {
+			return err
+		}
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			ws.Stop()
+		case <-done:
+		}
+	}()
+	return ws.Run(mux)
+}
+
+// BinanceOrderEvent 把 ORDER_TRADE_UPDATE 归一化为 VenueOrderEvent。
+func BinanceOrderEvent(o OrderUpdate) VenueOrderEvent {
+	ev := VenueOrderEvent{
+		Venue:         VenueBinance,
+		Symbol:        o.Symbol,
+		ClientOrderID: o.ClientOrderID,
+		ExchangeID:    strconv.FormatInt(o.OrderID, 10),
+		Side:          o.Side,
+		PositionSide:  o.PositionSide,
+		Status:        MapOrderStatus(o.Status),
+		Price:         o.Price,
+		Qty:           o.OrigQty,
+		CumQty:        o.AccumulatedQty,
+		LastQty:       o.LastFilledQty,
+		LastPrice:     o.LastFilledPrice,
+		Fee:           o.CommissionAmount,
+		FeeAsset:      o.CommissionAsset,
+		RealizedPnL:   o.RealizedPnL,
+		Maker:         o.Maker,
+		Time:          o.TradeTime,
+		RawStatus:     o.Status,
+	}
+	if o.TradeID > 0 {
+		ev.TradeID = strconv.FormatInt(o.TradeID, 10)
+	}
+	return ev
+}
+
+// binanceVenueMux 把 combined stream 原始消息分发给各解析器并转为归一化回调。
+type binanceVenueMux struct {
+	symbol string
+	depth  *DepthSynchronizer
+	user   *BinanceUserHandler
+	h      VenueHandler
+
+	mu       sync.Mutex
+	bid, ask float64
+}
+
+func (m *binanceVenueMux) OnDepth(symbol string, bid, ask float64) {}
+
+func (m *binanceVenueMux) OnTrade(symbol string, price, qty float64) {}
+
+func (m *binanceVenueMux) OnRawMessage(msg []byte) {
+	if m.user != nil {
+		m.user.OnRawMessage(msg)
+	}
+	m.depth.OnRawMessage(msg)
+	if bid, ask := m.depth.Book.Best(); bid > 0 && ask > 0 {
+		m.mu.Lock()
+		changed := bid != m.bid || ask != m.ask
+		m.bid, m.ask = bid, ask
+		m.mu.Unlock()
+		if changed {
+			m.h.book(m.symbol, bid, ask)
+		}
+	}
+	at, err := ParseAggTrade(msg)
+	if err != nil {
+		if !errors.Is(err, ErrNonAggTrade) {
+			log.Printf("parse aggTrade err: %v", err)
+		}
+		return
+	}
+	m.h.trade(market.Trade{
+		Symbol: at.Symbol,
+		ID:     at.AggTradeID,
+		Price:  at.Price,
+		Qty:    at.Qty,
+		IsBuy:  at.AggressorBuy(),
+		Ts:     time.UnixMilli(at.TradeTime).UTC(),
+	})
+}
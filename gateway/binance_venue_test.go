@@ -0,0 +1,28 @@
+package gateway
+
+import (
+	"testing"
+
+	"market-maker-go/order"
+)
+
+func TestBinanceOrderEventNormalizes(t *testing.T) {
+	ev := BinanceOrderEvent(OrderUpdate{
+		Symbol: "ETHUSDC", Side: "BUY", Status: "PARTIALLY_FILLED", OrderID: 8389765,
+		ClientOrderID: "mm-b-1", Price: 2000.1, OrigQty: 0.5, LastFilledQty: 0.2, AccumulatedQty: 0.2,
+		LastFilledPrice: 2000.1, CommissionAsset: "USDC", CommissionAmount: 0.02, PositionSide: "LONG",
+		TradeID: 77, Maker: true, TradeTime: 1700000001000,
+	})
+	if ev.Venue != VenueBinance || ev.ExchangeID != "8389765" || ev.Status != order.StatusPartial || ev.RawStatus != "PARTIALLY_FILLED" {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+	if ev.LastQty != 0.2 || ev.CumQty != 0.2 || ev.Qty != 0.5 || ev.Fee != 0.02 || ev.FeeAsset != "USDC" || ev.PositionSide != "LONG" {
+		t.Fatalf("unexpected fill fields %+v", ev)
+	}
+	if ev.TradeID != "77" || !ev.Maker || ev.Time != 1700000001000 {
+		t.Fatalf("unexpected trade fields %+v", ev)
+	}
+	if BinanceOrderEvent(OrderUpdate{Status: "NEW"}).TradeID != "" {
+		t.Fatalf("trade id should be empty without a fill")
+	}
+}
@@ -0,0 +1,419 @@
+package gateway
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var (
+	// ErrWSAPIDisconnected 表示请求未发出（socket 不可用），可安全地改走 REST 重试。
+	ErrWSAPIDisconnected = errors.New("ws api not connected")
+	// ErrWSAPITimeout 表示请求已发出但未在超时内收到响应（含等待期间断线），订单状态未知；
+	// 同时满足 errors.Is(err, ErrExecutionUnknown)。
+	ErrWSAPITimeout = fmt.Errorf("ws api request timeout: %w", ErrExecutionUnknown)
+)
+
+// WSAPIError 为 WS API 返回的业务错误，Error() 保留 {"code":..,"msg":..} 原文便于上层识别错误码。
+type WSAPIError struct {
+	Method string
+	Status int
+	Code   int
+	Msg    string
+}
+
+func (e *WSAPIError) Error() string {
+	body, _ := json.Marshal(struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}{e.Code, e.Msg})
+	return fmt.Sprintf("ws api %s status %d: %s", e.Method, e.Status, body)
+}
+
+// Unwrap 转为 BinanceAPIError，使 WS 与 REST 错误可统一用 errors.Is/As 按错误码处理。
+func (e *WSAPIError) Unwrap() error {
+	return &BinanceAPIError{Op: "ws api " + e.Method, Endpoint: e.Method, HTTPStatus: e.Status, Code: e.Code, Msg: e.Msg}
+}
+
+type wsAPIRequest struct {
+	ID     string                 `json:"id"`
+	Method string                 `json:"method"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+type wsAPIResponse struct {
+	ID     string          `json:"id"`
+	Status int             `json:"status"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	} `json:"error"`
+}
+
+type wsAPIResult struct {
+	resp wsAPIResponse
+	err  error
+}
+
+// BinanceWSAPIClient 通过 Binance 合约 WebSocket 交易 API 下单/撤单/改单，
+// 复用长连接避免每次请求的 HTTP 往返；实现 BinanceREST，可直接替换 BinanceRESTClient。
+//   - 请求以 id 关联响应，每个请求独立超时；
+//   - 配置 Ed25519Key 时使用 session.logon 鉴权，否则每条请求按 HMAC 单独签名；
+//   - socket 不可用时请求交给 Fallback（通常为 BinanceRESTClient），并在后台自动重连。
+type BinanceWSAPIClient struct {
+	Endpoint string // 默认 wss://ws-fapi.binance.com/ws-fapi/v1
+	APIKey   string
+	Secret   string
+	// Ed25519Key 可选；Binance 仅支持 Ed25519 key 的 session.logon。
+	Ed25519Key     ed25519.PrivateKey
+	RecvWindowMs   int
+	RequestTimeout time.Duration // 单请求超时，默认 5s
+	ReconnectDelay time.Duration // 重连初始间隔，默认 1s（指数退避，上限 30s）
+	Dialer         *websocket.Dialer
+	Fallback       BinanceREST
+	// TimeSync 可选：按交易所时间补偿 timestamp，收到 -1021 时触发重新同步。
+	TimeSync *TimeSync
+	// Precision 可选：按交易对 tick/step 序列化价格与数量。
+	Precision *SymbolPrecision
+
+	mu         sync.Mutex
+	writeMu    sync.Mutex
+	conn       *websocket.Conn
+	pending    map[string]chan wsAPIResult
+	seq        uint64
+	loggedOn   bool
+	connecting bool
+	closed     bool
+}
+
+// NewBinanceWSAPIClient 创建 WS API 下单客户端；fallback 可为 nil。
+func NewBinanceWSAPIClient(apiKey, secret string, fallback BinanceREST) *BinanceWSAPIClient {
+	return &BinanceWSAPIClient{
+		Endpoint: BinanceFuturesWSAPIEndpoint,
+		APIKey:   apiKey,
+		Secret:   secret,
+		Dialer:   websocket.DefaultDialer,
+		Fallback: fallback,
+	}
+}
+
+// Connect 建立连接（配置 Ed25519Key 时同时完成 session.logon）。
+func (c *BinanceWSAPIClient) Connect() error {
+	dialer := c.Dialer
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		endpoint = BinanceFuturesWSAPIEndpoint
+	}
+	c.mu.Lock()
+	if c.conn != nil {
+		c.mu.Unlock()
+		return nil
+	}
+	c.mu.Unlock()
+	conn, _, err := dialer.Dial(endpoint, nil)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	if c.closed || c.conn != nil {
+		c.mu.Unlock()
+		conn.Close()
+		return ErrWSAPIDisconnected
+	}
+	c.conn = conn
+	c.pending = make(map[string]chan wsAPIResult)
+	c.loggedOn = false
+	c.mu.Unlock()
+	go c.readLoop(conn)
+
+	if len(c.Ed25519Key) > 0 {
+		if err := c.logon(); err != nil {
+			c.dropConn(conn, err)
+			return fmt.Errorf("session.logon: %w", err)
+		}
+	}
+	return nil
+}
+
+// Connected 返回当前 socket 是否可用。
+func (c *BinanceWSAPIClient) Connected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn != nil
+}
+
+// Close 关闭连接并停止自动重连。
+func (c *BinanceWSAPIClient) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	conn := c.conn
+	c.mu.Unlock()
+	if conn != nil {
+		c.dropConn(conn, ErrWSAPIDisconnected)
+	}
+	return nil
+}
+
+// PlaceLimit 通过 order.place 下 LIMIT 单；socket 不可用时走 Fallback。
+func (c *BinanceWSAPIClient) PlaceLimit(symbol, side, tif string, price, qty float64, reduceOnly, postOnly bool, clientID string) (string, error) {
+	return c.PlaceLimitWithOptions(symbol, side, tif, price, qty, reduceOnly, postOnly, clientID, OrderOptions{})
+}
+
+// PlaceLimitWithOptions 同 PlaceLimit，附带双向持仓腿、自成交保护与 priceMatch 选项。
+func (c *BinanceWSAPIClient) PlaceLimitWithOptions(symbol, side, tif string, price, qty float64, reduceOnly, postOnly bool, clientID string, opts OrderOptions) (string, error) {
+	params, err := limitOrderParams(c.Precision, symbol, side, tif, price, qty, reduceOnly, postOnly, clientID, opts)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.call("order.place", params)
+	if errors.Is(err, ErrWSAPIDisconnected) && c.Fallback != nil {
+		return PlaceLimitOrder(c.Fallback, symbol, side, tif, price, qty, reduceOnly, postOnly, clientID, opts)
+	}
+	if err != nil {
+		return "", err
+	}
+	return parseWSAPIOrderID(resp)
+}
+
+// CancelOrder 通过 order.cancel 撤单；socket 不可用时走 Fallback。
+func (c *BinanceWSAPIClient) CancelOrder(symbol, orderID string) error {
+	params := map[string]string{
+		"symbol":  symbol,
+		"orderId": orderID,
+	}
+	_, err := c.call("order.cancel", params)
+	if errors.Is(err, ErrWSAPIDisconnected) && c.Fallback != nil {
+		return c.Fallback.CancelOrder(symbol, orderID)
+	}
+	return err
+}
+
+// ModifyOrder 通过 order.modify 修改挂单价格/数量（保留 orderId）。
+// socket 不可用且 Fallback 支持 ModifyOrder 时走 Fallback。
+func (c *BinanceWSAPIClient) ModifyOrder(symbol, orderID, side string, price, qty float64) (string, error) {
+	return c.ModifyOrderWithOptions(symbol, orderID, side, price, qty, OrderOptions{})
+}
+
+// ModifyOrderWithOptions 同 ModifyOrder；opts.PriceMatch 非空时按盘口重新定价，不发送 price。
+func (c *BinanceWSAPIClient) ModifyOrderWithOptions(symbol, orderID, side string, price, qty float64, opts OrderOptions) (string, error) {
+	params, err := modifyOrderParams(c.Precision, symbol, orderID, side, price, qty, opts)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.call("order.modify", params)
+	if errors.Is(err, ErrWSAPIDisconnected) {
+		if m, ok := c.Fallback.(BinanceOptionsModifier); ok && priceMatched(opts) {
+			return m.ModifyOrderWithOptions(symbol, orderID, side, price, qty, opts)
+		}
+		if m, ok := c.Fallback.(BinanceOrderModifier); ok && !priceMatched(opts) {
+			return m.ModifyOrder(symbol, orderID, side, price, qty)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return parseWSAPIOrderID(resp)
+}
+
+func (c *BinanceWSAPIClient) logon() error {
+	params := map[string]string{"apiKey": c.APIKey}
+	payload, _ := SignParams(params, "")
+	sig := ed25519.Sign(c.Ed25519Key, []byte(payload))
+	params["signature"] = base64.StdEncoding.EncodeToString(sig)
+	if _, err := c.send("session.logon", params); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.loggedOn = true
+	c.mu.Unlock()
+	return nil
+}
+
+// call 为请求补齐 timestamp/recvWindow 与鉴权字段后发送。
+func (c *BinanceWSAPIClient) call(method string, params map[string]string) (wsAPIResponse, error) {
+	c.mu.Lock()
+	loggedOn := c.loggedOn
+	c.mu.Unlock()
+	if c.RecvWindowMs > 0 {
+		params["recvWindow"] = strconv.Itoa(c.RecvWindowMs)
+	}
+	params["timestamp"] = strconv.FormatInt(c.TimeSync.NowMillis(), 10)
+	if !loggedOn {
+		params["apiKey"] = c.APIKey
+		_, sig := SignParams(params, c.Secret)
+		params["signature"] = sig
+	}
+	resp, err := c.send(method, params)
+	if c.TimeSync != nil && errors.Is(err, ErrTimestamp) {
+		c.TimeSync.Resync("-1021")
+	}
+	return resp, err
+}
+
+func (c *BinanceWSAPIClient) send(method string, params map[string]string) (wsAPIResponse, error) {
+	c.mu.Lock()
+	conn := c.conn
+	if conn == nil {
+		c.mu.Unlock()
+		c.scheduleReconnect()
+		return wsAPIResponse{}, ErrWSAPIDisconnected
+	}
+	c.seq++
+	id := strconv.FormatUint(c.seq, 10)
+	ch := make(chan wsAPIResult, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	req := wsAPIRequest{ID: id, Method: method, Params: make(map[string]interface{}, len(params))}
+	for k, v := range params {
+		req.Params[k] = v
+	}
+	for _, k := range []string{"timestamp", "recvWindow", "orderId"} {
+		if n, err := strconv.ParseInt(params[k], 10, 64); err == nil {
+			req.Params[k] = n
+		}
+	}
+	c.writeMu.Lock()
+	err := conn.WriteJSON(req)
+	c.writeMu.Unlock()
+	if err != nil {
+		c.removePending(id)
+		c.dropConn(conn, err)
+		c.scheduleReconnect()
+		return wsAPIResponse{}, fmt.Errorf("%w: %v", ErrWSAPIDisconnected, err)
+	}
+
+	timeout := c.RequestTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case res := <-ch:
+		if res.err != nil {
+			return wsAPIResponse{}, res.err
+		}
+		if res.resp.Status >= 300 || res.resp.Error != nil {
+			apiErr := &WSAPIError{Method: method, Status: res.resp.Status}
+			if res.resp.Error != nil {
+				apiErr.Code = res.resp.Error.Code
+				apiErr.Msg = res.resp.Error.Msg
+			}
+			return res.resp, apiErr
+		}
+		return res.resp, nil
+	case <-timer.C:
+		c.removePending(id)
+		return wsAPIResponse{}, fmt.Errorf("%w: %s id=%s after %s", ErrWSAPITimeout, method, id, timeout)
+	}
+}
+
+func (c *BinanceWSAPIClient) readLoop(conn *websocket.Conn) {
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			c.dropConn(conn, err)
+			c.scheduleReconnect()
+			return
+		}
+		var resp wsAPIResponse
+		if err := json.Unmarshal(msg, &resp); err != nil {
+			log.Printf("ws api decode err: %v", err)
+			continue
+		}
+		c.mu.Lock()
+		ch, ok := c.pending[resp.ID]
+		delete(c.pending, resp.ID)
+		c.mu.Unlock()
+		if ok {
+			ch <- wsAPIResult{resp: resp}
+		}
+	}
+}
+
+// dropConn 关闭指定连接，并让所有等待中的请求以 ErrWSAPITimeout 失败（已发出、结果未知）。
+func (c *BinanceWSAPIClient) dropConn(conn *websocket.Conn, cause error) {
+	c.mu.Lock()
+	if c.conn != conn {
+		c.mu.Unlock()
+		return
+	}
+	c.conn = nil
+	c.loggedOn = false
+	pending := c.pending
+	c.pending = nil
+	c.mu.Unlock()
+	conn.Close()
+	for id, ch := range pending {
+		ch <- wsAPIResult{err: fmt.Errorf("%w: connection lost before response id=%s: %v", ErrWSAPITimeout, id, cause)}
+	}
+}
+
+func (c *BinanceWSAPIClient) removePending(id string) {
+	c.mu.Lock()
+	if c.pending != nil {
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+}
+
+// scheduleReconnect 后台重连（同一时刻只有一个重连协程）。
+func (c *BinanceWSAPIClient) scheduleReconnect() {
+	c.mu.Lock()
+	if c.connecting || c.closed || c.conn != nil {
+		c.mu.Unlock()
+		return
+	}
+	c.connecting = true
+	c.mu.Unlock()
+	go func() {
+		delay := c.ReconnectDelay
+		if delay <= 0 {
+			delay = time.Second
+		}
+		for {
+			time.Sleep(delay)
+			c.mu.Lock()
+			closed := c.closed
+			c.mu.Unlock()
+			if closed {
+				break
+			}
+			err := c.Connect()
+			if err == nil {
+				break
+			}
+			log.Printf("ws api reconnect failed: %v, retry in %s", err, delay)
+			if delay < 30*time.Second {
+				delay *= 2
+			}
+		}
+		c.mu.Lock()
+		c.connecting = false
+		c.mu.Unlock()
+	}()
+}
+
+func parseWSAPIOrderID(resp wsAPIResponse) (string, error) {
+	var pr placeResp
+	if err := json.Unmarshal(resp.Result, &pr); err != nil {
+		return "", err
+	}
+	if pr.OrderID == "" {
+		return "", fmt.Errorf("empty orderId")
+	}
+	return pr.OrderID.String(), nil
+}
@@ -0,0 +1,266 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsAPIStub 为 WS API 测试服务端；respond 返回 nil 表示不回复（模拟超时）。
+type wsAPIStub struct {
+	t       *testing.T
+	server  *httptest.Server
+	respond func(method string, params map[string]string) map[string]interface{}
+
+	mu    sync.Mutex
+	conns []*websocket.Conn
+	reqs  []string
+}
+
+func newWSAPIStub(t *testing.T, respond func(method string, params map[string]string) map[string]interface{}) *wsAPIStub {
+	s := &wsAPIStub{t: t, respond: respond}
+	upgrader := websocket.Upgrader{}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var req struct {
+				ID     string                 `json:"id"`
+				Method string                 `json:"method"`
+				Params map[string]interface{} `json:"params"`
+			}
+			dec := json.NewDecoder(bytes.NewReader(msg))
+			dec.UseNumber()
+			if err := dec.Decode(&req); err != nil {
+				t.Errorf("decode request: %v", err)
+				return
+			}
+			params := make(map[string]string, len(req.Params))
+			for k, v := range req.Params {
+				params[k] = fmt.Sprint(v)
+			}
+			s.mu.Lock()
+			s.reqs = append(s.reqs, req.Method)
+			s.mu.Unlock()
+			resp := s.respond(req.Method, params)
+			if resp == nil {
+				continue
+			}
+			resp["id"] = req.ID
+			_ = conn.WriteJSON(resp)
+		}
+	}))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+func (s *wsAPIStub) endpoint() string {
+	return "ws" + strings.TrimPrefix(s.server.URL, "http")
+}
+
+func (s *wsAPIStub) dropAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.conns {
+		_ = c.Close()
+	}
+	s.conns = nil
+}
+
+func (s *wsAPIStub) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.reqs...)
+}
+
+type fallbackREST struct {
+	mu      sync.Mutex
+	placed  []string
+	cancels []string
+}
+
+func (s *fallbackREST) PlaceLimit(symbol, side, tif string, price, qty float64, reduceOnly, postOnly bool, clientID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.placed = append(s.placed, clientID)
+	return "rest-1", nil
+}
+
+func (s *fallbackREST) CancelOrder(symbol, orderID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cancels = append(s.cancels, orderID)
+	return nil
+}
+
+func newTestWSAPIClient(t *testing.T, endpoint string, fallback BinanceREST) *BinanceWSAPIClient {
+	t.Helper()
+	cli := NewBinanceWSAPIClient("key", "secret", fallback)
+	cli.Endpoint = endpoint
+	cli.RecvWindowMs = 5000
+	cli.RequestTimeout = 200 * time.Millisecond
+	cli.ReconnectDelay = 20 * time.Millisecond
+	t.Cleanup(func() { _ = cli.Close() })
+	return cli
+}
+
+func TestBinanceWSAPIClientPlaceModifyCancel(t *testing.T) {
+	timeNowMillis = func() int64 { return 1234567890000 }
+	defer func() { timeNowMillis = func() int64 { return time.Now().UnixMilli() } }()
+
+	var mu sync.Mutex
+	var got []map[string]string
+	stub := newWSAPIStub(t, func(method string, params map[string]string) map[string]interface{} {
+		mu.Lock()
+		got = append(got, params)
+		mu.Unlock()
+		sig := params["signature"]
+		unsigned := make(map[string]string, len(params))
+		for k, v := range params {
+			if k != "signature" {
+				unsigned[k] = v
+			}
+		}
+		if _, want := SignParams(unsigned, "secret"); sig != want {
+			return map[string]interface{}{"status": 400, "error": map[string]interface{}{"code": -1022, "msg": "Signature for this request is not valid."}}
+		}
+		return map[string]interface{}{"status": 200, "result": map[string]interface{}{"orderId": 4242, "status": "NEW"}}
+	})
+	cli := newTestWSAPIClient(t, stub.endpoint(), nil)
+	if err := cli.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	id, err := cli.PlaceLimit("ETHUSDC", "BUY", "GTC", 2000.5, 0.01, false, true, "cid-1")
+	if err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if id != "4242" {
+		t.Fatalf("unexpected order id %s", id)
+	}
+	if _, err := cli.ModifyOrder("ETHUSDC", id, "BUY", 2001, 0.02); err != nil {
+		t.Fatalf("modify: %v", err)
+	}
+	if err := cli.CancelOrder("ETHUSDC", id); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	if reqs := stub.requests(); strings.Join(reqs, ",") != "order.place,order.modify,order.cancel" {
+		t.Fatalf("unexpected methods %v", reqs)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	place := got[0]
+	if place["timeInForce"] != "GTX" || place["newClientOrderId"] != "cid-1" || place["apiKey"] != "key" {
+		t.Fatalf("unexpected place params %+v", place)
+	}
+	if place["timestamp"] != "1234567890000" || place["recvWindow"] != "5000" {
+		t.Fatalf("missing timestamp/recvWindow %+v", place)
+	}
+	if got[1]["orderId"] != "4242" || got[1]["price"] != "2001" {
+		t.Fatalf("unexpected modify params %+v", got[1])
+	}
+}
+
+func TestBinanceWSAPIClientErrorResponse(t *testing.T) {
+	stub := newWSAPIStub(t, func(method string, params map[string]string) map[string]interface{} {
+		return map[string]interface{}{"status": 400, "error": map[string]interface{}{"code": -5022, "msg": "Due to the order could not be executed as maker, the Post Only order will be rejected."}}
+	})
+	cli := newTestWSAPIClient(t, stub.endpoint(), &fallbackREST{})
+	if err := cli.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	_, err := cli.PlaceLimit("ETHUSDC", "BUY", "GTC", 2000, 0.01, false, true, "po")
+	var apiErr *WSAPIError
+	if !errors.As(err, &apiErr) || apiErr.Code != -5022 || apiErr.Status != 400 {
+		t.Fatalf("expected WSAPIError -5022, got %v", err)
+	}
+	// 与 REST 错误文本一致，便于沿用按错误码识别的逻辑
+	if !strings.Contains(err.Error(), `code":-5022`) {
+		t.Fatalf("error text should carry code, got %s", err.Error())
+	}
+}
+
+func TestBinanceWSAPIClientTimeout(t *testing.T) {
+	stub := newWSAPIStub(t, func(method string, params map[string]string) map[string]interface{} {
+		return nil
+	})
+	fallback := &fallbackREST{}
+	cli := newTestWSAPIClient(t, stub.endpoint(), fallback)
+	if err := cli.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	_, err := cli.PlaceLimit("ETHUSDC", "BUY", "GTC", 2000, 0.01, false, false, "slow")
+	if !errors.Is(err, ErrWSAPITimeout) || !errors.Is(err, ErrExecutionUnknown) {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+	// 已发出的请求状态未知，不能回退 REST 重复下单
+	if len(fallback.placed) != 0 {
+		t.Fatalf("timed-out request must not fall back, got %v", fallback.placed)
+	}
+}
+
+func TestBinanceWSAPIClientFallbackAndReconnect(t *testing.T) {
+	stub := newWSAPIStub(t, func(method string, params map[string]string) map[string]interface{} {
+		return map[string]interface{}{"status": 200, "result": map[string]interface{}{"orderId": 7}}
+	})
+	fallback := &fallbackREST{}
+	cli := newTestWSAPIClient(t, stub.endpoint(), fallback)
+	cli.ReconnectDelay = 200 * time.Millisecond
+
+	// 未连接：直接走 REST，并触发后台重连
+	id, err := cli.PlaceLimit("ETHUSDC", "SELL", "GTC", 2000, 0.01, false, false, "before")
+	if err != nil || id != "rest-1" {
+		t.Fatalf("expected REST fallback, got id=%s err=%v", id, err)
+	}
+	waitWSAPI(t, cli.Connected)
+	if id, err := cli.PlaceLimit("ETHUSDC", "SELL", "GTC", 2000, 0.01, false, false, "ws"); err != nil || id != "7" {
+		t.Fatalf("expected ws order after reconnect, got id=%s err=%v", id, err)
+	}
+
+	// 服务端断开：撤单回退 REST，随后自动重连
+	stub.dropAll()
+	waitWSAPI(t, func() bool { return !cli.Connected() })
+	if err := cli.CancelOrder("ETHUSDC", "7"); err != nil {
+		t.Fatalf("cancel fallback: %v", err)
+	}
+	waitWSAPI(t, cli.Connected)
+	fallback.mu.Lock()
+	defer fallback.mu.Unlock()
+	if len(fallback.placed) != 1 || fallback.placed[0] != "before" {
+		t.Fatalf("unexpected REST placements %v", fallback.placed)
+	}
+	if len(fallback.cancels) != 1 || fallback.cancels[0] != "7" {
+		t.Fatalf("unexpected REST cancels %v", fallback.cancels)
+	}
+}
+
+func waitWSAPI(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("condition not met before timeout")
+}
@@ -1,6 +1,7 @@
 package gateway
 
 import (
+	"errors"
 	"log"
 	"time"
 
@@ -37,3 +38,74 @@ func (h *BinanceWSHandler) OnRawMessage(msg []byte) {
 	}
 	h.OnDepth(sym, bid, ask)
 }
+
+// BinanceTradeHandler 解析 aggTrade 消息，按主动方向向 MarketService 推送成交。
+type BinanceTradeHandler struct {
+	Svc *market.Service
+}
+
+// OnRawMessage 满足 interface { OnRawMessage([]byte) }，非 aggTrade 消息静默忽略。
+func (h *BinanceTradeHandler) OnRawMessage(msg []byte) {
+	if h == nil {
+		return
+	}
+	at, err := ParseAggTrade(msg)
+	if err != nil {
+		if errors.Is(err, ErrNonAggTrade) {
+			return
+		}
+		log.Printf("parse aggTrade err: %v", err)
+		return
+	}
+	if h.Svc == nil {
+		return
+	}
+	h.Svc.OnTradeTick(market.Trade{
+		Symbol: at.Symbol,
+		ID:     at.AggTradeID,
+		Price:  at.Price,
+		Qty:    at.Qty,
+		IsBuy:  at.AggressorBuy(),
+		Ts:     time.UnixMilli(at.TradeTime).UTC(),
+	})
+}
+
+// BinanceMarkPriceHandler 解析 markPriceUpdate 消息，更新 MarketService 中的标记价与资金费率。
+type BinanceMarkPriceHandler struct {
+	Svc *market.Service
+	// OnUpdate 可选，每次更新后回调（如打点）。
+	OnUpdate func(market.FundingState)
+}
+
+// OnRawMessage 满足 interface { OnRawMessage([]byte) }，非标记价消息静默忽略。
+func (h *BinanceMarkPriceHandler) OnRawMessage(msg []byte) {
+	if h == nil {
+		return
+	}
+	upd, err := ParseMarkPrice(msg)
+	if err != nil {
+		if errors.Is(err, ErrNonMarkPrice) {
+			return
+		}
+		log.Printf("parse markPrice err: %v", err)
+		return
+	}
+	st := market.FundingState{
+		Symbol:      upd.Symbol,
+		MarkPrice:   upd.MarkPrice,
+		IndexPrice:  upd.IndexPrice,
+		FundingRate: upd.FundingRate,
+		EventTime:   time.UnixMilli(upd.EventTime).UTC(),
+		UpdatedAt:   time.Now(),
+	}
+	if upd.NextFundingTime > 0 {
+		st.NextFundingTime = time.UnixMilli(upd.NextFundingTime).UTC()
+	}
+	if h.Svc != nil {
+		h.Svc.OnMarkPrice(st)
+		st, _ = h.Svc.Funding(upd.Symbol)
+	}
+	if h.OnUpdate != nil {
+		h.OnUpdate(st)
+	}
+}
@@ -3,6 +3,7 @@ package gateway
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 )
@@ -16,6 +17,15 @@ type CombinedMessage struct {
 // ErrNonUserData 表示该 WS 消息不是用户数据流事件，应由调用方静默忽略。
 var ErrNonUserData = errors.New("ws message is not user data")
 
+// ErrNonDepthUpdate 表示该 WS 消息不是 diff depth 事件，应由调用方静默忽略。
+var ErrNonDepthUpdate = errors.New("ws message is not depth update")
+
+// ErrNonAggTrade 表示该 WS 消息不是 aggTrade 事件，应由调用方静默忽略。
+var ErrNonAggTrade = errors.New("ws message is not aggTrade")
+
+// ErrNonMarkPrice 表示该 WS 消息不是 markPriceUpdate 事件，应由调用方静默忽略。
+var ErrNonMarkPrice = errors.New("ws message is not markPriceUpdate")
+
 // DepthUpdate 提取 depth@100ms 消息的核心字段。
 type DepthUpdate struct {
 	EventType interface{}   `json:"e"`
@@ -24,11 +34,60 @@ type DepthUpdate struct {
 	Asks      []interface{} `json:"a"`
 }
 
+// DepthLevel 表示单个价位及其数量（数量为 0 表示删除该档）。
+type DepthLevel struct {
+	Price float64
+	Qty   float64
+}
+
+// DepthDiff 对应 <symbol>@depth@100ms 的增量事件，携带 U/u/pu 序号用于连续性校验。
+type DepthDiff struct {
+	Symbol            string
+	EventTime         int64
+	TransactionTime   int64
+	FirstUpdateID     int64 // U
+	FinalUpdateID     int64 // u
+	PrevFinalUpdateID int64 // pu
+	Bids              []DepthLevel
+	Asks              []DepthLevel
+}
+
+// AggTrade 对应 <symbol>@aggTrade 的归集成交事件。
+// BuyerIsMaker 为 true 表示买方是挂单方，即主动方为卖方。
+type AggTrade struct {
+	Symbol       string
+	AggTradeID   int64
+	Price        float64
+	Qty          float64
+	FirstTradeID int64
+	LastTradeID  int64
+	EventTime    int64
+	TradeTime    int64
+	BuyerIsMaker bool
+}
+
+// AggressorBuy 返回主动方是否为买方。
+func (t AggTrade) AggressorBuy() bool {
+	return !t.BuyerIsMaker
+}
+
+// MarkPriceUpdate 对应 <symbol>@markPrice@1s 事件：标记价、指数价与资金费率。
+type MarkPriceUpdate struct {
+	Symbol          string
+	MarkPrice       float64
+	IndexPrice      float64
+	EstSettlePrice  float64
+	FundingRate     float64
+	NextFundingTime int64
+	EventTime       int64
+}
+
 // UserEvent 表示解析后的用户流事件。
 type UserEvent struct {
 	EventType string
 	Order     *OrderUpdate
 	Account   *AccountUpdate
+	ListenKey string // listenKeyExpired 事件对应的 listenKey
 }
 
 // OrderUpdate 精简的订单回报。
@@ -49,6 +108,9 @@ type OrderUpdate struct {
 	CommissionAsset  string
 	CommissionAmount float64
 	PositionSide     string
+	TradeID          int64 // 成交 ID，无成交时为 0
+	Maker            bool
+	TradeTime        int64 // 成交时间（毫秒）
 }
 
 // AccountUpdate 精简的资产/仓位更新。
@@ -95,6 +157,162 @@ func ParseCombinedDepth(raw []byte) (symbol string, bestBid, bestAsk float64, er
 	return
 }
 
+// ParseDepthDiff 解析 combined stream 中的 depthUpdate 事件；非 depth 消息返回 ErrNonDepthUpdate。
+func ParseDepthDiff(raw []byte) (DepthDiff, error) {
+	var diff DepthDiff
+	var msg CombinedMessage
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return diff, err
+	}
+	if msg.Stream != "" && !strings.Contains(msg.Stream, "@depth") {
+		return diff, ErrNonDepthUpdate
+	}
+	var payload struct {
+		EventType string     `json:"e"`
+		EventTime int64      `json:"E"`
+		TransTime int64      `json:"T"`
+		Symbol    string     `json:"s"`
+		FirstID   int64      `json:"U"`
+		FinalID   int64      `json:"u"`
+		PrevID    int64      `json:"pu"`
+		Bids      [][]string `json:"b"`
+		Asks      [][]string `json:"a"`
+	}
+	if err := json.Unmarshal(msg.Data, &payload); err != nil {
+		return diff, err
+	}
+	if payload.EventType != "depthUpdate" {
+		return diff, ErrNonDepthUpdate
+	}
+	diff = DepthDiff{
+		Symbol:            payload.Symbol,
+		EventTime:         payload.EventTime,
+		TransactionTime:   payload.TransTime,
+		FirstUpdateID:     payload.FirstID,
+		FinalUpdateID:     payload.FinalID,
+		PrevFinalUpdateID: payload.PrevID,
+	}
+	var err error
+	if diff.Bids, err = parseDepthLevels(payload.Bids); err != nil {
+		return diff, err
+	}
+	if diff.Asks, err = parseDepthLevels(payload.Asks); err != nil {
+		return diff, err
+	}
+	return diff, nil
+}
+
+// ParseAggTrade 解析 combined stream 中的 aggTrade 事件；非 aggTrade 消息返回 ErrNonAggTrade。
+func ParseAggTrade(raw []byte) (AggTrade, error) {
+	var trade AggTrade
+	var msg CombinedMessage
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return trade, err
+	}
+	if msg.Stream != "" && !strings.HasSuffix(msg.Stream, "@aggTrade") {
+		return trade, ErrNonAggTrade
+	}
+	var payload struct {
+		EventType    string `json:"e"`
+		EventTime    int64  `json:"E"`
+		Symbol       string `json:"s"`
+		AggTradeID   int64  `json:"a"`
+		Price        string `json:"p"`
+		Qty          string `json:"q"`
+		FirstTradeID int64  `json:"f"`
+		LastTradeID  int64  `json:"l"`
+		TradeTime    int64  `json:"T"`
+		BuyerIsMaker bool   `json:"m"`
+	}
+	if err := json.Unmarshal(msg.Data, &payload); err != nil {
+		return trade, err
+	}
+	if payload.EventType != "aggTrade" {
+		return trade, ErrNonAggTrade
+	}
+	price, err := strconv.ParseFloat(payload.Price, 64)
+	if err != nil {
+		return trade, fmt.Errorf("parse aggTrade price %q: %w", payload.Price, err)
+	}
+	qty, err := strconv.ParseFloat(payload.Qty, 64)
+	if err != nil {
+		return trade, fmt.Errorf("parse aggTrade qty %q: %w", payload.Qty, err)
+	}
+	trade = AggTrade{
+		Symbol:       payload.Symbol,
+		AggTradeID:   payload.AggTradeID,
+		Price:        price,
+		Qty:          qty,
+		FirstTradeID: payload.FirstTradeID,
+		LastTradeID:  payload.LastTradeID,
+		EventTime:    payload.EventTime,
+		TradeTime:    payload.TradeTime,
+		BuyerIsMaker: payload.BuyerIsMaker,
+	}
+	return trade, nil
+}
+
+// ParseMarkPrice 解析 combined stream 中的 markPriceUpdate 事件；非标记价消息返回 ErrNonMarkPrice。
+func ParseMarkPrice(raw []byte) (MarkPriceUpdate, error) {
+	var upd MarkPriceUpdate
+	var msg CombinedMessage
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return upd, err
+	}
+	if msg.Stream != "" && !strings.Contains(msg.Stream, "@markPrice") {
+		return upd, ErrNonMarkPrice
+	}
+	var payload struct {
+		EventType       string `json:"e"`
+		EventTime       int64  `json:"E"`
+		Symbol          string `json:"s"`
+		MarkPrice       string `json:"p"`
+		IndexPrice      string `json:"i"`
+		EstSettlePrice  string `json:"P"`
+		FundingRate     string `json:"r"`
+		NextFundingTime int64  `json:"T"`
+	}
+	if err := json.Unmarshal(msg.Data, &payload); err != nil {
+		return upd, err
+	}
+	if payload.EventType != "markPriceUpdate" {
+		return upd, ErrNonMarkPrice
+	}
+	mark, err := strconv.ParseFloat(payload.MarkPrice, 64)
+	if err != nil {
+		return upd, fmt.Errorf("parse mark price %q: %w", payload.MarkPrice, err)
+	}
+	upd = MarkPriceUpdate{
+		Symbol:          payload.Symbol,
+		MarkPrice:       mark,
+		IndexPrice:      parseFloat(payload.IndexPrice),
+		EstSettlePrice:  parseFloat(payload.EstSettlePrice),
+		FundingRate:     parseFloat(payload.FundingRate),
+		NextFundingTime: payload.NextFundingTime,
+		EventTime:       payload.EventTime,
+	}
+	return upd, nil
+}
+
+func parseDepthLevels(raw [][]string) ([]DepthLevel, error) {
+	levels := make([]DepthLevel, 0, len(raw))
+	for _, lv := range raw {
+		if len(lv) < 2 {
+			return nil, fmt.Errorf("invalid depth level %v", lv)
+		}
+		price, err := strconv.ParseFloat(lv[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse depth price %q: %w", lv[0], err)
+		}
+		qty, err := strconv.ParseFloat(lv[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse depth qty %q: %w", lv[1], err)
+		}
+		levels = append(levels, DepthLevel{Price: price, Qty: qty})
+	}
+	return levels, nil
+}
+
 func parseDepthPrice(entry interface{}) (float64, error) {
 	switch v := entry.(type) {
 	case []interface{}:
@@ -148,6 +366,9 @@ func ParseUserData(raw []byte) (UserEvent, error) {
 				CommissionAsset  string `json:"N"`
 				CommissionAmount string `json:"n"`
 				PositionSide     string `json:"ps"`
+				TradeID          int64  `json:"t"`
+				Maker            bool   `json:"m"`
+				TradeTime        int64  `json:"T"`
 			} `json:"o"`
 		}
 		if err := json.Unmarshal(msg.Data, &payload); err != nil {
@@ -171,6 +392,9 @@ func ParseUserData(raw []byte) (UserEvent, error) {
 			CommissionAsset:  o.CommissionAsset,
 			CommissionAmount: parseFloat(o.CommissionAmount),
 			PositionSide:     o.PositionSide,
+			TradeID:          o.TradeID,
+			Maker:            o.Maker,
+			TradeTime:        o.TradeTime,
 		}
 	case "ACCOUNT_UPDATE":
 		var payload struct {
@@ -214,6 +438,12 @@ func ParseUserData(raw []byte) (UserEvent, error) {
 			})
 		}
 		ev.Account = &au
+	case "listenKeyExpired":
+		// 过期事件可能不带 listenKey 字段，此时以 stream 名为准
+		ev.ListenKey = toString(header["listenKey"])
+		if ev.ListenKey == "" {
+			ev.ListenKey = msg.Stream
+		}
 	}
 	return ev, nil
 }
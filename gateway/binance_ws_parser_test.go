@@ -1,6 +1,9 @@
 package gateway
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 func TestParseCombinedDepth(t *testing.T) {
 	raw := []byte(`{
@@ -28,7 +31,8 @@ func TestParseUserOrderUpdate(t *testing.T) {
 			"o":{
 				"s":"ETHUSDC","S":"BUY","o":"LIMIT","X":"NEW","x":"NEW",
 				"i":1001,"c":"cid","p":"2700.10","q":"1.5","l":"0.2","z":"0.2",
-				"L":"2700.00","rp":"0","N":"USDC","n":"0","ps":"BOTH"
+				"L":"2700.00","rp":"0","N":"USDC","n":"0","ps":"BOTH",
+				"t":555,"m":true,"T":1700000000123
 			}
 		}
 	}`)
@@ -42,6 +46,9 @@ func TestParseUserOrderUpdate(t *testing.T) {
 	if ev.Order.Price != 2700.10 || ev.Order.OrigQty != 1.5 || ev.Order.LastFilledQty != 0.2 {
 		t.Fatalf("unexpected order payload: %+v", ev.Order)
 	}
+	if ev.Order.TradeID != 555 || !ev.Order.Maker || ev.Order.TradeTime != 1700000000123 {
+		t.Fatalf("unexpected trade fields: %+v", ev.Order)
+	}
 }
 
 func TestParseUserAccountUpdate(t *testing.T) {
@@ -70,3 +77,76 @@ func TestParseUserAccountUpdate(t *testing.T) {
 		t.Fatalf("unexpected positions: %+v", ev.Account.Positions)
 	}
 }
+
+func TestParseDepthDiff(t *testing.T) {
+	raw := []byte(`{
+		"stream":"btcusdt@depth@100ms",
+		"data":{
+			"e":"depthUpdate","E":1700000000200,"T":1700000000199,"s":"BTCUSDT",
+			"U":996,"u":1003,"pu":995,
+			"b":[["100.00","2.500"],["99.90","0.000"]],
+			"a":[["100.10","1.500"]]
+		}
+	}`)
+	d, err := ParseDepthDiff(raw)
+	if err != nil {
+		t.Fatalf("parse err: %v", err)
+	}
+	if d.Symbol != "BTCUSDT" || d.FirstUpdateID != 996 || d.FinalUpdateID != 1003 || d.PrevFinalUpdateID != 995 {
+		t.Fatalf("unexpected ids: %+v", d)
+	}
+	if len(d.Bids) != 2 || d.Bids[1].Price != 99.9 || d.Bids[1].Qty != 0 {
+		t.Fatalf("unexpected bids: %+v", d.Bids)
+	}
+	if len(d.Asks) != 1 || d.Asks[0].Qty != 1.5 {
+		t.Fatalf("unexpected asks: %+v", d.Asks)
+	}
+	if _, err := ParseDepthDiff([]byte(`{"stream":"lk","data":{"e":"ACCOUNT_UPDATE"}}`)); err != ErrNonDepthUpdate {
+		t.Fatalf("expected ErrNonDepthUpdate, got %v", err)
+	}
+}
+
+func TestParseAggTradeFixture(t *testing.T) {
+	var trades []AggTrade
+	for _, msg := range loadStreamFixture(t, "aggtrade_btcusdt.jsonl") {
+		tr, err := ParseAggTrade(msg)
+		if errors.Is(err, ErrNonAggTrade) {
+			continue
+		}
+		if err != nil {
+			t.Fatalf("parse aggTrade: %v", err)
+		}
+		trades = append(trades, tr)
+	}
+	if len(trades) != 6 {
+		t.Fatalf("expected 6 aggTrades, got %d", len(trades))
+	}
+	first := trades[0]
+	if first.Symbol != "BTCUSDT" || first.AggTradeID != 101 || first.Price != 100 || first.Qty != 0.5 ||
+		first.FirstTradeID != 1001 || first.LastTradeID != 1002 || first.TradeTime != 1700000000100 || !first.AggressorBuy() {
+		t.Fatalf("unexpected first trade %+v", first)
+	}
+	// m=true：买方挂单，主动方为卖方
+	if trades[2].AggressorBuy() || !trades[2].BuyerIsMaker {
+		t.Fatalf("trade 103 should be sell aggressor: %+v", trades[2])
+	}
+
+	if _, err := ParseAggTrade([]byte(`{"stream":"btcusdt@aggTrade","data":{"e":"aggTrade","p":"x","q":"1"}}`)); err == nil || errors.Is(err, ErrNonAggTrade) {
+		t.Fatalf("invalid price should surface parse error, got %v", err)
+	}
+}
+
+func TestParseMarkPriceFixture(t *testing.T) {
+	msgs := loadStreamFixture(t, "markprice_btcusdt.jsonl")
+	upd, err := ParseMarkPrice(msgs[0])
+	if err != nil {
+		t.Fatalf("parse markPrice: %v", err)
+	}
+	if upd.Symbol != "BTCUSDT" || upd.MarkPrice != 36512.4 || upd.IndexPrice != 36515.72391304 ||
+		upd.EstSettlePrice != 36530.1152 || upd.FundingRate != 0.0001 || upd.NextFundingTime != 1700006400000 || upd.EventTime != 1700000000000 {
+		t.Fatalf("unexpected mark price update %+v", upd)
+	}
+	if _, err := ParseMarkPrice(msgs[1]); !errors.Is(err, ErrNonMarkPrice) {
+		t.Fatalf("aggTrade should be ignored, got %v", err)
+	}
+}
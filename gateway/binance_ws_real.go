@@ -1,34 +1,85 @@
 package gateway
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 )
 
-// BinanceWSReal 组合订阅深度/用户数据流并连接真实 WS（执行者确保网络可达）。
-// 仅提供最小骨架：连接 + 简单读取；业务层可在 handler 中扩展解析。
+// WSStreamKind 订阅流类别，缺口通知时用于区分消费方。
+type WSStreamKind string
+
+const (
+	StreamDepth     WSStreamKind = "depth"
+	StreamAggTrade  WSStreamKind = "aggTrade"
+	StreamMarkPrice WSStreamKind = "markPrice"
+	StreamUserData  WSStreamKind = "userData"
+)
+
+// 数据缺口原因。
+const (
+	GapReconnect = "reconnect" // 断线期间的推送已丢失
+	GapSilent    = "silent"    // 连接仍在，但该流超过静默阈值未推送
+)
+
+// WSGap 数据缺口通知：消费方应据此重建状态（深度重新拉快照、用户态按 REST 对账）。
+type WSGap struct {
+	Stream string
+	Kind   WSStreamKind
+	Reason string
+	Since  time.Time // 该流最后一次收到消息（或断线）的时间
+}
+
+type wsSubscription struct {
+	stream  string
+	kind    WSStreamKind
+	lastMsg time.Time
+	silent  bool
+}
+
+// BinanceWSReal 是 combined stream 的会话层：持有连接，断线后按指数退避重连并重放全部订阅，
+// 按订阅检测静默流，并通过 OnGap 通知消费方重建状态。
+// 首次连接成功后 Run 不会因瞬时断线返回，只有 Stop 才会结束。
 type BinanceWSReal struct {
 	BaseEndpoint string // 默认 wss://fstream.binance.com
-	depthStreams []string
-	userStream   string
 	Dialer       *websocket.Dialer
-	MaxRetries   int
-	RetryBackoff time.Duration
+	// MaxRetries 首次建连的最大重试次数（配置错误时尽早暴露）；连上之后断线无限重试。
+	MaxRetries int
+	Reconnect  WSReconnectConfig
+	// 各类流的静默阈值，0 表示不检测；超过阈值通知 GapSilent，超过 3 倍阈值主动重连。
+	DepthSilence     time.Duration
+	MarkPriceSilence time.Duration
+	TradeSilence     time.Duration
+	// Capture 非 nil 时记录每一条入站帧与数据缺口，供 ReplayWS 回放。
+	Capture *CaptureWriter
+
+	mu           sync.Mutex
+	writeMu      sync.Mutex
+	subs         []*wsSubscription
+	conn         *websocket.Conn
+	nextID       int64
+	stats        WSStats
+	stopCh       chan struct{}
+	stopOnce     sync.Once
 	onConnect    func()
 	onDisconnect func(error)
+	onGap        func(WSGap)
 }
 
 func NewBinanceWSReal() *BinanceWSReal {
 	return &BinanceWSReal{
-		BaseEndpoint: BinanceFuturesWSEndpoint,
-		Dialer:       websocket.DefaultDialer,
-		MaxRetries:   5,
-		RetryBackoff: time.Second,
+		BaseEndpoint:     BinanceFuturesWSEndpoint,
+		Dialer:           websocket.DefaultDialer,
+		MaxRetries:       5,
+		Reconnect:        DefaultWSReconnectConfig(),
+		DepthSilence:     10 * time.Second,
+		MarkPriceSilence: 5 * time.Second,
 	}
 }
 
@@ -36,17 +87,94 @@ func (b *BinanceWSReal) SubscribeDepth(symbol string) error {
 	if symbol == "" {
 		return fmt.Errorf("symbol required")
 	}
-	stream := strings.ToLower(symbol) + "@depth20@100ms"
-	b.depthStreams = append(b.depthStreams, stream)
-	return nil
+	return b.subscribe(strings.ToLower(symbol)+"@depth@100ms", StreamDepth)
 }
 
+// SubscribeTrade 订阅归集成交流（<symbol>@aggTrade）。
+func (b *BinanceWSReal) SubscribeTrade(symbol string) error {
+	if symbol == "" {
+		return fmt.Errorf("symbol required")
+	}
+	return b.subscribe(strings.ToLower(symbol)+"@aggTrade", StreamAggTrade)
+}
+
+// SubscribeMarkPrice 订阅标记价/资金费率流（<symbol>@markPrice@1s）。
+func (b *BinanceWSReal) SubscribeMarkPrice(symbol string) error {
+	if symbol == "" {
+		return fmt.Errorf("symbol required")
+	}
+	return b.subscribe(strings.ToLower(symbol)+"@markPrice@1s", StreamMarkPrice)
+}
+
+// SubscribeUserData 订阅用户数据流；已有 listenKey 时替换（连接中会即时退订旧流）。
 func (b *BinanceWSReal) SubscribeUserData(listenKey string) error {
 	if listenKey == "" {
 		return fmt.Errorf("listenKey required")
 	}
-	b.userStream = listenKey
-	return nil
+	b.mu.Lock()
+	var old string
+	for _, s := range b.subs {
+		if s.kind == StreamUserData {
+			old = s.stream
+		}
+	}
+	b.mu.Unlock()
+	if old == listenKey {
+		return nil
+	}
+	if old != "" {
+		if err := b.Unsubscribe(old); err != nil {
+			return err
+		}
+	}
+	return b.subscribe(listenKey, StreamUserData)
+}
+
+// Unsubscribe 移除订阅；连接中会即时发送 UNSUBSCRIBE，否则仅从重放列表移除。
+func (b *BinanceWSReal) Unsubscribe(stream string) error {
+	b.mu.Lock()
+	found := false
+	for i, s := range b.subs {
+		if s.stream == stream {
+			b.subs = append(b.subs[:i], b.subs[i+1:]...)
+			found = true
+			break
+		}
+	}
+	b.mu.Unlock()
+	if !found {
+		return nil
+	}
+	return b.sendMethod("UNSUBSCRIBE", stream)
+}
+
+func (b *BinanceWSReal) subscribe(stream string, kind WSStreamKind) error {
+	b.mu.Lock()
+	for _, s := range b.subs {
+		if s.stream == stream {
+			b.mu.Unlock()
+			return nil
+		}
+	}
+	b.subs = append(b.subs, &wsSubscription{stream: stream, kind: kind, lastMsg: time.Now()})
+	b.mu.Unlock()
+	return b.sendMethod("SUBSCRIBE", stream)
+}
+
+// sendMethod 在已连接时发送 SUBSCRIBE/UNSUBSCRIBE；未连接时无需发送，下次建连会按 URL 重放。
+func (b *BinanceWSReal) sendMethod(method, stream string) error {
+	b.mu.Lock()
+	conn := b.conn
+	b.nextID++
+	id := b.nextID
+	b.mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	_ = conn.SetWriteDeadline(time.Now().Add(b.writeWait()))
+	return conn.WriteJSON(map[string]interface{}{"method": method, "params": []string{stream}, "id": id})
 }
 
 func (b *BinanceWSReal) OnConnect(cb func()) {
@@ -57,74 +185,357 @@ func (b *BinanceWSReal) OnDisconnect(cb func(error)) {
 	b.onDisconnect = cb
 }
 
-// Run 构建 combined stream 并读取消息；对消息不做解析，业务可扩展。
-func (b *BinanceWSReal) Run(handler WSHandler) error {
-	streams := make([]string, 0, len(b.depthStreams)+1)
-	streams = append(streams, b.depthStreams...)
-	if b.userStream != "" {
-		streams = append(streams, b.userStream)
+// OnGap 注册数据缺口回调：重连后对每个订阅各通知一次，静默流在恢复推送前只通知一次。
+func (b *BinanceWSReal) OnGap(cb func(WSGap)) {
+	b.onGap = cb
+}
+
+// Stop 结束会话：关闭当前连接并让 Run 返回 nil。
+func (b *BinanceWSReal) Stop() {
+	stop := b.stopChan()
+	b.stopOnce.Do(func() { close(stop) })
+	b.mu.Lock()
+	conn := b.conn
+	b.mu.Unlock()
+	if conn != nil {
+		_ = conn.Close()
 	}
-	if len(streams) == 0 {
+}
+
+// Stats 返回连接统计。
+func (b *BinanceWSReal) Stats() WSStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st := b.stats
+	st.Connected = b.conn != nil
+	return st
+}
+
+// Streams 返回当前订阅（即重连时重放的流）。
+func (b *BinanceWSReal) Streams() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]string, 0, len(b.subs))
+	for _, s := range b.subs {
+		out = append(out, s.stream)
+	}
+	return out
+}
+
+// Run 建立 combined stream 会话并阻塞读取，消息交给 handler 的 OnRawMessage（若实现）。
+// 仅在首次建连失败超过 MaxRetries 时返回错误；Stop 后返回 nil。
+func (b *BinanceWSReal) Run(handler WSHandler) error {
+	if len(b.Streams()) == 0 {
 		return fmt.Errorf("no streams subscribed")
 	}
-	u := url.URL{
-		Scheme: "wss",
-		Host:   strings.TrimPrefix(b.BaseEndpoint, "wss://"),
-		Path:   "/stream",
+	stop := b.stopChan()
+	cfg := b.Reconnect
+	if cfg.InitialDelay <= 0 {
+		cfg = DefaultWSReconnectConfig()
 	}
-	q := u.Query()
-	q.Set("streams", strings.Join(streams, "/"))
-	u.RawQuery = q.Encode()
-
-	retries := 0
+	dialer := b.Dialer
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+	delay := cfg.InitialDelay
+	failures := 0
+	established := false
+	var lostAt time.Time
 	for {
 		select {
+		case <-stop:
+			return nil
 		default:
-			conn, _, err := b.Dialer.Dial(u.String(), nil)
-			if err != nil {
-				if retries >= b.MaxRetries {
-					return err
-				}
-				retries++
-				sleep := b.RetryBackoff * time.Duration(retries)
-				log.Printf("ws dial failed (%d/%d): %v, retry in %s", retries, b.MaxRetries, err, sleep)
-				time.Sleep(sleep)
-				continue
+		}
+		conn, _, err := dialer.Dial(b.streamURL(), nil)
+		if err != nil {
+			if !established && failures >= b.MaxRetries {
+				return err
+			}
+			failures++
+			log.Printf("ws dial failed (attempt %d): %v, retry in %s", failures, err, delay)
+			if !sleepOrStop(stop, delay) {
+				return nil
+			}
+			delay = cfg.nextDelay(delay)
+			continue
+		}
+		failures = 0
+		delay = cfg.InitialDelay
+		gaps := b.attach(conn, established, lostAt)
+		established = true
+		if b.onConnect != nil {
+			b.onConnect()
+		}
+		b.notifyGaps(gaps)
+
+		err = b.serve(conn, handler, cfg, stop)
+		lostAt = b.detach(conn)
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+		if b.onDisconnect != nil {
+			b.onDisconnect(err)
+		}
+		log.Printf("ws disconnected: %v, reconnecting in %s", err, delay)
+		if !sleepOrStop(stop, delay) {
+			return nil
+		}
+		delay = cfg.nextDelay(delay)
+	}
+}
+
+// serve 读取一条连接直到出错或停止；期间负责心跳与静默检测。
+func (b *BinanceWSReal) serve(conn *websocket.Conn, handler WSHandler, cfg WSReconnectConfig, stop <-chan struct{}) error {
+	done := make(chan struct{})
+	defer close(done)
+	defer conn.Close()
+
+	pongWait := cfg.PongWait
+	if pongWait <= 0 {
+		pongWait = 30 * time.Second
+	}
+	resetDeadline := func() {
+		_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+	}
+	resetDeadline()
+	conn.SetPongHandler(func(string) error {
+		resetDeadline()
+		return nil
+	})
+	go b.watch(conn, cfg, stop, done)
+
+	raw, rawOK := handler.(interface{ OnRawMessage([]byte) })
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		resetDeadline()
+		b.Capture.RecordWS(b.BaseEndpoint, message)
+		if !b.touch(message) {
+			continue
+		}
+		if rawOK {
+			raw.OnRawMessage(message)
+		} else if handler == nil {
+			log.Printf("binance ws recv: %s", string(message))
+		}
+	}
+}
+
+// watch 发送心跳 ping，并按订阅检查静默；静默超过 3 倍阈值时关闭连接触发重连。
+func (b *BinanceWSReal) watch(conn *websocket.Conn, cfg WSReconnectConfig, stop <-chan struct{}, done <-chan struct{}) {
+	var pingC, checkC <-chan time.Time
+	if cfg.EnableHeartbeat && cfg.PingInterval > 0 {
+		t := time.NewTicker(cfg.PingInterval)
+		defer t.Stop()
+		pingC = t.C
+	}
+	if tick := b.silenceTick(); tick > 0 {
+		t := time.NewTicker(tick)
+		defer t.Stop()
+		checkC = t.C
+	}
+	for {
+		select {
+		case <-done:
+			return
+		case <-stop:
+			_ = conn.Close()
+			return
+		case <-pingC:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(b.writeWait())); err != nil {
+				log.Printf("ws heartbeat failed: %v", err)
+				_ = conn.Close()
+				return
 			}
-			if b.onConnect != nil {
-				b.onConnect()
+		case now := <-checkC:
+			gaps, reconnect := b.checkSilence(now)
+			b.notifyGaps(gaps)
+			if reconnect {
+				log.Printf("ws stream silent too long, forcing reconnect")
+				_ = conn.Close()
+				return
 			}
-			func() {
-				defer conn.Close()
-				resetDeadline := func() {
-					_ = conn.SetReadDeadline(time.Now().Add(30 * time.Second))
-				}
-				resetDeadline()
-				conn.SetPongHandler(func(string) error {
-					resetDeadline()
-					return nil
-				})
-				retries = 0
-				for {
-					resetDeadline()
-					_, message, err := conn.ReadMessage()
-					if err != nil {
-						if b.onDisconnect != nil {
-							b.onDisconnect(err)
-						}
-						log.Printf("ws read err: %v", err)
-						break
-					}
-					if handler != nil {
-						if h, ok := handler.(interface{ OnRawMessage([]byte) }); ok {
-							h.OnRawMessage(message)
-						}
-					} else {
-						log.Printf("binance ws recv: %s", string(message))
-					}
-				}
-			}()
-		case <-time.After(1 * time.Millisecond):
 		}
 	}
 }
+
+// touch 记录消息所属订阅的到达时间；返回 false 表示是 SUBSCRIBE 等请求的应答，无需交给 handler。
+func (b *BinanceWSReal) touch(message []byte) bool {
+	var head struct {
+		Stream string          `json:"stream"`
+		ID     *int64          `json:"id"`
+		Error  json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(message, &head); err != nil {
+		return true
+	}
+	if head.Stream == "" && head.ID != nil {
+		if len(head.Error) > 0 {
+			log.Printf("ws subscribe request %d failed: %s", *head.ID, string(head.Error))
+		}
+		return false
+	}
+	now := time.Now()
+	b.mu.Lock()
+	for _, s := range b.subs {
+		if s.stream == head.Stream {
+			s.lastMsg = now
+			s.silent = false
+			break
+		}
+	}
+	b.mu.Unlock()
+	return true
+}
+
+func (b *BinanceWSReal) checkSilence(now time.Time) (gaps []WSGap, reconnect bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, s := range b.subs {
+		limit := b.silenceFor(s.kind)
+		if limit <= 0 {
+			continue
+		}
+		idle := now.Sub(s.lastMsg)
+		if idle > limit && !s.silent {
+			s.silent = true
+			gaps = append(gaps, WSGap{Stream: s.stream, Kind: s.kind, Reason: GapSilent, Since: s.lastMsg})
+		}
+		if idle > 3*limit {
+			reconnect = true
+		}
+	}
+	return gaps, reconnect
+}
+
+func (b *BinanceWSReal) silenceFor(kind WSStreamKind) time.Duration {
+	switch kind {
+	case StreamDepth:
+		return b.DepthSilence
+	case StreamMarkPrice:
+		return b.MarkPriceSilence
+	case StreamAggTrade:
+		return b.TradeSilence
+	}
+	return 0
+}
+
+// silenceTick 静默检测周期：最小阈值的 1/4，限制在 [50ms, 1s]。
+func (b *BinanceWSReal) silenceTick() time.Duration {
+	var min time.Duration
+	for _, d := range []time.Duration{b.DepthSilence, b.MarkPriceSilence, b.TradeSilence} {
+		if d > 0 && (min == 0 || d < min) {
+			min = d
+		}
+	}
+	if min == 0 {
+		return 0
+	}
+	tick := min / 4
+	if tick < 50*time.Millisecond {
+		tick = 50 * time.Millisecond
+	}
+	if tick > time.Second {
+		tick = time.Second
+	}
+	return tick
+}
+
+// attach 记录新连接；重连时为每个订阅生成 GapReconnect 并重置静默计时。
+func (b *BinanceWSReal) attach(conn *websocket.Conn, reconnect bool, lostAt time.Time) []WSGap {
+	now := time.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.conn = conn
+	b.stats.LastConnectTime = now
+	var gaps []WSGap
+	if reconnect {
+		b.stats.TotalReconnects++
+		for _, s := range b.subs {
+			since := s.lastMsg
+			if since.IsZero() || since.After(lostAt) {
+				since = lostAt
+			}
+			gaps = append(gaps, WSGap{Stream: s.stream, Kind: s.kind, Reason: GapReconnect, Since: since})
+		}
+	}
+	for _, s := range b.subs {
+		s.lastMsg = now
+		s.silent = false
+	}
+	return gaps
+}
+
+func (b *BinanceWSReal) detach(conn *websocket.Conn) time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.conn == conn {
+		b.conn = nil
+	}
+	return time.Now()
+}
+
+func (b *BinanceWSReal) notifyGaps(gaps []WSGap) {
+	if len(gaps) == 0 {
+		return
+	}
+	b.mu.Lock()
+	b.stats.Gaps += len(gaps)
+	b.mu.Unlock()
+	for _, g := range gaps {
+		log.Printf("ws gap stream=%s reason=%s since=%s", g.Stream, g.Reason, g.Since.Format(time.RFC3339Nano))
+		b.Capture.RecordGap(g)
+		if b.onGap != nil {
+			b.onGap(g)
+		}
+	}
+}
+
+func (b *BinanceWSReal) streamURL() string {
+	// 默认 wss；允许 ws:// 以便连接本地模拟交易所
+	scheme, host := "wss", b.BaseEndpoint
+	if i := strings.Index(host, "://"); i >= 0 {
+		scheme, host = host[:i], host[i+3:]
+	}
+	u := url.URL{
+		Scheme: scheme,
+		Host:   strings.TrimSuffix(host, "/"),
+		Path:   "/stream",
+	}
+	q := u.Query()
+	q.Set("streams", strings.Join(b.Streams(), "/"))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func (b *BinanceWSReal) stopChan() chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stopCh == nil {
+		b.stopCh = make(chan struct{})
+	}
+	return b.stopCh
+}
+
+func (b *BinanceWSReal) writeWait() time.Duration {
+	if b.Reconnect.WriteWait > 0 {
+		return b.Reconnect.WriteWait
+	}
+	return 10 * time.Second
+}
+
+func sleepOrStop(stop <-chan struct{}, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-stop:
+		return false
+	case <-t.C:
+		return true
+	}
+}
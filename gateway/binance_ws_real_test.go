@@ -0,0 +1,212 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeStreamServer 记录每次连接请求的 streams，并把连接交给测试脚本。
+type fakeStreamServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	streams []string
+	conns   chan *websocket.Conn
+}
+
+func newFakeStreamServer(t *testing.T) *fakeStreamServer {
+	t.Helper()
+	s := &fakeStreamServer{conns: make(chan *websocket.Conn, 8)}
+	up := websocket.Upgrader{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := up.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.streams = append(s.streams, r.URL.Query().Get("streams"))
+		s.mu.Unlock()
+		s.conns <- conn
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *fakeStreamServer) next(t *testing.T) *websocket.Conn {
+	t.Helper()
+	select {
+	case c := <-s.conns:
+		return c
+	case <-time.After(3 * time.Second):
+		t.Fatalf("no ws connection")
+		return nil
+	}
+}
+
+type rawCollector struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (c *rawCollector) OnDepth(string, float64, float64) {}
+func (c *rawCollector) OnTrade(string, float64, float64) {}
+func (c *rawCollector) OnRawMessage(msg []byte) {
+	c.mu.Lock()
+	c.msgs = append(c.msgs, string(msg))
+	c.mu.Unlock()
+}
+
+func (c *rawCollector) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.msgs)
+}
+
+func newTestWS(endpoint string) *BinanceWSReal {
+	ws := NewBinanceWSReal()
+	ws.BaseEndpoint = "ws://" + strings.TrimPrefix(endpoint, "http://")
+	ws.MaxRetries = 0
+	ws.Reconnect.InitialDelay = 10 * time.Millisecond
+	ws.Reconnect.MaxDelay = 50 * time.Millisecond
+	ws.DepthSilence = 0
+	ws.MarkPriceSilence = 0
+	return ws
+}
+
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("condition not met")
+}
+
+func TestBinanceWSRealReconnectReplaysSubscriptions(t *testing.T) {
+	srv := newFakeStreamServer(t)
+	ws := newTestWS(srv.URL)
+	_ = ws.SubscribeDepth("BTCUSDT")
+	_ = ws.SubscribeUserData("key-1")
+	var mu sync.Mutex
+	var gaps []WSGap
+	ws.OnGap(func(g WSGap) {
+		mu.Lock()
+		gaps = append(gaps, g)
+		mu.Unlock()
+	})
+	h := &rawCollector{}
+	done := make(chan error, 1)
+	go func() { done <- ws.Run(h) }()
+
+	first := srv.next(t)
+	_ = first.WriteMessage(websocket.TextMessage, []byte(`{"stream":"btcusdt@depth@100ms","data":{}}`))
+	eventually(t, func() bool { return h.count() == 1 })
+
+	// 运行中新增订阅：即时发送 SUBSCRIBE，应答不交给 handler
+	_ = ws.SubscribeMarkPrice("BTCUSDT")
+	var req struct {
+		Method string   `json:"method"`
+		Params []string `json:"params"`
+		ID     int64    `json:"id"`
+	}
+	if err := first.ReadJSON(&req); err != nil || req.Method != "SUBSCRIBE" || req.Params[0] != "btcusdt@markPrice@1s" {
+		t.Fatalf("expected live SUBSCRIBE, got %+v err=%v", req, err)
+	}
+	_ = first.WriteJSON(map[string]interface{}{"result": nil, "id": req.ID})
+
+	// 瞬时断线：会话重连、重放全部订阅，并为每个订阅通知缺口
+	first.Close()
+	second := srv.next(t)
+	defer second.Close()
+	select {
+	case err := <-done:
+		t.Fatalf("run should survive disconnect, returned %v", err)
+	default:
+	}
+	srv.mu.Lock()
+	replayed := srv.streams[1]
+	srv.mu.Unlock()
+	if replayed != "btcusdt@depth@100ms/key-1/btcusdt@markPrice@1s" {
+		t.Fatalf("subscriptions not replayed: %q", replayed)
+	}
+	eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(gaps) == 3
+	})
+	mu.Lock()
+	for _, g := range gaps {
+		if g.Reason != GapReconnect {
+			t.Fatalf("unexpected gap %+v", g)
+		}
+	}
+	if gaps[0].Kind != StreamDepth || gaps[1].Kind != StreamUserData {
+		t.Fatalf("unexpected gap kinds %+v", gaps)
+	}
+	mu.Unlock()
+	if h.count() != 1 {
+		t.Fatalf("subscribe ack should not reach handler, got %d messages", h.count())
+	}
+	if st := ws.Stats(); !st.Connected || st.TotalReconnects != 1 || st.Gaps != 3 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+
+	ws.Stop()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("stop should end run cleanly, got %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("run did not return after stop")
+	}
+}
+
+func TestBinanceWSRealDetectsSilentStream(t *testing.T) {
+	srv := newFakeStreamServer(t)
+	ws := newTestWS(srv.URL)
+	ws.DepthSilence = 100 * time.Millisecond
+	_ = ws.SubscribeDepth("BTCUSDT")
+	_ = ws.SubscribeTrade("BTCUSDT")
+	gapCh := make(chan WSGap, 8)
+	ws.OnGap(func(g WSGap) { gapCh <- g })
+	go func() { _ = ws.Run(&rawCollector{}) }()
+	defer ws.Stop()
+
+	conn := srv.next(t)
+	defer conn.Close()
+	// 只推成交：深度流静默应被单独识别（成交流未设阈值）
+	stopFeed := make(chan struct{})
+	defer close(stopFeed)
+	go func() {
+		for {
+			select {
+			case <-stopFeed:
+				return
+			case <-time.After(20 * time.Millisecond):
+				if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"stream":"btcusdt@aggTrade","data":{}}`)); err != nil {
+					return
+				}
+			}
+		}
+	}()
+	select {
+	case g := <-gapCh:
+		if g.Kind != StreamDepth || g.Reason != GapSilent || g.Stream != "btcusdt@depth@100ms" {
+			t.Fatalf("unexpected gap %+v", g)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("silent depth stream not detected")
+	}
+	// 持续静默超过 3 倍阈值：主动重连
+	again := srv.next(t)
+	again.Close()
+}
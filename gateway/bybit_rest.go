@@ -0,0 +1,684 @@
+package gateway
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"market-maker-go/order"
+)
+
+// Bybit v5 endpoints（USDT/USDC 线性永续，category=linear）。
+const (
+	BybitRestEndpoint      = "https://api.bybit.com"
+	BybitPublicWSEndpoint  = "wss://stream.bybit.com/v5/public/linear"
+	BybitPrivateWSEndpoint = "wss://stream.bybit.com/v5/private"
+
+	bybitCategory = "linear"
+)
+
+// Bybit v5 常见 retCode。
+const (
+	BybitCodeParamError         = 10001
+	BybitCodeTimestamp          = 10002
+	BybitCodeInvalidKey         = 10003
+	BybitCodeInvalidSign        = 10004
+	BybitCodePermissionDenied   = 10005
+	BybitCodeTooManyVisits      = 10006
+	BybitCodeServerBusy         = 10016
+	BybitCodeOrderNotExist      = 110001
+	BybitCodePriceOutOfRange    = 110003
+	BybitCodeMarginInsufficient = 110007
+	BybitCodeReduceOnlyReject   = 110017
+	BybitCodeMinNotional        = 110094
+)
+
+var bybitSentinels = map[int]error{
+	BybitCodeTimestamp:          ErrTimestamp,
+	BybitCodeInvalidKey:         ErrAuth,
+	BybitCodeInvalidSign:        ErrAuth,
+	BybitCodePermissionDenied:   ErrAuth,
+	BybitCodeTooManyVisits:      ErrRateLimited,
+	BybitCodeServerBusy:         ErrServerBusy,
+	BybitCodeOrderNotExist:      ErrOrderNotFound,
+	BybitCodePriceOutOfRange:    ErrFilterViolation,
+	BybitCodeMarginInsufficient: ErrMarginInsufficient,
+	BybitCodeReduceOnlyReject:   ErrReduceOnlyRejected,
+	BybitCodeMinNotional:        ErrMinNotional,
+}
+
+// BybitAPIError Bybit 返回的业务错误（retCode!=0）或 HTTP 错误；Unwrap 指向与 Binance 共用的错误哨兵。
+type BybitAPIError struct {
+	Op         string
+	Path       string
+	HTTPStatus int
+	RetCode    int
+	RetMsg     string
+}
+
+func (e *BybitAPIError) Error() string {
+	return fmt.Sprintf("bybit %s %s: status=%d retCode=%d retMsg=%s", e.Op, e.Path, e.HTTPStatus, e.RetCode, e.RetMsg)
+}
+
+func (e *BybitAPIError) Unwrap() error {
+	if s, ok := bybitSentinels[e.RetCode]; ok {
+		return s
+	}
+	switch {
+	case e.HTTPStatus == http.StatusTooManyRequests || e.HTTPStatus == http.StatusForbidden:
+		return ErrRateLimited
+	case e.HTTPStatus >= 500:
+		return ErrServerBusy
+	}
+	return nil
+}
+
+// BybitRESTClient Bybit v5 REST 客户端（线性永续）。签名：HMAC-SHA256(timestamp+apiKey+recvWindow+payload)，
+// payload 为 GET 的 query string 或 POST 的 JSON body。
+type BybitRESTClient struct {
+	BaseURL      string
+	APIKey       string
+	Secret       string
+	HTTPClient   *http.Client
+	RecvWindowMs int64
+	Limiter      RateLimiter
+	// Precision 下单价格/数量按交易对精度序列化，可为 nil。
+	Precision *SymbolPrecision
+	// MaxRetries 限流/网络错误的最大尝试次数，默认 3。
+	MaxRetries int
+	RetryDelay time.Duration
+}
+
+type bybitEnvelope struct {
+	RetCode int             `json:"retCode"`
+	RetMsg  string          `json:"retMsg"`
+	Result  json.RawMessage `json:"result"`
+}
+
+// get 发送 GET 请求并返回 result；signed 为 false 时为公共接口。
+func (c *BybitRESTClient) get(path, op string, params map[string]string, signed bool) (json.RawMessage, error) {
+	return c.do(http.MethodGet, path, op, bybitQuery(params), nil, signed)
+}
+
+// post 发送签名的 POST JSON 请求并返回 result。
+func (c *BybitRESTClient) post(path, op string, body map[string]interface{}) (json.RawMessage, error) {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(http.MethodPost, path, op, "", raw, true)
+}
+
+func (c *BybitRESTClient) do(method, path, op, query string, body []byte, signed bool) (json.RawMessage, error) {
+	if c == nil || c.HTTPClient == nil {
+		return nil, fmt.Errorf("http client not set")
+	}
+	endpoint := c.BaseURL + path
+	if query != "" {
+		endpoint += "?" + query
+	}
+	maxAttempts := c.MaxRetries
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+	delay := c.RetryDelay
+	if delay <= 0 {
+		delay = 200 * time.Millisecond
+	}
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay * time.Duration(attempt))
+		}
+		req, err := http.NewRequest(method, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if signed {
+			payload := query
+			if body != nil {
+				payload = string(body)
+			}
+			c.sign(req.Header, payload)
+		}
+		if c.Limiter != nil {
+			c.Limiter.Wait()
+		}
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		raw, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		result, err := parseBybitResponse(op, path, resp.StatusCode, raw)
+		if err == nil {
+			return result, nil
+		}
+		lastErr = err
+		if !isBybitRetryable(err) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("request failed after %d attempts: %w", maxAttempts, lastErr)
+}
+
+// isBybitRetryable 限流与服务端繁忙可退避重试，其余业务错误直接返回。
+func isBybitRetryable(err error) bool {
+	var apiErr *BybitAPIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.RetCode == BybitCodeTooManyVisits || apiErr.RetCode == BybitCodeServerBusy ||
+		apiErr.HTTPStatus == http.StatusTooManyRequests || apiErr.HTTPStatus >= 500
+}
+
+func parseBybitResponse(op, path string, status int, raw []byte) (json.RawMessage, error) {
+	var env bybitEnvelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		if status >= 300 {
+			return nil, &BybitAPIError{Op: op, Path: path, HTTPStatus: status, RetMsg: strings.TrimSpace(string(raw))}
+		}
+		return nil, fmt.Errorf("bybit %s: decode response: %w", op, err)
+	}
+	if status >= 300 || env.RetCode != 0 {
+		return nil, &BybitAPIError{Op: op, Path: path, HTTPStatus: status, RetCode: env.RetCode, RetMsg: env.RetMsg}
+	}
+	return env.Result, nil
+}
+
+func (c *BybitRESTClient) sign(h http.Header, payload string) {
+	ts := strconv.FormatInt(timeNowMillis(), 10)
+	recv := c.RecvWindowMs
+	if recv <= 0 {
+		recv = 5000
+	}
+	recvStr := strconv.FormatInt(recv, 10)
+	h.Set("X-BAPI-API-KEY", c.APIKey)
+	h.Set("X-BAPI-TIMESTAMP", ts)
+	h.Set("X-BAPI-RECV-WINDOW", recvStr)
+	h.Set("X-BAPI-SIGN", bybitSign(c.Secret, ts+c.APIKey+recvStr+payload))
+}
+
+func bybitSign(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// bybitQuery 按 key 排序拼接 query string（签名与实际发送使用同一字符串）。
+func bybitQuery(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(url.QueryEscape(k))
+		b.WriteByte('=')
+		b.WriteString(url.QueryEscape(params[k]))
+	}
+	return b.String()
+}
+
+// bybitSide BUY/SELL -> Buy/Sell。
+func bybitSide(side string) (string, error) {
+	switch strings.ToUpper(side) {
+	case "BUY":
+		return "Buy", nil
+	case "SELL":
+		return "Sell", nil
+	}
+	return "", fmt.Errorf("invalid side %q", side)
+}
+
+// fromBybitSide Buy/Sell -> BUY/SELL。
+func fromBybitSide(side string) string {
+	return strings.ToUpper(side)
+}
+
+// bybitPositionIdx 持仓腿：单向 0，双向多 1、空 2（平仓单同样指向被平的腿）。
+func bybitPositionIdx(positionSide string) int {
+	switch strings.ToUpper(positionSide) {
+	case order.PositionSideLong:
+		return 1
+	case order.PositionSideShort:
+		return 2
+	}
+	return 0
+}
+
+func fromBybitPositionIdx(idx int) string {
+	switch idx {
+	case 1:
+		return order.PositionSideLong
+	case 2:
+		return order.PositionSideShort
+	}
+	return "BOTH"
+}
+
+// bybitSMPType 把 selfTradePreventionMode 映射为 Bybit smpType。
+func bybitSMPType(stp string) (string, error) {
+	switch strings.ToUpper(stp) {
+	case "", order.STPNone:
+		return "", nil
+	case order.STPExpireTaker:
+		return "CancelTaker", nil
+	case order.STPExpireMaker:
+		return "CancelMaker", nil
+	case order.STPExpireBoth:
+		return "CancelBoth", nil
+	}
+	return "", fmt.Errorf("invalid selfTradePrevention %q", stp)
+}
+
+// MapBybitOrderStatus 把 Bybit orderStatus 映射为 order.Status；条件单的 Untriggered/Triggered 等返回空串。
+func MapBybitOrderStatus(s string) order.Status {
+	switch s {
+	case "New":
+		return order.StatusAck
+	case "PartiallyFilled":
+		return order.StatusPartial
+	case "Filled":
+		return order.StatusFilled
+	case "Cancelled", "PartiallyFilledCanceled", "Deactivated":
+		return order.StatusCanceled
+	case "Rejected":
+		return order.StatusRejected
+	}
+	return ""
+}
+
+// bybitOrderParams 构造 /v5/order/create 请求体；postOnly（或 GTX）映射为 timeInForce=PostOnly。
+func bybitOrderParams(prec *SymbolPrecision, o order.Order) (map[string]interface{}, error) {
+	side, err := bybitSide(o.Side)
+	if err != nil {
+		return nil, err
+	}
+	if o.PriceMatch != "" {
+		return nil, fmt.Errorf("priceMatch %s not supported on bybit", o.PriceMatch)
+	}
+	smp, err := bybitSMPType(o.SelfTradePrevention)
+	if err != nil {
+		return nil, err
+	}
+	if o.Quantity <= 0 {
+		return nil, fmt.Errorf("qty must be > 0")
+	}
+	qty, err := prec.FormatQty(o.Symbol, o.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]interface{}{
+		"category":    bybitCategory,
+		"symbol":      o.Symbol,
+		"side":        side,
+		"qty":         qty,
+		"positionIdx": bybitPositionIdx(o.PositionSide),
+	}
+	if strings.EqualFold(o.Type, "MARKET") {
+		body["orderType"] = "Market"
+	} else {
+		if o.Price <= 0 {
+			return nil, fmt.Errorf("price must be > 0")
+		}
+		price, err := prec.FormatPrice(o.Symbol, o.Price)
+		if err != nil {
+			return nil, err
+		}
+		body["orderType"] = "Limit"
+		body["price"] = price
+		tif := strings.ToUpper(o.TimeInForce)
+		switch {
+		case o.PostOnly || tif == "GTX":
+			body["timeInForce"] = "PostOnly"
+		case tif == "" || tif == "GTC" || tif == "IOC" || tif == "FOK":
+			if tif == "" {
+				tif = "GTC"
+			}
+			body["timeInForce"] = tif
+		default:
+			return nil, fmt.Errorf("invalid timeInForce %q", o.TimeInForce)
+		}
+	}
+	if o.ReduceOnly {
+		body["reduceOnly"] = true
+	}
+	if o.ID != "" {
+		body["orderLinkId"] = o.ID
+	}
+	if smp != "" {
+		body["smpType"] = smp
+	}
+	return body, nil
+}
+
+// PlaceOrder 调用 POST /v5/order/create，返回 orderId。Bybit 下单为异步确认，拒单以推送的 Rejected/Cancelled 状态体现。
+func (c *BybitRESTClient) PlaceOrder(o order.Order) (string, error) {
+	body, err := bybitOrderParams(c.Precision, o)
+	if err != nil {
+		return "", err
+	}
+	result, err := c.post("/v5/order/create", "place order", body)
+	if err != nil {
+		return "", err
+	}
+	var r struct {
+		OrderID string `json:"orderId"`
+	}
+	if err := json.Unmarshal(result, &r); err != nil {
+		return "", err
+	}
+	if r.OrderID == "" {
+		return "", fmt.Errorf("empty orderId")
+	}
+	return r.OrderID, nil
+}
+
+// CancelOrder 调用 POST /v5/order/cancel 按 orderId 撤单。
+func (c *BybitRESTClient) CancelOrder(symbol, orderID string) error {
+	_, err := c.post("/v5/order/cancel", "cancel order", map[string]interface{}{
+		"category": bybitCategory,
+		"symbol":   symbol,
+		"orderId":  orderID,
+	})
+	return err
+}
+
+// CancelAll 调用 POST /v5/order/cancel-all 撤销交易对全部挂单。
+func (c *BybitRESTClient) CancelAll(symbol string) error {
+	_, err := c.post("/v5/order/cancel-all", "cancel all", map[string]interface{}{
+		"category": bybitCategory,
+		"symbol":   symbol,
+	})
+	return err
+}
+
+type bybitOrderResp struct {
+	Symbol      string `json:"symbol"`
+	OrderID     string `json:"orderId"`
+	OrderLinkID string `json:"orderLinkId"`
+	Side        string `json:"side"`
+	OrderType   string `json:"orderType"`
+	Price       string `json:"price"`
+	Qty         string `json:"qty"`
+	TimeInForce string `json:"timeInForce"`
+	OrderStatus string `json:"orderStatus"`
+	PositionIdx int    `json:"positionIdx"`
+	CumExecQty  string `json:"cumExecQty"`
+	AvgPrice    string `json:"avgPrice"`
+	ReduceOnly  bool   `json:"reduceOnly"`
+	SmpType     string `json:"smpType"`
+	UpdatedTime string `json:"updatedTime"`
+}
+
+func (r bybitOrderResp) toOrder() *order.Order {
+	tif := strings.ToUpper(r.TimeInForce)
+	postOnly := r.TimeInForce == "PostOnly"
+	if postOnly {
+		tif = "GTX"
+	}
+	return &order.Order{
+		ID:           r.OrderLinkID,
+		ExchangeID:   r.OrderID,
+		Symbol:       r.Symbol,
+		Side:         fromBybitSide(r.Side),
+		Type:         strings.ToUpper(r.OrderType),
+		Price:        parseFloat(r.Price),
+		Quantity:     parseFloat(r.Qty),
+		Status:       MapBybitOrderStatus(r.OrderStatus),
+		ClientID:     r.OrderLinkID,
+		ReduceOnly:   r.ReduceOnly,
+		PostOnly:     postOnly,
+		TimeInForce:  tif,
+		PositionSide: fromBybitPositionIdx(r.PositionIdx),
+		FilledQty:    parseFloat(r.CumExecQty),
+		AvgFillPrice: parseFloat(r.AvgPrice),
+	}
+}
+
+func (c *BybitRESTClient) listOrders(path, op string, params map[string]string) ([]bybitOrderResp, string, error) {
+	params["category"] = bybitCategory
+	result, err := c.get(path, op, params, true)
+	if err != nil {
+		return nil, "", err
+	}
+	var r struct {
+		List           []bybitOrderResp `json:"list"`
+		NextPageCursor string           `json:"nextPageCursor"`
+	}
+	if err := json.Unmarshal(result, &r); err != nil {
+		return nil, "", err
+	}
+	return r.List, r.NextPageCursor, nil
+}
+
+// GetOrder 按 orderLinkId 查询：先查 /v5/order/realtime（活跃及近期订单），再查 /v5/order/history。
+// 两处均无时返回的错误满足 errors.Is(err, ErrOrderNotFound)。
+func (c *BybitRESTClient) GetOrder(symbol, clientOrderID string) (*order.Order, error) {
+	for _, path := range []string{"/v5/order/realtime", "/v5/order/history"} {
+		list, _, err := c.listOrders(path, "query order", map[string]string{"symbol": symbol, "orderLinkId": clientOrderID})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range list {
+			if r.OrderLinkID != clientOrderID {
+				continue
+			}
+			o := r.toOrder()
+			if o.Status == "" {
+				return nil, fmt.Errorf("unknown order status %q for %s", r.OrderStatus, clientOrderID)
+			}
+			return o, nil
+		}
+	}
+	return nil, &BybitAPIError{Op: "query order", Path: "/v5/order/history", HTTPStatus: http.StatusOK, RetCode: BybitCodeOrderNotExist, RetMsg: "order not exists"}
+}
+
+// OpenOrders 调用 GET /v5/order/realtime?openOnly=0 查询当前挂单，按 nextPageCursor 翻页（每页 50 条）直到取完。
+func (c *BybitRESTClient) OpenOrders(symbol string) ([]*order.Order, error) {
+	var out []*order.Order
+	seen := make(map[string]bool)
+	cursor := ""
+	for {
+		params := map[string]string{"symbol": symbol, "openOnly": "0", "limit": "50"}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		list, next, err := c.listOrders("/v5/order/realtime", "open orders", params)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range list {
+			out = append(out, r.toOrder())
+		}
+		// 游标为空或重复（防御交易所返回同一游标导致死循环）时结束
+		if next == "" || len(list) == 0 || seen[next] {
+			return out, nil
+		}
+		seen[next] = true
+		cursor = next
+	}
+}
+
+type bybitPositionResp struct {
+	Symbol        string `json:"symbol"`
+	Side          string `json:"side"`
+	Size          string `json:"size"`
+	AvgPrice      string `json:"avgPrice"`
+	EntryPrice    string `json:"entryPrice"`
+	MarkPrice     string `json:"markPrice"`
+	UnrealisedPnl string `json:"unrealisedPnl"`
+	PositionIdx   int    `json:"positionIdx"`
+	TradeMode     int    `json:"tradeMode"`
+}
+
+// toPosition 转为 FuturesPosition；size 恒为正，按 side 还原符号。
+func (r bybitPositionResp) toPosition() FuturesPosition {
+	amt := parseFloat(r.Size)
+	if r.Side == "Sell" {
+		amt = -amt
+	}
+	entry := r.AvgPrice
+	if entry == "" {
+		entry = r.EntryPrice
+	}
+	marginType := "cross"
+	if r.TradeMode == 1 {
+		marginType = "isolated"
+	}
+	return FuturesPosition{
+		Symbol:           r.Symbol,
+		PositionAmt:      amt,
+		EntryPrice:       parseFloat(entry),
+		MarkPrice:        parseFloat(r.MarkPrice),
+		UnrealizedProfit: parseFloat(r.UnrealisedPnl),
+		MarginType:       marginType,
+		PositionSide:     fromBybitPositionIdx(r.PositionIdx),
+	}
+}
+
+// Positions 调用 GET /v5/position/list；symbol 为空时按 USDT 结算币查询全部。
+func (c *BybitRESTClient) Positions(symbol string) ([]FuturesPosition, error) {
+	params := map[string]string{"category": bybitCategory}
+	if symbol != "" {
+		params["symbol"] = symbol
+	} else {
+		params["settleCoin"] = "USDT"
+	}
+	result, err := c.get("/v5/position/list", "position", params, true)
+	if err != nil {
+		return nil, err
+	}
+	var r struct {
+		List []bybitPositionResp `json:"list"`
+	}
+	if err := json.Unmarshal(result, &r); err != nil {
+		return nil, err
+	}
+	out := make([]FuturesPosition, 0, len(r.List))
+	for _, p := range r.List {
+		out = append(out, p.toPosition())
+	}
+	return out, nil
+}
+
+// Balances 调用 GET /v5/account/wallet-balance（统一账户）返回各币种余额。
+func (c *BybitRESTClient) Balances() ([]FuturesBalance, error) {
+	result, err := c.get("/v5/account/wallet-balance", "balance", map[string]string{"accountType": "UNIFIED"}, true)
+	if err != nil {
+		return nil, err
+	}
+	var r struct {
+		List []struct {
+			Coin []struct {
+				Coin                string `json:"coin"`
+				WalletBalance       string `json:"walletBalance"`
+				AvailableToWithdraw string `json:"availableToWithdraw"`
+			} `json:"coin"`
+		} `json:"list"`
+	}
+	if err := json.Unmarshal(result, &r); err != nil {
+		return nil, err
+	}
+	var out []FuturesBalance
+	for _, acct := range r.List {
+		for _, coin := range acct.Coin {
+			out = append(out, FuturesBalance{
+				Asset:     coin.Coin,
+				Balance:   parseFloat(coin.WalletBalance),
+				Available: parseFloat(coin.AvailableToWithdraw),
+			})
+		}
+	}
+	return out, nil
+}
+
+// Instruments 调用 GET /v5/market/instruments-info 查询交易对过滤器。
+func (c *BybitRESTClient) Instruments(symbol string) ([]ExchangeSymbolInfo, error) {
+	params := map[string]string{"category": bybitCategory}
+	if symbol != "" {
+		params["symbol"] = symbol
+	}
+	result, err := c.get("/v5/market/instruments-info", "instruments", params, false)
+	if err != nil {
+		return nil, err
+	}
+	var r struct {
+		List []struct {
+			Symbol      string `json:"symbol"`
+			Status      string `json:"status"`
+			BaseCoin    string `json:"baseCoin"`
+			QuoteCoin   string `json:"quoteCoin"`
+			PriceFilter struct {
+				MinPrice string `json:"minPrice"`
+				MaxPrice string `json:"maxPrice"`
+				TickSize string `json:"tickSize"`
+			} `json:"priceFilter"`
+			LotSizeFilter struct {
+				MaxOrderQty      string `json:"maxOrderQty"`
+				MinOrderQty      string `json:"minOrderQty"`
+				QtyStep          string `json:"qtyStep"`
+				MinNotionalValue string `json:"minNotionalValue"`
+			} `json:"lotSizeFilter"`
+		} `json:"list"`
+	}
+	if err := json.Unmarshal(result, &r); err != nil {
+		return nil, err
+	}
+	out := make([]ExchangeSymbolInfo, 0, len(r.List))
+	for _, s := range r.List {
+		out = append(out, ExchangeSymbolInfo{
+			Symbol:      s.Symbol,
+			Status:      strings.ToUpper(s.Status),
+			BaseAsset:   s.BaseCoin,
+			QuoteAsset:  s.QuoteCoin,
+			TickSize:    parseFloat(s.PriceFilter.TickSize),
+			MinPrice:    parseFloat(s.PriceFilter.MinPrice),
+			MaxPrice:    parseFloat(s.PriceFilter.MaxPrice),
+			StepSize:    parseFloat(s.LotSizeFilter.QtyStep),
+			MinQty:      parseFloat(s.LotSizeFilter.MinOrderQty),
+			MaxQty:      parseFloat(s.LotSizeFilter.MaxOrderQty),
+			MinNotional: parseFloat(s.LotSizeFilter.MinNotionalValue),
+		})
+	}
+	return out, nil
+}
+
+// BestBidAsk 调用 GET /v5/market/orderbook?limit=1 取最优买卖价。
+func (c *BybitRESTClient) BestBidAsk(symbol string) (float64, float64, error) {
+	result, err := c.get("/v5/market/orderbook", "orderbook", map[string]string{"category": bybitCategory, "symbol": symbol, "limit": "1"}, false)
+	if err != nil {
+		return 0, 0, err
+	}
+	var r struct {
+		Bids [][]string `json:"b"`
+		Asks [][]string `json:"a"`
+	}
+	if err := json.Unmarshal(result, &r); err != nil {
+		return 0, 0, err
+	}
+	if len(r.Bids) == 0 || len(r.Asks) == 0 || len(r.Bids[0]) == 0 || len(r.Asks[0]) == 0 {
+		return 0, 0, fmt.Errorf("empty orderbook for %s", symbol)
+	}
+	return parseFloat(r.Bids[0][0]), parseFloat(r.Asks[0][0]), nil
+}
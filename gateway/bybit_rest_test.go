@@ -0,0 +1,260 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"market-maker-go/order"
+)
+
+// bybitStub 模拟 Bybit v5 REST：校验签名头，按路径返回 testdata 中录制的响应或自定义处理。
+type bybitStub struct {
+	t        *testing.T
+	mu       sync.Mutex
+	bodies   map[string]map[string]interface{} // path -> 最近一次 POST body
+	handlers map[string]func(w http.ResponseWriter, r *http.Request)
+}
+
+func newBybitStub(t *testing.T) (*bybitStub, *BybitRESTClient) {
+	s := &bybitStub{t: t, bodies: map[string]map[string]interface{}{}, handlers: map[string]func(http.ResponseWriter, *http.Request){}}
+	ts := httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(ts.Close)
+	cli := &BybitRESTClient{BaseURL: ts.URL, APIKey: "key", Secret: "secret", HTTPClient: ts.Client(), Limiter: &mockLimiter{}, Precision: NewSymbolPrecision()}
+	return s, cli
+}
+
+func (s *bybitStub) fixture(path, name string) {
+	raw, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		s.t.Fatalf("read fixture %s: %v", name, err)
+	}
+	s.handlers[path] = func(w http.ResponseWriter, r *http.Request) { w.Write(raw) }
+}
+
+func (s *bybitStub) serve(w http.ResponseWriter, r *http.Request) {
+	payload := r.URL.RawQuery
+	if r.Method == http.MethodPost {
+		raw, _ := io.ReadAll(r.Body)
+		payload = string(raw)
+		var body map[string]interface{}
+		if err := json.Unmarshal(raw, &body); err != nil {
+			s.t.Errorf("decode body: %v", err)
+		}
+		s.mu.Lock()
+		s.bodies[r.URL.Path] = body
+		s.mu.Unlock()
+	}
+	if !strings.HasPrefix(r.URL.Path, "/v5/market/") {
+		h := r.Header
+		want := bybitSign("secret", h.Get("X-BAPI-TIMESTAMP")+"key"+h.Get("X-BAPI-RECV-WINDOW")+payload)
+		if h.Get("X-BAPI-API-KEY") != "key" || h.Get("X-BAPI-SIGN") != want {
+			s.t.Errorf("bad signature for %s %s", r.Method, r.URL)
+		}
+	}
+	if r.URL.Query().Get("category") != "" && r.URL.Query().Get("category") != "linear" {
+		s.t.Errorf("unexpected category in %s", r.URL)
+	}
+	h, ok := s.handlers[r.URL.Path]
+	if !ok {
+		s.t.Errorf("unexpected path %s", r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	h(w, r)
+}
+
+func (s *bybitStub) body(path string) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bodies[path]
+}
+
+func TestBybitPlaceOrderMapsOptions(t *testing.T) {
+	stub, cli := newBybitStub(t)
+	stub.handlers["/v5/order/create"] = func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"retCode":0,"retMsg":"OK","result":{"orderId":"1f2e3d4c-0009","orderLinkId":"mm-b-9"},"retExtInfo":{},"time":1}`)
+	}
+	cli.Precision.Set("ETHUSDT", order.SymbolConstraints{TickSize: 0.01, StepSize: 0.01})
+	id, err := cli.PlaceOrder(order.Order{
+		ID: "mm-b-9", Symbol: "ETHUSDT", Side: "BUY", Price: 2000.1, Quantity: 0.5,
+		PostOnly: true, PositionSide: order.PositionSideLong, SelfTradePrevention: order.STPExpireTaker,
+	})
+	if err != nil || id != "1f2e3d4c-0009" {
+		t.Fatalf("place: id=%q err=%v", id, err)
+	}
+	got := stub.body("/v5/order/create")
+	want := map[string]interface{}{
+		"category": "linear", "symbol": "ETHUSDT", "side": "Buy", "orderType": "Limit",
+		"price": "2000.1", "qty": "0.5", "timeInForce": "PostOnly", "positionIdx": float64(1),
+		"orderLinkId": "mm-b-9", "smpType": "CancelTaker",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("body[%s] = %v, want %v", k, got[k], v)
+		}
+	}
+	if _, ok := got["reduceOnly"]; ok {
+		t.Errorf("reduceOnly should be omitted: %v", got)
+	}
+
+	if _, err := cli.PlaceOrder(order.Order{Symbol: "ETHUSDT", Side: "BUY", Quantity: 0.5, PriceMatch: order.PriceMatchQueue}); err == nil {
+		t.Fatalf("expected priceMatch to be rejected")
+	}
+}
+
+func TestBybitGetOrderAndReconciler(t *testing.T) {
+	stub, cli := newBybitStub(t)
+	stub.fixture("/v5/order/realtime", "bybit_rest_order_realtime.json")
+	stub.handlers["/v5/order/history"] = func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("orderLinkId") == "mm-b-1" {
+			io.WriteString(w, `{"retCode":0,"retMsg":"OK","result":{"list":[{"orderId":"1f2e3d4c-0001","orderLinkId":"mm-b-1","symbol":"ETHUSDT","price":"2000.10","qty":"0.50","side":"Buy","positionIdx":0,"orderStatus":"Filled","timeInForce":"PostOnly","cumExecQty":"0.50","orderType":"Limit"}]},"time":1}`)
+			return
+		}
+		io.WriteString(w, `{"retCode":0,"retMsg":"OK","result":{"list":[]},"time":1}`)
+	}
+
+	o, err := cli.GetOrder("ETHUSDT", "mm-b-2")
+	if err != nil {
+		t.Fatalf("get order: %v", err)
+	}
+	if o.Status != order.StatusPartial || o.Side != "BUY" || o.PositionSide != order.PositionSideLong || !o.PostOnly || o.Price != 1999.9 {
+		t.Fatalf("unexpected order %+v", o)
+	}
+	if _, err := cli.GetOrder("ETHUSDT", "mm-lost"); !errors.Is(err, ErrOrderNotFound) {
+		t.Fatalf("expected ErrOrderNotFound, got %v", err)
+	}
+
+	venue := &BybitVenue{BybitRESTClient: cli}
+	mgr := order.NewManager(nil)
+	for _, id := range []string{"mm-b-1", "mm-s-2", "mm-lost"} {
+		if _, err := mgr.Submit(order.Order{ID: id, Symbol: "ETHUSDT", Side: "BUY", Price: 2000, Quantity: 0.5}); err != nil {
+			t.Fatalf("submit %s: %v", id, err)
+		}
+	}
+	rec := order.NewReconciler(NewOrderQueryAdapter(venue, "ETHUSDT"), mgr, order.ReconcilerConfig{})
+	if err := rec.Reconcile(); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	want := map[string]order.Status{
+		"mm-b-1":  order.StatusFilled,
+		"mm-s-2":  order.StatusAck,
+		"mm-lost": order.StatusExpired,
+	}
+	for id, st := range want {
+		if got, _ := mgr.Status(id); got != st {
+			t.Errorf("%s status = %s, want %s", id, got, st)
+		}
+	}
+
+	open, err := venue.OpenOrders("ETHUSDT")
+	if err != nil || len(open) != 2 || open[1].ID != "mm-s-2" || open[1].PositionSide != order.PositionSideShort {
+		t.Fatalf("open orders %+v err=%v", open, err)
+	}
+}
+
+func TestBybitAccountAndMarketQueries(t *testing.T) {
+	stub, cli := newBybitStub(t)
+	stub.fixture("/v5/position/list", "bybit_rest_position_list.json")
+	stub.fixture("/v5/account/wallet-balance", "bybit_rest_wallet_balance.json")
+	stub.fixture("/v5/market/instruments-info", "bybit_rest_instruments.json")
+	stub.handlers["/v5/market/orderbook"] = func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"retCode":0,"retMsg":"OK","result":{"s":"ETHUSDT","b":[["2000.1","12.5"]],"a":[["2000.2","8.1"]],"ts":1,"u":1},"time":1}`)
+	}
+
+	pos, err := cli.Positions("ETHUSDT")
+	if err != nil || len(pos) != 2 {
+		t.Fatalf("positions %+v err=%v", pos, err)
+	}
+	if pos[0].PositionAmt != 1.2 || pos[0].PositionSide != order.PositionSideLong || pos[0].EntryPrice != 1995.5 || pos[0].MarginType != "cross" {
+		t.Errorf("long leg %+v", pos[0])
+	}
+	if pos[1].PositionAmt != -0.4 || pos[1].PositionSide != order.PositionSideShort || pos[1].MarginType != "isolated" {
+		t.Errorf("short leg %+v", pos[1])
+	}
+
+	bals, err := cli.Balances()
+	if err != nil || len(bals) != 2 || bals[0].Asset != "USDT" || bals[0].Balance != 1498.52 || bals[0].Available != 1201.3 {
+		t.Fatalf("balances %+v err=%v", bals, err)
+	}
+
+	infos, err := cli.Instruments("ETHUSDT")
+	if err != nil || len(infos) != 1 {
+		t.Fatalf("instruments %+v err=%v", infos, err)
+	}
+	info := infos[0]
+	if info.Status != "TRADING" || info.TickSize != 0.01 || info.StepSize != 0.01 || info.MinQty != 0.01 || info.MinNotional != 5 {
+		t.Fatalf("unexpected instrument %+v", info)
+	}
+
+	bid, ask, err := cli.BestBidAsk("ETHUSDT")
+	if err != nil || math.Abs(bid-2000.1) > 1e-9 || math.Abs(ask-2000.2) > 1e-9 {
+		t.Fatalf("best bid/ask %v/%v err=%v", bid, ask, err)
+	}
+}
+
+func TestBybitRetCodeMapsToSentinels(t *testing.T) {
+	stub, cli := newBybitStub(t)
+	cli.RetryDelay = 1
+	var calls int
+	stub.handlers["/v5/order/cancel"] = func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			io.WriteString(w, `{"retCode":10006,"retMsg":"Too many visits!","result":{},"time":1}`)
+			return
+		}
+		io.WriteString(w, `{"retCode":110001,"retMsg":"order not exists or too late to cancel","result":{},"time":1}`)
+	}
+	err := cli.CancelOrder("ETHUSDT", "1f2e3d4c-0001")
+	if !errors.Is(err, ErrOrderNotFound) {
+		t.Fatalf("expected ErrOrderNotFound after retry, got %v", err)
+	}
+	var apiErr *BybitAPIError
+	if !errors.As(err, &apiErr) || apiErr.RetCode != BybitCodeOrderNotExist || calls != 2 {
+		t.Fatalf("unexpected error %#v after %d calls", err, calls)
+	}
+	if body := stub.body("/v5/order/cancel"); body["orderId"] != "1f2e3d4c-0001" || body["symbol"] != "ETHUSDT" {
+		t.Fatalf("unexpected cancel body %v", body)
+	}
+}
+
+func TestBybitOpenOrdersPaginates(t *testing.T) {
+	stub, cli := newBybitStub(t)
+	var cursors []string
+	stub.handlers["/v5/order/realtime"] = func(w http.ResponseWriter, r *http.Request) {
+		cursor := r.URL.Query().Get("cursor")
+		cursors = append(cursors, cursor)
+		next, start := "page2", 0
+		if cursor == "page2" {
+			next, start = "", 50
+		}
+		n := 50
+		if cursor == "page2" {
+			n = 3
+		}
+		items := make([]string, n)
+		for i := range items {
+			items[i] = fmt.Sprintf(`{"orderId":"x%d","orderLinkId":"mm-%d","symbol":"ETHUSDT","price":"2000","qty":"0.1","side":"Buy","orderStatus":"New","cumExecQty":"0"}`, start+i, start+i)
+		}
+		fmt.Fprintf(w, `{"retCode":0,"retMsg":"OK","result":{"nextPageCursor":%q,"category":"linear","list":[%s]},"time":1}`, next, strings.Join(items, ","))
+	}
+	open, err := cli.OpenOrders("ETHUSDT")
+	if err != nil {
+		t.Fatalf("open orders: %v", err)
+	}
+	if len(open) != 53 || len(cursors) != 2 || cursors[1] != "page2" {
+		t.Fatalf("expected 53 orders over 2 pages, got %d via cursors %q", len(open), cursors)
+	}
+	if open[52].ID != "mm-52" {
+		t.Fatalf("unexpected last order %+v", open[52])
+	}
+}
@@ -0,0 +1,94 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// VenueBybit Bybit v5 线性永续。
+const VenueBybit = "bybit"
+
+// StreamPrivate Bybit 私有流（无 listenKey，私有推送走独立的鉴权连接）。
+const StreamPrivate WSStreamKind = "private"
+
+// bybitExpiry 私有流鉴权签名的有效期。
+const bybitExpiry = 10 * time.Second
+
+// BybitVenue 把 Bybit v5 REST 与公共/私有 WS 适配为 Venue。尚未接入 cmd/runner，Runner 目前只能以 Binance 运行。
+type BybitVenue struct {
+	*BybitRESTClient
+	PublicWS  string // 默认 BybitPublicWSEndpoint
+	PrivateWS string // 默认 BybitPrivateWSEndpoint
+	// Reconnect 两条 WS 连接的重连/心跳配置，零值使用默认值。
+	Reconnect WSReconnectConfig
+}
+
+// NewBybitVenue 创建 Bybit 适配器；baseURL 为空时使用 BybitRestEndpoint。
+func NewBybitVenue(baseURL, apiKey, secret string, httpClient *http.Client) *BybitVenue {
+	if baseURL == "" {
+		baseURL = BybitRestEndpoint
+	}
+	if httpClient == nil {
+		httpClient = NewDefaultHTTPClient()
+	}
+	return &BybitVenue{
+		BybitRESTClient: &BybitRESTClient{
+			BaseURL:    baseURL,
+			APIKey:     apiKey,
+			Secret:     secret,
+			HTTPClient: httpClient,
+			Precision:  NewSymbolPrecision(),
+		},
+		PublicWS:  BybitPublicWSEndpoint,
+		PrivateWS: BybitPrivateWSEndpoint,
+	}
+}
+
+func (v *BybitVenue) Name() string { return VenueBybit }
+
+// Stream 公共连接订阅 orderbook.1 与 publicTrade，配置了 APIKey 时另开私有连接订阅 order/execution/position。
+func (v *BybitVenue) Stream(ctx context.Context, symbol string, h VenueHandler) error {
+	symbol = strings.ToUpper(symbol)
+	router := &bybitStreamRouter{h: h}
+	sessions := []*BybitWS{v.session(v.PublicWS, BybitPublicWSEndpoint, StreamDepth, h, router, "orderbook.1."+symbol, "publicTrade."+symbol)}
+	if v.APIKey != "" {
+		priv := v.session(v.PrivateWS, BybitPrivateWSEndpoint, StreamPrivate, h, router, "order", "execution", "position")
+		priv.APIKey, priv.Secret = v.APIKey, v.Secret
+		sessions = append(sessions, priv)
+	}
+	errs := make(chan error, len(sessions))
+	for _, s := range sessions {
+		go func(s *BybitWS) { errs <- s.Run() }(s)
+	}
+	defer func() {
+		for _, s := range sessions {
+			s.Stop()
+		}
+	}()
+	for range sessions {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errs:
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (v *BybitVenue) session(endpoint, fallback string, kind WSStreamKind, h VenueHandler, router *bybitStreamRouter, topics ...string) *BybitWS {
+	if endpoint == "" {
+		endpoint = fallback
+	}
+	s := NewBybitWS(endpoint, kind, topics...)
+	if v.Reconnect.InitialDelay > 0 {
+		s.Reconnect = v.Reconnect
+	}
+	s.OnMessage = router.OnMessage
+	s.OnGap = h.gap
+	return s
+}
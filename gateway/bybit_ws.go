@@ -0,0 +1,482 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"market-maker-go/market"
+	"market-maker-go/order"
+)
+
+// BybitWSMessage Bybit v5 WS 推送/应答的公共外层。
+type BybitWSMessage struct {
+	Topic   string          `json:"topic"`
+	Type    string          `json:"type"` // snapshot/delta（行情）
+	Ts      int64           `json:"ts"`
+	Data    json.RawMessage `json:"data"`
+	Op      string          `json:"op"` // auth/subscribe/pong 等应答
+	Success *bool           `json:"success"`
+	RetMsg  string          `json:"ret_msg"`
+}
+
+// ParseBybitWSMessage 解析外层。
+func ParseBybitWSMessage(msg []byte) (BybitWSMessage, error) {
+	var m BybitWSMessage
+	if err := json.Unmarshal(msg, &m); err != nil {
+		return m, fmt.Errorf("decode bybit ws message: %w", err)
+	}
+	return m, nil
+}
+
+// ParseBybitOrders 解析 order 主题。成交由 execution 主题逐笔上报，这里跳过 PartiallyFilled/Filled，避免重复计成交。
+func ParseBybitOrders(data []byte) ([]VenueOrderEvent, error) {
+	var raw []bybitOrderResp
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("decode bybit order: %w", err)
+	}
+	out := make([]VenueOrderEvent, 0, len(raw))
+	for _, r := range raw {
+		if r.OrderStatus == "PartiallyFilled" || r.OrderStatus == "Filled" {
+			continue
+		}
+		ts, _ := strconv.ParseInt(r.UpdatedTime, 10, 64)
+		out = append(out, VenueOrderEvent{
+			Venue:         VenueBybit,
+			Symbol:        r.Symbol,
+			ClientOrderID: r.OrderLinkID,
+			ExchangeID:    r.OrderID,
+			Side:          fromBybitSide(r.Side),
+			PositionSide:  fromBybitPositionIdx(r.PositionIdx),
+			Status:        MapBybitOrderStatus(r.OrderStatus),
+			Price:         parseFloat(r.Price),
+			Qty:           parseFloat(r.Qty),
+			CumQty:        parseFloat(r.CumExecQty),
+			Time:          ts,
+			RawStatus:     r.OrderStatus,
+		})
+	}
+	return out, nil
+}
+
+// ParseBybitExecutions 解析 execution 主题的成交（execType=Trade），按剩余数量判定 PARTIAL/FILLED。
+func ParseBybitExecutions(data []byte) ([]VenueOrderEvent, error) {
+	var raw []struct {
+		Symbol      string `json:"symbol"`
+		OrderID     string `json:"orderId"`
+		OrderLinkID string `json:"orderLinkId"`
+		Side        string `json:"side"`
+		OrderPrice  string `json:"orderPrice"`
+		OrderQty    string `json:"orderQty"`
+		LeavesQty   string `json:"leavesQty"`
+		ExecID      string `json:"execId"`
+		ExecType    string `json:"execType"`
+		ExecPrice   string `json:"execPrice"`
+		ExecQty     string `json:"execQty"`
+		ExecFee     string `json:"execFee"`
+		FeeCurrency string `json:"feeCurrency"`
+		ExecPnl     string `json:"execPnl"`
+		IsMaker     bool   `json:"isMaker"`
+		ExecTime    string `json:"execTime"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("decode bybit execution: %w", err)
+	}
+	out := make([]VenueOrderEvent, 0, len(raw))
+	for _, r := range raw {
+		if r.ExecType != "Trade" {
+			continue
+		}
+		qty, leaves := parseFloat(r.OrderQty), parseFloat(r.LeavesQty)
+		status, rawStatus := order.StatusPartial, "PartiallyFilled"
+		if leaves <= 0 {
+			status, rawStatus = order.StatusFilled, "Filled"
+		}
+		ts, _ := strconv.ParseInt(r.ExecTime, 10, 64)
+		out = append(out, VenueOrderEvent{
+			Venue:         VenueBybit,
+			Symbol:        r.Symbol,
+			ClientOrderID: r.OrderLinkID,
+			ExchangeID:    r.OrderID,
+			Side:          fromBybitSide(r.Side),
+			Status:        status,
+			Price:         parseFloat(r.OrderPrice),
+			Qty:           qty,
+			CumQty:        qty - leaves,
+			LastQty:       parseFloat(r.ExecQty),
+			LastPrice:     parseFloat(r.ExecPrice),
+			TradeID:       r.ExecID,
+			Fee:           parseFloat(r.ExecFee),
+			FeeAsset:      r.FeeCurrency,
+			RealizedPnL:   parseFloat(r.ExecPnl),
+			Maker:         r.IsMaker,
+			Time:          ts,
+			RawStatus:     rawStatus,
+		})
+	}
+	return out, nil
+}
+
+// ParseBybitPositions 解析 position 主题。
+func ParseBybitPositions(data []byte) ([]FuturesPosition, error) {
+	var raw []bybitPositionResp
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("decode bybit position: %w", err)
+	}
+	out := make([]FuturesPosition, 0, len(raw))
+	for _, r := range raw {
+		out = append(out, r.toPosition())
+	}
+	return out, nil
+}
+
+// ParseBybitTrades 解析 publicTrade.<symbol> 主题；S 为主动方向。
+func ParseBybitTrades(data []byte) ([]market.Trade, error) {
+	var raw []struct {
+		Time   int64  `json:"T"`
+		Symbol string `json:"s"`
+		Side   string `json:"S"`
+		Qty    string `json:"v"`
+		Price  string `json:"p"`
+		ID     string `json:"i"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("decode bybit trade: %w", err)
+	}
+	out := make([]market.Trade, 0, len(raw))
+	for _, r := range raw {
+		id, _ := strconv.ParseInt(r.ID, 10, 64) // 线性合约的成交 ID 为 UUID，无法转换时为 0
+		out = append(out, market.Trade{
+			Symbol: r.Symbol,
+			ID:     id,
+			Price:  parseFloat(r.Price),
+			Qty:    parseFloat(r.Qty),
+			IsBuy:  r.Side == "Buy",
+			Ts:     time.UnixMilli(r.Time).UTC(),
+		})
+	}
+	return out, nil
+}
+
+// BybitTopOfBook 维护 orderbook.1.<symbol> 的最优买卖价：snapshot 覆盖，delta 中数量为 0 表示删除该价位。
+type BybitTopOfBook struct {
+	Symbol   string
+	Bid, Ask float64
+}
+
+// Apply 处理一条 orderbook 推送，返回最优价是否变化。
+func (b *BybitTopOfBook) Apply(typ string, data []byte) (bool, error) {
+	var d struct {
+		Symbol string     `json:"s"`
+		Bids   [][]string `json:"b"`
+		Asks   [][]string `json:"a"`
+	}
+	if err := json.Unmarshal(data, &d); err != nil {
+		return false, fmt.Errorf("decode bybit orderbook: %w", err)
+	}
+	bid, ask := b.Bid, b.Ask
+	if typ == "snapshot" {
+		bid, ask = 0, 0
+	}
+	bid = applyBybitLevels(bid, d.Bids)
+	ask = applyBybitLevels(ask, d.Asks)
+	changed := bid != b.Bid || ask != b.Ask
+	b.Symbol, b.Bid, b.Ask = d.Symbol, bid, ask
+	return changed, nil
+}
+
+func applyBybitLevels(cur float64, levels [][]string) float64 {
+	for _, lv := range levels {
+		if len(lv) < 2 {
+			continue
+		}
+		price, qty := parseFloat(lv[0]), parseFloat(lv[1])
+		if qty == 0 {
+			if price == cur {
+				cur = 0
+			}
+			continue
+		}
+		cur = price
+	}
+	return cur
+}
+
+// BybitWS 单条 Bybit v5 WS 连接的会话层：建连后（私有流先鉴权）订阅 Topics，按 {"op":"ping"} 保活，
+// 断线按指数退避重连并重放订阅，重连后通过 OnGap 通知丢失的推送。
+type BybitWS struct {
+	Endpoint string
+	Topics   []string
+	// APIKey/Secret 非空时先发送 auth（私有流）。
+	APIKey    string
+	Secret    string
+	Dialer    *websocket.Dialer
+	Reconnect WSReconnectConfig
+	// MaxRetries 首次建连的最大重试次数；连上之后断线无限重试。
+	MaxRetries int
+	// Kind 用于 OnGap 通知的流类型。
+	Kind      WSStreamKind
+	OnMessage func(BybitWSMessage)
+	OnGap     func(WSGap)
+
+	mu       sync.Mutex
+	writeMu  sync.Mutex
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// NewBybitWS 创建会话；私有流需另行设置 APIKey/Secret。
+func NewBybitWS(endpoint string, kind WSStreamKind, topics ...string) *BybitWS {
+	return &BybitWS{
+		Endpoint:   endpoint,
+		Topics:     topics,
+		Dialer:     websocket.DefaultDialer,
+		Reconnect:  DefaultWSReconnectConfig(),
+		MaxRetries: 5,
+		Kind:       kind,
+	}
+}
+
+// Stop 关闭会话，Run 随之返回。
+func (w *BybitWS) Stop() {
+	w.stopOnce.Do(func() { close(w.stopChan()) })
+}
+
+func (w *BybitWS) stopChan() chan struct{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopCh == nil {
+		w.stopCh = make(chan struct{})
+	}
+	return w.stopCh
+}
+
+// Run 阻塞运行会话；仅首次建连（含鉴权、订阅）失败超过 MaxRetries 时返回错误，Stop 后返回 nil。
+func (w *BybitWS) Run() error {
+	stop := w.stopChan()
+	cfg := w.Reconnect
+	if cfg.InitialDelay <= 0 {
+		cfg = DefaultWSReconnectConfig()
+	}
+	dialer := w.Dialer
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+	delay := cfg.InitialDelay
+	failures := 0
+	established := false
+	var lostAt time.Time
+	for {
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+		conn, _, err := dialer.Dial(w.Endpoint, nil)
+		if err == nil {
+			if err = w.handshake(conn); err != nil {
+				conn.Close()
+			}
+		}
+		if err != nil {
+			if !established && failures >= w.MaxRetries {
+				return err
+			}
+			failures++
+			log.Printf("bybit ws %s dial failed (attempt %d): %v, retry in %s", w.Kind, failures, err, delay)
+			if !sleepOrStop(stop, delay) {
+				return nil
+			}
+			delay = cfg.nextDelay(delay)
+			continue
+		}
+		failures = 0
+		delay = cfg.InitialDelay
+		if established && w.OnGap != nil {
+			w.OnGap(WSGap{Stream: w.Endpoint, Kind: w.Kind, Reason: GapReconnect, Since: lostAt})
+		}
+		established = true
+		err = w.serve(conn, cfg, stop)
+		lostAt = time.Now()
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+		log.Printf("bybit ws %s disconnected: %v, reconnecting in %s", w.Kind, err, delay)
+		if !sleepOrStop(stop, delay) {
+			return nil
+		}
+		delay = cfg.nextDelay(delay)
+	}
+}
+
+// handshake 私有流先鉴权，再订阅全部主题，逐一等待应答。
+func (w *BybitWS) handshake(conn *websocket.Conn) error {
+	wait := w.Reconnect.WriteWait
+	if wait <= 0 {
+		wait = 10 * time.Second
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(wait))
+	defer conn.SetReadDeadline(time.Time{})
+	if w.APIKey != "" {
+		expires := timeNowMillis() + bybitExpiry.Milliseconds()
+		sig := bybitSign(w.Secret, "GET/realtime"+strconv.FormatInt(expires, 10))
+		if err := w.request(conn, "auth", []interface{}{w.APIKey, expires, sig}); err != nil {
+			return err
+		}
+	}
+	if len(w.Topics) == 0 {
+		return nil
+	}
+	return w.request(conn, "subscribe", w.Topics)
+}
+
+// request 发送 op 请求并读取到对应应答（期间到达的推送直接分发）。
+func (w *BybitWS) request(conn *websocket.Conn, op string, args interface{}) error {
+	if err := w.write(conn, map[string]interface{}{"op": op, "args": args}); err != nil {
+		return err
+	}
+	for {
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			return fmt.Errorf("bybit ws %s: %w", op, err)
+		}
+		m, err := ParseBybitWSMessage(raw)
+		if err != nil {
+			continue
+		}
+		if m.Op != op {
+			w.dispatch(m)
+			continue
+		}
+		if m.Success != nil && !*m.Success {
+			return fmt.Errorf("bybit ws %s rejected: %s", op, m.RetMsg)
+		}
+		return nil
+	}
+}
+
+func (w *BybitWS) write(conn *websocket.Conn, v interface{}) error {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+	wait := w.Reconnect.WriteWait
+	if wait <= 0 {
+		wait = 10 * time.Second
+	}
+	_ = conn.SetWriteDeadline(time.Now().Add(wait))
+	return conn.WriteJSON(v)
+}
+
+func (w *BybitWS) dispatch(m BybitWSMessage) {
+	if m.Topic == "" || w.OnMessage == nil {
+		return
+	}
+	w.OnMessage(m)
+}
+
+// serve 读取一条连接直到出错或停止；按 PingInterval 发送应用层 ping，超过 PongWait 无任何消息视为断线。
+func (w *BybitWS) serve(conn *websocket.Conn, cfg WSReconnectConfig, stop <-chan struct{}) error {
+	done := make(chan struct{})
+	defer close(done)
+	defer conn.Close()
+	pongWait := cfg.PongWait
+	if pongWait <= 0 {
+		pongWait = 30 * time.Second
+	}
+	go func() {
+		var pingC <-chan time.Time
+		if cfg.EnableHeartbeat && cfg.PingInterval > 0 {
+			t := time.NewTicker(cfg.PingInterval)
+			defer t.Stop()
+			pingC = t.C
+		}
+		for {
+			select {
+			case <-done:
+				return
+			case <-stop:
+				_ = conn.Close()
+				return
+			case <-pingC:
+				if err := w.write(conn, map[string]string{"op": "ping"}); err != nil {
+					log.Printf("bybit ws heartbeat failed: %v", err)
+					_ = conn.Close()
+					return
+				}
+			}
+		}
+	}()
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		m, err := ParseBybitWSMessage(raw)
+		if err != nil {
+			log.Printf("%v", err)
+			continue
+		}
+		w.dispatch(m)
+	}
+}
+
+// bybitStreamRouter 把 Bybit 推送按主题解析并转为 VenueHandler 回调。
+type bybitStreamRouter struct {
+	h    VenueHandler
+	mu   sync.Mutex
+	book BybitTopOfBook
+}
+
+func (r *bybitStreamRouter) OnMessage(m BybitWSMessage) {
+	var err error
+	switch {
+	case strings.HasPrefix(m.Topic, "orderbook."):
+		r.mu.Lock()
+		changed, perr := r.book.Apply(m.Type, m.Data)
+		sym, bid, ask := r.book.Symbol, r.book.Bid, r.book.Ask
+		r.mu.Unlock()
+		err = perr
+		if changed && bid > 0 && ask > 0 {
+			r.h.book(sym, bid, ask)
+		}
+	case strings.HasPrefix(m.Topic, "publicTrade."):
+		var trades []market.Trade
+		if trades, err = ParseBybitTrades(m.Data); err == nil {
+			for _, t := range trades {
+				r.h.trade(t)
+			}
+		}
+	case m.Topic == "order":
+		var evs []VenueOrderEvent
+		if evs, err = ParseBybitOrders(m.Data); err == nil {
+			for _, ev := range evs {
+				r.h.order(ev)
+			}
+		}
+	case m.Topic == "execution":
+		var evs []VenueOrderEvent
+		if evs, err = ParseBybitExecutions(m.Data); err == nil {
+			for _, ev := range evs {
+				r.h.order(ev)
+			}
+		}
+	case m.Topic == "position":
+		var ps []FuturesPosition
+		if ps, err = ParseBybitPositions(m.Data); err == nil {
+			for _, p := range ps {
+				r.h.position(p)
+			}
+		}
+	}
+	if err != nil {
+		log.Printf("bybit ws %s: %v", m.Topic, err)
+	}
+}
@@ -0,0 +1,237 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"market-maker-go/market"
+	"market-maker-go/order"
+)
+
+// venueRecorder 收集 VenueHandler 回调。
+type venueRecorder struct {
+	mu        sync.Mutex
+	books     [][2]float64
+	trades    []market.Trade
+	orders    []VenueOrderEvent
+	positions []FuturesPosition
+	gaps      []WSGap
+}
+
+func (r *venueRecorder) handler() VenueHandler {
+	return VenueHandler{
+		OnBook: func(_ string, bid, ask float64) {
+			r.mu.Lock()
+			r.books = append(r.books, [2]float64{bid, ask})
+			r.mu.Unlock()
+		},
+		OnTrade: func(t market.Trade) {
+			r.mu.Lock()
+			r.trades = append(r.trades, t)
+			r.mu.Unlock()
+		},
+		OnOrder: func(ev VenueOrderEvent) {
+			r.mu.Lock()
+			r.orders = append(r.orders, ev)
+			r.mu.Unlock()
+		},
+		OnPosition: func(p FuturesPosition) {
+			r.mu.Lock()
+			r.positions = append(r.positions, p)
+			r.mu.Unlock()
+		},
+		OnGap: func(g WSGap) {
+			r.mu.Lock()
+			r.gaps = append(r.gaps, g)
+			r.mu.Unlock()
+		},
+	}
+}
+
+func (r *venueRecorder) counts() (books, trades, orders, positions, gaps int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.books), len(r.trades), len(r.orders), len(r.positions), len(r.gaps)
+}
+
+func routeFixture(t *testing.T, router *bybitStreamRouter, name string) {
+	t.Helper()
+	for _, line := range loadStreamFixture(t, name) {
+		m, err := ParseBybitWSMessage(line)
+		if err != nil {
+			t.Fatalf("parse %s: %v", name, err)
+		}
+		router.OnMessage(m)
+	}
+}
+
+func TestBybitRouterPublicFixture(t *testing.T) {
+	rec := &venueRecorder{}
+	routeFixture(t, &bybitStreamRouter{h: rec.handler()}, "bybit_ws_public_ethusdt.jsonl")
+	// snapshot -> 2000.10/2000.20；ask 数量变化不触发；bid 档位删除后由 2000.00 接替
+	want := [][2]float64{{2000.10, 2000.20}, {2000.00, 2000.20}}
+	if len(rec.books) != len(want) {
+		t.Fatalf("books %v, want %v", rec.books, want)
+	}
+	for i := range want {
+		if rec.books[i] != want[i] {
+			t.Fatalf("books %v, want %v", rec.books, want)
+		}
+	}
+	if len(rec.trades) != 2 || !rec.trades[0].IsBuy || rec.trades[1].IsBuy || rec.trades[1].Qty != 1.1 || rec.trades[0].Symbol != "ETHUSDT" {
+		t.Fatalf("unexpected trades %+v", rec.trades)
+	}
+}
+
+func TestBybitRouterPrivateFixture(t *testing.T) {
+	rec := &venueRecorder{}
+	routeFixture(t, &bybitStreamRouter{h: rec.handler()}, "bybit_ws_private.jsonl")
+	// New、两笔成交（PartiallyFilled 的 order 推送与 Funding 被跳过）、Cancelled
+	want := []struct {
+		id      string
+		status  order.Status
+		lastQty float64
+		cumQty  float64
+	}{
+		{"mm-b-1", order.StatusAck, 0, 0},
+		{"mm-b-1", order.StatusPartial, 0.2, 0.2},
+		{"mm-b-1", order.StatusFilled, 0.3, 0.5},
+		{"mm-s-1", order.StatusCanceled, 0, 0},
+	}
+	if len(rec.orders) != len(want) {
+		t.Fatalf("orders %+v", rec.orders)
+	}
+	for i, w := range want {
+		ev := rec.orders[i]
+		if ev.ClientOrderID != w.id || ev.Status != w.status || ev.LastQty != w.lastQty || ev.CumQty != w.cumQty || ev.Venue != VenueBybit {
+			t.Errorf("order[%d] = %+v, want %+v", i, ev, w)
+		}
+	}
+	if fill := rec.orders[2]; fill.LastPrice != 2000.1 || fill.Fee != 0.03 || !fill.Maker || fill.Side != "BUY" || fill.Time != 1700000003000 || fill.TradeID != "e-2" {
+		t.Errorf("unexpected fill %+v", fill)
+	}
+	if len(rec.positions) != 1 || rec.positions[0].PositionAmt != 0.5 || rec.positions[0].EntryPrice != 2000.1 || rec.positions[0].PositionSide != "BOTH" {
+		t.Fatalf("unexpected positions %+v", rec.positions)
+	}
+}
+
+// bybitWSStub 模拟 Bybit 公共/私有 WS：校验 auth 签名与订阅，按需推送消息。
+type bybitWSStub struct {
+	*fakeStreamServer
+}
+
+func (s *bybitWSStub) url() string { return "ws" + s.URL[len("http"):] }
+
+// accept 取下一条连接并完成握手，返回收到的订阅主题。
+func (s *bybitWSStub) accept(t *testing.T, wantAuth bool) (*websocket.Conn, []string) {
+	t.Helper()
+	conn := s.next(t)
+	var req struct {
+		Op   string            `json:"op"`
+		Args []json.RawMessage `json:"args"`
+	}
+	if wantAuth {
+		if err := conn.ReadJSON(&req); err != nil || req.Op != "auth" || len(req.Args) != 3 {
+			t.Fatalf("expected auth, got %+v err=%v", req, err)
+		}
+		var key, sig string
+		var expires int64
+		json.Unmarshal(req.Args[0], &key)
+		json.Unmarshal(req.Args[1], &expires)
+		json.Unmarshal(req.Args[2], &sig)
+		if key != "key" || sig != bybitSign("secret", "GET/realtime"+strconv.FormatInt(expires, 10)) {
+			t.Fatalf("bad auth args key=%s sig=%s", key, sig)
+		}
+		conn.WriteJSON(map[string]interface{}{"op": "auth", "success": true, "ret_msg": ""})
+	}
+	if err := conn.ReadJSON(&req); err != nil || req.Op != "subscribe" {
+		t.Fatalf("expected subscribe, got %+v err=%v", req, err)
+	}
+	topics := make([]string, 0, len(req.Args))
+	for _, a := range req.Args {
+		var topic string
+		json.Unmarshal(a, &topic)
+		topics = append(topics, topic)
+	}
+	conn.WriteJSON(map[string]interface{}{"op": "subscribe", "success": true, "ret_msg": ""})
+	return conn, topics
+}
+
+func TestBybitVenueStreamAgainstStub(t *testing.T) {
+	pub := &bybitWSStub{newFakeStreamServer(t)}
+	priv := &bybitWSStub{newFakeStreamServer(t)}
+	v := NewBybitVenue("http://127.0.0.1:0", "key", "secret", nil)
+	v.PublicWS, v.PrivateWS = pub.url(), priv.url()
+	v.Reconnect = WSReconnectConfig{InitialDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond, BackoffFactor: 2, PongWait: 5 * time.Second, WriteWait: time.Second}
+
+	rec := &venueRecorder{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- v.Stream(ctx, "ethusdt", rec.handler()) }()
+
+	pubConn, topics := pub.accept(t, false)
+	if len(topics) != 2 || topics[0] != "orderbook.1.ETHUSDT" || topics[1] != "publicTrade.ETHUSDT" {
+		t.Fatalf("unexpected public topics %v", topics)
+	}
+	privConn, topics := priv.accept(t, true)
+	if len(topics) != 3 || topics[0] != "order" || topics[1] != "execution" || topics[2] != "position" {
+		t.Fatalf("unexpected private topics %v", topics)
+	}
+	for _, line := range loadStreamFixture(t, "bybit_ws_public_ethusdt.jsonl") {
+		pubConn.WriteMessage(websocket.TextMessage, line)
+	}
+	for _, line := range loadStreamFixture(t, "bybit_ws_private.jsonl") {
+		privConn.WriteMessage(websocket.TextMessage, line)
+	}
+	eventually(t, func() bool {
+		books, trades, orders, positions, _ := rec.counts()
+		return books == 2 && trades == 2 && orders == 4 && positions == 1
+	})
+
+	// 私有连接断开：重连后重新鉴权订阅，并通过 OnGap 通知调用方对账
+	privConn.Close()
+	priv.accept(t, true)
+	eventually(t, func() bool { _, _, _, _, gaps := rec.counts(); return gaps == 1 })
+	rec.mu.Lock()
+	g := rec.gaps[0]
+	rec.mu.Unlock()
+	if g.Kind != StreamPrivate || g.Reason != GapReconnect {
+		t.Fatalf("unexpected gap %+v", g)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("stream: %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("stream did not stop")
+	}
+}
+
+func TestBybitWSRejectedSubscribeFails(t *testing.T) {
+	srv := &bybitWSStub{newFakeStreamServer(t)}
+	ws := NewBybitWS(srv.url(), StreamDepth, "orderbook.1.NOPE")
+	ws.MaxRetries = 0
+	done := make(chan error, 1)
+	go func() { done <- ws.Run() }()
+	conn := srv.next(t)
+	var req map[string]interface{}
+	conn.ReadJSON(&req)
+	conn.WriteJSON(map[string]interface{}{"op": "subscribe", "success": false, "ret_msg": "Invalid symbol"})
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatalf("expected subscribe rejection")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("run did not return")
+	}
+}
@@ -0,0 +1,362 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 抓包记录类型。
+const (
+	CaptureWS   = "ws"   // WS 入站帧
+	CaptureREST = "rest" // REST 请求与响应
+	CaptureGap  = "gap"  // WS 数据缺口（重连/静默），回放时据此触发同样的重建
+)
+
+// captureRedacted 脱敏后的占位值。
+const captureRedacted = "***"
+
+// captureSecretParams query/body 中需要脱敏的参数（请求头一律不记录）。
+var captureSecretParams = map[string]bool{
+	"signature": true,
+	"apikey":    true,
+	"secret":    true,
+	"listenkey": true,
+}
+
+// CaptureRecord 一条原始流量记录，按 JSON Lines 存储（一行一条，字段名取单字母以压缩体积）。
+type CaptureRecord struct {
+	Time   int64  `json:"t"`           // 接收时间（Unix 纳秒）
+	Kind   string `json:"k"`           // CaptureWS/CaptureREST/CaptureGap
+	Source string `json:"s,omitempty"` // WS 连接地址或缺口所属的流
+	Method string `json:"m,omitempty"`
+	// URL 请求路径 + 脱敏后的 query，不含 scheme/host。
+	URL     string `json:"u,omitempty"`
+	Status  int    `json:"c,omitempty"`
+	Latency int64  `json:"l,omitempty"` // REST 往返耗时（微秒）
+	Body    string `json:"b,omitempty"` // REST 请求体（脱敏）
+	// Data WS 帧或 REST 响应体（合法 JSON 时原样内嵌）；Text 为非 JSON 内容。
+	Data json.RawMessage `json:"d,omitempty"`
+	Text string          `json:"x,omitempty"`
+	Err  string          `json:"e,omitempty"` // REST 传输错误
+}
+
+// Payload 返回帧/响应体原文。
+func (r CaptureRecord) Payload() []byte {
+	if len(r.Data) > 0 {
+		return r.Data
+	}
+	return []byte(r.Text)
+}
+
+func (r *CaptureRecord) setPayload(b []byte) {
+	if len(b) == 0 {
+		return
+	}
+	if json.Valid(b) {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, b); err == nil {
+			r.Data = buf.Bytes()
+			return
+		}
+	}
+	r.Text = string(b)
+}
+
+// CaptureWriter 把流量写入按大小轮转的抓包文件：当前文件为 Path，写满 MaxBytes 后重命名为
+// Path.<时间戳> 并新开文件，只保留最近 MaxFiles 个历史文件。并发安全，每条记录直接落盘。
+type CaptureWriter struct {
+	Path     string
+	MaxBytes int64 // 默认 64MB
+	MaxFiles int   // 默认 10
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+	now  func() time.Time
+}
+
+// NewCaptureWriter 打开（追加）抓包文件；maxBytes/maxFiles <=0 时使用默认值。
+func NewCaptureWriter(path string, maxBytes int64, maxFiles int) (*CaptureWriter, error) {
+	if path == "" {
+		return nil, fmt.Errorf("capture path required")
+	}
+	if maxBytes <= 0 {
+		maxBytes = 64 << 20
+	}
+	if maxFiles <= 0 {
+		maxFiles = 10
+	}
+	w := &CaptureWriter{Path: path, MaxBytes: maxBytes, MaxFiles: maxFiles, now: time.Now}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *CaptureWriter) open() error {
+	if dir := filepath.Dir(w.Path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("create capture dir: %w", err)
+		}
+	}
+	f, err := os.OpenFile(w.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open capture file: %w", err)
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.f, w.size = f, st.Size()
+	return nil
+}
+
+// Write 追加一条记录；Time 为 0 时取当前时间。
+func (w *CaptureWriter) Write(rec CaptureRecord) error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if rec.Time == 0 {
+		rec.Time = w.now().UnixNano()
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	if w.f == nil {
+		return fmt.Errorf("capture writer closed")
+	}
+	if w.size > 0 && w.size+int64(len(line)) > w.MaxBytes {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := w.f.Write(line)
+	w.size += int64(n)
+	return err
+}
+
+// rotate 把当前文件改名为带时间戳的历史文件，删除超出 MaxFiles 的最旧文件。
+func (w *CaptureWriter) rotate() error {
+	if err := w.f.Close(); err != nil {
+		return err
+	}
+	w.f = nil
+	rotated := w.Path + "." + w.now().UTC().Format("20060102T150405.000000")
+	if err := os.Rename(w.Path, rotated); err != nil {
+		return fmt.Errorf("rotate capture file: %w", err)
+	}
+	old, err := rotatedCaptureFiles(w.Path)
+	if err == nil && len(old) > w.MaxFiles {
+		for _, p := range old[:len(old)-w.MaxFiles] {
+			_ = os.Remove(p)
+		}
+	}
+	return w.open()
+}
+
+// RecordWS 记录一条 WS 入站帧；写入失败只打日志，不影响行情处理。
+func (w *CaptureWriter) RecordWS(source string, frame []byte) {
+	if w == nil {
+		return
+	}
+	rec := CaptureRecord{Time: w.now().UnixNano(), Kind: CaptureWS, Source: redactSource(source)}
+	rec.setPayload(frame)
+	if err := w.Write(rec); err != nil {
+		log.Printf("capture ws frame: %v", err)
+	}
+}
+
+// RecordGap 记录 WS 数据缺口。
+func (w *CaptureWriter) RecordGap(g WSGap) {
+	if w == nil {
+		return
+	}
+	data, _ := json.Marshal(captureGap{Kind: string(g.Kind), Reason: g.Reason, Since: g.Since.UnixNano()})
+	source := g.Stream
+	if g.Kind == StreamUserData {
+		// 用户流的 stream 名就是 listenKey
+		source = captureRedacted
+	}
+	if err := w.Write(CaptureRecord{Time: w.now().UnixNano(), Kind: CaptureGap, Source: source, Data: data}); err != nil {
+		log.Printf("capture ws gap: %v", err)
+	}
+}
+
+type captureGap struct {
+	Kind   string `json:"kind"`
+	Reason string `json:"reason"`
+	Since  int64  `json:"since"`
+}
+
+// Close 关闭当前文件。
+func (w *CaptureWriter) Close() error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
+
+// rotatedCaptureFiles 返回 path 的历史轮转文件，按时间从旧到新排序。
+func rotatedCaptureFiles(path string) ([]string, error) {
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// CaptureFiles 返回 path 对应的全部抓包文件（历史轮转文件在前，当前文件在后），用于按时间顺序回放。
+func CaptureFiles(path string) ([]string, error) {
+	files, err := rotatedCaptureFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no capture files at %s", path)
+	}
+	return files, nil
+}
+
+// redactQuery 把 query 中的签名/密钥参数替换为占位值，保持其余参数原样与原顺序。
+func redactQuery(raw string) string {
+	if raw == "" {
+		return raw
+	}
+	parts := strings.Split(raw, "&")
+	for i, p := range parts {
+		k := p
+		if j := strings.IndexByte(p, '='); j >= 0 {
+			k = p[:j]
+		}
+		if name, err := url.QueryUnescape(k); err == nil && captureSecretParams[strings.ToLower(name)] {
+			parts[i] = k + "=" + captureRedacted
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// redactSource 脱敏 WS 连接地址：query 中的密钥参数与 /ws/<listenKey> 形式的用户流路径。
+func redactSource(source string) string {
+	if source == "" {
+		return source
+	}
+	u, err := url.Parse(source)
+	if err != nil {
+		return captureRedacted
+	}
+	u.RawQuery = redactQuery(u.RawQuery)
+	if i := strings.Index(u.Path, "/ws/"); i >= 0 && !strings.Contains(u.Path[i+len("/ws/"):], "@") {
+		u.Path = u.Path[:i+len("/ws/")] + captureRedacted
+	}
+	return u.String()
+}
+
+// redactListenKeyResp 脱敏 listenKey 接口响应体中的 listenKey。
+func redactListenKeyResp(raw []byte) []byte {
+	var m map[string]any
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return raw
+	}
+	if _, ok := m["listenKey"]; !ok {
+		return raw
+	}
+	m["listenKey"] = captureRedacted
+	out, err := json.Marshal(m)
+	if err != nil {
+		return raw
+	}
+	return out
+}
+
+// CaptureTransport 记录经过的 REST 请求与响应：请求头不记录，query/表单中的签名与密钥、listenKey 响应脱敏。
+type CaptureTransport struct {
+	Base http.RoundTripper // 默认 http.DefaultTransport
+	W    *CaptureWriter
+}
+
+func (t *CaptureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	rec := CaptureRecord{Kind: CaptureREST, Method: req.Method, URL: req.URL.Path}
+	if q := redactQuery(req.URL.RawQuery); q != "" {
+		rec.URL += "?" + q
+	}
+	if req.GetBody != nil && req.ContentLength != 0 {
+		if body, err := req.GetBody(); err == nil {
+			raw, _ := io.ReadAll(body)
+			body.Close()
+			rec.Body = redactQuery(string(raw))
+		}
+	}
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	rec.Time = time.Now().UnixNano()
+	rec.Latency = time.Since(start).Microseconds()
+	if err != nil {
+		rec.Err = err.Error()
+		t.write(rec)
+		return nil, err
+	}
+	raw, rerr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(raw))
+	rec.Status = resp.StatusCode
+	if strings.HasSuffix(req.URL.Path, "/listenKey") {
+		rec.setPayload(redactListenKeyResp(raw))
+	} else {
+		rec.setPayload(raw)
+	}
+	if rerr != nil {
+		rec.Err = rerr.Error()
+	}
+	t.write(rec)
+	return resp, rerr
+}
+
+func (t *CaptureTransport) write(rec CaptureRecord) {
+	if err := t.W.Write(rec); err != nil {
+		log.Printf("capture rest %s %s: %v", rec.Method, rec.URL, err)
+	}
+}
+
+// WithCapture 返回 c 的副本，其 Transport 包装为 CaptureTransport；w 为 nil 时原样返回 c。
+func WithCapture(c *http.Client, w *CaptureWriter) *http.Client {
+	if w == nil {
+		return c
+	}
+	if c == nil {
+		c = NewDefaultHTTPClient()
+	}
+	cp := *c
+	cp.Transport = &CaptureTransport{Base: c.Transport, W: w}
+	return &cp
+}
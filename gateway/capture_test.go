@@ -0,0 +1,222 @@
+package gateway
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestCaptureTransportRedactsAndReplays(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fapi/v1/depth":
+			io.WriteString(w, `{"lastUpdateId":1,"bids":[["2000.10","1"]],"asks":[["2000.20","2"]]}`)
+		case "/fapi/v1/order":
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"code":-2011,"msg":"Unknown order sent."}`)
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "capture.jsonl")
+	cw, err := NewCaptureWriter(path, 0, 0)
+	if err != nil {
+		t.Fatalf("writer: %v", err)
+	}
+	cli := &BinanceRESTClient{BaseURL: ts.URL, APIKey: "key", Secret: "secret", HTTPClient: WithCapture(ts.Client(), cw), Limiter: &mockLimiter{}}
+	if bid, ask, err := cli.GetBestBidAsk("ETHUSDC", 5); err != nil || bid != 2000.1 || ask != 2000.2 {
+		t.Fatalf("best bid/ask %v/%v err=%v", bid, ask, err)
+	}
+	cancelErr := cli.CancelOrder("ETHUSDC", "42")
+	if cancelErr == nil {
+		t.Fatalf("expected cancel error")
+	}
+	cw.Close()
+
+	raw, _ := os.ReadFile(path)
+	if strings.Contains(string(raw), "secret") || strings.Contains(string(raw), "key") {
+		t.Fatalf("capture leaks credentials: %s", raw)
+	}
+	recs, err := ReadCapture(CaptureREST, path)
+	if err != nil || len(recs) != 2 {
+		t.Fatalf("records %+v err=%v", recs, err)
+	}
+	if !strings.Contains(recs[1].URL, "signature=***") || !strings.Contains(recs[1].URL, "orderId=42") || recs[1].Status != http.StatusBadRequest || recs[1].Method != http.MethodDelete {
+		t.Fatalf("unexpected cancel record %+v", recs[1])
+	}
+
+	// 回放：不访问网络，响应与错误分类和录制时一致
+	ts.Close()
+	replay := NewReplayRESTClient(recs)
+	if bid, ask, err := replay.GetBestBidAsk("ETHUSDC", 5); err != nil || bid != 2000.1 || ask != 2000.2 {
+		t.Fatalf("replayed best bid/ask %v/%v err=%v", bid, ask, err)
+	}
+	if err := replay.CancelOrder("ETHUSDC", "42"); err == nil || err.Error() != cancelErr.Error() {
+		t.Fatalf("replayed cancel err=%v, want %v", err, cancelErr)
+	}
+	// GET 用尽后重复最后一条；其余方法用尽返回错误
+	if _, _, err := replay.GetBestBidAsk("ETHUSDC", 5); err != nil {
+		t.Fatalf("repeated GET: %v", err)
+	}
+	if err := replay.CancelOrder("ETHUSDC", "42"); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Fatalf("expected exhausted replay error, got %v", err)
+	}
+}
+
+func TestCaptureWriterRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.jsonl")
+	cw, err := NewCaptureWriter(path, 200, 2)
+	if err != nil {
+		t.Fatalf("writer: %v", err)
+	}
+	clock := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	cw.now = func() time.Time { clock = clock.Add(time.Millisecond); return clock }
+	for i := 0; i < 20; i++ {
+		cw.RecordWS("ws://test", []byte(`{"stream":"ethusdc@aggTrade","data":{"a":`+string(rune('0'+i%10))+`}}`))
+	}
+	cw.Close()
+	files, err := CaptureFiles(path)
+	if err != nil {
+		t.Fatalf("files: %v", err)
+	}
+	if len(files) != 3 || files[2] != path {
+		t.Fatalf("expected 2 rotated files + current, got %v", files)
+	}
+	recs, err := ReadCapture("", files...)
+	if err != nil || len(recs) == 0 {
+		t.Fatalf("read: %v", err)
+	}
+	for i := 1; i < len(recs); i++ {
+		if recs[i].Time <= recs[i-1].Time {
+			t.Fatalf("records out of order at %d", i)
+		}
+	}
+	if recs[len(recs)-1].Time != clock.UnixNano() {
+		t.Fatalf("last record lost after rotation")
+	}
+}
+
+func TestReplayWSReproducesCapturedSession(t *testing.T) {
+	srv := newFakeStreamServer(t)
+	path := filepath.Join(t.TempDir(), "capture.jsonl")
+	cw, err := NewCaptureWriter(path, 0, 0)
+	if err != nil {
+		t.Fatalf("writer: %v", err)
+	}
+	ws := NewBinanceWSReal()
+	ws.BaseEndpoint = "ws://" + strings.TrimPrefix(srv.URL, "http://")
+	ws.Reconnect = WSReconnectConfig{InitialDelay: 10 * time.Millisecond, MaxDelay: 20 * time.Millisecond, BackoffFactor: 2, PongWait: 5 * time.Second, WriteWait: time.Second}
+	ws.DepthSilence = 0
+	ws.MarkPriceSilence = 0
+	ws.Capture = cw
+	ws.SubscribeDepth("ETHUSDC")
+	live := &rawCollector{}
+	go ws.Run(live)
+
+	frames := []string{
+		`{"stream":"ethusdc@depth@100ms","data":{"e":"depthUpdate","U":1,"u":2}}`,
+		`{"result":null,"id":7}`,
+		`{"stream":"ethusdc@depth@100ms","data":{"e":"depthUpdate","U":3,"u":4}}`,
+	}
+	conn := srv.next(t)
+	conn.WriteMessage(websocket.TextMessage, []byte(frames[0]))
+	conn.WriteMessage(websocket.TextMessage, []byte(frames[1]))
+	eventually(t, func() bool { live.mu.Lock(); defer live.mu.Unlock(); return len(live.msgs) == 1 })
+	conn.Close() // 断线重连产生 GapReconnect
+	conn = srv.next(t)
+	time.Sleep(100 * time.Millisecond)
+	conn.WriteMessage(websocket.TextMessage, []byte(frames[2]))
+	eventually(t, func() bool { live.mu.Lock(); defer live.mu.Unlock(); return len(live.msgs) == 2 })
+	ws.Stop()
+	cw.Close()
+
+	replay := NewReplayWS(0, path)
+	var gaps []WSGap
+	replay.OnGap(func(g WSGap) { gaps = append(gaps, g) })
+	got := &rawCollector{}
+	if err := replay.Run(got); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if len(got.msgs) != 2 || got.msgs[0] != frames[0] || got.msgs[1] != frames[2] {
+		t.Fatalf("replayed frames %v", got.msgs)
+	}
+	if len(gaps) != 1 || gaps[0].Kind != StreamDepth || gaps[0].Reason != GapReconnect {
+		t.Fatalf("replayed gaps %+v", gaps)
+	}
+
+	// 原速回放保留录制时的间隔（两帧间隔 >=100ms），4 倍速约为四分之一
+	start := time.Now()
+	if err := NewReplayWS(4, path).Run(&rawCollector{}); err != nil {
+		t.Fatalf("paced replay: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 25*time.Millisecond {
+		t.Fatalf("paced replay too fast: %s", elapsed)
+	}
+}
+
+func TestReplayWSStops(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.jsonl")
+	cw, _ := NewCaptureWriter(path, 0, 0)
+	cw.Write(CaptureRecord{Time: 1, Kind: CaptureWS, Data: []byte(`{"stream":"a","data":{}}`)})
+	cw.Write(CaptureRecord{Time: int64(time.Hour), Kind: CaptureWS, Data: []byte(`{"stream":"a","data":{}}`)})
+	cw.Close()
+	r := NewReplayWS(1, path)
+	done := make(chan error, 1)
+	go func() { done <- r.Run(&rawCollector{}) }()
+	eventually(t, func() bool { return r.Frames() == 1 })
+	r.Stop()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("replay did not stop")
+	}
+	if _, err := NewCaptureReader(filepath.Join(t.TempDir(), "missing")).Next(); err == nil || errors.Is(err, io.EOF) {
+		t.Fatalf("expected open error, got %v", err)
+	}
+}
+
+func TestCaptureRedactsListenKey(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"listenKey":"lk-secret-123"}`)
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "capture.jsonl")
+	cw, err := NewCaptureWriter(path, 0, 0)
+	if err != nil {
+		t.Fatalf("writer: %v", err)
+	}
+	lk := &ListenKeyClient{BaseURL: ts.URL, APIKey: "key", HTTPClient: WithCapture(ts.Client(), cw)}
+	if key, err := lk.NewListenKey(); err != nil || key != "lk-secret-123" {
+		t.Fatalf("listenKey %q err=%v", key, err)
+	}
+	if err := lk.KeepAlive("lk-secret-123"); err != nil {
+		t.Fatalf("keepalive: %v", err)
+	}
+	cw.RecordWS("wss://fstream.binance.com/ws/lk-secret-123", []byte(`{"e":"ORDER_TRADE_UPDATE"}`))
+	cw.RecordWS("wss://fstream.binance.com/stream?listenKey=lk-secret-123", []byte(`{}`))
+	cw.RecordWS("wss://fstream.binance.com/ws/ethusdc@depth", []byte(`{}`))
+	cw.RecordGap(WSGap{Stream: "lk-secret-123", Kind: StreamUserData, Reason: GapReconnect, Since: time.Now()})
+	cw.Close()
+
+	raw, _ := os.ReadFile(path)
+	if strings.Contains(string(raw), "lk-secret") {
+		t.Fatalf("capture leaks listenKey: %s", raw)
+	}
+	if !strings.Contains(string(raw), "ethusdc@depth") {
+		t.Fatalf("public stream source should be kept: %s", raw)
+	}
+}
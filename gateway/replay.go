@@ -0,0 +1,311 @@
+package gateway
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CaptureReader 按顺序读取一个或多个抓包文件。
+type CaptureReader struct {
+	files []string
+	f     *os.File
+	r     *bufio.Reader
+}
+
+// NewCaptureReader 依次读取 paths（通常来自 CaptureFiles）。
+func NewCaptureReader(paths ...string) *CaptureReader {
+	return &CaptureReader{files: paths}
+}
+
+// Next 返回下一条记录，全部读完时返回 io.EOF。
+func (r *CaptureReader) Next() (CaptureRecord, error) {
+	for {
+		if r.r == nil {
+			if len(r.files) == 0 {
+				return CaptureRecord{}, io.EOF
+			}
+			f, err := os.Open(r.files[0])
+			if err != nil {
+				return CaptureRecord{}, fmt.Errorf("open capture: %w", err)
+			}
+			r.f, r.r = f, bufio.NewReaderSize(f, 64<<10)
+			r.files = r.files[1:]
+		}
+		line, err := r.r.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			var rec CaptureRecord
+			if derr := json.Unmarshal(line, &rec); derr != nil {
+				return CaptureRecord{}, fmt.Errorf("decode capture %s: %w", r.f.Name(), derr)
+			}
+			return rec, nil
+		}
+		if err == io.EOF {
+			r.f.Close()
+			r.f, r.r = nil, nil
+			continue
+		}
+		if err != nil {
+			return CaptureRecord{}, err
+		}
+	}
+}
+
+// Close 关闭当前文件。
+func (r *CaptureReader) Close() error {
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f, r.r = nil, nil
+	return err
+}
+
+// ReadCapture 读取 paths 中指定类型的全部记录，kind 为空时返回全部。
+func ReadCapture(kind string, paths ...string) ([]CaptureRecord, error) {
+	r := NewCaptureReader(paths...)
+	defer r.Close()
+	var out []CaptureRecord
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if kind == "" || rec.Kind == kind {
+			out = append(out, rec)
+		}
+	}
+}
+
+// ReplayTransport 以抓包中的 REST 响应应答请求，不访问网络。按 method+path 依次取用录制顺序中的
+// 下一条响应（query 中的 timestamp/signature 每次不同，不参与匹配）；GET 用尽后重复最后一条，
+// 其余方法用尽时返回 404 与 code=-1 的错误体。
+type ReplayTransport struct {
+	mu     sync.Mutex
+	queues map[string][]CaptureRecord
+	last   map[string]CaptureRecord
+}
+
+// NewReplayTransport 由 REST 记录构建应答队列，非 REST 记录被忽略。
+func NewReplayTransport(records []CaptureRecord) *ReplayTransport {
+	t := &ReplayTransport{queues: make(map[string][]CaptureRecord), last: make(map[string]CaptureRecord)}
+	for _, rec := range records {
+		if rec.Kind != CaptureREST {
+			continue
+		}
+		path := rec.URL
+		if i := strings.IndexByte(path, '?'); i >= 0 {
+			path = path[:i]
+		}
+		key := rec.Method + " " + path
+		t.queues[key] = append(t.queues[key], rec)
+	}
+	return t
+}
+
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	key := req.Method + " " + req.URL.Path
+	t.mu.Lock()
+	rec, ok := t.next(key, req.Method)
+	t.mu.Unlock()
+	if !ok {
+		body := fmt.Sprintf(`{"code":-1,"msg":"replay: no recorded response for %s"}`, key)
+		return replayResponse(req, http.StatusNotFound, []byte(body)), nil
+	}
+	if rec.Err != "" {
+		return nil, fmt.Errorf("replay: %s", rec.Err)
+	}
+	return replayResponse(req, rec.Status, rec.Payload()), nil
+}
+
+func (t *ReplayTransport) next(key, method string) (CaptureRecord, bool) {
+	if q := t.queues[key]; len(q) > 0 {
+		t.queues[key] = q[1:]
+		t.last[key] = q[0]
+		return q[0], true
+	}
+	if method == http.MethodGet {
+		rec, ok := t.last[key]
+		return rec, ok
+	}
+	return CaptureRecord{}, false
+}
+
+// Remaining 返回尚未取用的录制响应数。
+func (t *ReplayTransport) Remaining() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := 0
+	for _, q := range t.queues {
+		n += len(q)
+	}
+	return n
+}
+
+func replayResponse(req *http.Request, status int, body []byte) *http.Response {
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// NewReplayRESTClient 返回以录制响应应答的 BinanceRESTClient（实现 BinanceREST 及各查询接口）。
+func NewReplayRESTClient(records []CaptureRecord) *BinanceRESTClient {
+	return &BinanceRESTClient{
+		BaseURL:    "http://replay",
+		APIKey:     "replay",
+		Secret:     "replay",
+		HTTPClient: &http.Client{Transport: NewReplayTransport(records)},
+		MaxRetries: 1,
+		Precision:  NewSymbolPrecision(),
+	}
+}
+
+// ReplayWS 按录制时的时间间隔把抓包中的 WS 帧回放给 handler，实现 WSSession，可替代 BinanceWSReal。
+// 订阅在录制时已确定，Subscribe* 仅为满足接口；录制的数据缺口按原时序经 OnGap 通知。
+type ReplayWS struct {
+	Paths []string
+	// Speed 回放倍速：1 为原速，>1 加速，<=0 不等待、尽快回放。
+	Speed float64
+
+	mu           sync.Mutex
+	stopCh       chan struct{}
+	stopOnce     sync.Once
+	frames       int
+	onConnect    func()
+	onDisconnect func(error)
+	onGap        func(WSGap)
+}
+
+// NewReplayWS 创建回放源，paths 通常来自 CaptureFiles。
+func NewReplayWS(speed float64, paths ...string) *ReplayWS {
+	return &ReplayWS{Paths: paths, Speed: speed}
+}
+
+func (r *ReplayWS) SubscribeDepth(symbol string) error       { return nil }
+func (r *ReplayWS) SubscribeTrade(symbol string) error       { return nil }
+func (r *ReplayWS) SubscribeMarkPrice(symbol string) error   { return nil }
+func (r *ReplayWS) SubscribeUserData(listenKey string) error { return nil }
+func (r *ReplayWS) OnConnect(cb func())                      { r.onConnect = cb }
+func (r *ReplayWS) OnDisconnect(cb func(error))              { r.onDisconnect = cb }
+func (r *ReplayWS) OnGap(cb func(WSGap))                     { r.onGap = cb }
+func (r *ReplayWS) Stop()                                    { r.stopOnce.Do(func() { close(r.stopChan()) }) }
+
+// Frames 返回已回放的帧数。
+func (r *ReplayWS) Frames() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.frames
+}
+
+func (r *ReplayWS) stopChan() chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopCh == nil {
+		r.stopCh = make(chan struct{})
+	}
+	return r.stopCh
+}
+
+// Run 阻塞回放至抓包结束或 Stop，均返回 nil；读取或解码失败时返回错误。
+// 订阅应答等非推送帧与 BinanceWSReal 一样不交给 handler。
+func (r *ReplayWS) Run(handler WSHandler) error {
+	stop := r.stopChan()
+	reader := NewCaptureReader(r.Paths...)
+	defer reader.Close()
+	raw, rawOK := handler.(interface{ OnRawMessage([]byte) })
+	if r.onConnect != nil {
+		r.onConnect()
+	}
+	var first int64
+	var started time.Time
+	for {
+		rec, err := reader.Next()
+		if err == io.EOF {
+			if r.onDisconnect != nil {
+				r.onDisconnect(io.EOF)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if rec.Kind != CaptureWS && rec.Kind != CaptureGap {
+			continue
+		}
+		if first == 0 {
+			first, started = rec.Time, time.Now()
+		} else if r.Speed > 0 {
+			due := started.Add(time.Duration(float64(rec.Time-first) / r.Speed))
+			if wait := time.Until(due); wait > 0 && !sleepOrStop(stop, wait) {
+				return nil
+			}
+		}
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+		if rec.Kind == CaptureGap {
+			r.replayGap(rec)
+			continue
+		}
+		msg := rec.Payload()
+		if isWSReply(msg) {
+			continue
+		}
+		r.mu.Lock()
+		r.frames++
+		r.mu.Unlock()
+		if rawOK {
+			raw.OnRawMessage(msg)
+		}
+	}
+}
+
+func (r *ReplayWS) replayGap(rec CaptureRecord) {
+	if r.onGap == nil {
+		return
+	}
+	var g captureGap
+	if err := json.Unmarshal(rec.Data, &g); err != nil {
+		return
+	}
+	r.onGap(WSGap{Stream: rec.Source, Kind: WSStreamKind(g.Kind), Reason: g.Reason, Since: time.Unix(0, g.Since)})
+}
+
+// isWSReply 判断是否为 SUBSCRIBE 等请求的应答（带 id、无 stream）。
+func isWSReply(msg []byte) bool {
+	var head struct {
+		Stream string `json:"stream"`
+		ID     *int64 `json:"id"`
+	}
+	if err := json.Unmarshal(msg, &head); err != nil {
+		return false
+	}
+	return head.Stream == "" && head.ID != nil
+}
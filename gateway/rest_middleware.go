@@ -3,7 +3,6 @@ package gateway
 import (
 	"fmt"
 	"net/http"
-	"strings"
 	"time"
 )
 
@@ -56,9 +55,7 @@ func (m *RESTMiddleware) ExecuteWithRetry(method, endpoint string, headers map[s
 
 	for attempt := 0; attempt < m.retryLogic.MaxAttempts; attempt++ {
 		// 等待限流器
-		if m.client.Limiter != nil {
-			m.client.Limiter.Wait()
-		}
+		m.client.waitLimit(method, endpoint)
 
 		// 执行请求
 		req, err := http.NewRequest(method, endpoint, nil)
@@ -80,6 +77,7 @@ func (m *RESTMiddleware) ExecuteWithRetry(method, endpoint string, headers map[s
 			}
 			continue
 		}
+		m.client.observeLimit(resp)
 
 		// 检查是否需要重试
 		if m.shouldRetry(resp.StatusCode) {
@@ -148,8 +146,12 @@ func (m *RESTMiddleware) calculateNextDelay(currentDelay time.Duration) time.Dur
 	return nextDelay
 }
 
-// ClassifyError 分类 REST 错误
+// ClassifyError 分类 REST 错误：优先按响应体中的错误码查目录，其次按 HTTP 状态归类。
 func ClassifyError(statusCode int, body string) ErrorType {
+	return ParseAPIError("", "", statusCode, []byte(body)).Type()
+}
+
+func classifyStatus(statusCode int) ErrorType {
 	switch {
 	case statusCode == 401 || statusCode == 403:
 		return ErrorTypeAuth
@@ -158,16 +160,6 @@ func ClassifyError(statusCode int, body string) ErrorType {
 	case statusCode >= 500:
 		return ErrorTypeServer
 	case statusCode >= 400 && statusCode < 500:
-		// 检查特定错误码
-		if strings.Contains(body, "-1021") { // Timestamp outside of recvWindow
-			return ErrorTypeAuth
-		}
-		if strings.Contains(body, "-5022") { // Post-only reject
-			return ErrorTypePostOnlyReject
-		}
-		if strings.Contains(body, "-2010") { // Insufficient balance
-			return ErrorTypeInsufficientBalance
-		}
 		return ErrorTypeClient
 	default:
 		return ErrorTypeUnknown
@@ -185,6 +177,7 @@ const (
 	ErrorTypeClient
 	ErrorTypePostOnlyReject
 	ErrorTypeInsufficientBalance
+	ErrorTypeTimestamp // -1021：本地时钟与交易所偏差超出 recvWindow，需重新校时后重新签名
 )
 
 // String 返回错误类型字符串
@@ -202,6 +195,8 @@ func (e ErrorType) String() string {
 		return "postonly_reject"
 	case ErrorTypeInsufficientBalance:
 		return "insufficient_balance"
+	case ErrorTypeTimestamp:
+		return "timestamp_error"
 	default:
 		return "unknown"
 	}
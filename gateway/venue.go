@@ -0,0 +1,161 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+
+	"market-maker-go/market"
+	"market-maker-go/order"
+)
+
+// Venue 与交易所无关的永续合约接入抽象：行情、下单、用户事件、账户/仓位查询与交易对元数据。
+// 约定：交易对使用交易所原生写法（如 ETHUSDC、ETHUSDT），方向为 BUY/SELL，
+// 本地订单 ID 作为交易所 clientOrderId 下发，查询与推送均以它关联本地订单；各交易所特有参数由适配器内部映射。
+// 目前 cmd/runner 只用它做 exchangeInfo、REST 兜底行情与对账查询，下单与行情/用户流仍直连 Binance 客户端。
+type Venue interface {
+	// Name 交易所标识，如 binance、bybit。
+	Name() string
+
+	// Instruments 查询交易对元数据（tick/step/最小下单量等），symbol 为空时返回全部。
+	Instruments(symbol string) ([]ExchangeSymbolInfo, error)
+	// BestBidAsk 以 REST 查询当前最优买卖价，用于推送中断时兜底。
+	BestBidAsk(symbol string) (bid, ask float64, err error)
+
+	// PlaceOrder 按 order.Order 下单（Type 为空按 LIMIT），返回交易所订单号。
+	PlaceOrder(o order.Order) (exchangeID string, err error)
+	// CancelOrder 按交易所订单号撤单。
+	CancelOrder(symbol, exchangeID string) error
+	// CancelAll 撤销交易对全部挂单。
+	CancelAll(symbol string) error
+
+	// GetOrder 按本地订单 ID 查询，查无此单时返回的错误满足 errors.Is(err, ErrOrderNotFound)。
+	GetOrder(symbol, clientOrderID string) (*order.Order, error)
+	// OpenOrders 查询交易对当前挂单。
+	OpenOrders(symbol string) ([]*order.Order, error)
+	// Positions 查询仓位，PositionAmt 带符号（空头为负），PositionSide 为 BOTH/LONG/SHORT。
+	Positions(symbol string) ([]FuturesPosition, error)
+	// Balances 查询保证金资产余额。
+	Balances() ([]FuturesBalance, error)
+
+	// Stream 订阅 symbol 的行情与账户推送并阻塞至 ctx 结束；首次建连失败时返回错误，
+	// 之后断线自动重连，丢失的推送通过 VenueHandler.OnGap 通知调用方按 REST 对账。
+	Stream(ctx context.Context, symbol string, h VenueHandler) error
+}
+
+// VenueOrderEvent 归一化的订单推送。成交事件中 LastQty>0，Status 为 PARTIAL/FILLED。
+type VenueOrderEvent struct {
+	Venue         string
+	Symbol        string
+	ClientOrderID string
+	ExchangeID    string
+	Side          string
+	PositionSide  string
+	Status        order.Status
+	Price         float64
+	Qty           float64
+	CumQty        float64
+	LastQty       float64
+	LastPrice     float64
+	TradeID       string  // 成交 ID，用于去重；无成交时为空
+	Fee           float64 // 手续费（正数为支出）
+	FeeAsset      string
+	RealizedPnL   float64
+	Maker         bool
+	Time          int64 // 毫秒
+	// RawStatus 交易所原始状态，便于日志排查。
+	RawStatus string
+}
+
+// VenueHandler 归一化推送回调，字段均可为空。
+type VenueHandler struct {
+	// OnBook 最优买卖价变化。
+	OnBook func(symbol string, bid, ask float64)
+	// OnTrade 公开成交（IsBuy 为主动方向）。
+	OnTrade func(market.Trade)
+	// OnOrder 本账户订单状态/成交。
+	OnOrder func(VenueOrderEvent)
+	// OnPosition 本账户仓位变化。
+	OnPosition func(FuturesPosition)
+	// OnGap 推送缺口（重连、静默或用户流凭证失效），调用方应按 REST 对账重建状态。
+	OnGap func(WSGap)
+}
+
+func (h VenueHandler) book(symbol string, bid, ask float64) {
+	if h.OnBook != nil {
+		h.OnBook(symbol, bid, ask)
+	}
+}
+
+func (h VenueHandler) trade(t market.Trade) {
+	if h.OnTrade != nil {
+		h.OnTrade(t)
+	}
+}
+
+func (h VenueHandler) order(ev VenueOrderEvent) {
+	if h.OnOrder != nil {
+		h.OnOrder(ev)
+	}
+}
+
+func (h VenueHandler) position(p FuturesPosition) {
+	if h.OnPosition != nil {
+		h.OnPosition(p)
+	}
+}
+
+func (h VenueHandler) gap(g WSGap) {
+	if h.OnGap != nil {
+		h.OnGap(g)
+	}
+}
+
+// OrderQueryAdapter 把 Venue 适配为 order.ExchangeGateway，供 order.NewReconciler 对账。
+// 本地订单 ID 即下单时的 clientOrderId，按它向交易所查询。
+type OrderQueryAdapter struct {
+	Venue Venue
+	// Symbol 默认交易对；SymbolOf 非 nil 且返回非空时以其为准（多交易对场景按本地订单查找）。
+	Symbol   string
+	SymbolOf func(orderID string) string
+}
+
+// NewOrderQueryAdapter 创建单交易对的对账适配器。
+func NewOrderQueryAdapter(venue Venue, symbol string) *OrderQueryAdapter {
+	return &OrderQueryAdapter{Venue: venue, Symbol: symbol}
+}
+
+// GetOrder 实现 order.ExchangeGateway；查无此单时返回 order.ErrRemoteOrderNotFound。
+func (a *OrderQueryAdapter) GetOrder(orderID string) (*order.Order, error) {
+	symbol := a.Symbol
+	if a.SymbolOf != nil {
+		if s := a.SymbolOf(orderID); s != "" {
+			symbol = s
+		}
+	}
+	o, err := a.Venue.GetOrder(symbol, orderID)
+	if err != nil {
+		return nil, err
+	}
+	o.ID = orderID
+	return o, nil
+}
+
+// CancelRemote 实现 order.RemoteCanceler：按交易所订单号撤销本地未登记的挂单。
+func (a *OrderQueryAdapter) CancelRemote(o *order.Order) error {
+	if o.ExchangeID == "" {
+		return fmt.Errorf("cancel %s: exchange order id unknown", o.ID)
+	}
+	symbol := o.Symbol
+	if symbol == "" {
+		symbol = a.Symbol
+	}
+	return a.Venue.CancelOrder(symbol, o.ExchangeID)
+}
+
+// GetOpenOrders 实现 order.ExchangeGateway；symbol 为空时使用默认交易对。
+func (a *OrderQueryAdapter) GetOpenOrders(symbol string) ([]*order.Order, error) {
+	if symbol == "" {
+		symbol = a.Symbol
+	}
+	return a.Venue.OpenOrders(symbol)
+}
@@ -0,0 +1,362 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Binance USDⓈ-M 默认限额（以 exchangeInfo.rateLimits 为准）。
+const (
+	DefaultMaxWeight1M  = 2400
+	DefaultMaxOrders10S = 300
+	DefaultMaxOrders1M  = 1200
+)
+
+// RequestCost 为单次 REST 请求的限流成本：IP 权重与下单计数（X-MBX-ORDER-COUNT-*）。
+// Cancel 为 true 的请求（撤单）走优先通道，并可使用为撤单预留的额度。
+type RequestCost struct {
+	Weight int
+	Orders int
+	Cancel bool
+}
+
+// CostLimiter 为 RateLimiter 的可选扩展：按请求成本排队，并用响应头校准已用额度。
+// BinanceRESTClient.sendWithRetry 与 RESTMiddleware.ExecuteWithRetry 检测到该接口时使用。
+type CostLimiter interface {
+	RateLimiter
+	WaitCost(cost RequestCost)
+	ObserveResponse(resp *http.Response)
+}
+
+// RateBudget 为剩余额度快照；Ratio 为三项中剩余比例的最小值（0~1）。
+type RateBudget struct {
+	Weight1M   int
+	Orders10S  int
+	Orders1M   int
+	Ratio      float64
+	BannedTill time.Time
+}
+
+// EndpointCost 返回 Binance 合约 REST 接口的请求成本（权重表摘自官方文档，未列出的接口按 1 计）。
+func EndpointCost(method, path string, query url.Values) RequestCost {
+	switch path {
+	case "/fapi/v1/order":
+		switch method {
+		case http.MethodPost:
+			return RequestCost{Weight: 0, Orders: 1}
+		case http.MethodPut:
+			return RequestCost{Weight: 1, Orders: 1}
+		case http.MethodDelete:
+			return RequestCost{Weight: 1, Cancel: true}
+		}
+		return RequestCost{Weight: 1}
+	case "/fapi/v1/batchOrders":
+		switch method {
+		case http.MethodPost, http.MethodPut:
+			// 批量中的每一笔都计入下单数；无法解析时按单批上限 5 笔计
+			orders := MaxBatchPlaceOrders
+			var items []json.RawMessage
+			if raw := query.Get("batchOrders"); raw != "" && json.Unmarshal([]byte(raw), &items) == nil && len(items) > 0 {
+				orders = len(items)
+			}
+			return RequestCost{Weight: 5, Orders: orders}
+		case http.MethodDelete:
+			return RequestCost{Weight: 1, Cancel: true}
+		}
+	case "/fapi/v1/allOpenOrders":
+		if method == http.MethodDelete {
+			return RequestCost{Weight: 1, Cancel: true}
+		}
+	case "/fapi/v1/depth":
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		switch {
+		case limit <= 0: // 缺省 500 档
+			return RequestCost{Weight: 10}
+		case limit <= 50:
+			return RequestCost{Weight: 2}
+		case limit <= 100:
+			return RequestCost{Weight: 5}
+		case limit <= 500:
+			return RequestCost{Weight: 10}
+		default:
+			return RequestCost{Weight: 20}
+		}
+	case "/fapi/v1/openOrders":
+		if query.Get("symbol") == "" {
+			return RequestCost{Weight: 40}
+		}
+	case "/fapi/v2/account", "/fapi/v2/balance", "/fapi/v2/positionRisk", "/fapi/v1/userTrades":
+		return RequestCost{Weight: 5}
+	case "/fapi/v1/income", "/fapi/v1/positionSide/dual":
+		if method == http.MethodGet {
+			return RequestCost{Weight: 30}
+		}
+	}
+	return RequestCost{Weight: 1}
+}
+
+// WeightLimiter 按 Binance 的固定窗口（1m 权重、10s/1m 下单数）记账：
+//   - 请求前按 EndpointCost 预扣额度，不足时等待到窗口重置；
+//   - 每个响应用 X-MBX-USED-WEIGHT-1M / X-MBX-ORDER-COUNT-10S / X-MBX-ORDER-COUNT-1M 校准为服务端值；
+//   - 新单只能使用 (1-CancelReserve) 比例的额度，撤单可用满额度；
+//   - 撤单不经 Pacer，且有撤单在排队时新单一律让行，直到撤单全部放行；
+//   - 收到 429/418 时按 Retry-After 暂停所有请求。
+type WeightLimiter struct {
+	MaxWeight1M  int
+	MaxOrders10S int
+	MaxOrders1M  int
+	// CancelReserve 为撤单预留的额度比例，默认 0.1。
+	CancelReserve float64
+	// Pacer 可选，在权重记账之前按请求数平滑突发（例如 TokenBucketLimiter）。
+	Pacer RateLimiter
+	// OnObserve 每次按响应头校准后回调，用于上报剩余额度指标。
+	OnObserve func(RateBudget)
+	// now 可注入时钟，便于测试。
+	now func() time.Time
+
+	mu          sync.Mutex
+	weight      windowCount
+	orders10s   windowCount
+	orders1m    windowCount
+	bannedUntil time.Time
+	// cancelWaiting 正在排队的撤单数，cancelIdle 在其归零时关闭以唤醒让行的新单
+	cancelWaiting int
+	cancelIdle    chan struct{}
+}
+
+// windowCount 为固定窗口内的计数。
+type windowCount struct {
+	start time.Time
+	used  int
+}
+
+func (w *windowCount) roll(now time.Time, size time.Duration) {
+	if start := now.Truncate(size); !start.Equal(w.start) {
+		w.start = start
+		w.used = 0
+	}
+}
+
+// NewWeightLimiter 创建权重限流器；参数 <= 0 时使用 Binance 默认限额。
+func NewWeightLimiter(maxWeight1M, maxOrders10S, maxOrders1M int) *WeightLimiter {
+	if maxWeight1M <= 0 {
+		maxWeight1M = DefaultMaxWeight1M
+	}
+	if maxOrders10S <= 0 {
+		maxOrders10S = DefaultMaxOrders10S
+	}
+	if maxOrders1M <= 0 {
+		maxOrders1M = DefaultMaxOrders1M
+	}
+	return &WeightLimiter{
+		MaxWeight1M:   maxWeight1M,
+		MaxOrders10S:  maxOrders10S,
+		MaxOrders1M:   maxOrders1M,
+		CancelReserve: 0.1,
+		now:           time.Now,
+	}
+}
+
+// Wait 满足 RateLimiter，按权重 1 计。
+func (l *WeightLimiter) Wait() {
+	l.WaitCost(RequestCost{Weight: 1})
+}
+
+// WaitCost 阻塞直到额度足够并预扣 cost；撤单优先于排队中的新单。
+func (l *WeightLimiter) WaitCost(cost RequestCost) {
+	if cost.Cancel {
+		l.beginCancel()
+		defer l.endCancel()
+	} else if l.Pacer != nil {
+		l.Pacer.Wait()
+	}
+	for {
+		wait, wake := l.acquire(cost)
+		if wait <= 0 {
+			return
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-wake:
+		}
+		t.Stop()
+	}
+}
+
+func (l *WeightLimiter) beginCancel() {
+	l.mu.Lock()
+	l.cancelWaiting++
+	l.mu.Unlock()
+}
+
+func (l *WeightLimiter) endCancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cancelWaiting--
+	if l.cancelWaiting <= 0 && l.cancelIdle != nil {
+		close(l.cancelIdle)
+		l.cancelIdle = nil
+	}
+}
+
+// tryAcquire 额度足够时预扣并返回 0，否则返回建议等待时长。
+func (l *WeightLimiter) tryAcquire(cost RequestCost) time.Duration {
+	wait, _ := l.acquire(cost)
+	return wait
+}
+
+// acquire 同 tryAcquire；新单因撤单排队而让行时另返回撤单清空时关闭的通道。
+func (l *WeightLimiter) acquire(cost RequestCost) (time.Duration, <-chan struct{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !cost.Cancel && l.cancelWaiting > 0 {
+		if l.cancelIdle == nil {
+			l.cancelIdle = make(chan struct{})
+		}
+		return time.Second, l.cancelIdle
+	}
+	return l.acquireLocked(cost), nil
+}
+
+func (l *WeightLimiter) acquireLocked(cost RequestCost) time.Duration {
+	now := l.now()
+	if now.Before(l.bannedUntil) {
+		return l.bannedUntil.Sub(now)
+	}
+	l.rollLocked(now)
+	share := 1 - l.CancelReserve
+	if cost.Cancel || share <= 0 || share > 1 {
+		share = 1
+	}
+	if cost.Weight > 0 && float64(l.weight.used+cost.Weight) > share*float64(l.MaxWeight1M) && l.weight.used > 0 {
+		return l.weight.start.Add(time.Minute).Sub(now) + time.Millisecond
+	}
+	if cost.Orders > 0 {
+		if float64(l.orders10s.used+cost.Orders) > share*float64(l.MaxOrders10S) && l.orders10s.used > 0 {
+			return l.orders10s.start.Add(10*time.Second).Sub(now) + time.Millisecond
+		}
+		if float64(l.orders1m.used+cost.Orders) > share*float64(l.MaxOrders1M) && l.orders1m.used > 0 {
+			return l.orders1m.start.Add(time.Minute).Sub(now) + time.Millisecond
+		}
+	}
+	l.weight.used += cost.Weight
+	l.orders10s.used += cost.Orders
+	l.orders1m.used += cost.Orders
+	return 0
+}
+
+func (l *WeightLimiter) rollLocked(now time.Time) {
+	l.weight.roll(now, time.Minute)
+	l.orders10s.roll(now, 10*time.Second)
+	l.orders1m.roll(now, time.Minute)
+}
+
+// ObserveResponse 用响应头校准已用额度；429/418 时按 Retry-After（缺省 429 为 1s、418 为 2min）暂停。
+func (l *WeightLimiter) ObserveResponse(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	l.observe(resp)
+	if l.OnObserve != nil {
+		l.OnObserve(l.Remaining())
+	}
+}
+
+func (l *WeightLimiter) observe(resp *http.Response) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.rollLocked(now)
+	if v, ok := headerInt(resp.Header, "X-MBX-USED-WEIGHT-1M"); ok {
+		l.weight.used = v
+	}
+	if v, ok := headerInt(resp.Header, "X-MBX-ORDER-COUNT-10S"); ok {
+		l.orders10s.used = v
+	}
+	if v, ok := headerInt(resp.Header, "X-MBX-ORDER-COUNT-1M"); ok {
+		l.orders1m.used = v
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == 418 {
+		wait := time.Second
+		if resp.StatusCode == 418 {
+			wait = 2 * time.Minute
+		}
+		if v, ok := headerInt(resp.Header, "Retry-After"); ok && v > 0 {
+			wait = time.Duration(v) * time.Second
+		}
+		if until := now.Add(wait); until.After(l.bannedUntil) {
+			l.bannedUntil = until
+		}
+	}
+}
+
+// Remaining 返回当前窗口的剩余额度。
+func (l *WeightLimiter) Remaining() RateBudget {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.rollLocked(now)
+	b := RateBudget{
+		Weight1M:   l.MaxWeight1M - l.weight.used,
+		Orders10S:  l.MaxOrders10S - l.orders10s.used,
+		Orders1M:   l.MaxOrders1M - l.orders1m.used,
+		BannedTill: l.bannedUntil,
+	}
+	b.Ratio = minRatio(b.Weight1M, l.MaxWeight1M)
+	if r := minRatio(b.Orders10S, l.MaxOrders10S); r < b.Ratio {
+		b.Ratio = r
+	}
+	if r := minRatio(b.Orders1M, l.MaxOrders1M); r < b.Ratio {
+		b.Ratio = r
+	}
+	if now.Before(l.bannedUntil) {
+		b.Ratio = 0
+	}
+	return b
+}
+
+// RemainingRatio 返回剩余额度比例（0~1），供 runner 在接近上限时放慢报价。
+func (l *WeightLimiter) RemainingRatio() float64 {
+	return l.Remaining().Ratio
+}
+
+func minRatio(remaining, max int) float64 {
+	if max <= 0 {
+		return 1
+	}
+	r := float64(remaining) / float64(max)
+	if r < 0 {
+		return 0
+	}
+	if r > 1 {
+		return 1
+	}
+	return r
+}
+
+func headerInt(h http.Header, key string) (int, bool) {
+	raw := strings.TrimSpace(h.Get(key))
+	if raw == "" {
+		return 0, false
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// requestCost 解析请求 URL 计算成本。
+func requestCost(method, endpoint string) RequestCost {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return RequestCost{Weight: 1}
+	}
+	return EndpointCost(method, u.Path, u.Query())
+}
@@ -0,0 +1,158 @@
+package gateway
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEndpointCost(t *testing.T) {
+	cases := []struct {
+		method, path string
+		query        url.Values
+		want         RequestCost
+	}{
+		{http.MethodGet, "/fapi/v1/depth", url.Values{"limit": {"50"}}, RequestCost{Weight: 2}},
+		{http.MethodGet, "/fapi/v1/depth", url.Values{"limit": {"1000"}}, RequestCost{Weight: 20}},
+		{http.MethodGet, "/fapi/v1/depth", nil, RequestCost{Weight: 10}},
+		{http.MethodPost, "/fapi/v1/order", nil, RequestCost{Weight: 0, Orders: 1}},
+		{http.MethodDelete, "/fapi/v1/order", nil, RequestCost{Weight: 1, Cancel: true}},
+		{http.MethodPost, "/fapi/v1/batchOrders", nil, RequestCost{Weight: 5, Orders: 5}},
+		{http.MethodPost, "/fapi/v1/batchOrders", url.Values{"batchOrders": {`[{"side":"BUY"},{"side":"SELL"}]`}}, RequestCost{Weight: 5, Orders: 2}},
+		{http.MethodGet, "/fapi/v1/openOrders", nil, RequestCost{Weight: 40}},
+		{http.MethodGet, "/fapi/v1/openOrders", url.Values{"symbol": {"BTCUSDT"}}, RequestCost{Weight: 1}},
+		{http.MethodGet, "/fapi/v2/account", nil, RequestCost{Weight: 5}},
+	}
+	for _, c := range cases {
+		if got := EndpointCost(c.method, c.path, c.query); got != c.want {
+			t.Errorf("%s %s %v: got %+v want %+v", c.method, c.path, c.query, got, c.want)
+		}
+	}
+	if got := requestCost(http.MethodGet, "https://fapi.binance.com/fapi/v1/depth?symbol=BTCUSDT&limit=100"); got.Weight != 5 {
+		t.Errorf("requestCost should parse query, got %+v", got)
+	}
+}
+
+func TestWeightLimiterCancelPriority(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 5, 0, time.UTC)
+	l := NewWeightLimiter(100, 10, 100)
+	l.now = func() time.Time { return now }
+
+	// 服务端报告已用 88：新单可用上限为 90，撤单可用满额
+	l.ObserveResponse(&http.Response{StatusCode: 200, Header: http.Header{"X-Mbx-Used-Weight-1m": {"88"}}})
+	if wait := l.tryAcquire(RequestCost{Weight: 5}); wait <= 0 {
+		t.Fatalf("new order request should wait near the limit")
+	}
+	if wait := l.tryAcquire(RequestCost{Weight: 5, Cancel: true}); wait != 0 {
+		t.Fatalf("cancel should use reserved budget, wait=%s", wait)
+	}
+	b := l.Remaining()
+	if b.Weight1M != 7 || b.Ratio != 0.07 {
+		t.Fatalf("unexpected budget %+v", b)
+	}
+
+	// 下单计数：10s 窗口
+	l.ObserveResponse(&http.Response{StatusCode: 200, Header: http.Header{"X-Mbx-Order-Count-10s": {"9"}}})
+	if wait := l.tryAcquire(RequestCost{Orders: 1}); wait != 5*time.Second+time.Millisecond {
+		t.Fatalf("order should wait for 10s window reset, wait=%s", wait)
+	}
+	now = now.Add(5 * time.Second)
+	if wait := l.tryAcquire(RequestCost{Orders: 1}); wait != 0 {
+		t.Fatalf("order should pass after window reset, wait=%s", wait)
+	}
+}
+
+// blockingPacer 在 release 关闭前阻塞所有请求。
+type blockingPacer struct{ release chan struct{} }
+
+func (p blockingPacer) Wait() { <-p.release }
+
+func TestWeightLimiterCancelLane(t *testing.T) {
+	l := NewWeightLimiter(100, 10, 100)
+	l.Pacer = blockingPacer{release: make(chan struct{})}
+	// 新单堵在 Pacer 上时撤单不排队
+	done := make(chan struct{})
+	go func() {
+		l.WaitCost(RequestCost{Weight: 1, Cancel: true})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("cancel should bypass the pacer")
+	}
+
+	// 有撤单排队时新单让行，撤单放行后被唤醒
+	l.beginCancel()
+	wait, wake := l.acquire(RequestCost{Orders: 1})
+	if wait <= 0 || wake == nil {
+		t.Fatalf("new order should yield to a queued cancel")
+	}
+	if wait, _ := l.acquire(RequestCost{Weight: 1, Cancel: true}); wait != 0 {
+		t.Fatalf("cancel should pass, wait=%s", wait)
+	}
+	l.endCancel()
+	select {
+	case <-wake:
+	default:
+		t.Fatalf("yielding orders not woken when cancels drained")
+	}
+	if wait, _ := l.acquire(RequestCost{Orders: 1}); wait != 0 {
+		t.Fatalf("new order should pass once cancels drained, wait=%s", wait)
+	}
+}
+
+func TestWeightLimiterBanOnTooManyRequests(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := NewWeightLimiter(0, 0, 0)
+	l.now = func() time.Time { return now }
+	var observed RateBudget
+	l.OnObserve = func(b RateBudget) { observed = b }
+
+	l.ObserveResponse(&http.Response{StatusCode: 418, Header: http.Header{"Retry-After": {"30"}}})
+	if wait := l.tryAcquire(RequestCost{Weight: 1, Cancel: true}); wait != 30*time.Second {
+		t.Fatalf("banned limiter should block all requests, wait=%s", wait)
+	}
+	if observed.Ratio != 0 || !observed.BannedTill.Equal(now.Add(30*time.Second)) {
+		t.Fatalf("unexpected observed budget %+v", observed)
+	}
+	now = now.Add(31 * time.Second)
+	if l.RemainingRatio() != 1 {
+		t.Fatalf("budget should recover after ban, got %.2f", l.RemainingRatio())
+	}
+}
+
+func TestBinanceRESTClientReconcilesUsedWeight(t *testing.T) {
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("X-MBX-USED-WEIGHT-1M", "1800")
+		w.Header().Set("X-MBX-ORDER-COUNT-1M", "600")
+		io.WriteString(w, `{"orderId":1}`)
+	}))
+	defer ts.Close()
+
+	l := NewWeightLimiter(2400, 300, 1200)
+	cli := &BinanceRESTClient{BaseURL: ts.URL, APIKey: "key", Secret: "secret", HTTPClient: ts.Client(), Limiter: l}
+	if _, err := cli.PlaceLimit("BTCUSDT", "BUY", "GTC", 100, 1, false, false, ""); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	b := l.Remaining()
+	if b.Weight1M != 600 || b.Orders1M != 600 || b.Ratio != 0.25 {
+		t.Fatalf("budget should follow server headers, got %+v", b)
+	}
+
+	m := NewRESTMiddleware(cli)
+	resp, err := m.ExecuteWithRetry(http.MethodGet, ts.URL+"/fapi/v1/depth?symbol=BTCUSDT&limit=1000", nil)
+	if err != nil {
+		t.Fatalf("middleware: %v", err)
+	}
+	resp.Body.Close()
+	if requests.Load() != 2 || l.Remaining().Weight1M != 600 {
+		t.Fatalf("middleware should reconcile with headers, requests=%d budget=%+v", requests.Load(), l.Remaining())
+	}
+}
@@ -12,3 +12,14 @@ type WSClient interface {
 	SubscribeTrade(symbol string) error
 	Run(handler WSHandler) error
 }
+
+// WSSession 带断线重连、订阅重放与缺口通知的行情/用户流会话；BinanceWSReal（实盘）与 ReplayWS（回放）均实现。
+type WSSession interface {
+	WSClient
+	SubscribeMarkPrice(symbol string) error
+	SubscribeUserData(listenKey string) error
+	OnConnect(cb func())
+	OnDisconnect(cb func(error))
+	OnGap(cb func(WSGap))
+	Stop()
+}
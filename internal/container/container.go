@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"market-maker-go/config"
@@ -26,11 +27,14 @@ type Container struct {
 
 	// 交易所网关
 	restClient *gateway.BinanceRESTClient
+	wsAPI      *gateway.BinanceWSAPIClient // gateway.orderTransport=ws 时启用
+	timeSync   *gateway.TimeSync
 
 	// 核心服务
 	marketData   *market.Service
 	inventory    *inventory.Tracker
 	orderManager *order.Manager
+	reconciler   *order.Reconciler
 
 	// HTTP服务器
 	metricsServer *http.Server
@@ -94,15 +98,30 @@ func (c *Container) buildInfrastructure() error {
 }
 
 func (c *Container) buildGateway() error {
+	limiter := gateway.NewWeightLimiter(0, 0, 0)
+	limiter.Pacer = gateway.NewTokenBucketLimiter(5.0, 10)
 	c.restClient = &gateway.BinanceRESTClient{
 		BaseURL:      c.cfg.Gateway.BaseURL,
 		APIKey:       c.cfg.Gateway.APIKey,
 		Secret:       c.cfg.Gateway.APISecret,
 		HTTPClient:   gateway.NewDefaultHTTPClient(),
 		RecvWindowMs: 5000,
-		Limiter:      gateway.NewTokenBucketLimiter(5.0, 10),
+		Limiter:      limiter,
 		MaxRetries:   3,
 		RetryDelay:   200 * time.Millisecond,
+		Precision:    gateway.NewSymbolPrecision(),
+	}
+	c.timeSync = gateway.NewTimeSync(c.cfg.Gateway.BaseURL, gateway.NewDefaultHTTPClient())
+	c.timeSync.Interval = time.Duration(c.cfg.Gateway.TimeSyncIntervalSec) * time.Second
+	c.restClient.TimeSync = c.timeSync
+	if strings.EqualFold(c.cfg.Gateway.OrderTransport, "ws") {
+		c.wsAPI = gateway.NewBinanceWSAPIClient(c.cfg.Gateway.APIKey, c.cfg.Gateway.APISecret, c.restClient)
+		if c.cfg.Gateway.WSAPIEndpoint != "" {
+			c.wsAPI.Endpoint = c.cfg.Gateway.WSAPIEndpoint
+		}
+		c.wsAPI.RecvWindowMs = 5000
+		c.wsAPI.TimeSync = c.timeSync
+		c.wsAPI.Precision = c.restClient.Precision
 	}
 
 	c.logger.Info("gateway built")
@@ -115,13 +134,21 @@ func (c *Container) buildCoreServices() error {
 
 	orderGw := &orderGatewayAdapter{
 		client:  c.restClient,
+		orders:  c.restClient,
 		logger:  c.logger,
 		monitor: c.monitor,
 	}
+	if c.wsAPI != nil {
+		orderGw.orders = c.wsAPI
+	}
 	c.orderManager = order.NewManager(orderGw)
 
 	symbolConstraints := make(map[string]order.SymbolConstraints)
+	stpModes := make(map[string]string)
 	for sym, sc := range c.cfg.Symbols {
+		if sc.Strategy.SelfTradePrevention != "" {
+			stpModes[sym] = strings.ToUpper(sc.Strategy.SelfTradePrevention)
+		}
 		symbolConstraints[sym] = order.SymbolConstraints{
 			TickSize:    sc.TickSize,
 			StepSize:    sc.StepSize,
@@ -129,8 +156,20 @@ func (c *Container) buildCoreServices() error {
 			MaxQty:      sc.MaxQty,
 			MinNotional: sc.MinNotional,
 		}
+		c.restClient.Precision.Set(sym, symbolConstraints[sym])
 	}
 	c.orderManager.SetConstraints(symbolConstraints)
+	c.orderManager.SetSelfTradePrevention(stpModes)
+
+	// 对账按本地订单所属交易对查询 /fapi/v1/order
+	query := &gateway.OrderQueryAdapter{Venue: gateway.NewBinanceVenue(c.restClient)}
+	query.SymbolOf = func(orderID string) string {
+		if o, err := c.orderManager.GetOrder(orderID); err == nil {
+			return o.Symbol
+		}
+		return ""
+	}
+	c.reconciler = order.NewReconciler(query, c.orderManager, order.ReconcilerConfig{})
 
 	c.logger.Info("core services built")
 	return nil
@@ -146,6 +185,15 @@ func (c *Container) registerLifecycleComponents() {
 			server:  &c.metricsServer,
 		})
 	}
+	if c.timeSync != nil {
+		c.lifecycle.Register(&timeSyncComponent{sync: c.timeSync, logger: c.logger})
+	}
+	if c.wsAPI != nil {
+		c.lifecycle.Register(&wsAPIComponent{client: c.wsAPI, logger: c.logger})
+	}
+	if c.reconciler != nil {
+		c.lifecycle.Register(&reconcilerComponent{reconciler: c.reconciler})
+	}
 }
 
 func (c *Container) Start(ctx context.Context) error {
@@ -181,6 +229,7 @@ func (c *Container) HealthCheck() error {
 // orderGatewayAdapter 适配器
 type orderGatewayAdapter struct {
 	client  *gateway.BinanceRESTClient
+	orders  gateway.BinanceREST // 限价单/撤单通道（REST 或 WS API）
 	logger  *logger.Logger
 	monitor *monitor.Monitor
 }
@@ -193,13 +242,13 @@ func (a *orderGatewayAdapter) Place(o order.Order) (string, error) {
 	var err error
 
 	if o.Type == "MARKET" {
-		orderID, err = a.client.PlaceMarket(o.Symbol, o.Side, o.Quantity, o.ReduceOnly, o.ID)
+		orderID, err = a.client.PlaceMarketSide(o.Symbol, o.Side, o.PositionSide, o.Quantity, o.ReduceOnly, o.ID)
 	} else {
 		tif := o.TimeInForce
 		if tif == "" {
 			tif = "GTC"
 		}
-		orderID, err = a.client.PlaceLimit(o.Symbol, o.Side, tif, o.Price, o.Quantity, o.ReduceOnly, o.PostOnly, o.ID)
+		orderID, err = gateway.PlaceLimitOrder(a.orders, o.Symbol, o.Side, tif, o.Price, o.Quantity, o.ReduceOnly, o.PostOnly, o.ID, gateway.OrderOptionsOf(o))
 	}
 
 	elapsed := time.Since(start).Seconds()
@@ -230,7 +279,7 @@ func (a *orderGatewayAdapter) Cancel(orderID string) error {
 	a.monitor.RecordRESTRequest("cancel")
 
 	// 这里需要symbol，简化处理先用空字符串
-	err := a.client.CancelOrder("", orderID)
+	err := a.orders.CancelOrder("", orderID)
 
 	elapsed := time.Since(start).Seconds()
 	a.monitor.RecordRESTLatency("cancel", elapsed)
@@ -7,7 +7,9 @@ import (
 	"sync"
 	"time"
 
+	"market-maker-go/gateway"
 	"market-maker-go/infrastructure/logger"
+	"market-maker-go/order"
 )
 
 // Lifecycle 生命周期接口
@@ -151,3 +153,93 @@ func (h *httpServerComponent) Health() error {
 	}
 	return nil
 }
+
+// wsAPIComponent WS 交易 API 连接组件；连接失败不阻塞启动（下单回退 REST 并后台重连）
+type wsAPIComponent struct {
+	client *gateway.BinanceWSAPIClient
+	logger *logger.Logger
+}
+
+func (w *wsAPIComponent) Start(ctx context.Context) error {
+	if err := w.client.Connect(); err != nil {
+		w.logger.LogError(err, map[string]interface{}{
+			"component": "ws_api",
+			"action":    "connect",
+		})
+		return nil
+	}
+	w.logger.Logger.Info(fmt.Sprintf("ws api connected to %s", w.client.Endpoint))
+	return nil
+}
+
+func (w *wsAPIComponent) Stop() error {
+	return w.client.Close()
+}
+
+// Health socket 断开时下单仍可走 REST，不视为不健康。
+func (w *wsAPIComponent) Health() error {
+	return nil
+}
+
+// timeSyncComponent 交易所校时组件；首次校时失败不阻塞启动（沿用本地时钟，后台继续重试）
+type timeSyncComponent struct {
+	sync   *gateway.TimeSync
+	logger *logger.Logger
+	cancel context.CancelFunc
+}
+
+func (t *timeSyncComponent) Start(ctx context.Context) error {
+	syncCtx, cancel := context.WithCancel(ctx)
+	t.cancel = cancel
+	if err := t.sync.Start(syncCtx); err != nil {
+		t.logger.LogError(err, map[string]interface{}{
+			"component": "time_sync",
+			"action":    "sync",
+		})
+		return nil
+	}
+	t.logger.Logger.Info(fmt.Sprintf("time sync offset=%s rtt=%s", t.sync.Offset(), t.sync.RTT()))
+	return nil
+}
+
+func (t *timeSyncComponent) Stop() error {
+	if t.cancel != nil {
+		t.cancel()
+	}
+	return nil
+}
+
+// Health 校时失败时签名仍使用本地时钟，不视为不健康。
+func (t *timeSyncComponent) Health() error {
+	return nil
+}
+
+// reconcilerComponent 订单对账组件：定时按交易所订单状态校正本地活跃订单
+type reconcilerComponent struct {
+	reconciler *order.Reconciler
+	started    bool
+}
+
+func (r *reconcilerComponent) Start(ctx context.Context) error {
+	if r.started {
+		return nil
+	}
+	if err := r.reconciler.Start(ctx); err != nil {
+		return fmt.Errorf("start reconciler failed: %w", err)
+	}
+	r.started = true
+	return nil
+}
+
+func (r *reconcilerComponent) Stop() error {
+	if !r.started {
+		return nil
+	}
+	r.started = false
+	return r.reconciler.Stop()
+}
+
+// Health 单次对账失败会在下一轮重试，不视为不健康。
+func (r *reconcilerComponent) Health() error {
+	return nil
+}
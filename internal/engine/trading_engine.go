@@ -9,6 +9,7 @@ import (
 
 	"go.uber.org/zap"
 
+	"market-maker-go/gateway"
 	"market-maker-go/infrastructure/alert"
 	"market-maker-go/infrastructure/logger"
 	"market-maker-go/internal/risk"
@@ -387,11 +388,43 @@ func (e *TradingEngine) onTick() {
 				zap.Float64("size", quote.Size),
 				zap.Error(err))
 			e.recordError()
+			e.alertOnOrderError(quote, err)
 			continue
 		}
 	}
 }
 
+// alertOnOrderError 按交易所错误码分级告警：密钥/持仓模式等需人工介入的错误为 CRITICAL，
+// 保证金不足与 IP 封禁为 ERROR；普通拒单（post-only、精度等）只记日志。
+func (e *TradingEngine) alertOnOrderError(quote strategy.Quote, err error) {
+	if e.alertMgr == nil {
+		return
+	}
+	apiErr, ok := gateway.AsAPIError(err)
+	if !ok {
+		return
+	}
+	var level string
+	switch {
+	case apiErr.Semantics() == gateway.SemanticFatal:
+		level = "CRITICAL"
+	case errors.Is(err, gateway.ErrMarginInsufficient), errors.Is(err, gateway.ErrIPBanned):
+		level = "ERROR"
+	default:
+		return
+	}
+	e.alertMgr.SendAlert(alert.Alert{
+		Level:     level,
+		Message:   fmt.Sprintf("下单被交易所拒绝: %v", err),
+		Timestamp: time.Now(),
+		Fields: map[string]interface{}{
+			"side":     quote.Side,
+			"code":     apiErr.Code,
+			"endpoint": apiErr.Endpoint,
+		},
+	})
+}
+
 // onReconcile 执行订单对账
 func (e *TradingEngine) onReconcile() {
 	if e.reconciler == nil {
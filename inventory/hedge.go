@@ -0,0 +1,108 @@
+package inventory
+
+import (
+	"math"
+	"strings"
+)
+
+// Leg 双向持仓下的一条腿；Qty 恒为非负，Cost 为该腿开仓均价。
+type Leg struct {
+	Qty  float64
+	Cost float64
+}
+
+// SetHedgeMode 切换双向持仓记账；关闭时清空两腿，净仓位保留。
+func (t *Tracker) SetHedgeMode(on bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.hedge = on
+	if !on {
+		t.long, t.short = Leg{}, Leg{}
+	}
+}
+
+// HedgeMode 是否处于双向持仓记账。
+func (t *Tracker) HedgeMode() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.hedge
+}
+
+// Legs 返回多、空两腿快照。
+func (t *Tracker) Legs() (long, short Leg) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.long, t.short
+}
+
+// UpdateLeg 按成交调整指定腿；deltaQty 按买卖方向带符号（买为正、卖为负）。
+// LONG 腿买入加仓、卖出减仓；SHORT 腿卖出加仓、买入减仓。减仓不改变均价，减到 0 为止。
+func (t *Tracker) UpdateLeg(positionSide string, deltaQty, price float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	leg := t.legLocked(positionSide)
+	if leg == nil {
+		return
+	}
+	t.hedge = true
+	open := deltaQty
+	if leg == &t.short {
+		open = -deltaQty
+	}
+	if open > 0 {
+		total := leg.Cost*leg.Qty + price*open
+		leg.Qty += open
+		leg.Cost = total / leg.Qty
+	} else {
+		leg.Qty = math.Max(leg.Qty+open, 0)
+		if leg.Qty == 0 {
+			leg.Cost = 0
+		}
+	}
+	t.syncNetLocked()
+}
+
+// ApplyPosition 应用交易所推送/查询到的持仓：LONG/SHORT 写入对应腿（positionAmt 的符号忽略），
+// BOTH 或空按单向持仓覆盖净仓位；双向持仓下交易所附带的 BOTH 空仓条目被忽略，避免覆盖两腿。
+func (t *Tracker) ApplyPosition(positionSide string, positionAmt, entryPrice float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if leg := t.legLocked(positionSide); leg != nil {
+		t.hedge = true
+		leg.Qty = math.Abs(positionAmt)
+		leg.Cost = entryPrice
+		if leg.Qty == 0 {
+			leg.Cost = 0
+		}
+		t.syncNetLocked()
+		return
+	}
+	if t.hedge {
+		return
+	}
+	t.net = positionAmt
+	t.cost = entryPrice
+}
+
+func (t *Tracker) legLocked(positionSide string) *Leg {
+	switch strings.ToUpper(positionSide) {
+	case "LONG":
+		return &t.long
+	case "SHORT":
+		return &t.short
+	}
+	return nil
+}
+
+// syncNetLocked 由两腿推导净仓位；均价取净仓位所在一侧那条腿的均价。
+func (t *Tracker) syncNetLocked() {
+	t.net = t.long.Qty - t.short.Qty
+	switch {
+	case t.net > 0:
+		t.cost = t.long.Cost
+	case t.net < 0:
+		t.cost = t.short.Cost
+	default:
+		t.cost = 0
+	}
+}
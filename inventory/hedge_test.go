@@ -0,0 +1,45 @@
+package inventory
+
+import "testing"
+
+func TestTrackerHedgeLegs(t *testing.T) {
+	var tr Tracker
+	tr.ApplyPosition("LONG", 2, 100)
+	tr.ApplyPosition("SHORT", -0.5, 120)
+	// 双向持仓下 BOTH 空仓条目不应覆盖两腿
+	tr.ApplyPosition("BOTH", 0, 0)
+	long, short := tr.Legs()
+	if !tr.HedgeMode() || long.Qty != 2 || short.Qty != 0.5 || short.Cost != 120 {
+		t.Fatalf("unexpected legs long=%+v short=%+v", long, short)
+	}
+	if tr.NetExposure() != 1.5 || tr.AvgCost() != 100 {
+		t.Fatalf("net should be long-short: net=%v cost=%v", tr.NetExposure(), tr.AvgCost())
+	}
+	// 多腿 (110-100)*2=20，空腿 (120-110)*0.5=5
+	if _, pnl := tr.Valuation(110); pnl != 25 {
+		t.Fatalf("unexpected hedge pnl %v", pnl)
+	}
+
+	// 卖出平多只动多腿；买入平空只动空腿
+	tr.UpdateLeg("LONG", -1, 105)
+	tr.UpdateLeg("SHORT", 1, 118)
+	long, short = tr.Legs()
+	if long.Qty != 1 || long.Cost != 100 || short.Qty != 0 || short.Cost != 0 {
+		t.Fatalf("unexpected legs after close long=%+v short=%+v", long, short)
+	}
+	// 卖出开空按加权均价累计
+	tr.UpdateLeg("SHORT", -1, 110)
+	tr.UpdateLeg("SHORT", -1, 130)
+	if _, short = tr.Legs(); short.Qty != 2 || short.Cost != 120 {
+		t.Fatalf("unexpected short leg %+v", short)
+	}
+	if tr.NetExposure() != -1 || tr.AvgCost() != 120 {
+		t.Fatalf("unexpected net %v cost %v", tr.NetExposure(), tr.AvgCost())
+	}
+
+	tr.SetHedgeMode(false)
+	tr.ApplyPosition("BOTH", 0.3, 101)
+	if tr.NetExposure() != 0.3 || tr.AvgCost() != 101 {
+		t.Fatalf("one-way position should overwrite net")
+	}
+}
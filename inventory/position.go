@@ -2,24 +2,30 @@ package inventory
 
 import "sync"
 
-// Tracker 维护净仓位。
+// Tracker 维护净仓位；双向持仓（hedge）模式下另按 LONG/SHORT 两条腿分别记账，净仓位为两腿之差。
 type Tracker struct {
-	mu   sync.RWMutex
-	net  float64
-	cost float64
+	mu    sync.RWMutex
+	net   float64
+	cost  float64
+	hedge bool
+	long  Leg
+	short Leg
 }
 
-// Update 根据成交数量调整仓位。
+// Update 根据成交数量调整仓位。加仓按加权平均更新均价，减仓不改变均价，
+// 反手时均价取成交价，平到 0 时清零。
 func (t *Tracker) Update(deltaQty float64, price float64) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	// 简化：加权平均成本
-	totalValue := t.cost*t.net + price*deltaQty
+	prev := t.net
 	t.net += deltaQty
-	if t.net != 0 {
-		t.cost = totalValue / t.net
-	} else {
+	switch {
+	case t.net == 0:
 		t.cost = 0
+	case prev == 0 || (prev > 0) != (t.net > 0):
+		t.cost = price
+	case (prev > 0) == (deltaQty > 0):
+		t.cost = (t.cost*prev + price*deltaQty) / t.net
 	}
 }
 
@@ -16,3 +16,28 @@ func TestTrackerUpdate(t *testing.T) {
 		t.Fatalf("unexpected avg cost %f", tr.AvgCost())
 	}
 }
+
+func TestTrackerUpdateReduceAndFlip(t *testing.T) {
+	var tr Tracker
+	tr.Update(2, 100)
+	tr.Update(-1, 120) // 减仓不改变均价
+	if tr.NetExposure() != 1 || tr.AvgCost() != 100 {
+		t.Fatalf("reduce: net %f cost %f", tr.NetExposure(), tr.AvgCost())
+	}
+	tr.Update(-3, 90) // 反手为空 2，均价取成交价
+	if tr.NetExposure() != -2 || tr.AvgCost() != 90 {
+		t.Fatalf("flip: net %f cost %f", tr.NetExposure(), tr.AvgCost())
+	}
+	tr.Update(-2, 80) // 空头加仓按加权平均
+	if tr.NetExposure() != -4 || tr.AvgCost() != 85 {
+		t.Fatalf("add short: net %f cost %f", tr.NetExposure(), tr.AvgCost())
+	}
+	tr.Update(1, 70)
+	if tr.AvgCost() != 85 {
+		t.Fatalf("reduce short: cost %f", tr.AvgCost())
+	}
+	tr.Update(3, 75)
+	if tr.NetExposure() != 0 || tr.AvgCost() != 0 {
+		t.Fatalf("flat: net %f cost %f", tr.NetExposure(), tr.AvgCost())
+	}
+}
@@ -1,10 +1,14 @@
 package inventory
 
-// Valuation 基于当前 mid 价计算未实现盈亏。
+// Valuation 基于估值价（mid 或标记价，由调用方选择）计算未实现盈亏。
 func (t *Tracker) Valuation(mid float64) (net float64, pnl float64) {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 	net = t.net
+	if t.hedge {
+		pnl = (mid-t.long.Cost)*t.long.Qty + (t.short.Cost-mid)*t.short.Qty
+		return
+	}
 	pnl = (mid - t.cost) * t.net
 	return
 }
@@ -0,0 +1,100 @@
+package market
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultMarkMaxAge 标记价超过该时长未更新视为过期，估值回退到 mid（markPrice@1s 正常每秒推送）。
+const DefaultMarkMaxAge = 10 * time.Second
+
+// PriceBasis 估值价格基准：mid（盘口中间价）或 mark（交易所标记价）。
+type PriceBasis string
+
+const (
+	PriceBasisMid  PriceBasis = "mid"
+	PriceBasisMark PriceBasis = "mark"
+)
+
+// ParsePriceBasis 解析配置中的估值基准，空字符串视为 mid。
+func ParsePriceBasis(s string) (PriceBasis, error) {
+	switch PriceBasis(strings.ToLower(strings.TrimSpace(s))) {
+	case "", PriceBasisMid:
+		return PriceBasisMid, nil
+	case PriceBasisMark:
+		return PriceBasisMark, nil
+	}
+	return PriceBasisMid, fmt.Errorf("unknown price basis %q (want mid or mark)", s)
+}
+
+// FundingState 单个合约的标记价/指数价与资金费率状态。
+// FundingRate 为下一次结算的预测费率；LastFundingRate 为观察到的上一次结算时的费率（未跨越结算点前为 0）。
+type FundingState struct {
+	Symbol          string
+	MarkPrice       float64
+	IndexPrice      float64
+	FundingRate     float64
+	LastFundingRate float64
+	NextFundingTime time.Time
+	EventTime       time.Time
+	UpdatedAt       time.Time // 本地接收时间
+}
+
+// Fresh 判断标记价在 maxAge 内是否有更新。
+func (f FundingState) Fresh(now time.Time, maxAge time.Duration) bool {
+	return f.MarkPrice > 0 && !f.UpdatedAt.IsZero() && now.Sub(f.UpdatedAt) <= maxAge
+}
+
+// UntilFunding 距离下次资金费结算的时长。
+func (f FundingState) UntilFunding(now time.Time) time.Duration {
+	if f.NextFundingTime.IsZero() {
+		return 0
+	}
+	return f.NextFundingTime.Sub(now)
+}
+
+// OnMarkPrice 更新标记价与资金费率；结算时间前移时把上一期预测费率记为 LastFundingRate。
+func (s *Service) OnMarkPrice(st FundingState) {
+	if st.UpdatedAt.IsZero() {
+		st.UpdatedAt = time.Now()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok := s.funding[st.Symbol]
+	if ok && st.LastFundingRate == 0 {
+		st.LastFundingRate = prev.LastFundingRate
+		if !prev.NextFundingTime.IsZero() && st.NextFundingTime.After(prev.NextFundingTime) {
+			st.LastFundingRate = prev.FundingRate
+		}
+	}
+	s.funding[st.Symbol] = st
+}
+
+// Funding 返回最新的资金费率状态。
+func (s *Service) Funding(symbol string) (FundingState, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	st, ok := s.funding[symbol]
+	return st, ok
+}
+
+// MarkPrice 返回标记价；过期（超过 DefaultMarkMaxAge）或缺失时 ok 为 false。
+func (s *Service) MarkPrice(symbol string) (float64, bool) {
+	st, ok := s.Funding(symbol)
+	if !ok || !st.Fresh(time.Now(), DefaultMarkMaxAge) {
+		return 0, false
+	}
+	return st.MarkPrice, true
+}
+
+// ValuationPrice 按估值基准选择价格：mark 基准下标记价不可用时回退到 mid。
+func (s *Service) ValuationPrice(symbol string, basis PriceBasis, mid float64) float64 {
+	if basis != PriceBasisMark {
+		return mid
+	}
+	if mark, ok := s.MarkPrice(symbol); ok {
+		return mark
+	}
+	return mid
+}
@@ -0,0 +1,51 @@
+package market
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFundingStateRollAndValuationPrice(t *testing.T) {
+	svc := NewService(nil)
+	if got := svc.ValuationPrice("BTCUSDT", PriceBasisMark, 100); got != 100 {
+		t.Fatalf("missing mark should fall back to mid, got %.2f", got)
+	}
+	next := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	svc.OnMarkPrice(FundingState{Symbol: "BTCUSDT", MarkPrice: 101, IndexPrice: 100.9, FundingRate: 0.0001, NextFundingTime: next})
+	svc.OnMarkPrice(FundingState{Symbol: "BTCUSDT", MarkPrice: 101.5, FundingRate: 0.0002, NextFundingTime: next})
+	if st, _ := svc.Funding("BTCUSDT"); st.LastFundingRate != 0 || st.FundingRate != 0.0002 {
+		t.Fatalf("no settlement yet, got %+v", st)
+	}
+	// 跨越结算点：上一期预测费率成为 LastFundingRate
+	svc.OnMarkPrice(FundingState{Symbol: "BTCUSDT", MarkPrice: 102, FundingRate: -0.0001, NextFundingTime: next.Add(8 * time.Hour)})
+	svc.OnMarkPrice(FundingState{Symbol: "BTCUSDT", MarkPrice: 102.5, FundingRate: -0.0002, NextFundingTime: next.Add(8 * time.Hour)})
+	st, ok := svc.Funding("BTCUSDT")
+	if !ok || st.LastFundingRate != 0.0002 || st.FundingRate != -0.0002 {
+		t.Fatalf("unexpected funding state %+v", st)
+	}
+	if got := svc.ValuationPrice("BTCUSDT", PriceBasisMark, 100); got != 102.5 {
+		t.Fatalf("expected mark valuation, got %.2f", got)
+	}
+	if got := svc.ValuationPrice("BTCUSDT", PriceBasisMid, 100); got != 100 {
+		t.Fatalf("mid basis should ignore mark, got %.2f", got)
+	}
+
+	// 标记价过期后回退 mid
+	svc.OnMarkPrice(FundingState{Symbol: "BTCUSDT", MarkPrice: 103, UpdatedAt: time.Now().Add(-time.Minute)})
+	if _, ok := svc.MarkPrice("BTCUSDT"); ok {
+		t.Fatalf("stale mark should not be reported")
+	}
+	if got := svc.ValuationPrice("BTCUSDT", PriceBasisMark, 100); got != 100 {
+		t.Fatalf("stale mark should fall back to mid, got %.2f", got)
+	}
+
+	if b, err := ParsePriceBasis("MARK"); err != nil || b != PriceBasisMark {
+		t.Fatalf("parse mark: %v %v", b, err)
+	}
+	if b, err := ParsePriceBasis(""); err != nil || b != PriceBasisMid {
+		t.Fatalf("empty basis should default to mid: %v %v", b, err)
+	}
+	if _, err := ParsePriceBasis("last"); err == nil {
+		t.Fatalf("unknown basis should error")
+	}
+}
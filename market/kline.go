@@ -4,9 +4,10 @@ import "time"
 
 // Kline represents OHLC data.
 type Kline struct {
-	Open  float64
-	High  float64
-	Low   float64
-	Close float64
-	Ts    time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64
+	Ts     time.Time
 }
@@ -36,11 +36,12 @@ func (a *KlineAggregator) OnTrade(price, qty float64, ts time.Time) *Kline {
 		}
 		// 开启新 kline
 		a.current = &Kline{
-			Open:  price,
-			High:  price,
-			Low:   price,
-			Close: price,
-			Ts:    ts,
+			Open:   price,
+			High:   price,
+			Low:    price,
+			Close:  price,
+			Volume: qty,
+			Ts:     ts,
 		}
 		return closed
 	}
@@ -52,5 +53,6 @@ func (a *KlineAggregator) OnTrade(price, qty float64, ts time.Time) *Kline {
 		a.current.Low = price
 	}
 	a.current.Close = price
+	a.current.Volume += qty
 	return nil
 }
@@ -50,6 +50,25 @@ func (ob *OrderBook) ApplyDelta(bidDelta map[float64]float64, askDelta map[float
 	ob.lastUpdate = time.Now()
 }
 
+// Reset 用全量快照替换整个 orderbook（数量 <= 0 的档位会被忽略）。
+func (ob *OrderBook) Reset(bids map[float64]float64, asks map[float64]float64) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.bids = make(map[float64]float64, len(bids))
+	ob.asks = make(map[float64]float64, len(asks))
+	for p, q := range bids {
+		if q > 0 {
+			ob.bids[p] = q
+		}
+	}
+	for p, q := range asks {
+		if q > 0 {
+			ob.asks[p] = q
+		}
+	}
+	ob.lastUpdate = time.Now()
+}
+
 // SetBest 重置 orderbook，仅保留当前最好 bid/ask。
 func (ob *OrderBook) SetBest(bid, ask float64) {
 	ob.mu.Lock()
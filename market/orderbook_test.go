@@ -38,3 +38,16 @@ func TestEstimateFillPrice(t *testing.T) {
 		t.Fatalf("unexpected bid cumulative %.2f", cum)
 	}
 }
+
+func TestOrderBookReset(t *testing.T) {
+	ob := NewOrderBook()
+	ob.ApplyDelta(map[float64]float64{100: 1}, map[float64]float64{101: 1})
+	ob.Reset(map[float64]float64{99: 2, 98.5: 0}, map[float64]float64{102: 3})
+	bid, ask := ob.Best()
+	if bid != 99 || ask != 102 {
+		t.Fatalf("unexpected best after reset: %f/%f", bid, ask)
+	}
+	if len(ob.BidPrices()) != 1 {
+		t.Fatalf("zero qty level should be dropped, got %v", ob.BidPrices())
+	}
+}
@@ -2,8 +2,9 @@ package market
 
 // Publisher 一个轻量事件分发器。
 type Publisher struct {
-	depthSubs []chan Depth
-	tradeSubs []chan Trade
+	depthSubs  []chan Depth
+	tradeSubs  []chan Trade
+	tradeFuncs []func(Trade)
 }
 
 func NewPublisher() *Publisher {
@@ -25,6 +26,14 @@ func (p *Publisher) SubscribeTrade() <-chan Trade {
 	return ch
 }
 
+// SubscribeTradeFunc 注册同步成交回调：与 channel 订阅不同，回调不会因消费慢而丢弃成交，
+// 适用于 VPIN 等需要完整成交量的计算；回调应尽快返回。需在开始发布前注册。
+func (p *Publisher) SubscribeTradeFunc(fn func(Trade)) {
+	if fn != nil {
+		p.tradeFuncs = append(p.tradeFuncs, fn)
+	}
+}
+
 func (p *Publisher) PublishDepth(d Depth) {
 	for _, ch := range p.depthSubs {
 		select {
@@ -35,6 +44,9 @@ func (p *Publisher) PublishDepth(d Depth) {
 }
 
 func (p *Publisher) PublishTrade(t Trade) {
+	for _, fn := range p.tradeFuncs {
+		fn(t)
+	}
 	for _, ch := range p.tradeSubs {
 		select {
 		case ch <- t:
@@ -7,10 +7,12 @@ import (
 
 // Service 维护最新深度与交易，并向订阅者广播。
 type Service struct {
-	pub   *Publisher
-	mu    sync.RWMutex
-	depth map[string]Depth
-	last  map[string]time.Time
+	pub     *Publisher
+	mu      sync.RWMutex
+	depth   map[string]Depth
+	last    map[string]time.Time
+	trade   map[string]Trade
+	funding map[string]FundingState
 }
 
 func NewService(pub *Publisher) *Service {
@@ -18,9 +20,11 @@ func NewService(pub *Publisher) *Service {
 		pub = NewPublisher()
 	}
 	return &Service{
-		pub:   pub,
-		depth: make(map[string]Depth),
-		last:  make(map[string]time.Time),
+		pub:     pub,
+		depth:   make(map[string]Depth),
+		last:    make(map[string]time.Time),
+		trade:   make(map[string]Trade),
+		funding: make(map[string]FundingState),
 	}
 }
 
@@ -35,9 +39,25 @@ func (s *Service) OnDepth(symbol string, bid, ask float64, ts time.Time) {
 	s.pub.PublishDepth(d)
 }
 
-// OnTrade 广播成交。
+// OnTrade 广播成交（无主动方向信息）。
 func (s *Service) OnTrade(symbol string, price, qty float64, ts time.Time) {
-	s.pub.PublishTrade(Trade{Price: price, Qty: qty, Ts: ts})
+	s.OnTradeTick(Trade{Symbol: symbol, Price: price, Qty: qty, Ts: ts})
+}
+
+// OnTradeTick 记录最新成交并广播，保留主动方向等完整字段（来自 aggTrade）。
+func (s *Service) OnTradeTick(t Trade) {
+	s.mu.Lock()
+	s.trade[t.Symbol] = t
+	s.mu.Unlock()
+	s.pub.PublishTrade(t)
+}
+
+// LastTrade 返回最近一笔成交。
+func (s *Service) LastTrade(symbol string) (Trade, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.trade[symbol]
+	return t, ok
 }
 
 // Mid 返回当前中间价；若缺失则返回 0。
@@ -3,8 +3,12 @@ package market
 import "time"
 
 // Trade represents a normalized trade tick.
+// IsBuy 为主动方向：true 表示买方吃单（Binance aggTrade 中 m=false）。
 type Trade struct {
-	Price float64
-	Qty   float64
-	Ts    time.Time
+	Symbol string
+	ID     int64
+	Price  float64
+	Qty    float64
+	IsBuy  bool
+	Ts     time.Time
 }
@@ -0,0 +1,28 @@
+package market
+
+// TradeFlow 将成交流分发给 VPIN 计算与 Kline 聚合，二者均可选。
+// 通过 Publisher.SubscribeTradeFunc 注册 OnTrade，以免慢消费丢弃成交导致成交量失真。
+type TradeFlow struct {
+	Symbol  string // 为空时不过滤
+	VPIN    *VPINCalculator
+	Klines  *KlineAggregator
+	OnKline func(Kline) // 可选，Kline 闭合时回调
+}
+
+// OnTrade 处理单笔成交。
+func (f *TradeFlow) OnTrade(t Trade) {
+	if f.Symbol != "" && t.Symbol != "" && t.Symbol != f.Symbol {
+		return
+	}
+	if t.Qty <= 0 {
+		return
+	}
+	if f.VPIN != nil {
+		f.VPIN.AddTrade(t.Price, t.Qty, t.IsBuy)
+	}
+	if f.Klines != nil {
+		if k := f.Klines.OnTrade(t.Price, t.Qty, t.Ts); k != nil && f.OnKline != nil {
+			f.OnKline(*k)
+		}
+	}
+}
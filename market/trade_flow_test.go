@@ -0,0 +1,34 @@
+package market
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTradeFlowFeedsVPINAndKlines(t *testing.T) {
+	pub := NewPublisher()
+	svc := NewService(pub)
+	var closed []Kline
+	flow := &TradeFlow{
+		Symbol:  "BTCUSDT",
+		VPIN:    NewVPINCalculator(2, 2, 0.4),
+		Klines:  NewKlineAggregator(time.Minute),
+		OnKline: func(k Kline) { closed = append(closed, k) },
+	}
+	pub.SubscribeTradeFunc(flow.OnTrade)
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc.OnTradeTick(Trade{Symbol: "BTCUSDT", Price: 100, Qty: 1.5, IsBuy: true, Ts: base})
+	svc.OnTradeTick(Trade{Symbol: "ETHUSDT", Price: 10, Qty: 5, IsBuy: false, Ts: base})
+	svc.OnTradeTick(Trade{Symbol: "BTCUSDT", Price: 101, Qty: 0.5, IsBuy: false, Ts: base.Add(10 * time.Second)})
+	if !flow.VPIN.IsReady() || flow.VPIN.GetVPIN() != 0.5 {
+		t.Fatalf("unexpected vpin %.3f", flow.VPIN.GetVPIN())
+	}
+	svc.OnTradeTick(Trade{Symbol: "BTCUSDT", Price: 99, Qty: 1, IsBuy: false, Ts: base.Add(time.Minute)})
+	if len(closed) != 1 || closed[0].Volume != 2 || closed[0].High != 101 || closed[0].Open != 100 {
+		t.Fatalf("unexpected klines %+v", closed)
+	}
+	if last, ok := svc.LastTrade("BTCUSDT"); !ok || last.Price != 99 {
+		t.Fatalf("unexpected last trade %+v", last)
+	}
+}
@@ -27,6 +27,8 @@ const (
 	MetricDynamicOrderCancelsTotal    = "mm_dynamic_order_cancels_total"
 	MetricPostOnlyUsageTotal          = "mm_postonly_usage_total"
 	MetricPostOnlyRejectFallbackTotal = "mm_postonly_reject_fallback_total"
+	MetricOrderAmendsTotal            = "mm_order_amends_total"
+	MetricOrderRejectsTotal           = "mm_order_rejects_total"
 	MetricFillRateCurrent             = "mm_fill_rate_current"
 	MetricRecentFillsCount            = "mm_recent_fills_count"
 	MetricCancelSuppressionActive     = "mm_cancel_suppression_active"
@@ -166,6 +168,14 @@ var (
 		Name: MetricPostOnlyRejectFallbackTotal,
 		Help: "Total post-only rejects that triggered fallback",
 	}, []string{"side"})
+	OrderAmends = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: MetricOrderAmendsTotal,
+		Help: "Total in-place order amends by result (ok/fallback)",
+	}, []string{"side", "result"})
+	OrderRejects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: MetricOrderRejectsTotal,
+		Help: "Total order rejects by exchange error code",
+	}, []string{"side", "code"})
 )
 
 // UpdateMarketMetrics 更新市场相关指标
@@ -215,6 +225,12 @@ func IncrementPostOnlyUsage(side string) {
 func IncrementPostOnlyRejectFallback(side string) {
 	PostOnlyRejectFallback.WithLabelValues(side).Inc()
 }
+func IncrementOrderAmend(side, result string) {
+	OrderAmends.WithLabelValues(side, result).Inc()
+}
+func IncrementOrderReject(side, code string) {
+	OrderRejects.WithLabelValues(side, code).Inc()
+}
 
 // UpdateFillTrackerMetrics 更新成交跟踪指标
 func UpdateFillTrackerMetrics(fillRate float64, recentFills int, suppressionActive bool) {
@@ -294,6 +310,60 @@ var (
 		Name: "mm_orders_canceled_count",
 		Help: "Total orders canceled",
 	}, []string{"symbol"})
+
+	// ClockOffsetMs 交易所时间与本地时钟的偏差（毫秒，正值表示本地落后）
+	ClockOffsetMs = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "mm_clock_offset_ms",
+		Help: "Estimated exchange server time minus local time in milliseconds",
+	})
+
+	// ClockRTTMs 校时请求往返时延（毫秒）
+	ClockRTTMs = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "mm_clock_rtt_ms",
+		Help: "Round-trip time of the last server time sample in milliseconds",
+	})
+
+	// MarkPrice 交易所标记价
+	MarkPrice = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mm_mark_price",
+		Help: "Exchange mark price",
+	}, []string{"symbol"})
+
+	// IndexPrice 交易所指数价
+	IndexPrice = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mm_index_price",
+		Help: "Exchange index price",
+	}, []string{"symbol"})
+
+	// FundingRate 下一期预测资金费率
+	FundingRate = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mm_funding_rate",
+		Help: "Predicted funding rate for the next settlement",
+	}, []string{"symbol"})
+
+	// NextFundingSeconds 距下次资金费结算的秒数
+	NextFundingSeconds = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mm_next_funding_seconds",
+		Help: "Seconds until the next funding settlement",
+	}, []string{"symbol"})
+
+	// RateLimitRemaining 交易所限流剩余额度（kind: weight_1m/orders_10s/orders_1m）
+	RateLimitRemaining = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mm_rate_limit_remaining",
+		Help: "Remaining exchange rate limit budget in the current window",
+	}, []string{"kind"})
+
+	// ReconcileDiscrepancies 对账发现的差异（kind: status_mismatch/missing_order/orphan_order/position_mismatch）
+	ReconcileDiscrepancies = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mm_reconcile_discrepancies_total",
+		Help: "Discrepancies found by order/position reconciliation, by kind and action taken",
+	}, []string{"symbol", "kind", "action"})
+
+	// PositionDrift 对账时本地净仓位减交易所净仓位
+	PositionDrift = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mm_position_drift",
+		Help: "Local net position minus exchange net position at the last reconciliation",
+	}, []string{"symbol"})
 )
 
 // UpdateMarketData 更新市场数据指标
@@ -339,3 +409,38 @@ func IncrementOrderPlaced(symbol, side string) {
 func IncrementOrderCanceled(symbol string) {
 	OrdersCanceled.WithLabelValues(symbol).Inc()
 }
+
+// UpdateFunding 更新标记价/指数价与资金费率指标
+func UpdateFunding(symbol string, mark, index, rate, untilFundingSec float64) {
+	if mark > 0 {
+		MarkPrice.WithLabelValues(symbol).Set(mark)
+	}
+	if index > 0 {
+		IndexPrice.WithLabelValues(symbol).Set(index)
+	}
+	FundingRate.WithLabelValues(symbol).Set(rate)
+	NextFundingSeconds.WithLabelValues(symbol).Set(untilFundingSec)
+}
+
+// UpdateRateLimitRemaining 更新限流剩余额度指标
+func UpdateRateLimitRemaining(weight1m, orders10s, orders1m int) {
+	RateLimitRemaining.WithLabelValues("weight_1m").Set(float64(weight1m))
+	RateLimitRemaining.WithLabelValues("orders_10s").Set(float64(orders10s))
+	RateLimitRemaining.WithLabelValues("orders_1m").Set(float64(orders1m))
+}
+
+// IncrementReconcileDiscrepancy 对账差异计数
+func IncrementReconcileDiscrepancy(symbol, kind, action string) {
+	ReconcileDiscrepancies.WithLabelValues(symbol, kind, action).Inc()
+}
+
+// UpdatePositionDrift 更新对账仓位偏差
+func UpdatePositionDrift(symbol string, drift float64) {
+	PositionDrift.WithLabelValues(symbol).Set(drift)
+}
+
+// UpdateClockSync 更新校时偏差与往返时延指标
+func UpdateClockSync(offsetMs, rttMs float64) {
+	ClockOffsetMs.Set(offsetMs)
+	ClockRTTMs.Set(rttMs)
+}
@@ -0,0 +1,81 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrAmendUnsupported 表示 Gateway 不支持改单，调用方应退回撤单+重挂。
+var ErrAmendUnsupported = errors.New("amend not supported")
+
+// AmendGateway 为 Gateway 的可选扩展：原地修改挂单价格/数量（保留交易所订单 ID 与队列外的其它属性）。
+// 入参 o 为修改后的订单视图（ID/Symbol/Side 不变，Price/Quantity 为新值）。
+type AmendGateway interface {
+	Gateway
+	Amend(o Order) error
+}
+
+// SupportsAmend 返回当前 Gateway 是否支持改单。
+func (m *Manager) SupportsAmend() bool {
+	_, ok := m.gw.(AmendGateway)
+	return ok
+}
+
+// Amend 修改挂单价格/数量：状态经 AMENDING 过渡，成功后写入新价格/数量并回到原状态；
+// 失败时价格/数量不变、状态回退，并记录 LastError。改单期间若收到成交/撤单回报，以回报状态为准。
+func (m *Manager) Amend(id string, price, qty float64) (*Order, error) {
+	ag, ok := m.gw.(AmendGateway)
+	if m.gw != nil && !ok {
+		return nil, ErrAmendUnsupported
+	}
+	m.mu.Lock()
+	o, exists := m.orders[id]
+	if !exists {
+		m.mu.Unlock()
+		return nil, ErrUnknownOrder
+	}
+	if !m.stateMachine.CanAmend(o.Status) {
+		st := o.Status
+		m.mu.Unlock()
+		return nil, fmt.Errorf("order %s not amendable in state %s", id, st)
+	}
+	amended := *o
+	amended.Price = price
+	amended.Quantity = qty
+	m.mu.Unlock()
+
+	if err := m.validateConstraint(amended); err != nil {
+		return nil, err
+	}
+	m.snapToGrid(&amended)
+
+	m.mu.Lock()
+	prev := o.Status
+	if err := m.stateMachine.ValidateTransition(prev, StatusAmending); err != nil {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("invalid state transition for order %s: %w", id, err)
+	}
+	o.Status = StatusAmending
+	m.mu.Unlock()
+
+	var err error
+	if ag != nil {
+		err = ag.Amend(amended)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// 改单在途时已被回报推进到其它状态（成交/撤销），不再覆盖
+	if o.Status == StatusAmending {
+		o.Status = prev
+	}
+	if err != nil {
+		o.LastError = err.Error()
+		return nil, err
+	}
+	o.Price = amended.Price
+	o.Quantity = amended.Quantity
+	m.journalLocked(JournalAmend, o, nil)
+	out := *o
+	return &out, nil
+}
@@ -0,0 +1,87 @@
+package order
+
+import (
+	"errors"
+	"testing"
+)
+
+type mockAmendGateway struct {
+	mockGateway
+	amended  []Order
+	errAmend error
+	// onAmend 在返回前调用，用于模拟改单在途时收到回报
+	onAmend func()
+}
+
+func (m *mockAmendGateway) Amend(o Order) error {
+	m.amended = append(m.amended, o)
+	if m.onAmend != nil {
+		m.onAmend()
+	}
+	return m.errAmend
+}
+
+func TestManagerAmend(t *testing.T) {
+	gw := &mockAmendGateway{}
+	m := NewManager(gw)
+	if !m.SupportsAmend() {
+		t.Fatalf("amend gateway should be detected")
+	}
+	sent, err := m.Submit(Order{Symbol: "BTCUSDT", Side: "BUY", Price: 100, Quantity: 1, PostOnly: true})
+	if err != nil {
+		t.Fatalf("submit err: %v", err)
+	}
+	gw.onAmend = func() {
+		if st, _ := m.Status(sent.ID); st != StatusAmending {
+			t.Errorf("expected AMENDING while in flight, got %s", st)
+		}
+	}
+	res, err := m.Amend(sent.ID, 101, 2)
+	if err != nil {
+		t.Fatalf("amend err: %v", err)
+	}
+	if res.ID != sent.ID || res.Price != 101 || res.Quantity != 2 || res.Status != StatusAck {
+		t.Fatalf("unexpected amended order %+v", res)
+	}
+	if len(gw.amended) != 1 || gw.amended[0].Side != "BUY" || !gw.amended[0].PostOnly {
+		t.Fatalf("gateway should receive full order view, got %+v", gw.amended)
+	}
+
+	// 改单失败：价格/数量不变，状态回退
+	gw.onAmend = nil
+	gw.errAmend = errors.New("modify order status 400: {\"code\":-5022}")
+	if _, err := m.Amend(sent.ID, 102, 2); err == nil {
+		t.Fatalf("expected amend error")
+	}
+	o, _ := m.GetOrder(sent.ID)
+	if o.Price != 101 || o.Status != StatusAck || o.LastError == "" {
+		t.Fatalf("failed amend should keep previous order, got %+v", o)
+	}
+
+	// 改单在途时成交：以回报状态为准
+	gw.errAmend = errors.New("order does not exist")
+	gw.onAmend = func() { _ = m.Update(sent.ID, StatusFilled) }
+	if _, err := m.Amend(sent.ID, 103, 2); err == nil {
+		t.Fatalf("expected amend error")
+	}
+	if st, _ := m.Status(sent.ID); st != StatusFilled {
+		t.Fatalf("expected FILLED after concurrent fill, got %s", st)
+	}
+	if _, err := m.Amend(sent.ID, 104, 2); err == nil {
+		t.Fatalf("filled order should not be amendable")
+	}
+}
+
+func TestManagerAmendUnsupported(t *testing.T) {
+	m := NewManager(&mockGateway{})
+	sent, _ := m.Submit(Order{Symbol: "BTCUSDT", Side: "SELL", Price: 100, Quantity: 1})
+	if m.SupportsAmend() {
+		t.Fatalf("plain gateway should not support amend")
+	}
+	if _, err := m.Amend(sent.ID, 101, 1); !errors.Is(err, ErrAmendUnsupported) {
+		t.Fatalf("expected ErrAmendUnsupported, got %v", err)
+	}
+	if _, err := NewManager(&mockAmendGateway{}).Amend("missing", 1, 1); !errors.Is(err, ErrUnknownOrder) {
+		t.Fatalf("expected ErrUnknownOrder, got %v", err)
+	}
+}
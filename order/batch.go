@@ -0,0 +1,137 @@
+package order
+
+import "errors"
+
+// ErrBatchResultMissing 表示批量响应缺少对应项的结果。
+var ErrBatchResultMissing = errors.New("batch result missing")
+
+// BatchResult 为批量请求中单笔的结果，顺序与请求一致。
+type BatchResult struct {
+	ExchangeID string
+	Err        error
+}
+
+// BatchGateway 为 Gateway 的可选扩展：一次往返批量下单/撤单。
+// 返回的切片长度须与入参一致；error 表示整批失败（此时各笔结果可为空）。
+type BatchGateway interface {
+	Gateway
+	PlaceBatch(orders []Order) ([]BatchResult, error)
+	CancelBatch(orderIDs []string) ([]BatchResult, error)
+}
+
+// SubmitBatch 批量下单并逐笔登记状态：成功为 ACK，失败为 REJECTED（结果未知的项与 Submit 一样先确认/重试）。
+// 返回与 orders 等长的结果；失败项 Order 为 nil、error 非空。
+// Gateway 未实现 BatchGateway 时逐笔调用 Place。
+func (m *Manager) SubmitBatch(orders []Order) ([]*Order, []error) {
+	sent := make([]*Order, len(orders))
+	errs := make([]error, len(orders))
+	pending := make([]Order, 0, len(orders))
+	idx := make([]int, 0, len(orders))
+	for i, o := range orders {
+		if err := m.prepare(&o); err != nil {
+			errs[i] = err
+			continue
+		}
+		sent[i] = m.register(o, m.submitStatus())
+		pending = append(pending, o)
+		idx = append(idx, i)
+	}
+	// 与 Submit 一致：未配置 Gateway 时保持 NEW
+	if len(pending) == 0 || m.gw == nil {
+		return sent, errs
+	}
+
+	results := m.placeBatch(pending)
+	for k, i := range idx {
+		var remote Status
+		err := results[k].Err
+		if err != nil && isExecutionUnknown(err) {
+			// 整批超时时逐笔按 clientOrderId 确认
+			remote, err = m.recoverPlace(pending[k], err)
+		}
+		if err != nil {
+			m.failPlace(sent[i].ID, err)
+			sent[i] = nil
+			errs[i] = err
+			continue
+		}
+		m.markPlaced(sent[i].ID, remote)
+	}
+	return sent, errs
+}
+
+// CancelBatch 批量撤单，逐笔标记 CANCELED；返回与 ids 等长的错误切片。
+func (m *Manager) CancelBatch(ids []string) []error {
+	errs := make([]error, len(ids))
+	known := make([]string, 0, len(ids))
+	idx := make([]int, 0, len(ids))
+	m.mu.RLock()
+	for i, id := range ids {
+		if _, ok := m.orders[id]; !ok {
+			errs[i] = ErrUnknownOrder
+			continue
+		}
+		known = append(known, id)
+		idx = append(idx, i)
+	}
+	m.mu.RUnlock()
+	if len(known) == 0 {
+		return errs
+	}
+
+	var results []BatchResult
+	if m.gw != nil {
+		results = m.cancelBatch(known)
+	} else {
+		results = make([]BatchResult, len(known))
+	}
+	for k, i := range idx {
+		if err := results[k].Err; err != nil {
+			errs[i] = err
+			continue
+		}
+		errs[i] = m.updateStatus(known[k], StatusCanceled, nil)
+	}
+	return errs
+}
+
+func (m *Manager) placeBatch(orders []Order) []BatchResult {
+	bg, ok := m.gw.(BatchGateway)
+	if !ok || len(orders) == 1 {
+		results := make([]BatchResult, len(orders))
+		for i, o := range orders {
+			results[i].ExchangeID, results[i].Err = m.gw.Place(o)
+		}
+		return results
+	}
+	results, err := bg.PlaceBatch(orders)
+	return normalizeBatch(results, len(orders), err)
+}
+
+func (m *Manager) cancelBatch(ids []string) []BatchResult {
+	bg, ok := m.gw.(BatchGateway)
+	if !ok || len(ids) == 1 {
+		results := make([]BatchResult, len(ids))
+		for i, id := range ids {
+			results[i].Err = m.gw.Cancel(id)
+		}
+		return results
+	}
+	results, err := bg.CancelBatch(ids)
+	return normalizeBatch(results, len(ids), err)
+}
+
+// normalizeBatch 保证结果与请求等长：整批失败或结果缺失的项按失败处理。
+func normalizeBatch(results []BatchResult, n int, err error) []BatchResult {
+	out := make([]BatchResult, n)
+	copy(out, results)
+	for i := range out {
+		if i >= len(results) && out[i].Err == nil {
+			out[i].Err = err
+			if out[i].Err == nil {
+				out[i].Err = ErrBatchResultMissing
+			}
+		}
+	}
+	return out
+}
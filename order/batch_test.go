@@ -0,0 +1,146 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type mockBatchGateway struct {
+	mockGateway
+	batches   [][]Order
+	cancels   [][]string
+	rejectIdx map[int]error
+	batchErr  error
+}
+
+func (m *mockBatchGateway) PlaceBatch(orders []Order) ([]BatchResult, error) {
+	m.batches = append(m.batches, orders)
+	if m.batchErr != nil {
+		return nil, m.batchErr
+	}
+	res := make([]BatchResult, len(orders))
+	for i, o := range orders {
+		if err := m.rejectIdx[i]; err != nil {
+			res[i].Err = err
+			continue
+		}
+		res[i].ExchangeID = "ex-" + o.ID
+	}
+	return res, nil
+}
+
+func (m *mockBatchGateway) CancelBatch(ids []string) ([]BatchResult, error) {
+	m.cancels = append(m.cancels, ids)
+	res := make([]BatchResult, len(ids))
+	if len(ids) > 1 {
+		res[1].Err = errors.New("unknown order sent")
+	}
+	return res, nil
+}
+
+func TestManagerSubmitBatchPerItemStatus(t *testing.T) {
+	gw := &mockBatchGateway{rejectIdx: map[int]error{1: errors.New(`{"code":-5022,"msg":"Post Only"}`)}}
+	m := NewManager(gw)
+	m.SetConstraints(map[string]SymbolConstraints{"ETHUSDC": {TickSize: 0.01, StepSize: 0.001}})
+	orders := []Order{
+		{ID: "a", Symbol: "ETHUSDC", Side: "BUY", Price: 100, Quantity: 0.01},
+		{ID: "b", Symbol: "ETHUSDC", Side: "BUY", Price: 99.99, Quantity: 0.01, PostOnly: true},
+		{ID: "c", Symbol: "ETHUSDC", Side: "BUY", Price: 99.985, Quantity: 0.01}, // 不满足 tick，不下发
+		{ID: "d", Symbol: "ETHUSDC", Side: "SELL", Price: 100.02, Quantity: 0.01},
+	}
+	sent, errs := m.SubmitBatch(orders)
+	if len(gw.batches) != 1 || len(gw.batches[0]) != 3 || len(gw.placed) != 0 {
+		t.Fatalf("expected one batch of 3, got %+v single=%d", gw.batches, len(gw.placed))
+	}
+	if errs[0] != nil || sent[0] == nil || sent[0].Status != StatusAck {
+		t.Fatalf("order a should be acked: %+v %v", sent[0], errs[0])
+	}
+	if errs[1] == nil || sent[1] != nil {
+		t.Fatalf("order b should be rejected")
+	}
+	if st, _ := m.Status("b"); st != StatusRejected {
+		t.Fatalf("order b status %s", st)
+	}
+	if errs[2] == nil {
+		t.Fatalf("order c should fail constraint")
+	}
+	if _, ok := m.Status("c"); ok {
+		t.Fatalf("order c should not be tracked")
+	}
+	if st, _ := m.Status("d"); st != StatusAck {
+		t.Fatalf("order d status %s", st)
+	}
+
+	cerrs := m.CancelBatch([]string{"a", "d", "missing"})
+	if len(gw.cancels) != 1 || len(gw.cancels[0]) != 2 {
+		t.Fatalf("expected one cancel batch of 2, got %+v", gw.cancels)
+	}
+	if cerrs[0] != nil || cerrs[1] == nil || !errors.Is(cerrs[2], ErrUnknownOrder) {
+		t.Fatalf("unexpected cancel errors %v", cerrs)
+	}
+	if st, _ := m.Status("a"); st != StatusCanceled {
+		t.Fatalf("order a status %s", st)
+	}
+	if st, _ := m.Status("d"); st != StatusAck {
+		t.Fatalf("failed cancel should keep status, got %s", st)
+	}
+}
+
+func TestManagerSubmitBatchWholeFailure(t *testing.T) {
+	gw := &mockBatchGateway{batchErr: errors.New("request failed")}
+	m := NewManager(gw)
+	sent, errs := m.SubmitBatch([]Order{
+		{ID: "a", Symbol: "ETHUSDC", Side: "BUY", Price: 100, Quantity: 1},
+		{ID: "b", Symbol: "ETHUSDC", Side: "SELL", Price: 101, Quantity: 1},
+	})
+	for i := range errs {
+		if errs[i] == nil || sent[i] != nil {
+			t.Fatalf("item %d should fail with batch error", i)
+		}
+	}
+	if st, _ := m.Status("b"); st != StatusRejected {
+		t.Fatalf("order b status %s", st)
+	}
+}
+
+func TestManagerSubmitBatchUnknownConfirmsByClientID(t *testing.T) {
+	gw := &mockBatchGateway{batchErr: fmt.Errorf("place batch: %w: connection reset by peer", ErrExecutionUnknown)}
+	lookup := &flakyGateway{remote: map[string]*Order{"a": {ID: "a", Status: StatusAck}}}
+	m := NewManager(gw)
+	m.Lookup = lookup
+	sent, errs := m.SubmitBatch([]Order{
+		{ID: "a", Symbol: "ETHUSDC", Side: "BUY", Price: 100, Quantity: 1},
+		{ID: "b", Symbol: "ETHUSDC", Side: "SELL", Price: 101, Quantity: 1},
+	})
+	if errs[0] != nil || errs[1] != nil || sent[0] == nil || sent[1] == nil {
+		t.Fatalf("unknown batch outcome should be confirmed per order: %v", errs)
+	}
+	// a 已在交易所，不重发；b 查无此单，以同一 ID 单笔重发
+	if lookup.lookups != 2 || len(gw.placed) != 1 || gw.placed[0].ID != "b" {
+		t.Fatalf("lookups=%d resent=%+v", lookup.lookups, gw.placed)
+	}
+	for _, id := range []string{"a", "b"} {
+		if st, _ := m.Status(id); st != StatusAck {
+			t.Fatalf("order %s status %s", id, st)
+		}
+	}
+}
+
+func TestManagerSubmitBatchFallsBackToPlace(t *testing.T) {
+	gw := &mockGateway{}
+	m := NewManager(gw)
+	sent, errs := m.SubmitBatch([]Order{
+		{Symbol: "ETHUSDC", Side: "BUY", Price: 100, Quantity: 1, ClientID: "bid"},
+		{Symbol: "ETHUSDC", Side: "SELL", Price: 101, Quantity: 1, ClientID: "ask"},
+	})
+	if len(gw.placed) != 2 || errs[0] != nil || errs[1] != nil {
+		t.Fatalf("expected two single placements, got %d errs=%v", len(gw.placed), errs)
+	}
+	if errs := m.CancelBatch([]string{sent[0].ID, sent[1].ID}); errs[0] != nil || errs[1] != nil {
+		t.Fatalf("cancel errs %v", errs)
+	}
+	if len(gw.canceled) != 2 {
+		t.Fatalf("expected two single cancels, got %d", len(gw.canceled))
+	}
+}
@@ -0,0 +1,162 @@
+package order
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// ClientIDPrefix 本系统生成的 clientOrderId 前缀，对账时据此识别自己的挂单。
+const ClientIDPrefix = "mm"
+
+// clientIDMaxLen Binance newClientOrderId / Bybit orderLinkId 的长度上限。
+const clientIDMaxLen = 36
+
+// runIDLen 随机 run ID 的长度（base36）。
+const runIDLen = 5
+
+// DefaultClientTag 未指定策略标签（Order.ClientID）时使用的标签。
+const DefaultClientTag = "q"
+
+// ClientIDParts clientOrderId 的各组成部分。
+type ClientIDParts struct {
+	RunID  string
+	Symbol string // 可能因长度限制被截断
+	Side   string // BUY/SELL
+	Level  int
+	Tag    string
+	Seq    uint64
+}
+
+// ClientIDGenerator 生成确定性的 clientOrderId：mm<run>-<symbol>-<B|S><level>-<tag>-<seq>，
+// 如 mmk3f9a-ETHUSDC-B0-dyn-1z。同一 run 内 seq 单调递增（base36），总长不超过 36，
+// 超长时截断 symbol。下单前生成并作为本地订单 ID，超时重试与成交回报均以它匹配。
+type ClientIDGenerator struct {
+	RunID string
+	seq   atomic.Uint64
+}
+
+// NewClientIDGenerator 创建生成器；runID 为空时随机生成，非法字符会被剔除。
+func NewClientIDGenerator(runID string) *ClientIDGenerator {
+	runID = sanitizeIDPart(strings.ToLower(runID), runIDLen)
+	if runID == "" {
+		runID = randomRunID()
+	}
+	return &ClientIDGenerator{RunID: runID}
+}
+
+// Next 为订单生成下一个 ID：Order.ClientID 作为策略标签，Order.Level 作为档位。
+func (g *ClientIDGenerator) Next(o Order) string {
+	return g.Format(ClientIDParts{
+		RunID:  g.RunID,
+		Symbol: o.Symbol,
+		Side:   o.Side,
+		Level:  o.Level,
+		Tag:    o.ClientID,
+		Seq:    g.seq.Add(1),
+	})
+}
+
+// Seq 返回最近一次分配的序号。
+func (g *ClientIDGenerator) Seq() uint64 {
+	return g.seq.Load()
+}
+
+// SetSeq 设置序号起点（如从日志恢复后继续编号，避免与上次进程的 ID 重复）。
+func (g *ClientIDGenerator) SetSeq(seq uint64) {
+	g.seq.Store(seq)
+}
+
+// Owns 判断 id 是否由本 run 生成。
+func (g *ClientIDGenerator) Owns(id string) bool {
+	p, ok := ParseClientID(id)
+	return ok && p.RunID == g.RunID
+}
+
+// Format 按 ClientIDParts 组装 ID。
+func (g *ClientIDGenerator) Format(p ClientIDParts) string {
+	side := "B"
+	if strings.EqualFold(p.Side, "SELL") {
+		side = "S"
+	}
+	level := p.Level
+	if level < 0 {
+		level = 0
+	}
+	if level > 99 {
+		level = 99
+	}
+	tag := sanitizeIDPart(strings.ToLower(p.Tag), 3)
+	if tag == "" {
+		tag = DefaultClientTag
+	}
+	tail := fmt.Sprintf("-%s%d-%s-%s", side, level, tag, strconv.FormatUint(p.Seq, 36))
+	head := ClientIDPrefix + p.RunID + "-"
+	symbol := sanitizeIDPart(strings.ToUpper(p.Symbol), clientIDMaxLen-len(head)-len(tail))
+	return head + symbol + tail
+}
+
+// ParseClientID 解析本系统生成的 clientOrderId；其他来源的 ID 返回 false。
+func ParseClientID(id string) (ClientIDParts, bool) {
+	var p ClientIDParts
+	if !strings.HasPrefix(id, ClientIDPrefix) || len(id) > clientIDMaxLen {
+		return p, false
+	}
+	parts := strings.Split(id[len(ClientIDPrefix):], "-")
+	if len(parts) != 5 || parts[0] == "" || len(parts[2]) < 2 || parts[3] == "" {
+		return p, false
+	}
+	switch parts[2][0] {
+	case 'B':
+		p.Side = "BUY"
+	case 'S':
+		p.Side = "SELL"
+	default:
+		return p, false
+	}
+	level, err := strconv.Atoi(parts[2][1:])
+	if err != nil {
+		return p, false
+	}
+	seq, err := strconv.ParseUint(parts[4], 36, 64)
+	if err != nil {
+		return p, false
+	}
+	p.RunID, p.Symbol, p.Level, p.Tag, p.Seq = parts[0], parts[1], level, parts[3], seq
+	return p, true
+}
+
+// IsOwnClientID 判断 id 是否为本系统（任意 run）生成。
+func IsOwnClientID(id string) bool {
+	_, ok := ParseClientID(id)
+	return ok
+}
+
+// sanitizeIDPart 只保留字母数字并截断到 max。
+func sanitizeIDPart(s string, max int) string {
+	var b strings.Builder
+	for _, r := range s {
+		if b.Len() >= max {
+			break
+		}
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func randomRunID() string {
+	const alphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
+	buf := make([]byte, runIDLen)
+	if _, err := rand.Read(buf); err != nil {
+		// 随机源不可用时退化为全零 run，seq 仍保证进程内唯一
+		return strings.Repeat("0", runIDLen)
+	}
+	for i, b := range buf {
+		buf[i] = alphabet[int(b)%len(alphabet)]
+	}
+	return string(buf)
+}
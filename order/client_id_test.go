@@ -0,0 +1,46 @@
+package order
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClientIDFormatAndParse(t *testing.T) {
+	g := NewClientIDGenerator("k3f9a")
+	id := g.Next(Order{Symbol: "ETHUSDC", Side: "SELL", ClientID: "dyn", Level: 2})
+	if id != "mmk3f9a-ETHUSDC-S2-dyn-1" {
+		t.Fatalf("unexpected id %s", id)
+	}
+	p, ok := ParseClientID(id)
+	if !ok || p.RunID != "k3f9a" || p.Symbol != "ETHUSDC" || p.Side != "SELL" || p.Level != 2 || p.Tag != "dyn" || p.Seq != 1 {
+		t.Fatalf("unexpected parts %+v ok=%v", p, ok)
+	}
+	if !g.Owns(id) || NewClientIDGenerator("other").Owns(id) || !IsOwnClientID(id) {
+		t.Fatalf("ownership check failed for %s", id)
+	}
+	// 序号递增、未指定标签用默认值
+	if next := g.Next(Order{Symbol: "ETHUSDC", Side: "BUY"}); next != "mmk3f9a-ETHUSDC-B0-q-2" {
+		t.Fatalf("unexpected second id %s", next)
+	}
+	for _, foreign := range []string{"web_abc", "mm_1700000000", "mmk3f9a-ETHUSDC-X0-q-1", "ord-1"} {
+		if IsOwnClientID(foreign) {
+			t.Errorf("%s should not parse as own id", foreign)
+		}
+	}
+}
+
+func TestClientIDFitsExchangeLimit(t *testing.T) {
+	g := NewClientIDGenerator("")
+	if len(g.RunID) != runIDLen {
+		t.Fatalf("random run id %q", g.RunID)
+	}
+	g.SetSeq(1<<63 - 1)
+	id := g.Next(Order{Symbol: "1000SHIBUSDCPERPETUALXYZ", Side: "BUY", ClientID: "static-long", Level: 250})
+	if len(id) > clientIDMaxLen {
+		t.Fatalf("id %s exceeds %d chars", id, clientIDMaxLen)
+	}
+	p, ok := ParseClientID(id)
+	if !ok || p.Level != 99 || p.Tag != "sta" || p.Seq != 1<<63 || !strings.HasPrefix("1000SHIBUSDCPERPETUALXYZ", p.Symbol) {
+		t.Fatalf("unexpected parts %+v ok=%v (%s)", p, ok, id)
+	}
+}
@@ -34,6 +34,33 @@ func (c SymbolConstraints) Validate(price, qty float64) error {
 	return nil
 }
 
+// PriceTick 返回定点形式的 tickSize。
+func (c SymbolConstraints) PriceTick() Decimal { return DecimalFromFloat(c.TickSize) }
+
+// QtyStep 返回定点形式的 stepSize。
+func (c SymbolConstraints) QtyStep() Decimal { return DecimalFromFloat(c.StepSize) }
+
+// QuantizePrice 把价格对齐到 tickSize 的整数倍；未配置 tickSize 时仅做十进制转换。
+func (c SymbolConstraints) QuantizePrice(price float64, mode RoundingMode) Decimal {
+	return QuantizeFloat(price, c.PriceTick(), mode)
+}
+
+// QuantizeQty 把数量对齐到 stepSize 的整数倍；未配置 stepSize 时仅做十进制转换。
+func (c SymbolConstraints) QuantizeQty(qty float64, mode RoundingMode) Decimal {
+	return QuantizeFloat(qty, c.QtyStep(), mode)
+}
+
+// Snap 把已通过 Validate 的价格/数量替换为 tick/step 精确倍数对应的 float，消除计算尾差。
+func (c SymbolConstraints) Snap(price, qty float64) (float64, float64) {
+	if c.TickSize > 0 && price > 0 {
+		price = c.QuantizePrice(price, RoundNearest).Float64()
+	}
+	if c.StepSize > 0 && qty > 0 {
+		qty = c.QuantizeQty(qty, RoundNearest).Float64()
+	}
+	return price, qty
+}
+
 func isMultiple(value, step float64) bool {
 	if step <= 0 {
 		return true
@@ -0,0 +1,204 @@
+package order
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// maxDecimalScale 为支持的最大小数位；Binance 的 tick/step 最细到 1e-8。
+const maxDecimalScale = 18
+
+// Decimal 定点十进制数，值为 units / 10^scale。
+// 价格/数量按 tick/step 对齐后以 Decimal 表示，序列化时不会出现 0.30000000000000004 之类的尾差。
+type Decimal struct {
+	units int64
+	scale int32
+}
+
+// RoundingMode 对齐到 tick/step 时的取整方向。
+type RoundingMode int
+
+const (
+	RoundNearest RoundingMode = iota
+	RoundDown
+	RoundUp
+)
+
+// NewDecimal 构造 units / 10^scale。
+func NewDecimal(units int64, scale int) Decimal {
+	return Decimal{units: units, scale: int32(scale)}.normalize()
+}
+
+// ParseDecimal 解析 "123.4500" 形式的十进制字符串，结果去掉小数末尾的 0。
+func ParseDecimal(s string) (Decimal, error) {
+	raw := strings.TrimSpace(s)
+	if raw == "" {
+		return Decimal{}, fmt.Errorf("empty decimal")
+	}
+	neg := false
+	switch raw[0] {
+	case '-':
+		neg = true
+		raw = raw[1:]
+	case '+':
+		raw = raw[1:]
+	}
+	intPart, fracPart := raw, ""
+	if i := strings.IndexByte(raw, '.'); i >= 0 {
+		intPart, fracPart = raw[:i], raw[i+1:]
+	}
+	if intPart == "" && fracPart == "" {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	fracPart = strings.TrimRight(fracPart, "0")
+	if len(fracPart) > maxDecimalScale {
+		return Decimal{}, fmt.Errorf("decimal %q exceeds %d fractional digits", s, maxDecimalScale)
+	}
+	digits := intPart + fracPart
+	if digits == "" {
+		digits = "0"
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+	}
+	units, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("decimal %q out of range: %w", s, err)
+	}
+	if neg {
+		units = -units
+	}
+	return Decimal{units: units, scale: int32(len(fracPart))}.normalize(), nil
+}
+
+// DecimalFromFloat 取 float64 的最短十进制表示（0.01 -> "0.01"），适合把配置中的 tickSize/stepSize 转为定点数。
+// 小数位超过上限时四舍五入到 maxDecimalScale 位。
+func DecimalFromFloat(v float64) Decimal {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return Decimal{}
+	}
+	d, err := ParseDecimal(strconv.FormatFloat(v, 'f', -1, 64))
+	if err == nil {
+		return d
+	}
+	d, err = ParseDecimal(strconv.FormatFloat(v, 'f', maxDecimalScale, 64))
+	if err == nil {
+		return d
+	}
+	return Decimal{}
+}
+
+// QuantizeFloat 把 v 对齐到 step 的整数倍；step 为 0 时退化为 DecimalFromFloat。
+// 倍数在 float 上计算并容忍 1e-9 的误差，结果由整数乘法得到，因而一定是 step 的精确倍数。
+func QuantizeFloat(v float64, step Decimal, mode RoundingMode) Decimal {
+	if step.units <= 0 {
+		return DecimalFromFloat(v)
+	}
+	n := v / step.Float64()
+	switch mode {
+	case RoundDown:
+		n = math.Floor(n + 1e-9)
+	case RoundUp:
+		n = math.Ceil(n - 1e-9)
+	default:
+		n = math.Round(n)
+	}
+	return Decimal{units: int64(n) * step.units, scale: step.scale}.normalize()
+}
+
+// Float64 返回最接近该十进制值的 float64。
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String 按最简小数位输出（如 "2700.1"），不会超过 tick/step 本身的精度。
+func (d Decimal) String() string {
+	neg := d.units < 0
+	u := d.units
+	if neg {
+		u = -u
+	}
+	s := strconv.FormatInt(u, 10)
+	if d.scale > 0 {
+		if pad := int(d.scale) + 1 - len(s); pad > 0 {
+			s = strings.Repeat("0", pad) + s
+		}
+		cut := len(s) - int(d.scale)
+		s = s[:cut] + "." + s[cut:]
+	}
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
+
+// Scale 返回小数位数。
+func (d Decimal) Scale() int { return int(d.scale) }
+
+// IsZero 是否为 0。
+func (d Decimal) IsZero() bool { return d.units == 0 }
+
+// Sign 返回 -1/0/1。
+func (d Decimal) Sign() int {
+	switch {
+	case d.units > 0:
+		return 1
+	case d.units < 0:
+		return -1
+	}
+	return 0
+}
+
+// Cmp 比较大小：d<o 返回 -1，相等 0，d>o 返回 1。
+func (d Decimal) Cmp(o Decimal) int {
+	a, b := d.units, o.units
+	if d.scale < o.scale {
+		a *= pow10(o.scale - d.scale)
+	} else if o.scale < d.scale {
+		b *= pow10(d.scale - o.scale)
+	}
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// IsMultipleOf 判断 d 是否为 step 的整数倍（step<=0 时恒为 true）。
+func (d Decimal) IsMultipleOf(step Decimal) bool {
+	if step.units <= 0 {
+		return true
+	}
+	if d.scale > step.scale {
+		// d 的小数位比 step 更细，必然不是倍数（两者均已规范化）
+		return false
+	}
+	return (d.units*pow10(step.scale-d.scale))%step.units == 0
+}
+
+// normalize 去掉小数末尾的 0，使同一数值只有一种表示。
+func (d Decimal) normalize() Decimal {
+	if d.units == 0 {
+		return Decimal{}
+	}
+	for d.scale > 0 && d.units%10 == 0 {
+		d.units /= 10
+		d.scale--
+	}
+	return d
+}
+
+func pow10(n int32) int64 {
+	p := int64(1)
+	for i := int32(0); i < n; i++ {
+		p *= 10
+	}
+	return p
+}
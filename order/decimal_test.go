@@ -0,0 +1,84 @@
+package order
+
+import "testing"
+
+func TestParseDecimalRoundTrip(t *testing.T) {
+	cases := map[string]string{
+		"2700.10":    "2700.1",
+		"0.00010000": "0.0001",
+		"-1.50":      "-1.5",
+		"42":         "42",
+		".5":         "0.5",
+		"0.000":      "0",
+	}
+	for in, want := range cases {
+		d, err := ParseDecimal(in)
+		if err != nil {
+			t.Fatalf("parse %q: %v", in, err)
+		}
+		if got := d.String(); got != want {
+			t.Errorf("ParseDecimal(%q) = %s, want %s", in, got, want)
+		}
+	}
+	for _, bad := range []string{"", ".", "1.2.3", "abc", "1e-8"} {
+		if _, err := ParseDecimal(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestQuantizeFloatRemovesFloatTails(t *testing.T) {
+	tick := DecimalFromFloat(0.1)
+	if got := QuantizeFloat(0.1+0.2, tick, RoundNearest).String(); got != "0.3" {
+		t.Fatalf("0.1+0.2 should format as 0.3, got %s", got)
+	}
+	fine := DecimalFromFloat(0.0000001)
+	if got := QuantizeFloat(0.12345678, fine, RoundDown).String(); got != "0.1234567" {
+		t.Fatalf("sub-%%f precision lost: %s", got)
+	}
+	half := DecimalFromFloat(0.5)
+	if got := QuantizeFloat(100.74, half, RoundDown).String(); got != "100.5" {
+		t.Fatalf("round down to 0.5 tick: %s", got)
+	}
+	if got := QuantizeFloat(100.26, half, RoundUp).String(); got != "100.5" {
+		t.Fatalf("round up to 0.5 tick: %s", got)
+	}
+	// 已在网格上的值不因浮点误差被多舍一格
+	if got := QuantizeFloat(2700.3, DecimalFromFloat(0.1), RoundDown).String(); got != "2700.3" {
+		t.Fatalf("on-grid value should stay: %s", got)
+	}
+	if got := QuantizeFloat(2700.3, DecimalFromFloat(0.1), RoundUp).String(); got != "2700.3" {
+		t.Fatalf("on-grid value should stay: %s", got)
+	}
+}
+
+func TestDecimalCompareAndMultiple(t *testing.T) {
+	a, _ := ParseDecimal("1.25")
+	b, _ := ParseDecimal("1.3")
+	if a.Cmp(b) != -1 || b.Cmp(a) != 1 || a.Cmp(NewDecimal(125, 2)) != 0 {
+		t.Fatalf("unexpected compare results")
+	}
+	tick := NewDecimal(5, 2) // 0.05
+	if !a.IsMultipleOf(tick) || b.IsMultipleOf(NewDecimal(25, 2)) {
+		t.Fatalf("unexpected multiple check")
+	}
+	if NewDecimal(1234, 4).IsMultipleOf(NewDecimal(1, 2)) {
+		t.Fatalf("0.1234 is not a multiple of 0.01")
+	}
+	if DecimalFromFloat(0).Sign() != 0 || !DecimalFromFloat(0).IsZero() || NewDecimal(-3, 0).Sign() != -1 {
+		t.Fatalf("unexpected sign")
+	}
+}
+
+func TestManagerSnapsOrdersToGrid(t *testing.T) {
+	gw := &mockGateway{}
+	m := NewManager(gw)
+	m.SetConstraints(map[string]SymbolConstraints{"BTCUSDT": {TickSize: 0.1, StepSize: 0.001}})
+	o, err := m.Submit(Order{Symbol: "BTCUSDT", Price: 0.1 + 0.2, Quantity: 0.001 * 3})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if o.Price != 0.3 || o.Quantity != 0.003 {
+		t.Fatalf("order not snapped to grid: price=%v qty=%v", o.Price, o.Quantity)
+	}
+}
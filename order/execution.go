@@ -0,0 +1,183 @@
+package order
+
+import (
+	"time"
+)
+
+// fillEpsilon 累计成交量比较的容差。
+const fillEpsilon = 1e-9
+
+// ExecutionReport 交易所执行回报（状态变化或成交），与交易所无关。
+// 成交回报 LastQty>0；Symbol/Side/PositionSide 用于未在本地登记的订单（如上次进程遗留的挂单）。
+type ExecutionReport struct {
+	OrderID      string // 本地订单 ID（即 clientOrderId）
+	ExchangeID   string
+	Symbol       string
+	Side         string
+	PositionSide string
+	// Status 交易所给出的订单状态，为空时按累计成交量推导。
+	Status Status
+	// TradeID 成交 ID，用于去重；为空时按 CumQty 去重（CumQty 未超过已计入数量视为重复）。
+	TradeID     string
+	LastQty     float64
+	LastPrice   float64
+	CumQty      float64 // 交易所累计成交量，0 表示未知
+	Fee         float64 // 本笔手续费（正数为支出）
+	FeeAsset    string
+	RealizedPnL float64
+	Maker       bool
+	Time        time.Time
+}
+
+// Fill 一笔新计入的成交，由 ApplyExecution 产生并交给 Manager.OnFill。
+type Fill struct {
+	OrderID      string
+	TradeID      string
+	Symbol       string
+	Side         string
+	PositionSide string
+	Price        float64
+	Qty          float64
+	Fee          float64
+	FeeAsset     string
+	RealizedPnL  float64
+	Maker        bool
+	Time         time.Time
+	// 计入本笔后订单的累计成交、剩余数量与成交均价。
+	FilledQty float64
+	Remaining float64
+	AvgPrice  float64
+	// Known 订单是否在本地登记；未登记订单只按回报字段产生成交事件。
+	Known bool
+}
+
+// SignedQty 按方向带符号的成交量（买为正、卖为负）。
+func (f Fill) SignedQty() float64 {
+	if f.Side == "SELL" {
+		return -f.Qty
+	}
+	return f.Qty
+}
+
+// ApplyExecution 应用一条执行回报：按成交 ID 幂等累计成交量、均价与手续费，推进订单状态，
+// 并对新计入的成交调用 OnFill。重复回报返回 nil, nil。
+// 状态迁移不合法时（如本地已乐观标记为已撤，随后才收到撤单前的成交）保留当前状态，成交照常计入。
+// 未登记的订单仍产生成交事件（Known=false），同时返回 ErrUnknownOrder。
+func (m *Manager) ApplyExecution(r ExecutionReport) (*Fill, error) {
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	m.mu.Lock()
+	o := m.orders[r.OrderID]
+	isFill := r.LastQty > 0 && !m.seenTradeLocked(o, r)
+	if o == nil {
+		m.mu.Unlock()
+		if !isFill {
+			return nil, ErrUnknownOrder
+		}
+		f := fillFromReport(r)
+		f.FilledQty, f.AvgPrice = r.CumQty, r.LastPrice
+		m.emitFill(f)
+		return &f, ErrUnknownOrder
+	}
+	var f *Fill
+	prevStatus := o.Status
+	if isFill {
+		prev := o.FilledQty
+		o.FilledQty += r.LastQty
+		o.AvgFillPrice = (o.AvgFillPrice*prev + r.LastPrice*r.LastQty) / o.FilledQty
+		o.Commission += r.Fee
+		if r.FeeAsset != "" {
+			o.CommissionAsset = r.FeeAsset
+		}
+	}
+	// 漏掉的更早成交以交易所累计量为准（不补发成交事件，库存由仓位对账修正）
+	if r.CumQty > o.FilledQty {
+		o.FilledQty = r.CumQty
+	}
+	// 重复/错序回报不得让成交量超过下单量
+	if o.Quantity > 0 && o.FilledQty > o.Quantity {
+		o.FilledQty = o.Quantity
+	}
+	st := r.Status
+	if st == "" && o.FilledQty > 0 {
+		st = StatusPartial
+		if o.Remaining() <= fillEpsilon {
+			st = StatusFilled
+		}
+	}
+	if o.Status == StatusPendingCancel && (st == StatusNew || st == StatusAck || st == StatusPartial) {
+		// 撤单在途：成交照常计入，状态等撤单结果
+		st = ""
+	}
+	if st != "" && (st != o.Status || st == StatusPartial) {
+		if m.stateMachine.ValidateTransition(o.Status, st) == nil {
+			o.Status = st
+		}
+	}
+	o.UpdatedAt = r.Time
+	if isFill {
+		fill := fillFromReport(r)
+		fill.Symbol, fill.Side, fill.PositionSide = o.Symbol, o.Side, o.PositionSide
+		fill.FilledQty, fill.Remaining, fill.AvgPrice = o.FilledQty, o.Remaining(), o.AvgFillPrice
+		fill.Known = true
+		f = &fill
+		m.journalLocked(JournalFill, o, f)
+	} else if o.Status != prevStatus {
+		m.journalLocked(journalEvent(o.Status), o, nil)
+	}
+	m.releaseTradesLocked(o)
+	m.mu.Unlock()
+	if f != nil {
+		m.emitFill(*f)
+	}
+	return f, nil
+}
+
+func fillFromReport(r ExecutionReport) Fill {
+	return Fill{
+		OrderID:      r.OrderID,
+		TradeID:      r.TradeID,
+		Symbol:       r.Symbol,
+		Side:         r.Side,
+		PositionSide: r.PositionSide,
+		Price:        r.LastPrice,
+		Qty:          r.LastQty,
+		Fee:          r.Fee,
+		FeeAsset:     r.FeeAsset,
+		RealizedPnL:  r.RealizedPnL,
+		Maker:        r.Maker,
+		Time:         r.Time,
+	}
+}
+
+// seenTradeLocked 判断成交是否已计入，未计入时登记其成交 ID。
+// 无成交 ID 或订单已结束（成交 ID 已释放）时按累计成交量判断。
+func (m *Manager) seenTradeLocked(o *Order, r ExecutionReport) bool {
+	if r.TradeID == "" || (o != nil && m.stateMachine.IsFinalState(o.Status)) {
+		return o != nil && r.CumQty > 0 && r.CumQty <= o.FilledQty+fillEpsilon
+	}
+	seen := m.trades[r.OrderID]
+	if _, ok := seen[r.TradeID]; ok {
+		return true
+	}
+	if seen == nil {
+		seen = make(map[string]struct{})
+		m.trades[r.OrderID] = seen
+	}
+	seen[r.TradeID] = struct{}{}
+	return false
+}
+
+// releaseTradesLocked 订单结束后释放其成交 ID 集合，之后的迟到回报按累计成交量去重。
+func (m *Manager) releaseTradesLocked(o *Order) {
+	if m.stateMachine.IsFinalState(o.Status) {
+		delete(m.trades, o.ID)
+	}
+}
+
+func (m *Manager) emitFill(f Fill) {
+	if m.OnFill != nil {
+		m.OnFill(f)
+	}
+}
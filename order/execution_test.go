@@ -0,0 +1,127 @@
+package order
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func submitForFill(t *testing.T, m *Manager, side string, qty float64) *Order {
+	t.Helper()
+	o, err := m.Submit(Order{Symbol: "ETHUSDC", Side: side, Price: 2000, Quantity: qty, PositionSide: "LONG"})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	return o
+}
+
+func TestApplyExecutionPartialThenFull(t *testing.T) {
+	m := NewManager(&mockGateway{})
+	var fills []Fill
+	m.OnFill = func(f Fill) { fills = append(fills, f) }
+	o := submitForFill(t, m, "BUY", 1)
+
+	f, err := m.ApplyExecution(ExecutionReport{OrderID: o.ID, TradeID: "1", LastQty: 0.4, LastPrice: 2000, CumQty: 0.4, Fee: 0.08, FeeAsset: "USDC", Maker: true})
+	if err != nil || f == nil {
+		t.Fatalf("first fill %+v err=%v", f, err)
+	}
+	if o.Status != StatusPartial || f.Remaining != 0.6 || f.FilledQty != 0.4 || !f.Known || f.PositionSide != "LONG" {
+		t.Fatalf("unexpected partial state status=%s fill=%+v", o.Status, f)
+	}
+	f, err = m.ApplyExecution(ExecutionReport{OrderID: o.ID, TradeID: "2", LastQty: 0.6, LastPrice: 2001, CumQty: 1, Fee: 0.12, FeeAsset: "USDC"})
+	if err != nil || f == nil {
+		t.Fatalf("second fill %+v err=%v", f, err)
+	}
+	if o.Status != StatusFilled || o.Remaining() != 0 || math.Abs(o.AvgFillPrice-2000.6) > 1e-9 || math.Abs(o.Commission-0.2) > 1e-9 || o.CommissionAsset != "USDC" {
+		t.Fatalf("unexpected filled order %+v", o)
+	}
+	if len(fills) != 2 || fills[1].SignedQty() != 0.6 || math.Abs(fills[1].AvgPrice-2000.6) > 1e-9 {
+		t.Fatalf("unexpected fill events %+v", fills)
+	}
+}
+
+func TestApplyExecutionDeduplicates(t *testing.T) {
+	m := NewManager(&mockGateway{})
+	n := 0
+	m.OnFill = func(Fill) { n++ }
+	o := submitForFill(t, m, "SELL", 1)
+
+	r := ExecutionReport{OrderID: o.ID, TradeID: "7", LastQty: 0.3, LastPrice: 2000, CumQty: 0.3}
+	m.ApplyExecution(r)
+	if f, err := m.ApplyExecution(r); f != nil || err != nil {
+		t.Fatalf("duplicate trade should be ignored, got %+v err=%v", f, err)
+	}
+	// 无成交 ID 时按累计成交量去重
+	r = ExecutionReport{OrderID: o.ID, LastQty: 0.3, LastPrice: 2000, CumQty: 0.3}
+	if f, _ := m.ApplyExecution(r); f != nil {
+		t.Fatalf("replayed cumulative fill should be ignored, got %+v", f)
+	}
+	r.CumQty = 0.5
+	r.LastQty = 0.2
+	if f, _ := m.ApplyExecution(r); f == nil || f.FilledQty != 0.5 {
+		t.Fatalf("new cumulative fill not applied: %+v", f)
+	}
+	if n != 2 || o.FilledQty != 0.5 {
+		t.Fatalf("fills=%d filled=%v", n, o.FilledQty)
+	}
+}
+
+func TestApplyExecutionReleasesTradesOnFinal(t *testing.T) {
+	m := NewManager(&mockGateway{})
+	n := 0
+	m.OnFill = func(Fill) { n++ }
+	o := submitForFill(t, m, "BUY", 1)
+
+	m.ApplyExecution(ExecutionReport{OrderID: o.ID, TradeID: "1", LastQty: 0.6, LastPrice: 2000, CumQty: 0.6})
+	last := ExecutionReport{OrderID: o.ID, TradeID: "2", LastQty: 0.4, LastPrice: 2000, CumQty: 1}
+	m.ApplyExecution(last)
+	if o.Status != StatusFilled || len(m.trades) != 0 {
+		t.Fatalf("trade ids should be released once filled: status=%s trades=%v", o.Status, m.trades)
+	}
+	// 释放后的重复回报按累计成交量去重
+	if f, _ := m.ApplyExecution(last); f != nil {
+		t.Fatalf("duplicate after release should be ignored, got %+v", f)
+	}
+	// 超出下单量的累计量被截断
+	m.ApplyExecution(ExecutionReport{OrderID: o.ID, TradeID: "3", LastQty: 0.5, LastPrice: 2000, CumQty: 1.5})
+	if o.FilledQty != 1 || n != 3 {
+		t.Fatalf("filled qty should be capped at quantity: filled=%v fills=%d", o.FilledQty, n)
+	}
+}
+
+func TestApplyExecutionFillAfterLocalCancel(t *testing.T) {
+	m := NewManager(&mockGateway{})
+	var got []Fill
+	m.OnFill = func(f Fill) { got = append(got, f) }
+	o := submitForFill(t, m, "BUY", 1)
+	if err := m.Cancel(o.ID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	// 撤单前已成交的部分晚于本地撤单到达：状态保持已撤，成交照常计入
+	f, err := m.ApplyExecution(ExecutionReport{OrderID: o.ID, TradeID: "9", Status: StatusPartial, LastQty: 0.2, LastPrice: 1999, CumQty: 0.2})
+	if err != nil || f == nil {
+		t.Fatalf("late fill %+v err=%v", f, err)
+	}
+	if o.Status != StatusCanceled || o.FilledQty != 0.2 || len(got) != 1 {
+		t.Fatalf("unexpected order after late fill %+v", o)
+	}
+}
+
+func TestApplyExecutionUnknownOrder(t *testing.T) {
+	m := NewManager(&mockGateway{})
+	var got []Fill
+	m.OnFill = func(f Fill) { got = append(got, f) }
+	f, err := m.ApplyExecution(ExecutionReport{OrderID: "stale-1", Symbol: "ETHUSDC", Side: "SELL", TradeID: "1", LastQty: 0.1, LastPrice: 2000})
+	if !errors.Is(err, ErrUnknownOrder) || f == nil || f.Known || f.SignedQty() != -0.1 {
+		t.Fatalf("unexpected unknown fill %+v err=%v", f, err)
+	}
+	if _, err := m.ApplyExecution(ExecutionReport{OrderID: "stale-1", TradeID: "1", LastQty: 0.1, LastPrice: 2000}); !errors.Is(err, ErrUnknownOrder) {
+		t.Fatalf("expected unknown order error, got %v", err)
+	}
+	if _, err := m.ApplyExecution(ExecutionReport{OrderID: "stale-2", Status: StatusCanceled}); !errors.Is(err, ErrUnknownOrder) {
+		t.Fatalf("expected unknown order error, got %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("duplicate unknown fill emitted: %+v", got)
+	}
+}
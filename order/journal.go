@@ -0,0 +1,166 @@
+package order
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 订单日志事件类型，见 JournalEntry.Event。
+const (
+	JournalSubmit = "submit"
+	JournalAck    = "ack"
+	JournalFill   = "fill"
+	JournalAmend  = "amend"
+	JournalCancel = "cancel"
+	JournalStatus = "status"
+	JournalAdopt  = "adopt"
+)
+
+// JournalEntry 订单日志的一行：事件类型与事件发生后的订单快照，成交事件附带本笔成交。
+type JournalEntry struct {
+	Time  time.Time `json:"time"`
+	Event string    `json:"event"`
+	Order Order     `json:"order"`
+	Fill  *Fill     `json:"fill,omitempty"`
+}
+
+// OrderJournal 接收 Manager 的订单事件（在 Manager 锁内同步调用，实现不得回调 Manager）。
+type OrderJournal interface {
+	Record(e JournalEntry)
+}
+
+// FileJournal 追加写的 JSONL 订单日志，每个事件一行、单次 write 落盘（不经用户态缓冲），
+// 进程崩溃最多丢失正在写的一行。
+type FileJournal struct {
+	// OnError 写入失败时回调（可选）。
+	OnError func(error)
+
+	mu   sync.Mutex
+	f    *os.File
+	path string
+}
+
+// OpenJournal 以追加方式打开（不存在时创建）订单日志。
+func OpenJournal(path string) (*FileJournal, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("create journal dir: %w", err)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open journal: %w", err)
+	}
+	return &FileJournal{f: f, path: path}, nil
+}
+
+// Record 实现 OrderJournal。
+func (j *FileJournal) Record(e JournalEntry) {
+	if err := j.Append(e); err != nil && j.OnError != nil {
+		j.OnError(err)
+	}
+}
+
+// Append 写入一行日志。
+func (j *FileJournal) Append(e JournalEntry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encode journal entry: %w", err)
+	}
+	line = append(line, '\n')
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.f == nil {
+		return errors.New("journal closed")
+	}
+	if _, err := j.f.Write(line); err != nil {
+		return fmt.Errorf("write journal: %w", err)
+	}
+	return nil
+}
+
+// Path 返回日志文件路径。
+func (j *FileJournal) Path() string {
+	return j.path
+}
+
+// Close 刷盘并关闭日志。
+func (j *FileJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.f == nil {
+		return nil
+	}
+	err := j.f.Sync()
+	if cerr := j.f.Close(); err == nil {
+		err = cerr
+	}
+	j.f = nil
+	return err
+}
+
+// ReadJournal 读取订单日志；文件不存在时返回空。崩溃时最后一行可能不完整，无法解析的行被跳过并计数。
+func ReadJournal(path string) (entries []JournalEntry, skipped int, err error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("open journal: %w", err)
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for sc.Scan() {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var e JournalEntry
+		if json.Unmarshal(sc.Bytes(), &e) != nil || e.Order.ID == "" {
+			skipped++
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return entries, skipped, fmt.Errorf("read journal: %w", err)
+	}
+	return entries, skipped, nil
+}
+
+// RotateJournal 把已回放的日志移到 path+".prev"（覆盖上一代），新进程从空日志开始记录。
+func RotateJournal(path string) error {
+	err := os.Rename(path, path+".prev")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("rotate journal: %w", err)
+	}
+	return nil
+}
+
+// journalLocked 在持有 m.mu 时记录订单事件。
+func (m *Manager) journalLocked(event string, o *Order, f *Fill) {
+	if m.Journal == nil || o == nil {
+		return
+	}
+	m.Journal.Record(JournalEntry{Time: time.Now(), Event: event, Order: *o, Fill: f})
+}
+
+// journalEvent 状态变化对应的日志事件类型。
+func journalEvent(st Status) string {
+	switch st {
+	case StatusAck:
+		return JournalAck
+	case StatusCanceled:
+		return JournalCancel
+	}
+	return JournalStatus
+}
@@ -0,0 +1,81 @@
+package order
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournalRecordsOrderLifecycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal", "ETHUSDC.jsonl")
+	j, err := OpenJournal(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	m := NewManager(&mockGateway{})
+	m.Journal = j
+	filled, _ := m.Submit(Order{Symbol: "ETHUSDC", Side: "BUY", Price: 2000, Quantity: 1, ClientID: "dyn", Level: 1})
+	m.ApplyExecution(ExecutionReport{OrderID: filled.ID, TradeID: "1", LastQty: 0.4, LastPrice: 2000, CumQty: 0.4})
+	canceled, _ := m.Submit(Order{Symbol: "ETHUSDC", Side: "SELL", Price: 2010, Quantity: 1})
+	if err := m.Cancel(canceled.ID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if err := j.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	entries, skipped, err := ReadJournal(path)
+	if err != nil || skipped != 0 {
+		t.Fatalf("read: %v skipped=%d", err, skipped)
+	}
+	var events []string
+	for _, e := range entries {
+		events = append(events, e.Event)
+	}
+	want := []string{JournalSubmit, JournalAck, JournalFill, JournalSubmit, JournalAck, JournalCancel}
+	if len(events) != len(want) {
+		t.Fatalf("events %v want %v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Fatalf("events %v want %v", events, want)
+		}
+	}
+	if entries[2].Fill == nil || entries[2].Fill.TradeID != "1" {
+		t.Fatalf("fill entry missing trade: %+v", entries[2])
+	}
+	orders := ReplayJournal(entries)
+	if o := orders[filled.ID]; o.Status != StatusPartial || o.FilledQty != 0.4 || o.ClientID != "dyn" || o.Level != 1 {
+		t.Fatalf("unexpected replayed order %+v", o)
+	}
+	if o := orders[canceled.ID]; o.Status != StatusCanceled {
+		t.Fatalf("unexpected replayed order %+v", o)
+	}
+}
+
+func TestReadJournalSkipsTruncatedLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "j.jsonl")
+	j, err := OpenJournal(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	j.Record(JournalEntry{Event: JournalSubmit, Order: Order{ID: "a", Status: StatusNew}})
+	j.Close()
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	f.WriteString(`{"event":"ack","order":{"ID":"a","Sta`)
+	f.Close()
+
+	entries, skipped, err := ReadJournal(path)
+	if err != nil || len(entries) != 1 || skipped != 1 {
+		t.Fatalf("entries=%d skipped=%d err=%v", len(entries), skipped, err)
+	}
+	if err := RotateJournal(path); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if entries, _, err := ReadJournal(path); err != nil || len(entries) != 0 {
+		t.Fatalf("expected empty journal after rotate, got %d err=%v", len(entries), err)
+	}
+	if _, err := os.Stat(path + ".prev"); err != nil {
+		t.Fatalf("previous generation missing: %v", err)
+	}
+}
@@ -20,6 +20,34 @@ type Manager struct {
 	mu           sync.RWMutex
 	orders       map[string]*Order
 	constraints  map[string]SymbolConstraints
+	stpDefaults  map[string]string
+	// trades 各订单已计入的成交 ID，用于 ApplyExecution 去重。
+	trades map[string]map[string]struct{}
+	// cancelFrom 撤单在途（PENDING_CANCEL）订单的撤单前状态，撤单失败时据此回退。
+	cancelFrom map[string]Status
+	pipeOnce   sync.Once
+	pipe       *pipeline
+
+	// OnFill 每笔新计入的成交回调（在锁外同步调用），库存/成交统计/事后分析均由此驱动。
+	OnFill func(Fill)
+	// IDs 为未指定 ID 的订单生成 clientOrderId；本地订单 ID 即交易所 clientOrderId。
+	IDs *ClientIDGenerator
+	// Lookup 下单结果未知（超时/5xx）时按 clientOrderId 查询交易所订单，为 nil 时不重试。
+	Lookup OrderLookup
+	// PlaceRetries 下单结果未知且交易所查无此单时，用同一 ID 重新下单的次数。
+	PlaceRetries int
+	// OnComplete 异步下单/撤单（SubmitAsync/CancelAsync）完成时的回调，在交易对 worker 中调用。
+	OnComplete func(Completion)
+	// QueueSize 异步管道每个交易对的队列长度，默认 256。
+	QueueSize int
+	// Journal 订单日志（可选）：下单、受理、成交、改单、撤单与其它状态变化逐条记录，供重启恢复。
+	Journal OrderJournal
+}
+
+// OrderLookup 按本地订单 ID（clientOrderId）查询交易所订单；查无此单时返回 ErrRemoteOrderNotFound。
+// ExchangeGateway 与 gateway.OrderQueryAdapter 均满足该接口。
+type OrderLookup interface {
+	GetOrder(orderID string) (*Order, error)
 }
 
 func NewManager(gw Gateway) *Manager {
@@ -27,35 +55,152 @@ func NewManager(gw Gateway) *Manager {
 		gw:           gw,
 		stateMachine: NewStateMachine(),
 		orders:       make(map[string]*Order),
+		trades:       make(map[string]map[string]struct{}),
+		cancelFrom:   make(map[string]Status),
+		IDs:          NewClientIDGenerator(""),
+		PlaceRetries: 2,
 	}
 }
 
 var ErrUnknownOrder = errors.New("unknown order")
 
-// Submit 同步调用 Gateway 下单并登记状态。
+// ErrExecutionUnknown 请求已发出但结果未知（超时、5xx、等待响应时断线），订单可能已被交易所受理。
+// gateway 包的超时类错误会 Unwrap 到该哨兵。
+var ErrExecutionUnknown = errors.New("order execution status unknown")
+
+// Submit 同步调用 Gateway 下单并登记状态；结果未知时经 Lookup 确认，必要时以同一 ID 重发。
+// 请求返回前订单登记为 PENDING_NEW，与异步管道一致，对账不会把尚未到达交易所的订单判为失效。
 func (m *Manager) Submit(o Order) (*Order, error) {
+	if err := m.prepare(&o); err != nil {
+		return nil, err
+	}
+	stored := m.register(o, m.submitStatus())
+
+	if m.gw != nil {
+		var remote Status
+		_, err := m.gw.Place(o)
+		if err != nil && isExecutionUnknown(err) {
+			remote, err = m.recoverPlace(o, err)
+		}
+		if err != nil {
+			m.failPlace(o.ID, err)
+			return nil, err
+		}
+		m.markPlaced(o.ID, remote)
+	}
+	return stored, nil
+}
+
+// prepare 补齐默认值、校验并对齐精度，未指定 ID 时生成 clientOrderId。
+func (m *Manager) prepare(o *Order) error {
 	if o.Type == "" {
 		o.Type = "LIMIT"
 	}
-	if err := m.validateConstraint(o); err != nil {
-		return nil, err
+	if err := m.validateConstraint(*o); err != nil {
+		return err
 	}
+	m.snapToGrid(o)
+	m.applyDefaults(o)
 	if o.ID == "" {
-		o.ID = generateID(o.ClientID)
+		o.ID = m.IDs.Next(*o)
 	}
-	o.Status = StatusNew
+	return nil
+}
+
+// submitStatus 同步下单登记的初始状态：有 Gateway 时为 PENDING_NEW，否则保持 NEW。
+func (m *Manager) submitStatus() Status {
+	if m.gw == nil {
+		return StatusNew
+	}
+	return StatusPendingNew
+}
+
+// register 以给定状态登记订单，返回登记的订单。
+func (m *Manager) register(o Order, st Status) *Order {
+	o.Status = st
+	o.CreatedAt = time.Now()
+	o.UpdatedAt = o.CreatedAt
 	m.mu.Lock()
 	m.orders[o.ID] = &o
+	m.journalLocked(JournalSubmit, &o, nil)
 	m.mu.Unlock()
+	return &o
+}
 
-	if m.gw != nil {
-		if _, err := m.gw.Place(o); err != nil {
-			m.updateStatus(o.ID, StatusRejected, err)
-			return nil, err
+// recoverPlace 处理结果未知的下单：按同一 clientOrderId 查询交易所，已受理则返回远端状态并视为成功；
+// 查无此单则用同一 ID 重发（交易所对重复的 clientOrderId 拒单，不会重复挂单），最多 PlaceRetries 次。
+// 查询本身失败时放弃重试，返回原错误。
+func (m *Manager) recoverPlace(o Order, err error) (Status, error) {
+	if m.Lookup == nil {
+		return "", err
+	}
+	for attempt := 0; attempt < m.PlaceRetries && err != nil && isExecutionUnknown(err); attempt++ {
+		remote, qerr := m.Lookup.GetOrder(o.ID)
+		if qerr == nil && remote != nil {
+			return remote.Status, nil
+		}
+		if !errors.Is(qerr, ErrRemoteOrderNotFound) {
+			return "", err
+		}
+		_, err = m.gw.Place(o)
+	}
+	return "", err
+}
+
+// markPlaced 登记下单成功：NEW/PENDING_NEW 推进到 ACK，remote 非空时再以交易所状态为准；
+// 撤单已排队（PENDING_CANCEL）时保持不变，只把撤单失败时的回退状态记为 ACK。
+// 回报先到而状态已越过 ACK 时不回退。
+func (m *Manager) markPlaced(id string, remote Status) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, ok := m.orders[id]
+	if !ok {
+		return
+	}
+	if o.Status == StatusPendingCancel {
+		m.cancelFrom[id] = StatusAck
+		return
+	}
+	for _, st := range []Status{StatusAck, remote} {
+		if st == "" || st == StatusNew || o.Status == st {
+			continue
+		}
+		if m.stateMachine.ValidateTransition(o.Status, st) == nil {
+			o.Status = st
+			o.UpdatedAt = time.Now()
 		}
-		m.updateStatus(o.ID, StatusAck, nil)
 	}
-	return &o, nil
+	m.journalLocked(JournalAck, o, nil)
+}
+
+// failPlace 登记下单失败：明确拒单标记为 REJECTED；结果仍未知时保持 NEW（PENDING_NEW 降为 NEW，仍计为活跃单），
+// 由对账按 clientOrderId 确认最终状态。撤单已排队时撤单失败的回退状态同样降为 NEW。
+func (m *Manager) failPlace(id string, err error) {
+	if isExecutionUnknown(err) {
+		m.mu.Lock()
+		if o, ok := m.orders[id]; ok {
+			if o.Status == StatusPendingNew {
+				o.Status = StatusNew
+			}
+			if m.cancelFrom[id] == StatusPendingNew {
+				m.cancelFrom[id] = StatusNew
+			}
+			o.LastError = err.Error()
+			o.UpdatedAt = time.Now()
+		}
+		m.mu.Unlock()
+		return
+	}
+	m.updateStatus(id, StatusRejected, err)
+}
+
+// isExecutionUnknown 判断错误是否表示请求结果未知：ErrExecutionUnknown 或网络层超时。
+func isExecutionUnknown(err error) bool {
+	if errors.Is(err, ErrExecutionUnknown) {
+		return true
+	}
+	var te interface{ Timeout() bool }
+	return errors.As(err, &te) && te.Timeout()
 }
 
 // Update 收到回报后更新状态。
@@ -104,9 +249,12 @@ func (m *Manager) updateStatus(id string, st Status, err error) error {
 	}
 
 	o.Status = st
+	o.UpdatedAt = time.Now()
 	if err != nil {
 		o.LastError = err.Error()
 	}
+	m.releaseTradesLocked(o)
+	m.journalLocked(journalEvent(st), o, nil)
 	return nil
 }
 
@@ -120,12 +268,24 @@ func (m *Manager) SetConstraints(c map[string]SymbolConstraints) {
 	}
 }
 
-// generateID 简单生成唯一 ID。生产环境应改为雪花/UUID。
-func generateID(prefix string) string {
-	if prefix == "" {
-		prefix = "ord"
+// SetSelfTradePrevention 设置各交易对默认的自成交保护模式，未显式指定 SelfTradePrevention 的订单按此下发。
+func (m *Manager) SetSelfTradePrevention(modes map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stpDefaults = make(map[string]string, len(modes))
+	for sym, mode := range modes {
+		m.stpDefaults[sym] = mode
 	}
-	return prefix + "-" + time.Now().UTC().Format("20060102150405.000000000")
+}
+
+// applyDefaults 为订单补齐交易对级默认值（当前为自成交保护模式）。
+func (m *Manager) applyDefaults(o *Order) {
+	if o.SelfTradePrevention != "" {
+		return
+	}
+	m.mu.RLock()
+	o.SelfTradePrevention = m.stpDefaults[o.Symbol]
+	m.mu.RUnlock()
 }
 
 func (m *Manager) validateConstraint(o Order) error {
@@ -141,6 +301,17 @@ func (m *Manager) validateConstraint(o Order) error {
 	return c.Validate(o.Price, o.Quantity)
 }
 
+// snapToGrid 把通过校验的限价单价格/数量替换为 tick/step 的精确倍数，下游序列化不会带出浮点尾差。
+func (m *Manager) snapToGrid(o *Order) {
+	m.mu.RLock()
+	c, ok := m.constraints[o.Symbol]
+	m.mu.RUnlock()
+	if !ok || o.Type == "MARKET" || o.Type == "market" {
+		return
+	}
+	o.Price, o.Quantity = c.Snap(o.Price, o.Quantity)
+}
+
 // GetActiveOrders 获取所有活跃订单（用于对账）
 func (m *Manager) GetActiveOrders() []*Order {
 	m.mu.RLock()
@@ -1,6 +1,10 @@
 package order
 
-import "testing"
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
 
 type mockGateway struct {
 	placed    []Order
@@ -52,3 +56,105 @@ func TestManagerConstraint(t *testing.T) {
 		t.Fatalf("expected ticksize error")
 	}
 }
+
+func TestManagerAppliesSelfTradePreventionDefault(t *testing.T) {
+	gw := &mockGateway{}
+	m := NewManager(gw)
+	m.SetSelfTradePrevention(map[string]string{"BTCUSDT": STPExpireMaker})
+	o, err := m.Submit(Order{Symbol: "BTCUSDT", Price: 100, Quantity: 1})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if o.SelfTradePrevention != STPExpireMaker {
+		t.Fatalf("default STP not applied: %q", o.SelfTradePrevention)
+	}
+	// 显式指定的模式不被覆盖；其它交易对不受影响
+	sent, errs := m.SubmitBatch([]Order{
+		{Symbol: "BTCUSDT", Price: 100, Quantity: 1, SelfTradePrevention: STPExpireBoth},
+		{Symbol: "ETHUSDT", Price: 100, Quantity: 1},
+	})
+	if errs[0] != nil || errs[1] != nil {
+		t.Fatalf("batch errors: %v", errs)
+	}
+	if sent[0].SelfTradePrevention != STPExpireBoth || sent[1].SelfTradePrevention != "" {
+		t.Fatalf("unexpected STP: %q %q", sent[0].SelfTradePrevention, sent[1].SelfTradePrevention)
+	}
+}
+
+// flakyGateway 前 n 次下单返回结果未知；accepted 为 true 时表示超时的那次请求实际已被交易所受理。
+type flakyGateway struct {
+	mockGateway
+	unknown  int
+	accepted bool
+	remote   map[string]*Order
+	lookups  int
+}
+
+func (f *flakyGateway) Place(o Order) (string, error) {
+	f.placed = append(f.placed, o)
+	if f.unknown > 0 {
+		f.unknown--
+		if f.accepted {
+			f.remote[o.ID] = &Order{ID: o.ID, Status: StatusAck}
+		}
+		return "", fmt.Errorf("place %s: %w", o.ID, ErrExecutionUnknown)
+	}
+	f.remote[o.ID] = &Order{ID: o.ID, Status: StatusAck}
+	return o.ID, nil
+}
+
+func (f *flakyGateway) GetOrder(id string) (*Order, error) {
+	f.lookups++
+	if o, ok := f.remote[id]; ok {
+		return o, nil
+	}
+	return nil, ErrRemoteOrderNotFound
+}
+
+func TestManagerSubmitRecoversAcceptedTimeout(t *testing.T) {
+	gw := &flakyGateway{unknown: 1, accepted: true, remote: map[string]*Order{}}
+	m := NewManager(gw)
+	m.Lookup = gw
+	o, err := m.Submit(Order{Symbol: "ETHUSDC", Side: "BUY", Price: 100, Quantity: 1})
+	if err != nil || o == nil {
+		t.Fatalf("submit: %v", err)
+	}
+	// 交易所已受理：不重发，本地按远端状态登记
+	if len(gw.placed) != 1 || gw.lookups != 1 {
+		t.Fatalf("placed=%d lookups=%d", len(gw.placed), gw.lookups)
+	}
+	if st, _ := m.Status(o.ID); st != StatusAck {
+		t.Fatalf("status %s", st)
+	}
+}
+
+func TestManagerSubmitResendsWithSameID(t *testing.T) {
+	gw := &flakyGateway{unknown: 1, remote: map[string]*Order{}}
+	m := NewManager(gw)
+	m.Lookup = gw
+	o, err := m.Submit(Order{Symbol: "ETHUSDC", Side: "SELL", Price: 100, Quantity: 1})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if len(gw.placed) != 2 || gw.placed[0].ID != gw.placed[1].ID || gw.placed[1].ID != o.ID {
+		t.Fatalf("resend should reuse the client id: %+v", gw.placed)
+	}
+}
+
+func TestManagerSubmitUnknownKeepsOrderActive(t *testing.T) {
+	gw := &flakyGateway{unknown: 5, remote: map[string]*Order{}}
+	m := NewManager(gw)
+	m.Lookup = gw
+	_, err := m.Submit(Order{Symbol: "ETHUSDC", Side: "BUY", Price: 100, Quantity: 1})
+	if !errors.Is(err, ErrExecutionUnknown) {
+		t.Fatalf("expected unknown error, got %v", err)
+	}
+	if len(gw.placed) != 1+m.PlaceRetries {
+		t.Fatalf("placed %d times", len(gw.placed))
+	}
+	// 结果仍未知：保持 NEW 交给对账，而不是标记为拒单
+	active := m.GetActiveOrders()
+	if len(active) != 1 || active[0].Status != StatusNew || active[0].LastError == "" {
+		t.Fatalf("unexpected active orders %+v", active)
+	}
+}
@@ -0,0 +1,22 @@
+package order
+
+// 自成交保护模式（Binance selfTradePreventionMode）。
+const (
+	STPNone        = "NONE"
+	STPExpireTaker = "EXPIRE_TAKER"
+	STPExpireMaker = "EXPIRE_MAKER"
+	STPExpireBoth  = "EXPIRE_BOTH"
+)
+
+// 按盘口定价（Binance priceMatch）：OPPONENT 取对手方第 1/5/10/20 档，QUEUE 取己方第 1/5/10/20 档。
+const (
+	PriceMatchNone       = "NONE"
+	PriceMatchOpponent   = "OPPONENT"
+	PriceMatchOpponent5  = "OPPONENT_5"
+	PriceMatchOpponent10 = "OPPONENT_10"
+	PriceMatchOpponent20 = "OPPONENT_20"
+	PriceMatchQueue      = "QUEUE"
+	PriceMatchQueue5     = "QUEUE_5"
+	PriceMatchQueue10    = "QUEUE_10"
+	PriceMatchQueue20    = "QUEUE_20"
+)
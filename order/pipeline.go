@@ -0,0 +1,317 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 异步请求类型，见 Completion.Op。
+const (
+	OpPlace  = "place"
+	OpCancel = "cancel"
+)
+
+var (
+	// ErrQueueFull 交易对的请求队列已满，请求未入队。
+	ErrQueueFull = errors.New("order pipeline queue full")
+	// ErrPipelineClosed 管道已关闭，请求未入队。
+	ErrPipelineClosed = errors.New("order pipeline closed")
+)
+
+// defaultQueueSize 每个交易对的默认队列长度。
+const defaultQueueSize = 256
+
+// Completion 一笔异步下单/撤单的结果；Order 为完成时的订单快照。
+type Completion struct {
+	Op    string
+	Order Order
+	Err   error
+}
+
+// pipeJob 一次 Gateway 往返：整批下单或整批撤单。
+type pipeJob struct {
+	op     string
+	orders []Order
+	ids    []string
+	done   func(Completion)
+}
+
+// pipeline 按交易对排队的异步请求：每个交易对一个 worker 顺序执行，同一交易对内先下后撤的顺序得以保持，
+// 不同交易对互不阻塞。
+type pipeline struct {
+	size    int
+	mu      sync.Mutex
+	workers map[string]chan pipeJob
+	wg      sync.WaitGroup
+	closed  bool
+}
+
+func (m *Manager) asyncPipeline() *pipeline {
+	m.pipeOnce.Do(func() {
+		size := m.QueueSize
+		if size <= 0 {
+			size = defaultQueueSize
+		}
+		m.pipe = &pipeline{size: size, workers: make(map[string]chan pipeJob)}
+	})
+	return m.pipe
+}
+
+// enqueue 把请求放入交易对队列，队列满或已关闭时立即返回错误，不阻塞调用方。
+func (m *Manager) enqueue(symbol string, j pipeJob) error {
+	p := m.asyncPipeline()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrPipelineClosed
+	}
+	ch, ok := p.workers[symbol]
+	if !ok {
+		ch = make(chan pipeJob, p.size)
+		p.workers[symbol] = ch
+		go m.runWorker(ch)
+	}
+	p.wg.Add(1)
+	select {
+	case ch <- j:
+		return nil
+	default:
+		p.wg.Done()
+		return ErrQueueFull
+	}
+}
+
+func (m *Manager) runWorker(ch chan pipeJob) {
+	for j := range ch {
+		if j.op == OpPlace {
+			m.runPlace(j)
+		} else {
+			m.runCancel(j)
+		}
+		m.pipe.wg.Done()
+	}
+}
+
+// SubmitAsync 校验并登记订单（PENDING_NEW）后入队，立即返回订单快照；下单结果经 done 与 OnComplete 回调
+// （在 worker goroutine 中调用）。入队失败时订单标记为 REJECTED 并返回错误。未配置 Gateway 时与 Submit 相同。
+func (m *Manager) SubmitAsync(o Order, done func(Completion)) (*Order, error) {
+	sent, errs := m.SubmitBatchAsync([]Order{o}, done)
+	return sent[0], errs[0]
+}
+
+// SubmitBatchAsync 批量版本的 SubmitAsync：同一交易对的订单作为一次批量请求下发，每笔订单各回调一次。
+// 返回与 orders 等长的快照与错误。
+func (m *Manager) SubmitBatchAsync(orders []Order, done func(Completion)) ([]*Order, []error) {
+	if m.gw == nil {
+		return m.SubmitBatch(orders)
+	}
+	sent := make([]*Order, len(orders))
+	errs := make([]error, len(orders))
+	bySymbol := make(map[string][]int)
+	var symbols []string
+	for i, o := range orders {
+		if err := m.prepare(&o); err != nil {
+			errs[i] = err
+			continue
+		}
+		orders[i] = o
+		snap := *m.register(o, StatusPendingNew)
+		sent[i] = &snap
+		if _, ok := bySymbol[o.Symbol]; !ok {
+			symbols = append(symbols, o.Symbol)
+		}
+		bySymbol[o.Symbol] = append(bySymbol[o.Symbol], i)
+	}
+	for _, sym := range symbols {
+		idx := bySymbol[sym]
+		batch := make([]Order, len(idx))
+		for k, i := range idx {
+			batch[k] = orders[i]
+		}
+		if err := m.enqueue(sym, pipeJob{op: OpPlace, orders: batch, done: done}); err != nil {
+			for _, i := range idx {
+				m.updateStatus(orders[i].ID, StatusRejected, err)
+				sent[i], errs[i] = nil, err
+			}
+		}
+	}
+	return sent, errs
+}
+
+// CancelAsync 把订单标记为 PENDING_CANCEL 并入队撤单，立即返回；撤单结果经 done 与 OnComplete 回调。
+// 下单在途的订单同样可撤，撤单在同一交易对队列中排在下单之后执行。
+func (m *Manager) CancelAsync(id string, done func(Completion)) error {
+	return m.CancelBatchAsync([]string{id}, done)[0]
+}
+
+// CancelBatchAsync 批量版本的 CancelAsync，返回与 ids 等长的入队错误。
+func (m *Manager) CancelBatchAsync(ids []string, done func(Completion)) []error {
+	if m.gw == nil {
+		return m.CancelBatch(ids)
+	}
+	errs := make([]error, len(ids))
+	bySymbol := make(map[string][]int)
+	var symbols []string
+	m.mu.Lock()
+	for i, id := range ids {
+		o, ok := m.orders[id]
+		if !ok {
+			errs[i] = ErrUnknownOrder
+			continue
+		}
+		if o.Status == StatusPendingCancel {
+			// 已在撤单途中，不重复入队
+			continue
+		}
+		if !m.stateMachine.CanCancel(o.Status) {
+			errs[i] = fmt.Errorf("order %s not cancelable in state %s", id, o.Status)
+			continue
+		}
+		m.cancelFrom[id] = o.Status
+		o.Status = StatusPendingCancel
+		o.UpdatedAt = time.Now()
+		if _, ok := bySymbol[o.Symbol]; !ok {
+			symbols = append(symbols, o.Symbol)
+		}
+		bySymbol[o.Symbol] = append(bySymbol[o.Symbol], i)
+	}
+	m.mu.Unlock()
+	for _, sym := range symbols {
+		idx := bySymbol[sym]
+		batch := make([]string, len(idx))
+		for k, i := range idx {
+			batch[k] = ids[i]
+		}
+		if err := m.enqueue(sym, pipeJob{op: OpCancel, ids: batch, done: done}); err != nil {
+			for _, i := range idx {
+				m.restoreCancel(ids[i])
+				errs[i] = err
+			}
+		}
+	}
+	return errs
+}
+
+func (m *Manager) runPlace(j pipeJob) {
+	results := m.placeBatch(j.orders)
+	for i, o := range j.orders {
+		var remote Status
+		err := results[i].Err
+		if err != nil && isExecutionUnknown(err) {
+			remote, err = m.recoverPlace(o, err)
+		}
+		if err != nil {
+			m.failPlace(o.ID, err)
+		} else {
+			m.markPlaced(o.ID, remote)
+		}
+		m.complete(j.done, OpPlace, o.ID, err)
+	}
+}
+
+func (m *Manager) runCancel(j pipeJob) {
+	// 排队期间已成交/被拒/已撤的订单不再发撤单
+	ids := make([]string, 0, len(j.ids))
+	for _, id := range j.ids {
+		if st, ok := m.Status(id); ok && st == StatusPendingCancel {
+			ids = append(ids, id)
+			continue
+		}
+		m.mu.Lock()
+		delete(m.cancelFrom, id)
+		m.mu.Unlock()
+		m.complete(j.done, OpCancel, id, nil)
+	}
+	if len(ids) == 0 {
+		return
+	}
+	results := m.cancelBatch(ids)
+	for i, id := range ids {
+		err := results[i].Err
+		if err == nil {
+			m.mu.Lock()
+			if o, ok := m.orders[id]; ok && o.Status == StatusPendingCancel {
+				o.Status = StatusCanceled
+				o.UpdatedAt = time.Now()
+				m.journalLocked(JournalCancel, o, nil)
+			}
+			delete(m.cancelFrom, id)
+			m.mu.Unlock()
+		} else {
+			m.restoreCancel(id)
+		}
+		m.complete(j.done, OpCancel, id, err)
+	}
+}
+
+// restoreCancel 撤单失败或未入队时回到撤单前的状态（期间已被回报推进的不覆盖）。
+func (m *Manager) restoreCancel(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prev, ok := m.cancelFrom[id]
+	delete(m.cancelFrom, id)
+	if o := m.orders[id]; o != nil && o.Status == StatusPendingCancel {
+		if !ok {
+			prev = StatusAck
+		}
+		o.Status = prev
+		o.UpdatedAt = time.Now()
+	}
+}
+
+func (m *Manager) complete(done func(Completion), op, id string, err error) {
+	c := Completion{Op: op, Err: err}
+	m.mu.RLock()
+	if o, ok := m.orders[id]; ok {
+		c.Order = *o
+	}
+	m.mu.RUnlock()
+	if done != nil {
+		done(c)
+	}
+	if m.OnComplete != nil {
+		m.OnComplete(c)
+	}
+}
+
+// Flush 阻塞直到已入队的异步请求全部完成。
+func (m *Manager) Flush() {
+	m.asyncPipeline().wg.Wait()
+}
+
+// Close 等待已入队的请求完成后停止 worker，此后异步请求返回 ErrPipelineClosed。
+func (m *Manager) Close() {
+	p := m.asyncPipeline()
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	p.mu.Unlock()
+	p.wg.Wait()
+	p.mu.Lock()
+	for _, ch := range p.workers {
+		close(ch)
+	}
+	p.mu.Unlock()
+}
+
+// InFlight 返回交易对下单在途（PENDING_NEW）订单的买、卖剩余数量，用于按在途敞口做决策。
+func (m *Manager) InFlight(symbol string) (buy, sell float64) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, o := range m.orders {
+		if o.Symbol != symbol || o.Status != StatusPendingNew {
+			continue
+		}
+		if o.Side == "SELL" {
+			sell += o.Remaining()
+		} else {
+			buy += o.Remaining()
+		}
+	}
+	return buy, sell
+}
@@ -0,0 +1,218 @@
+package order
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// gatedGateway 在 release 关闭前阻塞下单，并按执行顺序记录请求。
+type gatedGateway struct {
+	mu        sync.Mutex
+	ops       []string
+	release   chan struct{}
+	errPlace  error
+	errCancel error
+}
+
+func newGatedGateway() *gatedGateway {
+	return &gatedGateway{release: make(chan struct{})}
+}
+
+func (g *gatedGateway) Place(o Order) (string, error) {
+	<-g.release
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.ops = append(g.ops, "place:"+o.ID)
+	return o.ID, g.errPlace
+}
+
+func (g *gatedGateway) Cancel(id string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.ops = append(g.ops, "cancel:"+id)
+	return g.errCancel
+}
+
+func (g *gatedGateway) Ops() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]string(nil), g.ops...)
+}
+
+func TestSubmitAsyncPendingUntilAck(t *testing.T) {
+	gw := newGatedGateway()
+	m := NewManager(gw)
+	var mu sync.Mutex
+	var got []Completion
+	sent, err := m.SubmitAsync(Order{Symbol: "ETHUSDC", Side: "BUY", Price: 2000, Quantity: 0.5}, func(c Completion) {
+		mu.Lock()
+		got = append(got, c)
+		mu.Unlock()
+	})
+	if err != nil || sent.Status != StatusPendingNew {
+		t.Fatalf("expected pending order, got %+v err=%v", sent, err)
+	}
+	if buy, sell := m.InFlight("ETHUSDC"); buy != 0.5 || sell != 0 {
+		t.Fatalf("unexpected in-flight buy=%v sell=%v", buy, sell)
+	}
+	close(gw.release)
+	m.Flush()
+	if st, _ := m.Status(sent.ID); st != StatusAck {
+		t.Fatalf("expected ACK after completion, got %s", st)
+	}
+	if len(got) != 1 || got[0].Op != OpPlace || got[0].Err != nil || got[0].Order.Status != StatusAck {
+		t.Fatalf("unexpected completions %+v", got)
+	}
+	if buy, _ := m.InFlight("ETHUSDC"); buy != 0 {
+		t.Fatalf("in-flight not cleared: %v", buy)
+	}
+}
+
+func TestCancelAsyncRunsAfterPendingPlace(t *testing.T) {
+	gw := newGatedGateway()
+	m := NewManager(gw)
+	sent, err := m.SubmitAsync(Order{Symbol: "ETHUSDC", Side: "SELL", Price: 2000, Quantity: 1}, nil)
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if err := m.CancelAsync(sent.ID, nil); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if st, _ := m.Status(sent.ID); st != StatusPendingCancel {
+		t.Fatalf("expected PENDING_CANCEL, got %s", st)
+	}
+	close(gw.release)
+	m.Flush()
+	ops := gw.Ops()
+	if len(ops) != 2 || ops[0] != "place:"+sent.ID || ops[1] != "cancel:"+sent.ID {
+		t.Fatalf("cancel not ordered after place: %v", ops)
+	}
+	if st, _ := m.Status(sent.ID); st != StatusCanceled {
+		t.Fatalf("expected CANCELED, got %s", st)
+	}
+}
+
+func TestCancelAsyncFailureRestoresStatus(t *testing.T) {
+	gw := newGatedGateway()
+	close(gw.release)
+	gw.errCancel = errors.New("unknown order sent")
+	m := NewManager(gw)
+	var completions []Completion
+	m.OnComplete = func(c Completion) { completions = append(completions, c) }
+	sent, err := m.Submit(Order{Symbol: "ETHUSDC", Side: "BUY", Price: 2000, Quantity: 1})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if err := m.CancelAsync(sent.ID, nil); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	m.Flush()
+	if st, _ := m.Status(sent.ID); st != StatusAck {
+		t.Fatalf("expected status restored to ACK, got %s", st)
+	}
+	if len(completions) != 1 || completions[0].Op != OpCancel || completions[0].Err == nil {
+		t.Fatalf("unexpected completions %+v", completions)
+	}
+}
+
+func TestCancelPendingPlaceFailureRestoresPlacedStatus(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		errPlace error
+		want     Status
+	}{
+		{"acked", nil, StatusAck},
+		{"unknown", ErrExecutionUnknown, StatusNew},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gw := newGatedGateway()
+			gw.errPlace = tc.errPlace
+			gw.errCancel = errors.New("unknown order sent")
+			m := NewManager(gw)
+			sent, err := m.SubmitAsync(Order{Symbol: "ETHUSDC", Side: "BUY", Price: 2000, Quantity: 1}, nil)
+			if err != nil {
+				t.Fatalf("submit: %v", err)
+			}
+			if err := m.CancelAsync(sent.ID, nil); err != nil {
+				t.Fatalf("cancel: %v", err)
+			}
+			close(gw.release)
+			m.Flush()
+			// 下单已有结果后撤单失败，不能回到 PENDING_NEW
+			if st, _ := m.Status(sent.ID); st != tc.want {
+				t.Fatalf("expected %s after failed cancel, got %s", tc.want, st)
+			}
+			if buy, _ := m.InFlight("ETHUSDC"); buy != 0 {
+				t.Fatalf("order stuck in flight: %v", buy)
+			}
+		})
+	}
+}
+
+func TestSubmitAsyncRejected(t *testing.T) {
+	gw := newGatedGateway()
+	close(gw.release)
+	gw.errPlace = errors.New("insufficient margin")
+	m := NewManager(gw)
+	var got Completion
+	sent, err := m.SubmitAsync(Order{Symbol: "ETHUSDC", Side: "BUY", Price: 2000, Quantity: 1}, func(c Completion) { got = c })
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	m.Flush()
+	if got.Err == nil || got.Order.Status != StatusRejected {
+		t.Fatalf("expected rejected completion, got %+v", got)
+	}
+	if st, _ := m.Status(sent.ID); st != StatusRejected {
+		t.Fatalf("expected REJECTED, got %s", st)
+	}
+}
+
+func TestSubmitAsyncQueueFullAndClose(t *testing.T) {
+	gw := newGatedGateway()
+	m := NewManager(gw)
+	m.QueueSize = 1
+	var full *Order
+	for i := 0; i < 3; i++ {
+		o := Order{Symbol: "ETHUSDC", Side: "BUY", Price: 2000, Quantity: 1}
+		if _, err := m.SubmitAsync(o, nil); errors.Is(err, ErrQueueFull) {
+			full = &o
+			break
+		}
+	}
+	if full == nil {
+		t.Fatalf("expected queue full with QueueSize=1")
+	}
+	close(gw.release)
+	m.Close()
+	if _, err := m.SubmitAsync(Order{Symbol: "ETHUSDC", Side: "BUY", Price: 2000, Quantity: 1}, nil); !errors.Is(err, ErrPipelineClosed) {
+		t.Fatalf("expected closed pipeline error, got %v", err)
+	}
+	if buy, _ := m.InFlight("ETHUSDC"); buy != 0 {
+		t.Fatalf("rejected or completed orders counted in flight: %v", buy)
+	}
+}
+
+func TestPendingStateTransitions(t *testing.T) {
+	sm := NewStateMachine()
+	for _, tc := range []struct {
+		from, to Status
+		ok       bool
+	}{
+		{StatusPendingNew, StatusAck, true},
+		{StatusPendingNew, StatusPendingCancel, true},
+		{StatusPendingCancel, StatusCanceled, true},
+		{StatusPendingCancel, StatusAck, true},
+		{StatusAck, StatusPendingCancel, true},
+		{StatusFilled, StatusPendingCancel, false},
+		{StatusCanceled, StatusPendingNew, false},
+	} {
+		if err := sm.ValidateTransition(tc.from, tc.to); (err == nil) != tc.ok {
+			t.Errorf("%s -> %s: err=%v want ok=%v", tc.from, tc.to, err, tc.ok)
+		}
+	}
+	if !sm.IsActiveState(StatusPendingNew) || !sm.IsActiveState(StatusPendingCancel) || !sm.CanCancel(StatusPendingNew) {
+		t.Fatalf("pending states should be active and cancelable")
+	}
+}
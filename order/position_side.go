@@ -0,0 +1,32 @@
+package order
+
+import "strings"
+
+// 持仓方向（Binance positionSide）。
+const (
+	PositionSideBoth  = "BOTH"
+	PositionSideLong  = "LONG"
+	PositionSideShort = "SHORT"
+)
+
+// IsHedgeSide 判断 positionSide 是否为双向持仓下的某一条腿。
+func IsHedgeSide(positionSide string) bool {
+	ps := strings.ToUpper(positionSide)
+	return ps == PositionSideLong || ps == PositionSideShort
+}
+
+// OpeningPositionSide 返回 side 开仓所对应的腿：BUY 开多，SELL 开空。
+func OpeningPositionSide(side string) string {
+	if strings.EqualFold(side, "SELL") {
+		return PositionSideShort
+	}
+	return PositionSideLong
+}
+
+// ClosingPositionSide 返回 side 平仓所对应的腿：SELL 平多，BUY 平空。
+func ClosingPositionSide(side string) string {
+	if strings.EqualFold(side, "SELL") {
+		return PositionSideLong
+	}
+	return PositionSideShort
+}
@@ -2,22 +2,76 @@ package order
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"sync"
 	"time"
 )
 
+// 对账时 ExchangeGateway 可返回的错误哨兵（gateway 包的结构化错误会 Unwrap 到这些值）。
+var (
+	// ErrRemoteOrderNotFound 交易所查无此单（Binance -2013）：本地活跃订单视为已失效。
+	ErrRemoteOrderNotFound = errors.New("order not found on exchange")
+)
+
 // ExchangeGateway 交易所接口（用于对账）
 type ExchangeGateway interface {
 	GetOrder(orderID string) (*Order, error)
 	GetOpenOrders(symbol string) ([]*Order, error)
 }
 
+// RemoteCanceler 为 ExchangeGateway 的可选扩展：撤销本地未登记的交易所挂单（按 Order.ExchangeID）。
+type RemoteCanceler interface {
+	CancelRemote(o *Order) error
+}
+
+// 孤儿挂单（交易所上有、本地未登记或已结束的本系统挂单）的处置策略，见 ReconcilerConfig.OrphanPolicy。
+const (
+	OrphanReport = "report"
+	OrphanCancel = "cancel"
+	OrphanAdopt  = "adopt"
+)
+
+// 对账差异类型，见 Discrepancy.Kind。
+const (
+	DiscrepancyStatus   = "status_mismatch"
+	DiscrepancyMissing  = "missing_order"
+	DiscrepancyOrphan   = "orphan_order"
+	DiscrepancyPosition = "position_mismatch"
+)
+
+// 对账差异的处置结果，见 Discrepancy.Action。
+const (
+	ActionReported = "reported"
+	ActionUpdated  = "updated"
+	ActionExpired  = "expired"
+	ActionCanceled = "canceled"
+	ActionAdopted  = "adopted"
+	ActionSynced   = "synced"
+	ActionHalted   = "halted"
+	ActionFailed   = "failed"
+)
+
+// Discrepancy 一条对账差异：订单类差异带 OrderID 与本地/远端状态，仓位差异带本地/远端净仓位。
+type Discrepancy struct {
+	Kind         string
+	Action       string
+	Symbol       string
+	OrderID      string
+	LocalStatus  Status
+	RemoteStatus Status
+	LocalQty     float64
+	RemoteQty    float64
+	Err          error
+}
+
 // Reconciler 订单对账器
 type Reconciler struct {
 	gateway  ExchangeGateway
 	manager  *Manager
 	interval time.Duration
+	config   ReconcilerConfig
 
 	stopChan chan struct{}
 	doneChan chan struct{}
@@ -26,12 +80,35 @@ type Reconciler struct {
 	// 统计信息
 	totalReconciliations int64
 	conflictsResolved    int64
+	discrepancies        int64
 	lastReconcileTime    time.Time
+	positionDrift        float64
+	driftStreak          int
 }
 
 // ReconcilerConfig 对账器配置
 type ReconcilerConfig struct {
 	Interval time.Duration // 对账间隔
+	// Symbol 非空时按交易对对账（ReconcileBySymbol）：一次查询挂单列表，另外发现孤儿挂单与交易所已不存在的本地订单。
+	Symbol string
+	// OrphanPolicy 孤儿挂单处置：report（默认，只报告）、cancel（需 gateway 实现 RemoteCanceler）、
+	// adopt（登记到 Manager，本地已结束的订单仍撤销）。非本系统前缀的挂单（手工单等）不处理。
+	OrphanPolicy string
+	// Owns 判断挂单是否归本实例处置（如 ClientIDGenerator.Owns 只认本 run），为 nil 时按 IsOwnClientID。
+	// 同一账户同时运行多个实例时必须设置，否则会处置其它实例的挂单。
+	Owns func(id string) bool
+
+	// LocalPosition/RemotePosition 均非空时每轮核对净仓位；偏差超过 PositionTolerance 且连续两轮存在
+	// （排除成交回报与仓位查询的时间差）时调用 SyncPosition 以交易所为准修正；偏差达到 HaltDrift（>0）时立即调用 OnHalt。
+	LocalPosition     func() float64
+	RemotePosition    func() (float64, error)
+	SyncPosition      func()
+	PositionTolerance float64
+	HaltDrift         float64
+	OnHalt            func(reason string)
+
+	// OnDiscrepancy 每条差异的回调（在对账 goroutine 中调用），用于结构化日志与指标。
+	OnDiscrepancy func(Discrepancy)
 }
 
 // NewReconciler 创建订单对账器
@@ -40,10 +117,15 @@ func NewReconciler(gateway ExchangeGateway, manager *Manager, config ReconcilerC
 		config.Interval = 30 * time.Second // 默认30秒
 	}
 
+	if config.OrphanPolicy == "" {
+		config.OrphanPolicy = OrphanReport
+	}
+
 	return &Reconciler{
 		gateway:  gateway,
 		manager:  manager,
 		interval: config.Interval,
+		config:   config,
 		stopChan: make(chan struct{}),
 		doneChan: make(chan struct{}),
 	}
@@ -91,18 +173,29 @@ func (r *Reconciler) Reconcile() error {
 	r.lastReconcileTime = time.Now()
 	r.mu.Unlock()
 
-	// 获取本地所有活跃订单
-	localOrders := r.manager.GetActiveOrders()
-
-	// 对每个订单进行对账
 	var reconcileErr error
-	for _, localOrder := range localOrders {
-		if err := r.reconcileOrder(localOrder); err != nil {
-			reconcileErr = err
-			// 继续处理其他订单
+	if r.config.Symbol != "" {
+		reconcileErr = r.ReconcileBySymbol(r.config.Symbol)
+	} else {
+		// 获取本地所有活跃订单
+		localOrders := r.manager.GetActiveOrders()
+
+		// 对每个订单进行对账
+		for _, localOrder := range localOrders {
+			if isInFlight(localOrder.Status) {
+				// 请求在途，交易所可能尚未看到该订单，等异步结果再对账
+				continue
+			}
+			if err := r.reconcileOrder(localOrder); err != nil {
+				reconcileErr = err
+				// 继续处理其他订单
+			}
 		}
 	}
 
+	if err := r.reconcilePosition(); err != nil {
+		reconcileErr = err
+	}
 	return reconcileErr
 }
 
@@ -110,6 +203,20 @@ func (r *Reconciler) Reconcile() error {
 func (r *Reconciler) reconcileOrder(localOrder *Order) error {
 	// 从交易所获取订单状态
 	remoteOrder, err := r.gateway.GetOrder(localOrder.ID)
+	if errors.Is(err, ErrRemoteOrderNotFound) {
+		// 交易所不存在该订单（下单未到达或已过期清理），本地按已失效处理
+		d := Discrepancy{Kind: DiscrepancyMissing, Action: ActionExpired, Symbol: localOrder.Symbol, OrderID: localOrder.ID, LocalStatus: localOrder.Status}
+		if err := r.manager.UpdateStatus(localOrder.ID, StatusExpired); err != nil {
+			d.Action, d.Err = ActionFailed, err
+			r.report(d)
+			return fmt.Errorf("update status failed: %w", err)
+		}
+		r.report(d)
+		r.mu.Lock()
+		r.conflictsResolved++
+		r.mu.Unlock()
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("get remote order failed: %w", err)
 	}
@@ -129,10 +236,14 @@ func (r *Reconciler) resolveConflict(local, remote *Order) error {
 	// 检查订单状态
 	if local.Status != remote.Status {
 		hasConflict = true
+		d := Discrepancy{Kind: DiscrepancyStatus, Action: ActionUpdated, Symbol: local.Symbol, OrderID: local.ID, LocalStatus: local.Status, RemoteStatus: remote.Status}
 		// 以交易所状态为准
 		if err := r.manager.UpdateStatus(local.ID, remote.Status); err != nil {
+			d.Action, d.Err = ActionFailed, err
+			r.report(d)
 			return fmt.Errorf("update status failed: %w", err)
 		}
+		r.report(d)
 	}
 
 	// 可以在这里添加更多字段的对比
@@ -148,7 +259,8 @@ func (r *Reconciler) resolveConflict(local, remote *Order) error {
 	return nil
 }
 
-// ReconcileBySymbol 对指定交易对进行对账
+// ReconcileBySymbol 对指定交易对进行对账：交易所挂单与本地活跃订单逐一比对；
+// 本地未登记或已结束的本系统挂单按 OrphanPolicy 处置，本地活跃但不在挂单列表中的订单再单独查询确认终态。
 func (r *Reconciler) ReconcileBySymbol(symbol string) error {
 	// 从交易所获取所有活跃订单
 	remoteOrders, err := r.gateway.GetOpenOrders(symbol)
@@ -165,22 +277,142 @@ func (r *Reconciler) ReconcileBySymbol(symbol string) error {
 		localOrderMap[order.ID] = order
 	}
 
-	// 检查交易所订单是否在本地存在
+	var reconcileErr error
+	onExchange := make(map[string]struct{}, len(remoteOrders))
 	for _, remoteOrder := range remoteOrders {
+		onExchange[remoteOrder.ID] = struct{}{}
 		if localOrder, exists := localOrderMap[remoteOrder.ID]; exists {
+			if isInFlight(localOrder.Status) {
+				continue
+			}
 			// 本地存在，进行对账
 			if err := r.resolveConflict(localOrder, remoteOrder); err != nil {
-				return err
+				reconcileErr = err
 			}
-		} else {
-			// 本地不存在但交易所存在，可能是遗漏的订单
-			// 这里可以选择添加到本地或者记录告警
+		} else if err := r.handleOrphan(symbol, remoteOrder); err != nil {
+			reconcileErr = err
 		}
 	}
 
+	// 本地活跃但已不在挂单列表：已成交/撤销/过期，查询确认终态
+	for _, localOrder := range localOrders {
+		if _, ok := onExchange[localOrder.ID]; ok || isInFlight(localOrder.Status) {
+			continue
+		}
+		if err := r.reconcileOrder(localOrder); err != nil {
+			reconcileErr = err
+		}
+	}
+
+	return reconcileErr
+}
+
+// handleOrphan 处置交易所上有、本地未登记或已结束的挂单；非本系统 ID 的挂单不处理。
+func (r *Reconciler) handleOrphan(symbol string, remote *Order) error {
+	owns := r.config.Owns
+	if owns == nil {
+		owns = IsOwnClientID
+	}
+	if !owns(remote.ID) {
+		return nil
+	}
+	d := Discrepancy{Kind: DiscrepancyOrphan, Action: ActionReported, Symbol: symbol, OrderID: remote.ID, RemoteStatus: remote.Status}
+	_, err := r.manager.GetOrder(remote.ID)
+	known := err == nil
+	if known {
+		st, _ := r.manager.Status(remote.ID)
+		d.LocalStatus = st
+	}
+	policy := r.config.OrphanPolicy
+	if policy == OrphanAdopt && known {
+		// 本地已结束的订单不再接管
+		policy = OrphanCancel
+	}
+	switch policy {
+	case OrphanAdopt:
+		o := *remote
+		if o.Symbol == "" {
+			o.Symbol = symbol
+		}
+		if p, ok := ParseClientID(o.ID); ok {
+			o.ClientID, o.Level = p.Tag, p.Level
+		}
+		if o.Status == "" || o.Status == StatusNew {
+			o.Status = StatusAck
+		}
+		r.manager.Restore([]Order{o})
+		d.Action = ActionAdopted
+	case OrphanCancel:
+		canceler, ok := r.gateway.(RemoteCanceler)
+		if !ok {
+			d.Action, d.Err = ActionFailed, errors.New("gateway cannot cancel remote orders")
+			break
+		}
+		if err := canceler.CancelRemote(remote); err != nil {
+			d.Action, d.Err = ActionFailed, err
+			break
+		}
+		d.Action = ActionCanceled
+	}
+	r.report(d)
+	if d.Err != nil {
+		return fmt.Errorf("orphan order %s: %w", remote.ID, d.Err)
+	}
 	return nil
 }
 
+// reconcilePosition 核对本地与交易所净仓位，见 ReconcilerConfig.LocalPosition。
+func (r *Reconciler) reconcilePosition() error {
+	if r.config.LocalPosition == nil || r.config.RemotePosition == nil {
+		return nil
+	}
+	remote, err := r.config.RemotePosition()
+	if err != nil {
+		return fmt.Errorf("get remote position failed: %w", err)
+	}
+	local := r.config.LocalPosition()
+	drift := local - remote
+	r.mu.Lock()
+	r.positionDrift = drift
+	if math.Abs(drift) <= r.config.PositionTolerance {
+		r.driftStreak = 0
+		r.mu.Unlock()
+		return nil
+	}
+	r.driftStreak++
+	streak := r.driftStreak
+	r.mu.Unlock()
+
+	d := Discrepancy{Kind: DiscrepancyPosition, Action: ActionReported, Symbol: r.config.Symbol, LocalQty: local, RemoteQty: remote}
+	if streak >= 2 && r.config.SyncPosition != nil {
+		r.config.SyncPosition()
+		d.Action = ActionSynced
+		r.mu.Lock()
+		r.driftStreak = 0
+		r.conflictsResolved++
+		r.mu.Unlock()
+	}
+	// 偏差过大不等第二轮确认，立即停机
+	if r.config.HaltDrift > 0 && math.Abs(drift) >= r.config.HaltDrift {
+		d.Action = ActionHalted
+		if r.config.OnHalt != nil {
+			r.config.OnHalt(fmt.Sprintf("position_drift local=%.6f remote=%.6f", local, remote))
+		}
+	}
+	r.report(d)
+	return nil
+}
+
+// report 计数并回调一条差异。
+func (r *Reconciler) report(d Discrepancy) {
+	r.mu.Lock()
+	r.discrepancies++
+	r.mu.Unlock()
+	if r.config.OnDiscrepancy != nil {
+		r.config.OnDiscrepancy(d)
+	}
+}
+
 // GetStatistics 获取对账统计信息
 func (r *Reconciler) GetStatistics() ReconcilerStats {
 	r.mu.RLock()
@@ -189,7 +421,9 @@ func (r *Reconciler) GetStatistics() ReconcilerStats {
 	return ReconcilerStats{
 		TotalReconciliations: r.totalReconciliations,
 		ConflictsResolved:    r.conflictsResolved,
+		Discrepancies:        r.discrepancies,
 		LastReconcileTime:    r.lastReconcileTime,
+		PositionDrift:        r.positionDrift,
 		Interval:             r.interval,
 	}
 }
@@ -198,7 +432,9 @@ func (r *Reconciler) GetStatistics() ReconcilerStats {
 type ReconcilerStats struct {
 	TotalReconciliations int64
 	ConflictsResolved    int64
+	Discrepancies        int64
 	LastReconcileTime    time.Time
+	PositionDrift        float64 // 最近一次仓位核对的本地减交易所净仓位
 	Interval             time.Duration
 }
 
@@ -216,3 +452,8 @@ func (r *Reconciler) UpdateInterval(interval time.Duration) {
 		r.interval = interval
 	}
 }
+
+// isInFlight 下单或撤单请求尚未返回。
+func isInFlight(st Status) bool {
+	return st == StatusPendingNew || st == StatusPendingCancel
+}
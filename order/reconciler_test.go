@@ -3,6 +3,7 @@ package order
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 )
@@ -427,3 +428,179 @@ func BenchmarkReconcile(b *testing.B) {
 		rec.Reconcile()
 	}
 }
+
+// notFoundGateway 模拟交易所对查无此单返回的结构化错误（包装 ErrRemoteOrderNotFound）。
+type notFoundGateway struct{ *MockGateway }
+
+func (g notFoundGateway) GetOrder(orderID string) (*Order, error) {
+	if _, ok := g.orders[orderID]; !ok {
+		return nil, fmt.Errorf("query order status 400: %w", ErrRemoteOrderNotFound)
+	}
+	return g.MockGateway.GetOrder(orderID)
+}
+
+func TestReconcileSkipsSyncPlaceInFlight(t *testing.T) {
+	place := newGatedGateway()
+	mgr := NewManager(place)
+	remote := notFoundGateway{NewMockGateway()}
+	rec := NewReconciler(remote, mgr, ReconcilerConfig{Symbol: "ETHUSDC"})
+
+	done := make(chan *Order)
+	go func() {
+		o, _ := mgr.Submit(Order{ID: "slow-1", Symbol: "ETHUSDC", Side: "BUY", Price: 2000, Quantity: 0.1})
+		done <- o
+	}()
+	for {
+		if st, ok := mgr.Status("slow-1"); ok {
+			if st != StatusPendingNew {
+				t.Fatalf("sync place in flight should be PENDING_NEW, got %s", st)
+			}
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// 下单请求尚未返回：交易所查无此单，但不能判为失效
+	if err := rec.Reconcile(); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if st, _ := mgr.Status("slow-1"); st != StatusPendingNew {
+		t.Fatalf("in-flight order touched by reconcile: %s", st)
+	}
+	close(place.release)
+	if o := <-done; o == nil || o.Status != StatusAck {
+		t.Fatalf("order should be ACK after place returns, got %+v", o)
+	}
+}
+
+func TestReconcileRemoteNotFoundMarksExpired(t *testing.T) {
+	gw := notFoundGateway{NewMockGateway()}
+	mgr := NewManager(gw)
+	mgr.Submit(Order{ID: "lost-1", Symbol: "ETHUSDC", Side: "BUY", Price: 2000.0, Quantity: 0.1})
+	delete(gw.orders, "lost-1")
+
+	rec := NewReconciler(gw, mgr, ReconcilerConfig{})
+	if err := rec.Reconcile(); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if st, _ := mgr.Status("lost-1"); st != StatusExpired {
+		t.Fatalf("order missing on exchange should be expired locally, got %s", st)
+	}
+	if rec.GetStatistics().ConflictsResolved != 1 {
+		t.Fatalf("expected one resolved conflict")
+	}
+}
+
+// cancelingGateway 在 notFoundGateway 基础上实现 RemoteCanceler。
+type cancelingGateway struct {
+	notFoundGateway
+	remoteCanceled []string
+}
+
+func (g *cancelingGateway) CancelRemote(o *Order) error {
+	g.remoteCanceled = append(g.remoteCanceled, o.ID)
+	delete(g.orders, o.ID)
+	return nil
+}
+
+func TestReconcileBySymbolRepairsDrift(t *testing.T) {
+	gw := &cancelingGateway{notFoundGateway: notFoundGateway{NewMockGateway()}}
+	mgr := NewManager(gw)
+	ids := NewClientIDGenerator("k3f9a")
+	lost, _ := mgr.Submit(Order{Symbol: "ETHUSDC", Side: "BUY", Price: 2000, Quantity: 0.1})
+	delete(gw.orders, lost.ID)
+	orphan := ids.Next(Order{Symbol: "ETHUSDC", Side: "SELL", ClientID: "dyn", Level: 1})
+	gw.SetOrder(&Order{ID: orphan, ExchangeID: "9001", Symbol: "ETHUSDC", Side: "SELL", Status: StatusAck})
+	gw.SetOrder(&Order{ID: "web_manual", Symbol: "ETHUSDC", Side: "BUY", Status: StatusAck})
+	// 同账户另一实例（其它 run）的挂单不处置
+	other := NewClientIDGenerator("z9x8w").Next(Order{Symbol: "ETHUSDC", Side: "BUY", ClientID: "dyn", Level: 1})
+	gw.SetOrder(&Order{ID: other, ExchangeID: "9002", Symbol: "ETHUSDC", Side: "BUY", Status: StatusAck})
+
+	var got []Discrepancy
+	rec := NewReconciler(gw, mgr, ReconcilerConfig{
+		Symbol:        "ETHUSDC",
+		OrphanPolicy:  OrphanCancel,
+		Owns:          ids.Owns,
+		OnDiscrepancy: func(d Discrepancy) { got = append(got, d) },
+	})
+	if err := rec.Reconcile(); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if len(gw.remoteCanceled) != 1 || gw.remoteCanceled[0] != orphan {
+		t.Fatalf("expected orphan canceled, got %v", gw.remoteCanceled)
+	}
+	if st, _ := mgr.Status(lost.ID); st != StatusExpired {
+		t.Fatalf("missing order should be expired, got %s", st)
+	}
+	kinds := map[string]string{}
+	for _, d := range got {
+		kinds[d.Kind] = d.Action
+	}
+	if len(got) != 2 || kinds[DiscrepancyOrphan] != ActionCanceled || kinds[DiscrepancyMissing] != ActionExpired {
+		t.Fatalf("unexpected discrepancies %+v", got)
+	}
+	if rec.GetStatistics().Discrepancies != 2 {
+		t.Fatalf("unexpected stats %+v", rec.GetStatistics())
+	}
+}
+
+func TestReconcileAdoptsOrphan(t *testing.T) {
+	gw := NewMockGateway()
+	mgr := NewManager(gw)
+	orphan := NewClientIDGenerator("k3f9a").Next(Order{Symbol: "ETHUSDC", Side: "BUY", ClientID: "dyn", Level: 2})
+	gw.SetOrder(&Order{ID: orphan, Symbol: "ETHUSDC", Side: "BUY", Price: 1990, Quantity: 0.1, Status: StatusAck})
+
+	rec := NewReconciler(gw, mgr, ReconcilerConfig{Symbol: "ETHUSDC", OrphanPolicy: OrphanAdopt})
+	if err := rec.Reconcile(); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	o, err := mgr.GetOrder(orphan)
+	if err != nil || o.Status != StatusAck || o.ClientID != "dyn" || o.Level != 2 {
+		t.Fatalf("orphan not adopted: %+v err=%v", o, err)
+	}
+}
+
+func TestReconcilePositionDrift(t *testing.T) {
+	gw := NewMockGateway()
+	local := 1.0
+	syncs := 0
+	var halted string
+	rec := NewReconciler(gw, NewManager(gw), ReconcilerConfig{
+		Symbol:            "ETHUSDC",
+		LocalPosition:     func() float64 { return local },
+		RemotePosition:    func() (float64, error) { return 0.5, nil },
+		SyncPosition:      func() { syncs++ },
+		PositionTolerance: 0.001,
+		HaltDrift:         0.4,
+		OnHalt:            func(reason string) { halted = reason },
+	})
+	// 偏差达到停机阈值时首轮即停机，修正仍等连续两轮确认
+	rec.Reconcile()
+	if syncs != 0 || halted == "" {
+		t.Fatalf("large drift should halt at once without syncing, syncs=%d halted=%q", syncs, halted)
+	}
+	rec.Reconcile()
+	if syncs != 1 {
+		t.Fatalf("persistent drift should sync, syncs=%d", syncs)
+	}
+	if rec.GetStatistics().PositionDrift != 0.5 {
+		t.Fatalf("unexpected drift %v", rec.GetStatistics().PositionDrift)
+	}
+	local = 0.5
+	rec.Reconcile()
+	rec.Reconcile()
+	if syncs != 1 || rec.GetStatistics().PositionDrift != 0 {
+		t.Fatalf("no action expected once positions agree, syncs=%d", syncs)
+	}
+
+	// 未达停机阈值的偏差只报告，连续两轮才修正
+	halted = ""
+	local = 0.7
+	rec.Reconcile()
+	if syncs != 1 || halted != "" {
+		t.Fatalf("single small drift should only report, syncs=%d halted=%q", syncs, halted)
+	}
+	rec.Reconcile()
+	if syncs != 2 || halted != "" {
+		t.Fatalf("persistent small drift should sync without halting, syncs=%d halted=%q", syncs, halted)
+	}
+}
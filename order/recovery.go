@@ -0,0 +1,127 @@
+package order
+
+import (
+	"sort"
+	"time"
+)
+
+// ReplayJournal 按日志顺序重建每笔订单的最后快照。
+func ReplayJournal(entries []JournalEntry) map[string]Order {
+	orders := make(map[string]Order)
+	for _, e := range entries {
+		orders[e.Order.ID] = e.Order
+	}
+	return orders
+}
+
+// LastSeq 返回日志中 runID 已用过的最大 clientOrderId 序号，重用同一 run 时据此续号（ClientIDGenerator.SetSeq）。
+func LastSeq(orders map[string]Order, runID string) uint64 {
+	var seq uint64
+	for id := range orders {
+		if p, ok := ParseClientID(id); ok && p.RunID == runID && p.Seq > seq {
+			seq = p.Seq
+		}
+	}
+	return seq
+}
+
+// JournalOwner 返回启动恢复用的归属判断：ID 的 run 出现在日志中，或由 ids 当前的 run 生成。
+// 同账户其它实例（其它 run）的挂单不归本实例处置。
+func JournalOwner(journal map[string]Order, ids *ClientIDGenerator) func(id string) bool {
+	runs := make(map[string]bool)
+	for id := range journal {
+		if p, ok := ParseClientID(id); ok {
+			runs[p.RunID] = true
+		}
+	}
+	return func(id string) bool {
+		p, ok := ParseClientID(id)
+		return ok && (runs[p.RunID] || (ids != nil && p.RunID == ids.RunID))
+	}
+}
+
+// RecoveryPlan 启动恢复时对交易所挂单与日志订单的处置。
+type RecoveryPlan struct {
+	// Adopt 交易所仍挂着且日志有记录的本系统订单，已合并交易所的状态/价格/成交量，交由 Manager.Restore 继续管理。
+	Adopt []Order
+	// Cancel 交易所仍挂着、归本实例但不接管的订单（日志无记录，或未开启接管）。
+	Cancel []Order
+	// Closed 日志中仍活跃、交易所已无挂单的订单：停机期间已成交或撤销，库存以仓位对账为准。
+	Closed []Order
+	// Foreign 不归本实例的挂单（手工单、其它 run 的挂单等），不处理。
+	Foreign []Order
+}
+
+// PlanRecovery 对照日志快照与交易所当前挂单生成处置计划；adopt 为 false 时归本实例的挂单一律撤销。
+// owns 判断挂单是否归本实例（见 JournalOwner），为 nil 时按 IsOwnClientID。
+func PlanRecovery(journal map[string]Order, open []*Order, adopt bool, owns func(id string) bool) RecoveryPlan {
+	if owns == nil {
+		owns = IsOwnClientID
+	}
+	var plan RecoveryPlan
+	sm := NewStateMachine()
+	onExchange := make(map[string]struct{}, len(open))
+	for _, remote := range open {
+		if remote == nil {
+			continue
+		}
+		onExchange[remote.ID] = struct{}{}
+		if !owns(remote.ID) {
+			plan.Foreign = append(plan.Foreign, *remote)
+			continue
+		}
+		local, known := journal[remote.ID]
+		if !known || !adopt {
+			plan.Cancel = append(plan.Cancel, *remote)
+			continue
+		}
+		plan.Adopt = append(plan.Adopt, mergeRemote(local, *remote))
+	}
+	for id, local := range journal {
+		if _, ok := onExchange[id]; ok || sm.IsFinalState(local.Status) {
+			continue
+		}
+		plan.Closed = append(plan.Closed, local)
+	}
+	sort.Slice(plan.Closed, func(i, j int) bool { return plan.Closed[i].ID < plan.Closed[j].ID })
+	return plan
+}
+
+// mergeRemote 以交易所的状态、价格、数量与累计成交覆盖日志快照，保留策略标签、档位等本地字段。
+func mergeRemote(local, remote Order) Order {
+	local.Status = remote.Status
+	if local.Status == "" || local.Status == StatusNew || local.Status == StatusPendingNew || local.Status == StatusPendingCancel {
+		local.Status = StatusAck
+	}
+	if remote.Price > 0 {
+		local.Price = remote.Price
+	}
+	if remote.Quantity > 0 {
+		local.Quantity = remote.Quantity
+	}
+	if remote.FilledQty > local.FilledQty {
+		local.FilledQty = remote.FilledQty
+		if remote.AvgFillPrice > 0 {
+			local.AvgFillPrice = remote.AvgFillPrice
+		}
+	}
+	local.UpdatedAt = time.Now()
+	return local
+}
+
+// Restore 把恢复接管的订单原样登记（不调用 Gateway），已存在的 ID 跳过，返回登记数量。
+func (m *Manager) Restore(orders []Order) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, o := range orders {
+		if _, ok := m.orders[o.ID]; ok || o.ID == "" {
+			continue
+		}
+		o := o
+		m.orders[o.ID] = &o
+		m.journalLocked(JournalAdopt, &o, nil)
+		n++
+	}
+	return n
+}
@@ -0,0 +1,74 @@
+package order
+
+import "testing"
+
+func TestPlanRecovery(t *testing.T) {
+	g := NewClientIDGenerator("k3f9a")
+	adopted := Order{Symbol: "ETHUSDC", Side: "BUY", Price: 2000, Quantity: 1, ClientID: "dyn", Level: 2}
+	adopted.ID = g.Next(adopted)
+	adopted.Status = StatusNew
+	closed := Order{Symbol: "ETHUSDC", Side: "SELL", Price: 2010, Quantity: 1, Status: StatusAck}
+	closed.ID = g.Next(closed)
+	done := Order{ID: g.Next(Order{Symbol: "ETHUSDC"}), Symbol: "ETHUSDC", Status: StatusFilled}
+	journal := map[string]Order{adopted.ID: adopted, closed.ID: closed, done.ID: done}
+
+	orphan := g.Next(Order{Symbol: "ETHUSDC", Side: "SELL"})
+	open := []*Order{
+		{ID: adopted.ID, Status: StatusPartial, Price: 2000, Quantity: 1, FilledQty: 0.3, AvgFillPrice: 2000},
+		{ID: orphan, Status: StatusAck},
+		{ID: "web_manual", Status: StatusAck},
+	}
+	plan := PlanRecovery(journal, open, true, nil)
+	if len(plan.Adopt) != 1 || len(plan.Cancel) != 1 || len(plan.Closed) != 1 || len(plan.Foreign) != 1 {
+		t.Fatalf("unexpected plan %+v", plan)
+	}
+	a := plan.Adopt[0]
+	if a.ID != adopted.ID || a.Status != StatusPartial || a.FilledQty != 0.3 || a.ClientID != "dyn" || a.Level != 2 || a.Side != "BUY" {
+		t.Fatalf("adopted order not merged: %+v", a)
+	}
+	if plan.Cancel[0].ID != orphan || plan.Closed[0].ID != closed.ID || plan.Foreign[0].ID != "web_manual" {
+		t.Fatalf("unexpected plan %+v", plan)
+	}
+	if seq := LastSeq(journal, "k3f9a"); seq != 3 {
+		t.Fatalf("unexpected last seq %d", seq)
+	}
+
+	// 不接管时本系统挂单一律撤销
+	plan = PlanRecovery(journal, open, false, nil)
+	if len(plan.Adopt) != 0 || len(plan.Cancel) != 2 {
+		t.Fatalf("cancel mode plan %+v", plan)
+	}
+
+	// 同账户另一实例的挂单不在日志的 run 中，也不是当前 run：记为 foreign，不撤销
+	other := NewClientIDGenerator("z9x8w").Next(Order{Symbol: "ETHUSDC", Side: "BUY"})
+	current := NewClientIDGenerator("m2n4p")
+	mine := current.Next(Order{Symbol: "ETHUSDC", Side: "BUY"})
+	open = append(open, &Order{ID: other, Status: StatusAck}, &Order{ID: mine, Status: StatusAck})
+	plan = PlanRecovery(journal, open, false, JournalOwner(journal, current))
+	if len(plan.Cancel) != 3 || len(plan.Foreign) != 2 {
+		t.Fatalf("other run's order should be foreign: %+v", plan)
+	}
+	for _, o := range plan.Cancel {
+		if o.ID == other {
+			t.Fatalf("other run's order must not be canceled: %+v", plan)
+		}
+	}
+}
+
+func TestManagerRestore(t *testing.T) {
+	gw := &mockGateway{}
+	m := NewManager(gw)
+	o := Order{ID: "mmk3f9a-ETHUSDC-B0-dyn-1", Symbol: "ETHUSDC", Side: "BUY", Price: 2000, Quantity: 1, Status: StatusAck}
+	if n := m.Restore([]Order{o, o}); n != 1 {
+		t.Fatalf("expected one restored order, got %d", n)
+	}
+	if len(gw.placed) != 0 {
+		t.Fatalf("restore must not place orders")
+	}
+	if active := m.GetActiveOrdersBySymbol("ETHUSDC"); len(active) != 1 {
+		t.Fatalf("restored order not active")
+	}
+	if err := m.Cancel(o.ID); err != nil || len(gw.canceled) != 1 {
+		t.Fatalf("restored order should be cancelable: %v", err)
+	}
+}
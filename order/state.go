@@ -1,5 +1,7 @@
 package order
 
+import "time"
+
 // Status represents order lifecycle.
 type Status string
 
@@ -16,15 +18,41 @@ const (
 // Order holds a simplified order view.
 type Order struct {
 	ID          string
+	ExchangeID  string // 交易所订单号，查询交易所订单时填充
 	Symbol      string
 	Side        string // BUY/SELL
 	Type        string // LIMIT/MARKET
 	Price       float64
 	Quantity    float64
 	Status      Status
-	ClientID    string
+	ClientID    string // 策略标签（如 dyn），与 Level 一起编码进生成的订单 ID，见 ClientIDGenerator
 	LastError   string
 	ReduceOnly  bool
 	PostOnly    bool
 	TimeInForce string
+	// PositionSide 双向持仓下的目标腿（LONG/SHORT）；单向持仓留空或 BOTH。
+	PositionSide string
+	// SelfTradePrevention 自成交保护模式（NONE/EXPIRE_TAKER/EXPIRE_MAKER/EXPIRE_BOTH），留空按 Manager 的交易对默认值。
+	SelfTradePrevention string
+	// PriceMatch 按盘口定价（OPPONENT[_5/10/20]、QUEUE[_5/10/20]）；设置后交易所忽略 Price，本地仍用 Price 做名义价值校验。
+	PriceMatch string
+	// Level 报价梯档位（0 为最内档）。
+	Level int
+
+	// FilledQty 已成交数量，AvgFillPrice 成交均价，由 Manager.ApplyExecution 按成交累计。
+	FilledQty    float64
+	AvgFillPrice float64
+	// Commission 累计手续费（正数为支出），CommissionAsset 为其计价资产。
+	Commission      float64
+	CommissionAsset string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// Remaining 剩余未成交数量。
+func (o *Order) Remaining() float64 {
+	if r := o.Quantity - o.FilledQty; r > 0 {
+		return r
+	}
+	return 0
 }
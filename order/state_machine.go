@@ -9,6 +9,10 @@ import (
 const (
 	StatusPending   Status = "PENDING"   // 待提交
 	StatusCanceling Status = "CANCELING" // 撤单中
+	StatusAmending  Status = "AMENDING"  // 改单中（价格/数量修改已发出，等待确认）
+	// 异步管道的在途状态：请求已入队/已发出，结果尚未返回。
+	StatusPendingNew    Status = "PENDING_NEW"    // 下单在途
+	StatusPendingCancel Status = "PENDING_CANCEL" // 撤单在途
 )
 
 // StateTransition 状态转换
@@ -40,6 +44,16 @@ func (sm *StateMachine) initializeTransitions() {
 		{StatusPending, StatusNew},
 		{StatusPending, StatusRejected},
 
+		// 从PENDING_NEW可以转到：下单结果返回前成交回报可能先到；撤单可在下单在途时排队
+		{StatusPendingNew, StatusNew},
+		{StatusPendingNew, StatusAck},
+		{StatusPendingNew, StatusPartial},
+		{StatusPendingNew, StatusFilled},
+		{StatusPendingNew, StatusRejected},
+		{StatusPendingNew, StatusCanceled},
+		{StatusPendingNew, StatusExpired},
+		{StatusPendingNew, StatusPendingCancel},
+
 		// 从NEW可以转到
 		{StatusNew, StatusAck},
 		{StatusNew, StatusPartial},
@@ -48,6 +62,8 @@ func (sm *StateMachine) initializeTransitions() {
 		{StatusNew, StatusCanceled},
 		{StatusNew, StatusRejected},
 		{StatusNew, StatusExpired},
+		{StatusNew, StatusAmending},
+		{StatusNew, StatusPendingCancel},
 
 		// 从ACK可以转到
 		{StatusAck, StatusPartial},
@@ -55,6 +71,8 @@ func (sm *StateMachine) initializeTransitions() {
 		{StatusAck, StatusCanceling},
 		{StatusAck, StatusCanceled},
 		{StatusAck, StatusExpired},
+		{StatusAck, StatusAmending},
+		{StatusAck, StatusPendingCancel},
 
 		// 从PARTIAL可以转到
 		{StatusPartial, StatusPartial}, // 多次部分成交
@@ -62,12 +80,33 @@ func (sm *StateMachine) initializeTransitions() {
 		{StatusPartial, StatusCanceling},
 		{StatusPartial, StatusCanceled},
 		{StatusPartial, StatusExpired},
+		{StatusPartial, StatusAmending},
+		{StatusPartial, StatusPendingCancel},
 
 		// 从CANCELING可以转到
 		{StatusCanceling, StatusCanceled},
 		{StatusCanceling, StatusFilled},  // 撤单时全部成交
 		{StatusCanceling, StatusPartial}, // 撤单时部分成交
 
+		// 从PENDING_CANCEL可以转到：撤单成功、撤单前成交、下单被拒；撤单失败时回到撤单前的状态
+		{StatusPendingCancel, StatusCanceled},
+		{StatusPendingCancel, StatusPartial},
+		{StatusPendingCancel, StatusFilled},
+		{StatusPendingCancel, StatusRejected},
+		{StatusPendingCancel, StatusExpired},
+		{StatusPendingCancel, StatusNew},
+		{StatusPendingCancel, StatusAck},
+
+		// 从AMENDING可以转到：改单完成（或失败）回到原状态，改单期间也可能成交/撤销
+		{StatusAmending, StatusNew},
+		{StatusAmending, StatusAck},
+		{StatusAmending, StatusPartial},
+		{StatusAmending, StatusFilled},
+		{StatusAmending, StatusCanceling},
+		{StatusAmending, StatusCanceled},
+		{StatusAmending, StatusExpired},
+		{StatusAmending, StatusPendingCancel},
+
 		// 终态不能转换（FILLED, CANCELED, REJECTED, EXPIRED）
 	}
 
@@ -122,15 +161,25 @@ func (sm *StateMachine) IsFinalState(status Status) bool {
 // IsActiveState 判断是否是活跃状态（可能产生成交）
 func (sm *StateMachine) IsActiveState(status Status) bool {
 	switch status {
-	case StatusNew, StatusAck, StatusPartial:
+	case StatusNew, StatusAck, StatusPartial, StatusAmending, StatusPendingNew, StatusPendingCancel:
 		return true
 	default:
 		return false
 	}
 }
 
-// CanCancel 判断当前状态下是否可以撤单
+// CanCancel 判断当前状态下是否可以撤单（下单在途时撤单排在下单之后执行）
 func (sm *StateMachine) CanCancel(status Status) bool {
+	switch status {
+	case StatusNew, StatusAck, StatusPartial, StatusPendingNew:
+		return true
+	default:
+		return false
+	}
+}
+
+// CanAmend 判断当前状态下是否可以改单（同一时刻仅允许一笔改单在途）
+func (sm *StateMachine) CanAmend(status Status) bool {
 	switch status {
 	case StatusNew, StatusAck, StatusPartial:
 		return true
@@ -142,15 +191,18 @@ func (sm *StateMachine) CanCancel(status Status) bool {
 // GetStateDescription 获取状态描述
 func (sm *StateMachine) GetStateDescription(status Status) string {
 	descriptions := map[Status]string{
-		StatusPending:   "订单待提交",
-		StatusNew:       "订单已创建",
-		StatusAck:       "订单已确认",
-		StatusPartial:   "订单部分成交",
-		StatusFilled:    "订单完全成交",
-		StatusCanceling: "订单撤销中",
-		StatusCanceled:  "订单已撤销",
-		StatusRejected:  "订单被拒绝",
-		StatusExpired:   "订单已过期",
+		StatusPending:       "订单待提交",
+		StatusNew:           "订单已创建",
+		StatusAck:           "订单已确认",
+		StatusPartial:       "订单部分成交",
+		StatusFilled:        "订单完全成交",
+		StatusCanceling:     "订单撤销中",
+		StatusAmending:      "订单改单中",
+		StatusPendingNew:    "下单在途",
+		StatusPendingCancel: "撤单在途",
+		StatusCanceled:      "订单已撤销",
+		StatusRejected:      "订单被拒绝",
+		StatusExpired:       "订单已过期",
 	}
 
 	if desc, ok := descriptions[status]; ok {
@@ -0,0 +1,224 @@
+package posttrade
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 资金流水类型（Binance incomeType）。
+const (
+	IncomeRealizedPnL = "REALIZED_PNL"
+	IncomeFundingFee  = "FUNDING_FEE"
+	IncomeCommission  = "COMMISSION"
+	IncomeTransfer    = "TRANSFER"
+)
+
+// IncomeEntry 账本中的一条资金流水，以（类型, tranId, 资产）为唯一键，见 Key。
+type IncomeEntry struct {
+	TranID  int64   `json:"tranId"`
+	Symbol  string  `json:"symbol,omitempty"`
+	Type    string  `json:"type"`
+	Amount  float64 `json:"amount"`
+	Asset   string  `json:"asset"`
+	Time    int64   `json:"time"` // 毫秒时间戳
+	TradeID string  `json:"tradeId,omitempty"`
+	Info    string  `json:"info,omitempty"`
+}
+
+// IncomeKey 资金流水唯一键：Binance 只保证 tranId 在同一 incomeType 内唯一，
+// 同一笔成交的 COMMISSION 与 REALIZED_PNL 可能共用 tranId，不同资产的流水也分开记。
+type IncomeKey struct {
+	Type   string
+	TranID int64
+	Asset  string
+}
+
+// Key 返回流水的唯一键。
+func (e IncomeEntry) Key() IncomeKey {
+	return IncomeKey{Type: e.Type, TranID: e.TranID, Asset: e.Asset}
+}
+
+// IncomeLedger 追加写入的本地资金流水账本（JSONL，每行一条）。
+// 同一键（IncomeEntry.Key）只记一次，重复同步同一时间窗是幂等的；已写入的行不会被修改或删除。
+type IncomeLedger struct {
+	mu      sync.Mutex
+	path    string
+	entries []IncomeEntry
+	seen    map[IncomeKey]bool
+}
+
+// OpenIncomeLedger 打开（不存在则新建）账本并载入已有流水。
+func OpenIncomeLedger(path string) (*IncomeLedger, error) {
+	l := &IncomeLedger{path: path, seen: make(map[IncomeKey]bool)}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open income ledger: %w", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e IncomeEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("income ledger %s line %d: %w", path, line, err)
+		}
+		if l.seen[e.Key()] {
+			continue
+		}
+		l.seen[e.Key()] = true
+		l.entries = append(l.entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read income ledger: %w", err)
+	}
+	return l, nil
+}
+
+// Append 追加账本中尚未出现的流水并落盘，返回新增条数。
+func (l *IncomeLedger) Append(entries []IncomeEntry) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fresh := make([]IncomeEntry, 0, len(entries))
+	batch := make(map[IncomeKey]bool, len(entries))
+	for _, e := range entries {
+		if l.seen[e.Key()] || batch[e.Key()] {
+			continue
+		}
+		batch[e.Key()] = true
+		fresh = append(fresh, e)
+	}
+	if len(fresh) == 0 {
+		return 0, nil
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("open income ledger: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	for _, e := range fresh {
+		raw, err := json.Marshal(e)
+		if err != nil {
+			f.Close()
+			return 0, err
+		}
+		w.Write(raw)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return 0, fmt.Errorf("write income ledger: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return 0, fmt.Errorf("sync income ledger: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return 0, err
+	}
+	for _, e := range fresh {
+		l.seen[e.Key()] = true
+		l.entries = append(l.entries, e)
+	}
+	return len(fresh), nil
+}
+
+// Entries 返回按时间（同一时间按 tranId）升序的全部流水副本。
+func (l *IncomeLedger) Entries() []IncomeEntry {
+	l.mu.Lock()
+	out := append([]IncomeEntry(nil), l.entries...)
+	l.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Time != out[j].Time {
+			return out[i].Time < out[j].Time
+		}
+		return out[i].TranID < out[j].TranID
+	})
+	return out
+}
+
+// LastTime 返回账本中最新一条流水的时间（毫秒），空账本为 0；增量同步从该时间开始（含），重叠部分按 Key 去重。
+func (l *IncomeLedger) LastTime() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var last int64
+	for _, e := range l.entries {
+		if e.Time > last {
+			last = e.Time
+		}
+	}
+	return last
+}
+
+// DailyPnL 某交易对某个 UTC 自然日、某一资产的资金流水汇总（不同资产不相加）。
+type DailyPnL struct {
+	Date        string // YYYY-MM-DD（UTC）
+	Symbol      string
+	Asset       string // 计价资产，如 USDT、USDC、BNB（BNB 抵扣手续费单独成行）
+	RealizedPnL float64
+	Commission  float64 // 手续费，通常为负
+	Funding     float64 // 资金费，收为正、付为负
+	Transfer    float64 // 划转，不计入盈亏
+	Other       float64 // 其它类型（返佣、赠金等），不计入 Net
+	Net         float64 // RealizedPnL + Commission + Funding
+}
+
+// SummarizeDaily 按（UTC 日期, 交易对, 资产）汇总流水；symbol 非空时只统计该交易对，since 非零时忽略更早的流水。
+// 结果按日期、交易对、资产排序。划转等不带交易对的流水归入空交易对。
+func SummarizeDaily(entries []IncomeEntry, symbol string, since time.Time) []DailyPnL {
+	type key struct{ date, symbol, asset string }
+	acc := make(map[key]*DailyPnL)
+	for _, e := range entries {
+		if symbol != "" && e.Symbol != symbol {
+			continue
+		}
+		ts := time.UnixMilli(e.Time).UTC()
+		if !since.IsZero() && ts.Before(since) {
+			continue
+		}
+		k := key{ts.Format("2006-01-02"), e.Symbol, e.Asset}
+		d, ok := acc[k]
+		if !ok {
+			d = &DailyPnL{Date: k.date, Symbol: k.symbol, Asset: k.asset}
+			acc[k] = d
+		}
+		switch e.Type {
+		case IncomeRealizedPnL:
+			d.RealizedPnL += e.Amount
+		case IncomeCommission:
+			d.Commission += e.Amount
+		case IncomeFundingFee:
+			d.Funding += e.Amount
+		case IncomeTransfer:
+			d.Transfer += e.Amount
+		default:
+			d.Other += e.Amount
+		}
+		d.Net = d.RealizedPnL + d.Commission + d.Funding
+	}
+	out := make([]DailyPnL, 0, len(acc))
+	for _, d := range acc {
+		out = append(out, *d)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Date != out[j].Date {
+			return out[i].Date < out[j].Date
+		}
+		if out[i].Symbol != out[j].Symbol {
+			return out[i].Symbol < out[j].Symbol
+		}
+		return out[i].Asset < out[j].Asset
+	})
+	return out
+}
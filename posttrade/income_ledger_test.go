@@ -0,0 +1,96 @@
+package posttrade
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIncomeLedgerAppendIsIdempotent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "income.jsonl")
+	l, err := OpenIncomeLedger(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	batch := []IncomeEntry{
+		{TranID: 1, Symbol: "ETHUSDC", Type: IncomeRealizedPnL, Amount: 2, Time: 1000},
+		{TranID: 2, Symbol: "ETHUSDC", Type: IncomeCommission, Amount: -0.1, Time: 1000},
+	}
+	if n, err := l.Append(batch); err != nil || n != 2 {
+		t.Fatalf("append: %d %v", n, err)
+	}
+	// 重叠同步：已有 tranId 跳过，批内重复也只记一次
+	overlap := append(batch, IncomeEntry{TranID: 3, Type: IncomeTransfer, Amount: 100, Time: 2000}, IncomeEntry{TranID: 3, Type: IncomeTransfer, Amount: 100, Time: 2000})
+	if n, err := l.Append(overlap); err != nil || n != 1 {
+		t.Fatalf("overlap append: %d %v", n, err)
+	}
+
+	reopened, err := OpenIncomeLedger(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if got := len(reopened.Entries()); got != 3 {
+		t.Fatalf("expected 3 entries after reopen, got %d", got)
+	}
+	if reopened.LastTime() != 2000 {
+		t.Fatalf("unexpected last time %d", reopened.LastTime())
+	}
+}
+
+func TestSummarizeDailyNetPnL(t *testing.T) {
+	day1 := time.Date(2025, 11, 22, 23, 0, 0, 0, time.UTC).UnixMilli()
+	day2 := time.Date(2025, 11, 23, 1, 0, 0, 0, time.UTC).UnixMilli()
+	entries := []IncomeEntry{
+		{TranID: 1, Symbol: "ETHUSDC", Type: IncomeRealizedPnL, Amount: 5, Time: day1},
+		{TranID: 2, Symbol: "ETHUSDC", Type: IncomeCommission, Amount: -0.4, Time: day1},
+		{TranID: 3, Symbol: "ETHUSDC", Type: IncomeFundingFee, Amount: -0.6, Time: day1},
+		{TranID: 4, Symbol: "BTCUSDC", Type: IncomeRealizedPnL, Amount: 1, Time: day1},
+		{TranID: 5, Symbol: "ETHUSDC", Type: IncomeFundingFee, Amount: 0.2, Time: day2},
+		{TranID: 6, Type: IncomeTransfer, Amount: 1000, Time: day2},
+	}
+	days := SummarizeDaily(entries, "ETHUSDC", time.Time{})
+	if len(days) != 2 {
+		t.Fatalf("expected 2 days, got %+v", days)
+	}
+	if d := days[0]; d.Date != "2025-11-22" || math.Abs(d.Net-4) > 1e-9 || d.Funding != -0.6 || d.Commission != -0.4 {
+		t.Fatalf("unexpected day1 %+v", d)
+	}
+	if d := days[1]; d.Date != "2025-11-23" || d.Net != 0.2 || d.Transfer != 0 {
+		t.Fatalf("unexpected day2 %+v", d)
+	}
+
+	// 全量：划转单列且不计入净盈亏
+	all := SummarizeDaily(entries, "", time.Unix(0, day2*int64(time.Millisecond)))
+	if len(all) != 2 || all[0].Symbol != "" || all[0].Transfer != 1000 || all[0].Net != 0 || all[1].Symbol != "ETHUSDC" {
+		t.Fatalf("unexpected summary %+v", all)
+	}
+}
+
+func TestIncomeLedgerKeysByTypeAndAsset(t *testing.T) {
+	l, err := OpenIncomeLedger(filepath.Join(t.TempDir(), "income.jsonl"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	// tranId 只在同一类型内唯一：同一成交的已实现盈亏与手续费共用 tranId
+	entries := []IncomeEntry{
+		{TranID: 7, Symbol: "ETHUSDC", Type: IncomeRealizedPnL, Amount: 1.5, Asset: "USDC", Time: 1000},
+		{TranID: 7, Symbol: "ETHUSDC", Type: IncomeCommission, Amount: -0.02, Asset: "USDC", Time: 1000},
+		{TranID: 7, Symbol: "ETHUSDC", Type: IncomeCommission, Amount: -0.0001, Asset: "BNB", Time: 1000},
+	}
+	if n, err := l.Append(entries); err != nil || n != 3 {
+		t.Fatalf("append: %d %v", n, err)
+	}
+	if n, _ := l.Append(entries); n != 0 {
+		t.Fatalf("re-append should be idempotent, added %d", n)
+	}
+
+	// 不同资产分行汇总，不相加
+	days := SummarizeDaily(l.Entries(), "ETHUSDC", time.Time{})
+	if len(days) != 2 || days[0].Asset != "BNB" || days[1].Asset != "USDC" {
+		t.Fatalf("expected per-asset rows, got %+v", days)
+	}
+	if days[0].Net != -0.0001 || math.Abs(days[1].Net-1.48) > 1e-9 {
+		t.Fatalf("unexpected per-asset net %+v", days)
+	}
+}
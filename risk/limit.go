@@ -43,6 +43,10 @@ func (l *LimitChecker) PreOrder(symbol string, deltaQty float64) error {
 	if l.limits.SingleMax > 0 && abs(deltaQty) > l.limits.SingleMax {
 		return fmt.Errorf("single order limit exceeded: %.4f > %.4f", abs(deltaQty), l.limits.SingleMax)
 	}
+	if l.pos == nil {
+		// 未提供仓位来源时只检查单笔上限
+		return nil
+	}
 
 	// Daily limit
 	if l.limits.DailyMax > 0 {
@@ -1,17 +1,32 @@
 package risk
 
-import "market-maker-go/inventory"
+import (
+	"market-maker-go/inventory"
+	"market-maker-go/market"
+)
 
 // InventoryPnL 可从 inventory.Tracker 估算浮盈。
+// Basis 为 mark 时优先使用 MarkFn 返回的标记价，标记价不可用（<=0）时回退到 mid。
 type InventoryPnL struct {
 	Tracker *inventory.Tracker
 	MidFn   func() float64 // 返回当前 mid 价
+	MarkFn  func() float64 // 可选，返回当前标记价
+	Basis   market.PriceBasis
 }
 
 func (p InventoryPnL) CurrentPnL(symbol string) float64 {
 	if p.Tracker == nil || p.MidFn == nil {
 		return 0
 	}
-	_, pnl := p.Tracker.Valuation(p.MidFn())
+	_, pnl := p.Tracker.Valuation(p.price())
 	return pnl
 }
+
+func (p InventoryPnL) price() float64 {
+	if p.Basis == market.PriceBasisMark && p.MarkFn != nil {
+		if mark := p.MarkFn(); mark > 0 {
+			return mark
+		}
+	}
+	return p.MidFn()
+}
@@ -4,9 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"market-maker-go/gateway"
 	"market-maker-go/inventory"
 	"market-maker-go/market"
 	"market-maker-go/metrics"
@@ -58,6 +61,22 @@ type RiskGuard interface {
 	PreOrder(symbol string, deltaQty float64) error
 }
 
+// RateBudget 提供交易所限流剩余额度比例（0~1），例如 gateway.WeightLimiter。
+type RateBudget interface {
+	RemainingRatio() float64
+}
+
+// VPINSource 提供订单流毒性（VPIN），例如 market.VPINCalculator。
+type VPINSource interface {
+	GetVPIN() float64
+	IsReady() bool
+}
+
+// ValuationSource 按估值基准返回估值价格，例如 market.Service（mark 不可用时回退 mid）。
+type ValuationSource interface {
+	ValuationPrice(symbol string, basis market.PriceBasis, mid float64) float64
+}
+
 // Runner 将行情->策略->下单串起来，负责把 OrderBook/Inventory 状态与策略引擎的报价结果对齐，
 // 并在内部管理静态/动态挂单、Reduce-only、止损等逻辑。cmd/runner 会使用真实 gateway 将其接入交易所。
 type Runner struct {
@@ -68,6 +87,19 @@ type Runner struct {
 	OrderMgr     *order.Manager
 	Risk         RiskGuard
 	Book         *market.OrderBook // 可选，供 VWAPGuard 使用
+	// RateBudget 可选；剩余额度低于 RateSlowdownRatio（默认 0.3）时按比例拉长报价间隔，避免 429/418。
+	RateBudget        RateBudget
+	RateSlowdownRatio float64
+	// VPIN 可选；就绪后写入 ASMM 的 Snapshot.VPIN，驱动毒性流规避。
+	VPIN VPINSource
+	// Valuation/ValuationBasis 可选；止损估值默认使用 mid，设为 mark 时使用交易所标记价。
+	Valuation      ValuationSource
+	ValuationBasis market.PriceBasis
+	// HedgeMode 双向持仓：买卖两侧按各自的腿独立报价，见 routePositionSide；Inv 需按腿记账。
+	HedgeMode bool
+	// AsyncOrders 多档动态挂单与撤单走 order.Manager 的异步管道：下单后立即记入档位（PENDING_NEW），
+	// 结果在下一 tick 开始时处理；开仓限额按含在途挂单的预估净仓位判断。静态挂单与市价减仓仍同步下单。
+	AsyncOrders bool
 	// Constraints 用于在下单前对齐 tickSize/stepSize，并满足 minQty/minNotional。
 	Constraints             order.SymbolConstraints
 	BaseSpread              float64
@@ -84,6 +116,7 @@ type Runner struct {
 	reduceFallbackUntil     time.Time
 	reduceFallbackActive    bool
 	reduceBackoffUntil      time.Time
+	marginBackoffUntil      time.Time
 	StaticFraction          float64
 	StaticThresholdTicks    int
 	StaticRestDuration      time.Duration
@@ -114,6 +147,13 @@ type Runner struct {
 	// 多档动态挂单状态
 	dynamicBids []levelState
 	dynamicAsks []levelState
+	// 异步下单：在途的动态档位与 worker 回传的结果
+	asyncPlaces map[string]dynamicPlacement
+	asyncMu     sync.Mutex
+	asyncDone   []order.Completion
+	// 外部（对账等其他 goroutine）请求的停机，下一次 OnTick 执行
+	haltMu      sync.Mutex
+	haltRequest string
 	// 自适应风控相关
 	postTradeAnalyzer  *posttrade.Analyzer
 	adaptiveRisk       *risk.AdaptiveRiskManager
@@ -147,6 +187,10 @@ func (r *Runner) OnTick(mid float64) error {
 	if mid <= 0 {
 		return errors.New("invalid mid")
 	}
+	r.drainAsyncResults()
+	if reason := r.takeHaltRequest(); reason != "" {
+		return r.triggerHalt(reason)
+	}
 	now := time.Now()
 	if !r.haltUntil.IsZero() && now.Before(r.haltUntil) {
 		return fmt.Errorf("halted until %s", r.haltUntil.UTC().Format(time.RFC3339))
@@ -190,7 +234,7 @@ func (r *Runner) OnTick(mid float64) error {
 	}
 
 	if r.StopLoss != 0 {
-		_, pnl := r.Inv.Valuation(mid)
+		_, pnl := r.Inv.Valuation(r.ValuationPrice(mid))
 		if (r.StopLoss < 0 && pnl <= r.StopLoss) || (r.StopLoss > 0 && pnl >= r.StopLoss) {
 			return r.triggerHalt(fmt.Sprintf("stop_loss pnl=%.2f", pnl))
 		}
@@ -219,6 +263,9 @@ func (r *Runner) OnTick(mid float64) error {
 		if bestBid > 0 && bestAsk > 0 && bestAsk > bestBid {
 			snap.Spread = bestAsk - bestBid
 		}
+		if r.VPIN != nil && r.VPIN.IsReady() {
+			snap.VPIN = r.VPIN.GetVPIN()
+		}
 		quotes = r.ASMMStrategy.GenerateQuotes(snap, r.Inv.NetExposure())
 		var bidFound, askFound bool
 		var bidSize, askSize float64
@@ -339,23 +386,30 @@ func (r *Runner) OnTick(mid float64) error {
 
 	allowBuy, allowSell := true, true
 	net := r.Inv.NetExposure()
+	// 异步下单时在途买单/卖单按全部成交计入预估净仓位
+	longNet, shortNet := net, net
+	if r.AsyncOrders {
+		inBuy, inSell := r.OrderMgr.InFlight(r.Symbol)
+		longNet += inBuy
+		shortNet -= inSell
+	}
 	reduceLimit := r.reduceOnlyLimit()
 	hardReduce := false
 	softReduce := false
 	if reduceLimit > 0 {
-		if net >= reduceLimit {
+		if longNet >= reduceLimit {
 			allowBuy = false
 			hardReduce = true
-		} else if net <= -reduceLimit {
+		} else if shortNet <= -reduceLimit {
 			allowSell = false
 			hardReduce = true
 		} else {
 			softLimit := reduceLimit * 0.7
 			if softLimit > 0 {
-				if net >= softLimit {
+				if longNet >= softLimit {
 					allowBuy = false
 					softReduce = true
-				} else if net <= -softLimit {
+				} else if shortNet <= -softLimit {
 					allowSell = false
 					softReduce = true
 				}
@@ -444,8 +498,8 @@ func (r *Runner) OnTick(mid float64) error {
 			}
 		}
 		if len(bidQuotes) > 1 || len(askQuotes) > 1 {
-			// 取消旧的单档动态订单
-			r.cancelOutstanding(true, true)
+			// 取消旧的单档动态订单；多档挂单由差分逻辑按档替换
+			r.cancelSingleQuotes()
 			// 差分下发多档
 			r.reconcileDynamicQuotes(mid, bidQuotes, askQuotes, allowBuy, allowSell)
 			// 通知与静态挂单维护
@@ -472,17 +526,8 @@ func (r *Runner) OnTick(mid float64) error {
 	}
 
 	// 非多档路径，清理残留动态档位挂单
-	if len(r.dynamicBids) > 0 {
-		for i := range r.dynamicBids {
-			_ = r.cancelDynamicLeg(true, i)
-		}
-		r.dynamicBids = nil
-	}
-	if len(r.dynamicAsks) > 0 {
-		for i := range r.dynamicAsks {
-			_ = r.cancelDynamicLeg(false, i)
-		}
-		r.dynamicAsks = nil
+	if ids := r.takeDynamicLegs(true, true); len(ids) > 0 {
+		r.cancelBatch(ids)
 	}
 	placeBuy := allowBuy
 	placeSell := allowSell
@@ -513,6 +558,19 @@ func (r *Runner) OnTick(mid float64) error {
 		}
 	}
 
+	// 仅价格/数量变化的普通挂单优先原地改单
+	if placeBuy && cancelBid && !buyReduceOnly && r.riskAllows(qty) && r.tryAmend("BUY", r.lastBidID, bid, qty) {
+		cancelBid, placeBuy = false, false
+		r.decayMakerShift("BUY")
+		r.lastBidPrice = bid
+		r.lastBidPlacedAt = time.Now()
+	}
+	if placeSell && cancelAsk && !sellReduceOnly && r.riskAllows(-qty) && r.tryAmend("SELL", r.lastAskID, ask, qty) {
+		cancelAsk, placeSell = false, false
+		r.decayMakerShift("SELL")
+		r.lastAskPrice = ask
+		r.lastAskPlacedAt = time.Now()
+	}
 	r.cancelOutstanding(cancelBid, cancelAsk)
 	if reduceOnly && r.tryMarketReduce(mid, net) {
 		if net > 0 {
@@ -539,7 +597,8 @@ func (r *Runner) OnTick(mid float64) error {
 			TimeInForce: buyTIF,
 		}, buyReduceOnly)
 		if err != nil {
-			if buyReduceOnly {
+			// -2022：交易所认为已无可减仓位，切换 IOC/市价也无济于事，不计入减仓失败
+			if buyReduceOnly && !errors.Is(err, gateway.ErrReduceOnlyRejected) {
 				r.recordReduceFailure("BUY")
 			}
 			return err
@@ -575,7 +634,8 @@ func (r *Runner) OnTick(mid float64) error {
 			TimeInForce: sellTIF,
 		}, sellReduceOnly)
 		if err != nil {
-			if sellReduceOnly {
+			// -2022：交易所认为已无可减仓位，切换 IOC/市价也无济于事，不计入减仓失败
+			if sellReduceOnly && !errors.Is(err, gateway.ErrReduceOnlyRejected) {
 				r.recordReduceFailure("SELL")
 			}
 			return err
@@ -942,7 +1002,9 @@ func (r *Runner) tryMarketReduce(mid, net float64) bool {
 		Quantity:   qty,
 		ReduceOnly: true,
 		Type:       "MARKET",
+		ClientID:   "ro",
 	}
+	r.routePositionSide(&ord)
 	if _, err := r.OrderMgr.Submit(ord); err != nil {
 		return false
 	}
@@ -994,7 +1056,7 @@ func (r *Runner) manageStaticOrders(mid, spreadAbs, baseSize float64, reduceOnly
 	}
 }
 
-// ensureStaticOrder 在满足阈值的情况下提交静态挂单，并仅当价格/时间超过阈值时才撤单重挂。
+// ensureStaticOrder 在满足阈值的情况下提交静态挂单，并仅当价格/时间超过阈值时才改单（不支持时撤单重挂）。
 func (r *Runner) ensureStaticOrder(id *string, price *float64, placedAt *time.Time, target float64, qty float64, side string, threshold float64) {
 	now := time.Now()
 	if r.staticOrderActive(*id) {
@@ -1004,6 +1066,11 @@ func (r *Runner) ensureStaticOrder(id *string, price *float64, placedAt *time.Ti
 		if r.StaticRestDuration > 0 && !placedAt.IsZero() && now.Sub(*placedAt) < r.StaticRestDuration {
 			return
 		}
+		if r.tryAmend(side, *id, target, qty) {
+			*price = target
+			*placedAt = now
+			return
+		}
 		r.cancelStaticByID(id, placedAt)
 	}
 	order := order.Order{
@@ -1012,7 +1079,9 @@ func (r *Runner) ensureStaticOrder(id *string, price *float64, placedAt *time.Ti
 		Price:    target,
 		Quantity: qty,
 		PostOnly: true,
+		ClientID: "sta",
 	}
+	r.routePositionSide(&order)
 	res, err := r.OrderMgr.Submit(order)
 	if err != nil {
 		return
@@ -1083,6 +1152,7 @@ const (
 	reduceFailThreshold    = 3
 	reduceFallbackDuration = 2 * time.Second
 	maxMakerShiftTicks     = 5
+	marginBackoffDuration  = 5 * time.Second
 )
 
 func (r *Runner) recordReduceFailure(side string) {
@@ -1196,8 +1266,13 @@ func (r *Runner) clearPostOnlyCooldown(side string) {
 }
 
 func (r *Runner) submitOrderWithFallback(side string, ord order.Order, reduceOnly bool) (*order.Order, bool, error) {
+	if !reduceOnly && !ord.ReduceOnly && r.marginBackoffActive() {
+		return nil, ord.PostOnly, fmt.Errorf("margin backoff until %s: %w", r.marginBackoffUntil.Format(time.RFC3339), gateway.ErrMarginInsufficient)
+	}
+	r.routePositionSide(&ord)
 	if strings.ToUpper(ord.Type) == "MARKET" {
 		res, err := r.OrderMgr.Submit(ord)
+		r.noteOrderError(side, err)
 		return res, false, err
 	}
 	currentPostOnly := ord.PostOnly
@@ -1207,7 +1282,8 @@ func (r *Runner) submitOrderWithFallback(side string, ord order.Order, reduceOnl
 		if err == nil {
 			return res, currentPostOnly, nil
 		}
-		if !reduceOnly && currentPostOnly && isPostOnlyReject(err) && !triedFallback {
+		r.noteOrderError(side, err)
+		if !reduceOnly && currentPostOnly && errors.Is(err, gateway.ErrPostOnlyReject) && !triedFallback {
 			r.enterPostOnlyCooldown(side)
 			r.bumpMakerShift(side)
 			metrics.IncrementPostOnlyRejectFallback(strings.ToLower(side))
@@ -1220,12 +1296,24 @@ func (r *Runner) submitOrderWithFallback(side string, ord order.Order, reduceOnl
 	}
 }
 
-func isPostOnlyReject(err error) bool {
+// noteOrderError 按交易所错误码记录拒单并做出反应：保证金不足（-2019）时暂停开仓方向的新单一段时间，
+// 避免每个 tick 重复撞同一个拒单。
+func (r *Runner) noteOrderError(side string, err error) {
 	if err == nil {
-		return false
+		return
+	}
+	code := "other"
+	if c := gateway.ErrorCode(err); c != 0 {
+		code = strconv.Itoa(c)
+	}
+	metrics.IncrementOrderReject(strings.ToLower(side), code)
+	if errors.Is(err, gateway.ErrMarginInsufficient) {
+		r.marginBackoffUntil = time.Now().Add(marginBackoffDuration)
 	}
-	msg := err.Error()
-	return strings.Contains(msg, "Post Only") || strings.Contains(msg, "code\":-5022")
+}
+
+func (r *Runner) marginBackoffActive() bool {
+	return !r.marginBackoffUntil.IsZero() && time.Now().Before(r.marginBackoffUntil)
 }
 
 func (r *Runner) ReadyForNext(mid float64) bool {
@@ -1258,11 +1346,50 @@ func (r *Runner) dynamicInterval(mid float64) time.Duration {
 	if r.ReduceOnlyThreshold > 0 && math.Abs(r.Inv.NetExposure()) >= r.ReduceOnlyThreshold {
 		factor *= 1.5
 	}
+	factor *= r.rateBudgetFactor()
 	return time.Duration(float64(base) * factor)
 }
 
-// 多档差分下发与状态维护
+// rateBudgetFactor 在限流额度紧张时返回 >1 的间隔放大系数：额度耗尽时为 4 倍。
+func (r *Runner) rateBudgetFactor() float64 {
+	if r.RateBudget == nil {
+		return 1
+	}
+	threshold := r.RateSlowdownRatio
+	if threshold <= 0 {
+		threshold = 0.3
+	}
+	remaining := r.RateBudget.RemainingRatio()
+	if remaining >= threshold {
+		return 1
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+	return 1 + 3*(threshold-remaining)/threshold
+}
+
+// dynamicPlacement 为一次差分中待下发的动态腿新单；amendID 非空时优先对该挂单改价，失败再撤单重挂。
+type dynamicPlacement struct {
+	isBuy   bool
+	idx     int
+	price   float64
+	ord     order.Order
+	amendID string
+}
+
+// dynamicCancel 为一次差分中待撤销的动态腿（被替换或多余档位）。
+type dynamicCancel struct {
+	isBuy bool
+	idx   int
+	id    string
+}
+
+// 多档差分下发与状态维护：先算出整条报价梯的差分，再以一次批量撤单 + 一次批量下单完成替换，
+// 避免逐笔 REST 造成的权重消耗与买卖两侧更新时间差。
 func (r *Runner) reconcileDynamicQuotes(mid float64, bidQuotes []asmm.Quote, askQuotes []asmm.Quote, allowBuy, allowSell bool) {
+	var places []dynamicPlacement
+	var cancels []dynamicCancel
 	// 处理买侧
 	if allowBuy {
 		// 确保切片长度
@@ -1293,7 +1420,18 @@ func (r *Runner) reconcileDynamicQuotes(mid float64, bidQuotes []asmm.Quote, ask
 					continue
 				}
 			}
-			_ = r.ensureDynamicOrder(true, i, price, qty, q.ReduceOnly, buyPOAllowed && !usedPOBuy)
+			ord, err := r.buildDynamicOrder(true, i, price, qty, q.ReduceOnly, buyPOAllowed && !usedPOBuy)
+			if err == nil {
+				p := dynamicPlacement{isBuy: true, idx: i, price: price, ord: ord}
+				if st.id != "" {
+					if q.ReduceOnly {
+						cancels = append(cancels, dynamicCancel{isBuy: true, idx: i, id: st.id})
+					} else {
+						p.amendID = st.id
+					}
+				}
+				places = append(places, p)
+			}
 			if buyPOAllowed && !usedPOBuy {
 				metrics.IncrementPostOnlyUsage("buy")
 			}
@@ -1301,8 +1439,9 @@ func (r *Runner) reconcileDynamicQuotes(mid float64, bidQuotes []asmm.Quote, ask
 		}
 		// 取消多余档位
 		for i := len(bidQuotes); i < len(r.dynamicBids); i++ {
-			_ = r.cancelDynamicLeg(true, i)
-			metrics.IncrementDynamicOrderCancel("buy")
+			if id := r.dynamicBids[i].id; id != "" {
+				cancels = append(cancels, dynamicCancel{isBuy: true, idx: i, id: id})
+			}
 		}
 	}
 	// 处理卖侧
@@ -1332,35 +1471,45 @@ func (r *Runner) reconcileDynamicQuotes(mid float64, bidQuotes []asmm.Quote, ask
 					continue
 				}
 			}
-			_ = r.ensureDynamicOrder(false, i, price, qty, q.ReduceOnly, sellPOAllowed && !usedPOSell)
+			ord, err := r.buildDynamicOrder(false, i, price, qty, q.ReduceOnly, sellPOAllowed && !usedPOSell)
+			if err == nil {
+				p := dynamicPlacement{isBuy: false, idx: i, price: price, ord: ord}
+				if st.id != "" {
+					if q.ReduceOnly {
+						cancels = append(cancels, dynamicCancel{isBuy: false, idx: i, id: st.id})
+					} else {
+						p.amendID = st.id
+					}
+				}
+				places = append(places, p)
+			}
 			if sellPOAllowed && !usedPOSell {
 				metrics.IncrementPostOnlyUsage("sell")
 			}
 			usedPOSell = true
 		}
 		for i := len(askQuotes); i < len(r.dynamicAsks); i++ {
-			_ = r.cancelDynamicLeg(false, i)
-			metrics.IncrementDynamicOrderCancel("sell")
+			if id := r.dynamicAsks[i].id; id != "" {
+				cancels = append(cancels, dynamicCancel{isBuy: false, idx: i, id: id})
+			}
 		}
 	}
+	r.applyDynamicBatch(cancels, places)
 }
 
-func (r *Runner) ensureDynamicOrder(isBuy bool, idx int, price, qty float64, reduceOnly bool, postOnlyAllowed bool) error {
-	if r.OrderMgr == nil {
-		return errors.New("order manager nil")
-	}
+// buildDynamicOrder 对单档新单做风控预检并构造订单。
+func (r *Runner) buildDynamicOrder(isBuy bool, idx int, price, qty float64, reduceOnly bool, postOnlyAllowed bool) (order.Order, error) {
 	side := "SELL"
 	if isBuy {
 		side = "BUY"
 	}
-	postOnly := postOnlyAllowed
 	if r.Risk != nil {
 		delta := qty
 		if !isBuy {
 			delta = -qty
 		}
 		if err := r.Risk.PreOrder(r.Symbol, delta); err != nil {
-			return err
+			return order.Order{}, err
 		}
 	}
 	ord := order.Order{
@@ -1369,111 +1518,384 @@ func (r *Runner) ensureDynamicOrder(isBuy bool, idx int, price, qty float64, red
 		Price:       price,
 		Quantity:    qty,
 		ReduceOnly:  reduceOnly,
-		PostOnly:    postOnly && !reduceOnly,
+		PostOnly:    postOnlyAllowed && !reduceOnly,
 		TimeInForce: "",
-		ClientID:    fmt.Sprintf("dyn-%s-%d", strings.ToLower(side), idx),
+		ClientID:    "dyn",
+		Level:       idx,
 	}
 	if reduceOnly {
 		ord.PostOnly = false
 		ord.TimeInForce = "IOC"
 	}
-	res, usedPostOnly, err := r.submitOrderWithFallback(side, ord, reduceOnly)
-	if err != nil {
-		return err
+	// 腿由 applyDynamicBatch 在撤单之后、下单之前统一选择，见 routeClosingBatch
+	return ord, nil
+}
+
+// routePositionSide 双向持仓下为订单选择腿：reduce-only 单平对应腿（卖平多、买平空）；
+// 普通报价在对应腿扣除已挂平仓单后仍足以覆盖数量时平仓，否则开新腿。两侧各看各自的腿，多空两本账互不干扰。
+func (r *Runner) routePositionSide(o *order.Order) {
+	r.routePositionSideReserved(o, nil)
+}
+
+// routeClosingBatch 为同一批新单依次选腿，前面已选为平仓的数量占用腿的余量。
+func (r *Runner) routeClosingBatch(places []dynamicPlacement) {
+	reserved := make(map[string]float64)
+	for i := range places {
+		r.routePositionSideReserved(&places[i].ord, reserved)
 	}
-	if isBuy {
-		if idx >= len(r.dynamicBids) {
-			return nil
+}
+
+// routePositionSideReserved 同 routePositionSide；reserved 非空时累加本批已选为平仓的数量（按腿）。
+func (r *Runner) routePositionSideReserved(o *order.Order, reserved map[string]float64) {
+	if !r.HedgeMode || r.Inv == nil || o.PositionSide != "" {
+		return
+	}
+	closing := order.ClosingPositionSide(o.Side)
+	if o.ReduceOnly {
+		o.PositionSide = closing
+		return
+	}
+	long, short := r.Inv.Legs()
+	avail := long.Qty
+	if closing == order.PositionSideShort {
+		avail = short.Qty
+	}
+	avail -= r.restingCloseQty(closing, o.Side) + reserved[closing]
+	if avail > 0 && avail+1e-12 >= o.Quantity {
+		o.PositionSide = closing
+		if reserved != nil {
+			reserved[closing] += o.Quantity
+		}
+		return
+	}
+	o.PositionSide = order.OpeningPositionSide(o.Side)
+}
+
+// restingCloseQty 已挂在该腿上的同向平仓单剩余数量（撤单在途的不计）。
+func (r *Runner) restingCloseQty(positionSide, side string) float64 {
+	if r.OrderMgr == nil {
+		return 0
+	}
+	total := 0.0
+	for _, o := range r.OrderMgr.GetActiveOrdersBySymbol(r.Symbol) {
+		if o.PositionSide != positionSide || o.Side != side || o.Status == order.StatusPendingCancel {
+			continue
+		}
+		if rest := o.Quantity - o.FilledQty; rest > 0 {
+			total += rest
+		}
+	}
+	return total
+}
+
+// applyDynamicBatch 先对仅价格/数量变化的档位原地改单，改单失败的并入撤单+重挂；
+// 随后批量撤销被替换/多余的档位，再批量下发新单；
+// post-only 被拒的非 reduce-only 单按 submitOrderWithFallback 的规则降级为普通限价单再批量补发一次。
+func (r *Runner) applyDynamicBatch(cancels []dynamicCancel, places []dynamicPlacement) {
+	if r.OrderMgr == nil {
+		return
+	}
+	suppress := r.shouldSuppressCancel()
+	pending := places[:0]
+	for _, p := range places {
+		if p.amendID == "" {
+			pending = append(pending, p)
+			continue
+		}
+		// 高频成交抑制撤单时同样不改单，沿用原有撤单/下单路径
+		if !suppress && r.tryAmend(p.ord.Side, p.amendID, p.price, p.ord.Quantity) {
+			r.setDynamicLevel(p.isBuy, p.idx, levelState{id: p.amendID, price: p.price, placedAt: time.Now()})
+			metrics.IncrementDynamicOrderUpdate(strings.ToLower(p.ord.Side))
+			continue
+		}
+		cancels = append(cancels, dynamicCancel{isBuy: p.isBuy, idx: p.idx, id: p.amendID})
+		pending = append(pending, p)
+	}
+	places = pending
+	if r.marginBackoffActive() {
+		// 保证金不足退避期间只撤不挂（减仓单除外）
+		pending = places[:0]
+		for _, p := range places {
+			if p.ord.ReduceOnly {
+				pending = append(pending, p)
+			}
+		}
+		places = pending
+	}
+	if len(cancels) > 0 && !suppress {
+		ids := make([]string, len(cancels))
+		for i, c := range cancels {
+			ids[i] = c.id
+		}
+		r.cancelBatch(ids)
+		for _, c := range cancels {
+			metrics.IncrementDynamicOrderCancel(map[bool]string{true: "buy", false: "sell"}[c.isBuy])
+			r.setDynamicLevel(c.isBuy, c.idx, levelState{})
+		}
+	}
+	if len(places) == 0 {
+		return
+	}
+	r.routeClosingBatch(places)
+	if r.AsyncOrders {
+		r.submitDynamicAsync(places)
+		return
+	}
+	orders := make([]order.Order, len(places))
+	for i, p := range places {
+		orders[i] = p.ord
+	}
+	res, errs := r.OrderMgr.SubmitBatch(orders)
+	var retry []dynamicPlacement
+	for i, p := range places {
+		if errs[i] == nil {
+			r.recordDynamicPlacement(p, res[i].ID, p.ord.PostOnly)
+			continue
+		}
+		r.noteOrderError(p.ord.Side, errs[i])
+		if !p.ord.ReduceOnly && p.ord.PostOnly && errors.Is(errs[i], gateway.ErrPostOnlyReject) {
+			side := p.ord.Side
+			r.enterPostOnlyCooldown(side)
+			r.bumpMakerShift(side)
+			metrics.IncrementPostOnlyRejectFallback(strings.ToLower(side))
+			p.ord.PostOnly = false
+			retry = append(retry, p)
+		}
+	}
+	if len(retry) == 0 {
+		return
+	}
+	orders = orders[:0]
+	for _, p := range retry {
+		orders = append(orders, p.ord)
+	}
+	res, errs = r.OrderMgr.SubmitBatch(orders)
+	for i, p := range retry {
+		if errs[i] == nil {
+			r.recordDynamicPlacement(p, res[i].ID, false)
+		}
+	}
+}
+
+// submitDynamicAsync 异步批量下发动态档位：入队成功即记入档位，结果由 drainAsyncResults 处理。
+// post-only 被拒时不立即补发，冷却期内下一 tick 按普通限价单重挂。
+func (r *Runner) submitDynamicAsync(places []dynamicPlacement) {
+	orders := make([]order.Order, len(places))
+	for i, p := range places {
+		orders[i] = p.ord
+	}
+	res, errs := r.OrderMgr.SubmitBatchAsync(orders, r.queueAsyncResult)
+	if r.asyncPlaces == nil {
+		r.asyncPlaces = make(map[string]dynamicPlacement)
+	}
+	for i, p := range places {
+		if errs[i] != nil {
+			r.noteOrderError(p.ord.Side, errs[i])
+			continue
+		}
+		r.asyncPlaces[res[i].ID] = p
+		r.recordDynamicPlacement(p, res[i].ID, false)
+	}
+}
+
+// queueAsyncResult 在管道 worker 中调用，只登记结果，由主循环处理。
+func (r *Runner) queueAsyncResult(c order.Completion) {
+	r.asyncMu.Lock()
+	r.asyncDone = append(r.asyncDone, c)
+	r.asyncMu.Unlock()
+}
+
+// drainAsyncResults 处理已完成的异步下单：失败的档位清空以便重挂，并按拒单原因退避。
+func (r *Runner) drainAsyncResults() {
+	r.asyncMu.Lock()
+	done := r.asyncDone
+	r.asyncDone = nil
+	r.asyncMu.Unlock()
+	for _, c := range done {
+		p, ok := r.asyncPlaces[c.Order.ID]
+		if !ok {
+			continue
+		}
+		delete(r.asyncPlaces, c.Order.ID)
+		side := p.ord.Side
+		if c.Err == nil {
+			if p.ord.PostOnly {
+				r.clearPostOnlyCooldown(side)
+			}
+			continue
 		}
-		st := r.dynamicBids[idx]
-		st.id = res.ID
-		st.price = price
-		st.placedAt = time.Now()
+		r.clearDynamicLevel(p.isBuy, p.idx, c.Order.ID)
+		r.noteOrderError(side, c.Err)
+		if !p.ord.ReduceOnly && p.ord.PostOnly && errors.Is(c.Err, gateway.ErrPostOnlyReject) {
+			r.enterPostOnlyCooldown(side)
+			r.bumpMakerShift(side)
+			metrics.IncrementPostOnlyRejectFallback(strings.ToLower(side))
+		}
+	}
+}
+
+// clearDynamicLevel 档位仍挂着 id 时清空（已被替换的不动）。
+func (r *Runner) clearDynamicLevel(isBuy bool, idx int, id string) {
+	levels := r.dynamicAsks
+	if isBuy {
+		levels = r.dynamicBids
+	}
+	if idx < len(levels) && levels[idx].id == id {
+		levels[idx] = levelState{}
+	}
+}
+
+// cancelBatch 批量撤单；AsyncOrders 时只入队不等待。
+func (r *Runner) cancelBatch(ids []string) {
+	if r.AsyncOrders {
+		_ = r.OrderMgr.CancelBatchAsync(ids, nil)
+		return
+	}
+	_ = r.OrderMgr.CancelBatch(ids)
+}
+
+func (r *Runner) recordDynamicPlacement(p dynamicPlacement, id string, usedPostOnly bool) {
+	if p.isBuy && p.idx >= len(r.dynamicBids) || !p.isBuy && p.idx >= len(r.dynamicAsks) {
+		return
+	}
+	r.setDynamicLevel(p.isBuy, p.idx, levelState{id: id, price: p.price, placedAt: time.Now()})
+	if p.isBuy {
 		metrics.IncrementOrdersPlaced("buy")
 		metrics.IncrementDynamicOrderUpdate("buy")
 		if usedPostOnly {
 			r.clearPostOnlyCooldown("BUY")
 		}
-		r.dynamicBids[idx] = st
 	} else {
-		if idx >= len(r.dynamicAsks) {
-			return nil
-		}
-		st := r.dynamicAsks[idx]
-		st.id = res.ID
-		st.price = price
-		st.placedAt = time.Now()
 		metrics.IncrementOrdersPlaced("sell")
 		metrics.IncrementDynamicOrderUpdate("sell")
 		if usedPostOnly {
 			r.clearPostOnlyCooldown("SELL")
 		}
-		r.dynamicAsks[idx] = st
 	}
-	return nil
 }
 
-func (r *Runner) cancelDynamicLeg(isBuy bool, idx int) error {
-	// 检查是否应抑制撤单（高频成交时）
-	if r.shouldSuppressCancel() {
-		return nil // 抑制撤单，保持现有订单
-	}
-
-	var st levelState
+func (r *Runner) setDynamicLevel(isBuy bool, idx int, st levelState) {
 	if isBuy {
-		if idx >= len(r.dynamicBids) {
-			return nil
+		if idx < len(r.dynamicBids) {
+			r.dynamicBids[idx] = st
 		}
-		st = r.dynamicBids[idx]
-	} else {
-		if idx >= len(r.dynamicAsks) {
-			return nil
-		}
-		st = r.dynamicAsks[idx]
-	}
-	if st.id == "" || r.OrderMgr == nil {
-		return nil
+		return
 	}
-	_ = r.OrderMgr.Cancel(st.id)
-	metrics.IncrementDynamicOrderCancel(map[bool]string{true: "buy", false: "sell"}[isBuy])
-	st = levelState{}
-	if isBuy {
-		r.dynamicBids[idx] = st
-	} else {
+	if idx < len(r.dynamicAsks) {
 		r.dynamicAsks[idx] = st
 	}
-	return nil
 }
 
+// tryAmend 在 Gateway 支持改单时原地修改普通挂单的价格/数量，成功返回 true；
+// reduce-only/IOC 单不改单，改单失败（post-only 穿价、订单已成交等）返回 false，由调用方退回撤单+重挂。
+func (r *Runner) tryAmend(side, id string, price, qty float64) bool {
+	if r.OrderMgr == nil || id == "" || !r.OrderMgr.SupportsAmend() {
+		return false
+	}
+	o, err := r.OrderMgr.GetOrder(id)
+	if err != nil || o.ReduceOnly || o.TimeInForce == "IOC" || o.TimeInForce == "FOK" {
+		return false
+	}
+	label := strings.ToLower(side)
+	if _, err := r.OrderMgr.Amend(id, price, qty); err != nil {
+		metrics.IncrementOrderAmend(label, "fallback")
+		return false
+	}
+	metrics.IncrementOrderAmend(label, "ok")
+	return true
+}
+
+// riskAllows 对改单后的数量做与下单相同的风控预检。
+func (r *Runner) riskAllows(delta float64) bool {
+	return r.Risk == nil || r.Risk.PreOrder(r.Symbol, delta) == nil
+}
+
+// cancelOutstanding 以一次批量撤单撤销单档与多档动态挂单。
 func (r *Runner) cancelOutstanding(cancelBid, cancelAsk bool) {
 	if r.OrderMgr == nil {
 		return
 	}
+	var ids []string
 	if cancelBid && r.lastBidID != "" {
-		_ = r.OrderMgr.Cancel(r.lastBidID)
+		ids = append(ids, r.lastBidID)
 		r.lastBidID = ""
 		r.lastBidPrice = 0
 		r.lastBidPlacedAt = time.Time{}
 	}
 	if cancelAsk && r.lastAskID != "" {
-		_ = r.OrderMgr.Cancel(r.lastAskID)
+		ids = append(ids, r.lastAskID)
 		r.lastAskID = ""
 		r.lastAskPrice = 0
 		r.lastAskPlacedAt = time.Time{}
 	}
 	// 同步取消多档动态腿
-	if cancelBid && len(r.dynamicBids) > 0 {
-		for i := range r.dynamicBids {
-			_ = r.cancelDynamicLeg(true, i)
+	ids = append(ids, r.takeDynamicLegs(cancelBid, cancelAsk)...)
+	if len(ids) > 0 {
+		r.cancelBatch(ids)
+	}
+}
+
+// takeDynamicLegs 清空指定侧的多档动态腿状态，返回需撤销的订单 ID；
+// 高频成交时抑制撤单，保持现有挂单，此时不返回 ID。
+func (r *Runner) takeDynamicLegs(bids, asks bool) []string {
+	var ids []string
+	suppress := r.shouldSuppressCancel()
+	if bids && len(r.dynamicBids) > 0 {
+		for _, st := range r.dynamicBids {
+			if st.id != "" && !suppress {
+				ids = append(ids, st.id)
+				metrics.IncrementDynamicOrderCancel("buy")
+			}
 		}
 		r.dynamicBids = nil
 	}
-	if cancelAsk && len(r.dynamicAsks) > 0 {
-		for i := range r.dynamicAsks {
-			_ = r.cancelDynamicLeg(false, i)
+	if asks && len(r.dynamicAsks) > 0 {
+		for _, st := range r.dynamicAsks {
+			if st.id != "" && !suppress {
+				ids = append(ids, st.id)
+				metrics.IncrementDynamicOrderCancel("sell")
+			}
 		}
 		r.dynamicAsks = nil
 	}
+	return ids
+}
+
+// cancelSingleQuotes 撤销单档报价（lastBid/lastAsk），切换到多档报价时使用。
+func (r *Runner) cancelSingleQuotes() {
+	if r.OrderMgr == nil || (r.lastBidID == "" && r.lastAskID == "") {
+		return
+	}
+	var ids []string
+	if r.lastBidID != "" {
+		ids = append(ids, r.lastBidID)
+	}
+	if r.lastAskID != "" {
+		ids = append(ids, r.lastAskID)
+	}
+	r.cancelBatch(ids)
+	r.lastBidID, r.lastAskID = "", ""
+	r.lastBidPrice, r.lastAskPrice = 0, 0
+	r.lastBidPlacedAt, r.lastAskPlacedAt = time.Time{}, time.Time{}
+}
+
+// RequestHalt 供其他 goroutine 请求停机（撤单并暂停 haltDuration），在下一次 OnTick 开头执行。
+func (r *Runner) RequestHalt(reason string) {
+	if reason == "" {
+		reason = "halt_requested"
+	}
+	r.haltMu.Lock()
+	r.haltRequest = reason
+	r.haltMu.Unlock()
+}
+
+// takeHaltRequest 取出并清空待执行的停机请求。
+func (r *Runner) takeHaltRequest() string {
+	r.haltMu.Lock()
+	defer r.haltMu.Unlock()
+	reason := r.haltRequest
+	r.haltRequest = ""
+	return reason
 }
 
 func (r *Runner) triggerHalt(reason string) error {
@@ -1544,6 +1966,74 @@ func (r *Runner) OnFill(orderID string, fillPrice float64, side string, quantity
 	}
 }
 
+// HandleFill 处理 order.Manager 产生的成交：按腿（双向持仓）或净仓位更新库存，再通知 OnFill。
+// 作为 Manager.OnFill 使用，库存、FillTracker 与 PostTrade 由同一成交事件驱动。
+func (r *Runner) HandleFill(f order.Fill) {
+	if r.Inv != nil {
+		switch strings.ToUpper(f.PositionSide) {
+		case "LONG", "SHORT":
+			r.Inv.UpdateLeg(f.PositionSide, f.SignedQty(), f.Price)
+		default:
+			r.Inv.Update(f.SignedQty(), f.Price)
+		}
+	}
+	r.OnFill(f.OrderID, f.Price, f.Side, f.Qty)
+}
+
+// AdoptOrders 重启恢复时接管上一进程的挂单：按策略标签与档位放回静态/多档/单档槽位，
+// 之后与新报价一样参与替换与撤单。槽位已被占用的订单不接管，返回其 ID 由调用方撤销。
+func (r *Runner) AdoptOrders(orders []order.Order) []string {
+	var rejected []string
+	now := time.Now()
+	for _, o := range orders {
+		isBuy := o.Side != "SELL"
+		var ok bool
+		switch {
+		case o.ClientID == "sta" && isBuy:
+			ok = adoptQuote(&r.staticBidID, &r.staticBidPrice, &r.staticBidPlacedAt, o, now)
+		case o.ClientID == "sta":
+			ok = adoptQuote(&r.staticAskID, &r.staticAskPrice, &r.staticAskPlacedAt, o, now)
+		case o.ClientID == "dyn":
+			ok = r.adoptDynamicLevel(isBuy, o, now)
+		case isBuy:
+			ok = adoptQuote(&r.lastBidID, &r.lastBidPrice, &r.lastBidPlacedAt, o, now)
+		default:
+			ok = adoptQuote(&r.lastAskID, &r.lastAskPrice, &r.lastAskPlacedAt, o, now)
+		}
+		if !ok {
+			rejected = append(rejected, o.ID)
+		}
+	}
+	return rejected
+}
+
+// adoptQuote 槽位为空时放入订单。
+func adoptQuote(id *string, price *float64, placedAt *time.Time, o order.Order, now time.Time) bool {
+	if *id != "" {
+		return false
+	}
+	*id, *price, *placedAt = o.ID, o.Price, now
+	return true
+}
+
+func (r *Runner) adoptDynamicLevel(isBuy bool, o order.Order, now time.Time) bool {
+	if o.Level < 0 {
+		return false
+	}
+	levels := &r.dynamicAsks
+	if isBuy {
+		levels = &r.dynamicBids
+	}
+	if n := o.Level + 1 - len(*levels); n > 0 {
+		*levels = append(*levels, make([]levelState, n)...)
+	}
+	if (*levels)[o.Level].id != "" {
+		return false
+	}
+	(*levels)[o.Level] = levelState{id: o.ID, price: o.Price, placedAt: now}
+	return true
+}
+
 // shouldSuppressCancel 判断是否应抑制撤单（高频成交时）
 func (r *Runner) shouldSuppressCancel() bool {
 	if !r.cancelSuppressionEnabled || r.fillTracker == nil {
@@ -1582,7 +2072,7 @@ func alignQuote(c order.SymbolConstraints, bid, ask, qty float64) (float64, floa
 		bid = snapDown(bid, c.TickSize)
 		ask = snapUp(ask, c.TickSize)
 		if ask <= bid {
-			ask = bid + c.TickSize
+			ask = snapUp(bid+c.TickSize, c.TickSize)
 		}
 	}
 	if c.StepSize > 0 {
@@ -1614,30 +2104,38 @@ func alignQuote(c order.SymbolConstraints, bid, ask, qty float64) (float64, floa
 	return bid, ask, qty, nil
 }
 
+// snapDown/snapUp/roundToStep/ceilToStep 经 order.Decimal 对齐，结果恰为 tick/step 的整数倍，
+// 不会出现 0.30000000000000004 这类尾差。
 func snapDown(price, tick float64) float64 {
-	if tick <= 0 {
-		return price
-	}
-	return math.Floor(price/tick+1e-9) * tick
+	return quantize(price, tick, order.RoundDown)
 }
 
 func snapUp(price, tick float64) float64 {
-	if tick <= 0 {
-		return price
-	}
-	return math.Ceil(price/tick-1e-9) * tick
+	return quantize(price, tick, order.RoundUp)
 }
 
 func roundToStep(qty, step float64) float64 {
-	if step <= 0 {
-		return qty
-	}
-	return math.Round(qty/step) * step
+	return quantize(qty, step, order.RoundNearest)
 }
 
 func ceilToStep(val, step float64) float64 {
+	return quantize(val, step, order.RoundUp)
+}
+
+func quantize(v, step float64, mode order.RoundingMode) float64 {
 	if step <= 0 {
-		return val
+		return v
+	}
+	return order.QuantizeFloat(v, order.DecimalFromFloat(step), mode).Float64()
+}
+
+// ValuationPrice 返回用于止损/浮亏估值的价格；未配置 Valuation 时即为 mid。
+func (r *Runner) ValuationPrice(mid float64) float64 {
+	if r.Valuation == nil {
+		return mid
+	}
+	if p := r.Valuation.ValuationPrice(r.Symbol, r.ValuationBasis, mid); p > 0 {
+		return p
 	}
-	return math.Ceil(val/step-1e-9) * step
+	return mid
 }
@@ -0,0 +1,78 @@
+package sim
+
+import (
+	"testing"
+	"time"
+
+	"market-maker-go/gateway"
+	"market-maker-go/inventory"
+	"market-maker-go/order"
+)
+
+// amendGateway 在 batchGateway 基础上支持改单；rejectAmend 指定的订单改单失败。
+type amendGateway struct {
+	batchGateway
+	amended     []order.Order
+	rejectAmend map[string]bool
+}
+
+func (g *amendGateway) Amend(o order.Order) error {
+	if g.rejectAmend[o.ID] {
+		return &gateway.BinanceAPIError{Op: "modify order", HTTPStatus: 400, Code: gateway.CodePostOnlyReject, Msg: "Post Only order will be rejected."}
+	}
+	g.amended = append(g.amended, o)
+	return nil
+}
+
+func TestReconcileDynamicQuotesPrefersAmend(t *testing.T) {
+	gw := &amendGateway{rejectAmend: map[string]bool{}}
+	r := &Runner{
+		Symbol:                "ETHUSDC",
+		Inv:                   &inventory.Tracker{},
+		OrderMgr:              order.NewManager(gw),
+		Constraints:           order.SymbolConstraints{TickSize: 0.01, StepSize: 0.001},
+		DynamicThresholdTicks: 5,
+		DynamicRestDuration:   time.Hour,
+	}
+	bids, asks := ladder(2000, 0.5, 2)
+	r.reconcileDynamicQuotes(2000, bids, asks, true, true)
+	bidID, askID := r.dynamicBids[0].id, r.dynamicAsks[0].id
+
+	// 卖一改单被拒：该档退回撤单+重挂，其余档位原地改价
+	gw.rejectAmend[askID] = true
+	bids, asks = ladder(2001, 0.5, 2)
+	r.reconcileDynamicQuotes(2001, bids, asks, true, true)
+	if len(gw.amended) != 3 {
+		t.Fatalf("expected 3 amends, got %d", len(gw.amended))
+	}
+	if r.dynamicBids[0].id != bidID || r.dynamicBids[0].price != 2000.5 {
+		t.Fatalf("amended level should keep order id, got %+v", r.dynamicBids[0])
+	}
+	if o, _ := r.OrderMgr.GetOrder(bidID); o.Price != 2000.5 || o.Status != order.StatusAck {
+		t.Fatalf("unexpected amended order %+v", o)
+	}
+	if len(gw.cancelBatches) != 0 || gw.singles != 2 {
+		t.Fatalf("expected single cancel+place for rejected amend, got cancels=%v singles=%d", gw.cancelBatches, gw.singles)
+	}
+	if r.dynamicAsks[0].id == askID || r.dynamicAsks[0].id == "" {
+		t.Fatalf("rejected amend should be replaced by a new order, got %+v", r.dynamicAsks[0])
+	}
+	if st, _ := r.OrderMgr.Status(askID); st != order.StatusCanceled {
+		t.Fatalf("replaced order status %s", st)
+	}
+}
+
+func TestEnsureStaticOrderAmends(t *testing.T) {
+	gw := &amendGateway{}
+	r := &Runner{
+		Symbol:   "ETHUSDC",
+		Inv:      &inventory.Tracker{},
+		OrderMgr: order.NewManager(gw),
+	}
+	r.ensureStaticOrder(&r.staticBidID, &r.staticBidPrice, &r.staticBidPlacedAt, 1999, 0.01, "BUY", 0.5)
+	id := r.staticBidID
+	r.ensureStaticOrder(&r.staticBidID, &r.staticBidPrice, &r.staticBidPlacedAt, 1998, 0.01, "BUY", 0.5)
+	if r.staticBidID != id || r.staticBidPrice != 1998 || len(gw.amended) != 1 || gw.singles != 1 {
+		t.Fatalf("expected in-place amend, got id=%s price=%.2f amends=%d singles=%d", r.staticBidID, r.staticBidPrice, len(gw.amended), gw.singles)
+	}
+}
@@ -0,0 +1,183 @@
+package sim
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"market-maker-go/gateway"
+	"market-maker-go/inventory"
+	"market-maker-go/order"
+	"market-maker-go/strategy/asmm"
+)
+
+// batchGateway 记录批量请求；rejectPostOnly 为 true 时首个 post-only 单返回 -5022。
+type batchGateway struct {
+	singles        int
+	placed         []order.Order
+	placeBatches   [][]order.Order
+	cancelBatches  [][]string
+	rejectPostOnly bool
+}
+
+func (g *batchGateway) Place(o order.Order) (string, error) {
+	g.singles++
+	g.placed = append(g.placed, o)
+	return o.ID, nil
+}
+
+func (g *batchGateway) Cancel(orderID string) error {
+	g.singles++
+	return nil
+}
+
+func (g *batchGateway) PlaceBatch(orders []order.Order) ([]order.BatchResult, error) {
+	g.placeBatches = append(g.placeBatches, orders)
+	res := make([]order.BatchResult, len(orders))
+	for i, o := range orders {
+		if g.rejectPostOnly && o.PostOnly {
+			g.rejectPostOnly = false
+			res[i].Err = &gateway.BinanceAPIError{Op: "place batch item", HTTPStatus: 200, Code: gateway.CodePostOnlyReject, Msg: "Post Only order will be rejected."}
+			continue
+		}
+		res[i].ExchangeID = "ex-" + o.ID
+	}
+	return res, nil
+}
+
+func (g *batchGateway) CancelBatch(ids []string) ([]order.BatchResult, error) {
+	g.cancelBatches = append(g.cancelBatches, ids)
+	return make([]order.BatchResult, len(ids)), nil
+}
+
+func ladder(mid, step float64, n int) ([]asmm.Quote, []asmm.Quote) {
+	var bids, asks []asmm.Quote
+	for i := 0; i < n; i++ {
+		off := step * float64(i+1)
+		bids = append(bids, asmm.Quote{Price: mid - off, Size: 0.01, Side: asmm.Bid})
+		asks = append(asks, asmm.Quote{Price: mid + off, Size: 0.01, Side: asmm.Ask})
+	}
+	return bids, asks
+}
+
+func TestReconcileDynamicQuotesBatchesLadder(t *testing.T) {
+	gw := &batchGateway{}
+	r := &Runner{
+		Symbol:                "ETHUSDC",
+		Inv:                   &inventory.Tracker{},
+		OrderMgr:              order.NewManager(gw),
+		Constraints:           order.SymbolConstraints{TickSize: 0.01, StepSize: 0.001},
+		DynamicThresholdTicks: 5,
+		DynamicRestDuration:   time.Hour,
+	}
+
+	bids, asks := ladder(2000, 0.5, 3)
+	r.reconcileDynamicQuotes(2000, bids, asks, true, true)
+	if gw.singles != 0 || len(gw.placeBatches) != 1 || len(gw.placeBatches[0]) != 6 {
+		t.Fatalf("expected one batch of 6, got batches=%d singles=%d", len(gw.placeBatches), gw.singles)
+	}
+	var firstIDs []string
+	for _, st := range append(append([]levelState{}, r.dynamicBids...), r.dynamicAsks...) {
+		if st.id == "" {
+			t.Fatalf("level not recorded: %+v", r.dynamicBids)
+		}
+		firstIDs = append(firstIDs, st.id)
+	}
+
+	// 整体上移：被替换的 6 档一次批量撤单，新 6 档一次批量下单
+	bids, asks = ladder(2001, 0.5, 3)
+	r.reconcileDynamicQuotes(2001, bids, asks, true, true)
+	if len(gw.cancelBatches) != 1 || len(gw.placeBatches) != 2 || gw.singles != 0 {
+		t.Fatalf("expected one cancel batch + one place batch, got cancels=%d places=%d singles=%d", len(gw.cancelBatches), len(gw.placeBatches), gw.singles)
+	}
+	if strings.Join(gw.cancelBatches[0], ",") != strings.Join(firstIDs, ",") {
+		t.Fatalf("unexpected cancel ids %v want %v", gw.cancelBatches[0], firstIDs)
+	}
+	for _, id := range firstIDs {
+		if st, _ := r.OrderMgr.Status(id); st != order.StatusCanceled {
+			t.Fatalf("replaced order %s status %s", id, st)
+		}
+	}
+
+	// 档位减少：价格未变的首档保留，多余档位批量撤销
+	r.reconcileDynamicQuotes(2001, bids[:1], asks[:1], true, true)
+	if len(gw.cancelBatches) != 2 || len(gw.cancelBatches[1]) != 4 || len(gw.placeBatches) != 2 {
+		t.Fatalf("expected 4 excess levels canceled in one batch, got %v", gw.cancelBatches)
+	}
+	if r.dynamicBids[0].id == "" || r.dynamicAsks[0].id == "" {
+		t.Fatalf("unchanged first levels should be kept")
+	}
+}
+
+func TestReconcileDynamicQuotesPostOnlyFallbackBatch(t *testing.T) {
+	gw := &batchGateway{rejectPostOnly: true}
+	r := &Runner{
+		Symbol:      "ETHUSDC",
+		Inv:         &inventory.Tracker{},
+		OrderMgr:    order.NewManager(gw),
+		Constraints: order.SymbolConstraints{TickSize: 0.01, StepSize: 0.001},
+	}
+	bids, asks := ladder(2000, 0.5, 2)
+	r.reconcileDynamicQuotes(2000, bids, asks, true, true)
+	if len(gw.placeBatches) != 1 {
+		t.Fatalf("expected a single ladder batch, got %d", len(gw.placeBatches))
+	}
+	// 仅被拒的一笔降级为普通限价单重发
+	if len(gw.placed) != 1 || gw.placed[0].PostOnly || gw.placed[0].Side != "BUY" {
+		t.Fatalf("unexpected retry %+v", gw.placed)
+	}
+	if r.dynamicBids[0].id == "" || r.postOnlyReady("BUY") {
+		t.Fatalf("expected level recorded and buy post-only cooldown, got %+v", r.dynamicBids[0])
+	}
+}
+
+func TestReconcileDynamicQuotesAsyncPostOnlyReject(t *testing.T) {
+	gw := &batchGateway{rejectPostOnly: true}
+	r := &Runner{
+		Symbol:      "ETHUSDC",
+		Inv:         &inventory.Tracker{},
+		OrderMgr:    order.NewManager(gw),
+		Constraints: order.SymbolConstraints{TickSize: 0.01, StepSize: 0.001},
+		AsyncOrders: true,
+	}
+	bids, asks := ladder(2000, 0.5, 2)
+	r.reconcileDynamicQuotes(2000, bids, asks, true, true)
+	// 入队即记入档位，不等待交易所响应
+	for _, st := range append(append([]levelState{}, r.dynamicBids...), r.dynamicAsks...) {
+		if st.id == "" {
+			t.Fatalf("expected all levels recorded before completion, got bids=%+v asks=%+v", r.dynamicBids, r.dynamicAsks)
+		}
+	}
+	rejected := r.dynamicBids[0].id
+	r.OrderMgr.Flush()
+	r.drainAsyncResults()
+	if len(gw.placeBatches) != 1 || len(gw.placed) != 0 {
+		t.Fatalf("async path should not retry inline, batches=%d singles=%d", len(gw.placeBatches), len(gw.placed))
+	}
+	if r.dynamicBids[0].id != "" || r.dynamicBids[1].id == "" || r.postOnlyReady("BUY") {
+		t.Fatalf("expected rejected level cleared with buy cooldown, got %+v", r.dynamicBids)
+	}
+	if st, _ := r.OrderMgr.Status(rejected); st != order.StatusRejected {
+		t.Fatalf("expected rejected order status, got %s", st)
+	}
+}
+
+func TestAdoptOrdersRestoresSlots(t *testing.T) {
+	r := &Runner{Symbol: "ETHUSDC", Inv: &inventory.Tracker{}, OrderMgr: order.NewManager(&batchGateway{})}
+	rejected := r.AdoptOrders([]order.Order{
+		{ID: "b2", Side: "BUY", Price: 1999, ClientID: "dyn", Level: 2},
+		{ID: "a0", Side: "SELL", Price: 2001, ClientID: "dyn", Level: 0},
+		{ID: "sb", Side: "BUY", Price: 1990, ClientID: "sta"},
+		{ID: "q1", Side: "SELL", Price: 2002, ClientID: "q"},
+		{ID: "b2dup", Side: "BUY", Price: 1998, ClientID: "dyn", Level: 2},
+	})
+	if len(rejected) != 1 || rejected[0] != "b2dup" {
+		t.Fatalf("unexpected rejected %v", rejected)
+	}
+	if len(r.dynamicBids) != 3 || r.dynamicBids[2].id != "b2" || r.dynamicBids[2].price != 1999 || r.dynamicAsks[0].id != "a0" {
+		t.Fatalf("dynamic levels not restored: bids=%+v asks=%+v", r.dynamicBids, r.dynamicAsks)
+	}
+	if r.staticBidID != "sb" || r.staticBidPrice != 1990 || r.lastAskID != "q1" {
+		t.Fatalf("static/single slots not restored: static=%s last=%s", r.staticBidID, r.lastAskID)
+	}
+}
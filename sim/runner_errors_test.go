@@ -0,0 +1,54 @@
+package sim
+
+import (
+	"errors"
+	"testing"
+
+	"market-maker-go/gateway"
+	"market-maker-go/inventory"
+	"market-maker-go/order"
+)
+
+// rejectGateway 对非减仓单返回指定的交易所错误。
+type rejectGateway struct {
+	err   error
+	calls int
+}
+
+func (g *rejectGateway) Place(o order.Order) (string, error) {
+	g.calls++
+	if !o.ReduceOnly {
+		return "", g.err
+	}
+	return o.ID, nil
+}
+
+func (g *rejectGateway) Cancel(orderID string) error { return nil }
+
+func TestMarginInsufficientBacksOffOpeningOrders(t *testing.T) {
+	gw := &rejectGateway{err: &gateway.BinanceAPIError{Op: "place limit", HTTPStatus: 400, Code: gateway.CodeMarginInsufficient, Msg: "Margin is insufficient."}}
+	r := &Runner{Symbol: "ETHUSDC", Inv: &inventory.Tracker{}, OrderMgr: order.NewManager(gw)}
+	buy := order.Order{Symbol: "ETHUSDC", Side: "BUY", Price: 2000, Quantity: 0.01, PostOnly: true}
+
+	if _, _, err := r.submitOrderWithFallback("BUY", buy, false); !errors.Is(err, gateway.ErrMarginInsufficient) {
+		t.Fatalf("expected margin error, got %v", err)
+	}
+	// 退避期间新单不再发往交易所
+	if _, _, err := r.submitOrderWithFallback("BUY", buy, false); !errors.Is(err, gateway.ErrMarginInsufficient) || gw.calls != 1 {
+		t.Fatalf("expected local backoff without exchange call, err=%v calls=%d", err, gw.calls)
+	}
+	// 减仓单不受影响
+	sell := order.Order{Symbol: "ETHUSDC", Side: "SELL", Price: 2000, Quantity: 0.01, ReduceOnly: true, TimeInForce: "IOC"}
+	if _, _, err := r.submitOrderWithFallback("SELL", sell, true); err != nil || gw.calls != 2 {
+		t.Fatalf("reduce-only order should pass during backoff, err=%v calls=%d", err, gw.calls)
+	}
+}
+
+func TestPostOnlyFallbackOnlyOnGTXReject(t *testing.T) {
+	gw := &rejectGateway{err: &gateway.BinanceAPIError{Op: "place limit", HTTPStatus: 400, Code: gateway.CodeMinNotional, Msg: "Order's notional must be no smaller than 5"}}
+	r := &Runner{Symbol: "ETHUSDC", Inv: &inventory.Tracker{}, OrderMgr: order.NewManager(gw)}
+	buy := order.Order{Symbol: "ETHUSDC", Side: "BUY", Price: 2000, Quantity: 0.001, PostOnly: true}
+	if _, _, err := r.submitOrderWithFallback("BUY", buy, false); !errors.Is(err, gateway.ErrMinNotional) || gw.calls != 1 {
+		t.Fatalf("non post-only rejects should not retry as taker, err=%v calls=%d", err, gw.calls)
+	}
+}
@@ -0,0 +1,132 @@
+package sim
+
+import (
+	"math"
+	"testing"
+
+	"market-maker-go/inventory"
+	"market-maker-go/order"
+	"market-maker-go/strategy"
+)
+
+func TestRoutePositionSideByLeg(t *testing.T) {
+	tr := &inventory.Tracker{}
+	tr.SetHedgeMode(true)
+	tr.ApplyPosition(order.PositionSideLong, 1, 100)
+	r := &Runner{HedgeMode: true, Inv: tr}
+
+	cases := []struct {
+		o    order.Order
+		want string
+	}{
+		// 多头腿足以覆盖：卖单平多
+		{order.Order{Side: "SELL", Quantity: 0.5}, order.PositionSideLong},
+		// 超出多头腿：卖单开空，不去穿透多头
+		{order.Order{Side: "SELL", Quantity: 2}, order.PositionSideShort},
+		// 空头腿为空：买单开多
+		{order.Order{Side: "BUY", Quantity: 0.5}, order.PositionSideLong},
+		// reduce-only 买单只能平空
+		{order.Order{Side: "BUY", Quantity: 0.5, ReduceOnly: true}, order.PositionSideShort},
+	}
+	for i, c := range cases {
+		o := c.o
+		r.routePositionSide(&o)
+		if o.PositionSide != c.want {
+			t.Errorf("case %d: %s qty=%.1f reduceOnly=%v routed to %s, want %s", i, o.Side, o.Quantity, o.ReduceOnly, o.PositionSide, c.want)
+		}
+	}
+
+	// 单向持仓不写 positionSide
+	oneWay := &Runner{Inv: &inventory.Tracker{}}
+	o := order.Order{Side: "BUY", Quantity: 1}
+	oneWay.routePositionSide(&o)
+	if o.PositionSide != "" {
+		t.Fatalf("one-way mode should leave positionSide empty, got %s", o.PositionSide)
+	}
+}
+
+func TestRunnerQuotesHedgeLegsIndependently(t *testing.T) {
+	engine, _ := strategy.NewEngine(strategy.EngineConfig{MinSpread: 0.001, MaxDrift: 1, BaseSize: 0.5})
+	tr := &inventory.Tracker{}
+	tr.SetHedgeMode(true)
+	tr.ApplyPosition(order.PositionSideLong, 1, 100)
+	tr.ApplyPosition(order.PositionSideShort, -1, 100)
+	gw := &stubGateway{}
+	r := &Runner{
+		Symbol:    "ETHUSDC",
+		Engine:    engine,
+		Inv:       tr,
+		OrderMgr:  order.NewManager(gw),
+		NetMax:    5,
+		HedgeMode: true,
+	}
+	if net, _ := tr.Valuation(100); net != 0 {
+		t.Fatalf("offsetting legs should net to zero, got %.2f", net)
+	}
+	if err := r.OnTick(100); err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+	if len(gw.orders) == 0 {
+		t.Fatalf("expected quotes")
+	}
+	// 两腿都有仓位时，买单平空、卖单平多，净敞口为 0 也不会互相抵消
+	for _, o := range gw.orders {
+		want := order.ClosingPositionSide(o.Side)
+		if o.PositionSide != want {
+			t.Fatalf("%s quote routed to %s, want %s", o.Side, o.PositionSide, want)
+		}
+	}
+}
+
+func TestHandleFillUpdatesLegAndNet(t *testing.T) {
+	tr := &inventory.Tracker{}
+	tr.SetHedgeMode(true)
+	r := &Runner{HedgeMode: true, Inv: tr}
+	r.HandleFill(order.Fill{Side: "BUY", PositionSide: order.PositionSideLong, Price: 100, Qty: 1})
+	r.HandleFill(order.Fill{Side: "SELL", PositionSide: order.PositionSideShort, Price: 102, Qty: 0.4})
+	long, short := tr.Legs()
+	if long.Qty != 1 || long.Cost != 100 || short.Qty != 0.4 || short.Cost != 102 {
+		t.Fatalf("unexpected legs long=%+v short=%+v", long, short)
+	}
+	if math.Abs(tr.NetExposure()-0.6) > 1e-9 {
+		t.Fatalf("net=%v, want 0.6", tr.NetExposure())
+	}
+
+	oneWay := &Runner{Inv: &inventory.Tracker{}}
+	oneWay.HandleFill(order.Fill{Side: "SELL", Price: 100, Qty: 0.3})
+	if oneWay.Inv.NetExposure() != -0.3 {
+		t.Fatalf("one-way net=%v, want -0.3", oneWay.Inv.NetExposure())
+	}
+}
+
+func TestRoutePositionSideCountsRestingCloses(t *testing.T) {
+	tr := &inventory.Tracker{}
+	tr.SetHedgeMode(true)
+	tr.ApplyPosition(order.PositionSideLong, 1, 100)
+	mgr := order.NewManager(&stubGateway{})
+	r := &Runner{Symbol: "ETHUSDC", HedgeMode: true, Inv: tr, OrderMgr: mgr}
+
+	// 同一批梯子：多头腿 1 只够两档 0.5 平仓，第三档开空
+	places := []dynamicPlacement{
+		{ord: order.Order{Symbol: "ETHUSDC", Side: "SELL", Quantity: 0.5}},
+		{ord: order.Order{Symbol: "ETHUSDC", Side: "SELL", Quantity: 0.5}},
+		{ord: order.Order{Symbol: "ETHUSDC", Side: "SELL", Quantity: 0.5}},
+	}
+	r.routeClosingBatch(places)
+	want := []string{order.PositionSideLong, order.PositionSideLong, order.PositionSideShort}
+	for i, p := range places {
+		if p.ord.PositionSide != want[i] {
+			t.Fatalf("level %d routed to %s, want %s", i, p.ord.PositionSide, want[i])
+		}
+	}
+
+	// 已挂 0.8 的卖平多：新卖单不再平多
+	if _, err := mgr.Submit(order.Order{Symbol: "ETHUSDC", Side: "SELL", Price: 101, Quantity: 0.8, PositionSide: order.PositionSideLong}); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	o := order.Order{Symbol: "ETHUSDC", Side: "SELL", Quantity: 0.5}
+	r.routePositionSide(&o)
+	if o.PositionSide != order.PositionSideShort {
+		t.Fatalf("resting close should consume the leg, routed to %s", o.PositionSide)
+	}
+}
@@ -0,0 +1,31 @@
+package sim
+
+import (
+	"testing"
+	"time"
+
+	"market-maker-go/inventory"
+)
+
+type fixedBudget float64
+
+func (b fixedBudget) RemainingRatio() float64 { return float64(b) }
+
+func TestDynamicIntervalSlowsOnLowRateBudget(t *testing.T) {
+	r := &Runner{Inv: &inventory.Tracker{}, BaseInterval: time.Second}
+	if got := r.dynamicInterval(0); got != time.Second {
+		t.Fatalf("no budget source should keep base interval, got %s", got)
+	}
+	r.RateBudget = fixedBudget(0.8)
+	if got := r.dynamicInterval(0); got != time.Second {
+		t.Fatalf("ample budget should keep base interval, got %s", got)
+	}
+	r.RateBudget = fixedBudget(0.15)
+	if got := r.dynamicInterval(0); got != 2500*time.Millisecond {
+		t.Fatalf("half-way below threshold should slow to 2.5x, got %s", got)
+	}
+	r.RateBudget = fixedBudget(0)
+	if got := r.dynamicInterval(0); got != 4*time.Second {
+		t.Fatalf("exhausted budget should slow to 4x, got %s", got)
+	}
+}
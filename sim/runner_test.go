@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"market-maker-go/gateway"
 	"market-maker-go/inventory"
 	"market-maker-go/market"
 	"market-maker-go/order"
@@ -40,7 +41,7 @@ func (f *flakyPostOnlyGateway) Place(o order.Order) (string, error) {
 	f.calls++
 	f.orders = append(f.orders, o)
 	if f.calls <= f.failUntil {
-		return "", fmt.Errorf("code\":-5022 post only reject")
+		return "", &gateway.BinanceAPIError{Op: "place limit", HTTPStatus: 400, Code: gateway.CodePostOnlyReject, Msg: "post only reject"}
 	}
 	return fmt.Sprintf("ok-%d", f.calls), nil
 }
@@ -351,6 +352,31 @@ func TestRunnerStopLossTriggersHalt(t *testing.T) {
 	}
 }
 
+func TestRunnerRequestHalt(t *testing.T) {
+	engine, _ := strategy.NewEngine(strategy.EngineConfig{MinSpread: 0.001, MaxDrift: 1, BaseSize: 0.5})
+	gw := &stubGateway{}
+	r := Runner{
+		Symbol:       "ETHUSDC",
+		Engine:       engine,
+		Inv:          &inventory.Tracker{},
+		OrderMgr:     order.NewManager(gw),
+		HaltDuration: time.Second,
+		BaseSpread:   0.001,
+		BaseInterval: time.Second,
+		NetMax:       5,
+		riskState:    RiskStateNormal,
+	}
+	var reason string
+	r.SetRiskStateListener(func(s RiskState, why string) { reason = why })
+	r.RequestHalt("position_drift drift=0.3")
+	if err := r.OnTick(100); err == nil || reason != "position_drift drift=0.3" {
+		t.Fatalf("expected requested halt, err=%v reason=%q", err, reason)
+	}
+	if len(gw.orders) != 0 {
+		t.Fatalf("expected no orders when halted")
+	}
+}
+
 func TestRunnerStrategyAdjustCallback(t *testing.T) {
 	engine, _ := strategy.NewEngine(strategy.EngineConfig{
 		MinSpread:      0.001,
@@ -446,11 +472,11 @@ func TestPlanReduceOnlyUsesBestOpposite(t *testing.T) {
 		ReduceOnlyMaxSlippage: 0.01,
 	}
 	r.Book.ApplyDelta(map[float64]float64{99: 5, 98.5: 3}, map[float64]float64{101: 4, 101.5: 6})
-	plan := r.planReduceOnlyPrice(true, 100, 98, 1, false)
+	plan := r.planReduceOnlyPrice(true, 100, 98, 1)
 	if plan.price < 101 {
 		t.Fatalf("expected plan price >= best ask, got %.2f", plan.price)
 	}
-	planSell := r.planReduceOnlyPrice(false, 100, 102, 1, false)
+	planSell := r.planReduceOnlyPrice(false, 100, 102, 1)
 	if planSell.price > 99 {
 		t.Fatalf("expected plan price <= best bid, got %.2f", planSell.price)
 	}
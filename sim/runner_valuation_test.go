@@ -0,0 +1,54 @@
+package sim
+
+import (
+	"testing"
+	"time"
+
+	"market-maker-go/inventory"
+	"market-maker-go/market"
+	"market-maker-go/order"
+	"market-maker-go/strategy"
+)
+
+func TestRunnerStopLossUsesMarkPriceBasis(t *testing.T) {
+	svc := market.NewService(nil)
+	svc.OnMarkPrice(market.FundingState{Symbol: "ETHUSDC", MarkPrice: 98})
+
+	newRunner := func(basis market.PriceBasis) (*Runner, *RiskState) {
+		engine, _ := strategy.NewEngine(strategy.EngineConfig{MinSpread: 0.001, MaxDrift: 1, BaseSize: 0.5})
+		tr := &inventory.Tracker{}
+		tr.SetExposure(1, 100)
+		r := &Runner{
+			Symbol:         "ETHUSDC",
+			Engine:         engine,
+			Inv:            tr,
+			OrderMgr:       order.NewManager(&stubGateway{}),
+			StopLoss:       -1,
+			HaltDuration:   time.Second,
+			BaseSpread:     0.001,
+			BaseInterval:   time.Second,
+			NetMax:         5,
+			Valuation:      svc,
+			ValuationBasis: basis,
+			riskState:      RiskStateNormal,
+		}
+		state := RiskStateNormal
+		r.SetRiskStateListener(func(s RiskState, reason string) { state = s })
+		return r, &state
+	}
+
+	// mid 100 与成本持平：按 mid 估值不触发止损
+	r, state := newRunner(market.PriceBasisMid)
+	_ = r.OnTick(100)
+	if *state == RiskStateHalted {
+		t.Fatalf("mid basis should not trigger stop loss")
+	}
+	// 标记价 98：按 mark 估值浮亏 -2 触发止损
+	r, state = newRunner(market.PriceBasisMark)
+	if err := r.OnTick(100); err == nil || *state != RiskStateHalted {
+		t.Fatalf("mark basis should trigger stop loss, err=%v state=%s", err, state.String())
+	}
+	if got := r.ValuationPrice(100); got != 98 {
+		t.Fatalf("expected mark valuation price, got %.2f", got)
+	}
+}
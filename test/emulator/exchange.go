@@ -0,0 +1,312 @@
+// Package emulator 提供进程内的 Binance USDⓈ-M 合约交易所模拟器，
+// 使用真实的 REST/WS 协议（签名校验、combined stream、用户数据流），
+// 让 runner 的完整链路可以在 CI 中离线跑通。
+package emulator
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"market-maker-go/gateway"
+)
+
+// SymbolSpec 描述模拟合约的交易规则。
+type SymbolSpec struct {
+	Symbol      string
+	TickSize    float64 // 0 表示不校验价格精度
+	StepSize    float64 // 0 表示不校验数量精度
+	MinNotional float64 // 0 表示不校验最小名义价值
+	Leverage    int     // 默认 20
+}
+
+// Config 模拟器配置。
+type Config struct {
+	APIKey         string
+	Secret         string
+	Asset          string  // 保证金资产，默认 USDC
+	InitialBalance float64 // 初始钱包余额，默认 10000
+	MakerFeeRate   float64
+	TakerFeeRate   float64
+	Symbols        []SymbolSpec
+	// Now 可注入时钟，默认 time.Now。
+	Now func() time.Time
+}
+
+// Order 为模拟器内部的订单状态，字段对齐 /fapi/v1/order 返回。
+type Order struct {
+	OrderID       int64
+	ClientOrderID string
+	Symbol        string
+	Side          string
+	Type          string
+	TimeInForce   string
+	Price         float64
+	OrigQty       float64
+	ExecutedQty   float64
+	CumQuote      float64
+	ReduceOnly    bool
+	Status        string
+	UpdateTime    int64
+}
+
+// Remaining 返回未成交数量。
+func (o Order) Remaining() float64 {
+	return o.OrigQty - o.ExecutedQty
+}
+
+// AvgPrice 返回成交均价。
+func (o Order) AvgPrice() float64 {
+	if o.ExecutedQty <= 0 {
+		return 0
+	}
+	return o.CumQuote / o.ExecutedQty
+}
+
+// Position 为单合约（单向持仓模式）仓位。
+type Position struct {
+	Symbol      string
+	Amount      float64
+	EntryPrice  float64
+	RealizedPnL float64
+}
+
+type symbolState struct {
+	spec      SymbolSpec
+	bids      map[float64]float64 // 外部流动性
+	asks      map[float64]float64
+	pubBids   map[float64]float64 // 最近一次推送的聚合深度（外部 + 挂单）
+	pubAsks   map[float64]float64
+	updateID  int64
+	position  Position
+	lastTrade float64
+}
+
+// Exchange 进程内交易所：持有撮合状态，并通过 httptest 服务 REST/WS。
+type Exchange struct {
+	cfg Config
+
+	mu          sync.Mutex
+	symbols     map[string]*symbolState
+	orders      map[int64]*Order
+	nextOrderID int64
+	nextTradeID int64
+	wallet      float64
+	listenKeys  map[string]bool
+	nextKey     int
+
+	wsMu    sync.Mutex
+	clients map[*wsClient]struct{}
+	// apiClients 为 /ws-fapi/v1 交易 API 连接
+	apiClients map[*wsClient]struct{}
+
+	server *httptest.Server
+}
+
+// New 创建模拟器（尚未启动 HTTP 服务，可配合 Start 或 Handler 使用）。
+func New(cfg Config) *Exchange {
+	if cfg.Asset == "" {
+		cfg.Asset = "USDC"
+	}
+	if cfg.InitialBalance == 0 {
+		cfg.InitialBalance = 10000
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	e := &Exchange{
+		cfg:         cfg,
+		symbols:     make(map[string]*symbolState),
+		orders:      make(map[int64]*Order),
+		nextOrderID: 1000,
+		nextTradeID: 1,
+		wallet:      cfg.InitialBalance,
+		listenKeys:  make(map[string]bool),
+		clients:     make(map[*wsClient]struct{}),
+		apiClients:  make(map[*wsClient]struct{}),
+	}
+	for _, spec := range cfg.Symbols {
+		sym := strings.ToUpper(spec.Symbol)
+		spec.Symbol = sym
+		if spec.Leverage <= 0 {
+			spec.Leverage = 20
+		}
+		e.symbols[sym] = &symbolState{
+			spec:     spec,
+			bids:     make(map[float64]float64),
+			asks:     make(map[float64]float64),
+			pubBids:  make(map[float64]float64),
+			pubAsks:  make(map[float64]float64),
+			updateID: 1,
+			position: Position{Symbol: sym},
+		}
+	}
+	return e
+}
+
+// Start 启动 httptest 服务，返回自身便于链式调用。
+func (e *Exchange) Start() *Exchange {
+	e.server = httptest.NewServer(e.Handler())
+	return e
+}
+
+// Close 断开所有 WS 连接并关闭 HTTP 服务。
+func (e *Exchange) Close() {
+	e.DisconnectStreams()
+	e.DisconnectWSAPI()
+	if e.server != nil {
+		e.server.Close()
+	}
+}
+
+// URL 返回 REST 基础地址（可直接作为 BinanceRESTClient.BaseURL）。
+func (e *Exchange) URL() string {
+	if e.server == nil {
+		return ""
+	}
+	return e.server.URL
+}
+
+// WSEndpoint 返回 WS 基础地址（可直接作为 BinanceWSReal.BaseEndpoint）。
+func (e *Exchange) WSEndpoint() string {
+	return "ws://" + strings.TrimPrefix(e.URL(), "http://")
+}
+
+// WSAPIEndpoint 返回 WS 交易 API 地址（可直接作为 BinanceWSAPIClient.Endpoint）。
+func (e *Exchange) WSAPIEndpoint() string {
+	return e.WSEndpoint() + "/ws-fapi/v1"
+}
+
+// Handler 返回处理 REST 与 WS 的 http.Handler，可挂到任意 http.Server。
+func (e *Exchange) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/fapi/v1/time", e.handleTime)
+	mux.HandleFunc("/fapi/v1/order", e.handleOrder)
+	mux.HandleFunc("/fapi/v1/batchOrders", e.handleBatchOrders)
+	mux.HandleFunc("/fapi/v1/openOrders", e.handleOpenOrders)
+	mux.HandleFunc("/fapi/v1/allOpenOrders", e.handleCancelAll)
+	mux.HandleFunc("/fapi/v1/depth", e.handleDepth)
+	mux.HandleFunc("/fapi/v1/listenKey", e.handleListenKey)
+	mux.HandleFunc("/fapi/v2/account", e.handleAccount)
+	mux.HandleFunc("/fapi/v2/positionRisk", e.handlePositionRisk)
+	mux.HandleFunc("/stream", e.handleStream)
+	mux.HandleFunc("/ws-fapi/v1", e.handleWSAPI)
+	return mux
+}
+
+// SetBook 替换指定合约的外部流动性；与挂单交叉的部分会立即按挂单价成交（挂单为 maker）。
+func (e *Exchange) SetBook(symbol string, bids, asks []gateway.DepthLevel) {
+	e.mu.Lock()
+	st := e.symbols[strings.ToUpper(symbol)]
+	if st == nil {
+		e.mu.Unlock()
+		return
+	}
+	st.bids = make(map[float64]float64, len(bids))
+	st.asks = make(map[float64]float64, len(asks))
+	for _, lv := range bids {
+		if lv.Qty > 0 {
+			st.bids[lv.Price] = lv.Qty
+		}
+	}
+	for _, lv := range asks {
+		if lv.Qty > 0 {
+			st.asks[lv.Price] = lv.Qty
+		}
+	}
+	var out []outbound
+	out = append(out, e.matchRestingLocked(st)...)
+	out = append(out, e.depthDiffLocked(st)...)
+	e.broadcastLocked(out)
+	e.mu.Unlock()
+}
+
+// Trade 模拟一笔外部成交打印：价格穿过的挂单按挂单价成交，每个方向最多成交 qty。
+func (e *Exchange) Trade(symbol string, price, qty float64) {
+	e.mu.Lock()
+	st := e.symbols[strings.ToUpper(symbol)]
+	if st == nil {
+		e.mu.Unlock()
+		return
+	}
+	st.lastTrade = price
+	var out []outbound
+	for _, side := range []string{"BUY", "SELL"} {
+		left := qty
+		for _, o := range e.restingLocked(st.spec.Symbol, side) {
+			if left <= 0 {
+				break
+			}
+			if (side == "BUY" && o.Price < price) || (side == "SELL" && o.Price > price) {
+				break
+			}
+			fill := minFloat(left, o.Remaining())
+			out = append(out, e.fillLocked(st, o, o.Price, fill, true)...)
+			left -= fill
+		}
+	}
+	out = append(out, e.depthDiffLocked(st)...)
+	e.broadcastLocked(out)
+	e.mu.Unlock()
+}
+
+// OpenOrders 返回指定合约当前挂单（按 orderId 升序）。
+func (e *Exchange) OpenOrders(symbol string) []Order {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var out []Order
+	for _, o := range e.sortedOrdersLocked() {
+		if isOpen(o.Status) && (symbol == "" || strings.EqualFold(o.Symbol, symbol)) {
+			out = append(out, *o)
+		}
+	}
+	return out
+}
+
+// Position 返回指定合约的当前仓位。
+func (e *Exchange) Position(symbol string) Position {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st := e.symbols[strings.ToUpper(symbol)]
+	if st == nil {
+		return Position{Symbol: strings.ToUpper(symbol)}
+	}
+	return st.position
+}
+
+// WalletBalance 返回保证金资产钱包余额（含已实现盈亏与手续费）。
+func (e *Exchange) WalletBalance() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.wallet
+}
+
+// DisconnectStreams 主动断开所有 WS 连接，用于模拟网络抖动。
+func (e *Exchange) DisconnectStreams() {
+	e.wsMu.Lock()
+	clients := make([]*wsClient, 0, len(e.clients))
+	for c := range e.clients {
+		clients = append(clients, c)
+	}
+	e.wsMu.Unlock()
+	for _, c := range clients {
+		c.close()
+	}
+}
+
+func (e *Exchange) nowMillis() int64 {
+	return e.cfg.Now().UnixMilli()
+}
+
+func isOpen(status string) bool {
+	return status == "NEW" || status == "PARTIALLY_FILLED"
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
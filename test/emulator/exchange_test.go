@@ -0,0 +1,390 @@
+package emulator
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"market-maker-go/gateway"
+	"market-maker-go/market"
+)
+
+const (
+	testKey    = "emu-key"
+	testSecret = "emu-secret"
+)
+
+func newTestExchange(t *testing.T) *Exchange {
+	t.Helper()
+	ex := New(Config{
+		APIKey:       testKey,
+		Secret:       testSecret,
+		TakerFeeRate: 0.0004,
+		Symbols:      []SymbolSpec{{Symbol: "ETHUSDC", TickSize: 0.01, StepSize: 0.001, MinNotional: 5}},
+	}).Start()
+	t.Cleanup(ex.Close)
+	ex.SetBook("ETHUSDC",
+		[]gateway.DepthLevel{{Price: 2999.99, Qty: 1}, {Price: 2999.98, Qty: 2}},
+		[]gateway.DepthLevel{{Price: 3000.01, Qty: 1}, {Price: 3000.02, Qty: 2}},
+	)
+	return ex
+}
+
+func newTestREST(ex *Exchange, secret string) *gateway.BinanceRESTClient {
+	return &gateway.BinanceRESTClient{
+		BaseURL:      ex.URL(),
+		APIKey:       testKey,
+		Secret:       secret,
+		HTTPClient:   gateway.NewDefaultHTTPClient(),
+		RecvWindowMs: 5000,
+	}
+}
+
+func TestExchangeRejectsInvalidSignature(t *testing.T) {
+	ex := newTestExchange(t)
+	client := newTestREST(ex, "wrong-secret")
+	_, err := client.PlaceLimit("ETHUSDC", "BUY", "GTC", 2999, 0.01, false, false, "bad-sig")
+	if err == nil || !strings.Contains(err.Error(), "-1022") {
+		t.Fatalf("expected signature error, got %v", err)
+	}
+	if n := len(ex.OpenOrders("ETHUSDC")); n != 0 {
+		t.Fatalf("unsigned order should not rest, got %d", n)
+	}
+}
+
+func TestExchangeTimeSyncCompensatesClockDrift(t *testing.T) {
+	// 交易所时钟领先本地 8s，超出 recvWindow
+	ex := New(Config{
+		APIKey:  testKey,
+		Secret:  testSecret,
+		Symbols: []SymbolSpec{{Symbol: "ETHUSDC", TickSize: 0.01, StepSize: 0.001}},
+		Now:     func() time.Time { return time.Now().Add(8 * time.Second) },
+	}).Start()
+	defer ex.Close()
+	client := newTestREST(ex, testSecret)
+	if _, err := client.PlaceLimit("ETHUSDC", "BUY", "GTC", 2999, 0.01, false, false, "drift"); err == nil || !strings.Contains(err.Error(), "-1021") {
+		t.Fatalf("expected -1021 without time sync, got %v", err)
+	}
+
+	client.TimeSync = gateway.NewTimeSync(ex.URL(), gateway.NewDefaultHTTPClient())
+	if err := client.TimeSync.Sync(); err != nil {
+		t.Fatalf("time sync: %v", err)
+	}
+	if off := client.TimeSync.Offset(); off < 7900*time.Millisecond || off > 8100*time.Millisecond {
+		t.Fatalf("unexpected offset %s", off)
+	}
+	if _, err := client.PlaceLimit("ETHUSDC", "BUY", "GTC", 2999, 0.01, false, false, "synced"); err != nil {
+		t.Fatalf("place with time sync: %v", err)
+	}
+}
+
+func TestExchangeOrderLifecycleREST(t *testing.T) {
+	ex := newTestExchange(t)
+	client := newTestREST(ex, testSecret)
+
+	if _, err := client.PlaceLimit("ETHUSDC", "BUY", "GTC", 3000.01, 0.01, false, true, "po-cross"); err == nil || !strings.Contains(err.Error(), "-5022") {
+		t.Fatalf("expected post-only reject, got %v", err)
+	}
+	if _, err := client.PlaceLimit("ETHUSDC", "BUY", "GTC", 2999.995, 0.01, false, false, "bad-tick"); err == nil || !strings.Contains(err.Error(), "-4014") {
+		t.Fatalf("expected tick size reject, got %v", err)
+	}
+	id, err := client.PlaceLimit("ETHUSDC", "BUY", "GTC", 2999.5, 0.01, false, true, "bid-1")
+	if err != nil {
+		t.Fatalf("place bid: %v", err)
+	}
+	open := ex.OpenOrders("ETHUSDC")
+	if len(open) != 1 || open[0].ClientOrderID != "bid-1" {
+		t.Fatalf("unexpected open orders %+v", open)
+	}
+	snap, err := client.DepthSnapshot("ETHUSDC", 10)
+	if err != nil {
+		t.Fatalf("depth: %v", err)
+	}
+	found := false
+	for _, lv := range snap.Bids {
+		if lv.Price == 2999.5 && lv.Qty == 0.01 {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("resting order missing from depth %+v", snap.Bids)
+	}
+	if modID, err := client.ModifyOrder("ETHUSDC", id, "BUY", 2999.4, 0.02); err != nil || modID != id {
+		t.Fatalf("modify: id=%s err=%v", modID, err)
+	}
+	if open := ex.OpenOrders("ETHUSDC"); len(open) != 1 || open[0].Price != 2999.4 || open[0].OrigQty != 0.02 {
+		t.Fatalf("unexpected order after modify %+v", open)
+	}
+	if err := client.CancelOrder("ETHUSDC", id); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if err := client.CancelOrder("ETHUSDC", id); err == nil {
+		t.Fatalf("expected unknown order on second cancel")
+	}
+
+	// 市价单吃掉两档卖盘
+	if _, err := client.PlaceMarket("ETHUSDC", "BUY", 1.5, false, "mkt-1"); err != nil {
+		t.Fatalf("market buy: %v", err)
+	}
+	positions, err := client.PositionRisk("ETHUSDC")
+	if err != nil {
+		t.Fatalf("position risk: %v", err)
+	}
+	if len(positions) != 1 || positions[0].PositionAmt != 1.5 {
+		t.Fatalf("unexpected positions %+v", positions)
+	}
+	wantEntry := (3000.01*1 + 3000.02*0.5) / 1.5
+	if diff := positions[0].EntryPrice - wantEntry; diff > 1e-6 || diff < -1e-6 {
+		t.Fatalf("entry price %.6f want %.6f", positions[0].EntryPrice, wantEntry)
+	}
+	if _, err := client.PlaceMarket("ETHUSDC", "BUY", 0.1, true, "ro-wrong-side"); err == nil || !strings.Contains(err.Error(), "-2022") {
+		t.Fatalf("expected reduce-only reject, got %v", err)
+	}
+	acct, err := client.AccountInfo()
+	if err != nil {
+		t.Fatalf("account: %v", err)
+	}
+	if acct.TotalWalletBalance >= 10000 {
+		t.Fatalf("taker fee should reduce wallet, got %.4f", acct.TotalWalletBalance)
+	}
+
+	if _, err := client.PlaceLimit("ETHUSDC", "SELL", "GTC", 3001, 0.5, false, false, "ask-1"); err != nil {
+		t.Fatalf("place ask: %v", err)
+	}
+	if err := client.CancelAll("ETHUSDC"); err != nil {
+		t.Fatalf("cancel all: %v", err)
+	}
+	if n := len(ex.OpenOrders("ETHUSDC")); n != 0 {
+		t.Fatalf("expected no open orders after cancel all, got %d", n)
+	}
+}
+
+func TestExchangeWSAPIOrderEntry(t *testing.T) {
+	ex := newTestExchange(t)
+	rest := newTestREST(ex, testSecret)
+	client := gateway.NewBinanceWSAPIClient(testKey, testSecret, rest)
+	client.Endpoint = ex.WSAPIEndpoint()
+	client.RecvWindowMs = 5000
+	client.ReconnectDelay = time.Hour // 断线后保持回退状态
+	defer client.Close()
+	if err := client.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	if _, err := client.PlaceLimit("ETHUSDC", "BUY", "GTC", 3000.01, 0.01, false, true, "ws-cross"); err == nil || !strings.Contains(err.Error(), "-5022") {
+		t.Fatalf("expected post-only reject, got %v", err)
+	}
+	id, err := client.PlaceLimit("ETHUSDC", "BUY", "GTC", 2999.5, 0.01, false, true, "ws-bid")
+	if err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if _, err := client.ModifyOrder("ETHUSDC", id, "BUY", 3000.01, 0.02); err == nil || !strings.Contains(err.Error(), "-5022") {
+		t.Fatalf("expected post-only reject on crossing modify, got %v", err)
+	}
+	modID, err := client.ModifyOrder("ETHUSDC", id, "BUY", 2999.6, 0.02)
+	if err != nil {
+		t.Fatalf("modify: %v", err)
+	}
+	open := ex.OpenOrders("ETHUSDC")
+	if modID != id || len(open) != 1 || open[0].Price != 2999.6 || open[0].OrigQty != 0.02 {
+		t.Fatalf("unexpected order after modify id=%s open=%+v", modID, open)
+	}
+	if err := client.CancelOrder("ETHUSDC", id); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	// WS API 断开后，下单经 REST 完成
+	ex.DisconnectWSAPI()
+	waitFor(t, func() bool { return !client.Connected() })
+	if _, err := client.PlaceLimit("ETHUSDC", "SELL", "GTC", 3001, 0.01, false, false, "rest-ask"); err != nil {
+		t.Fatalf("fallback place: %v", err)
+	}
+	if open := ex.OpenOrders("ETHUSDC"); len(open) != 1 || open[0].ClientOrderID != "rest-ask" {
+		t.Fatalf("unexpected open orders %+v", open)
+	}
+}
+
+func TestExchangeBatchOrders(t *testing.T) {
+	ex := newTestExchange(t)
+	client := newTestREST(ex, testSecret)
+
+	orders := []gateway.BatchLimitOrder{
+		{Symbol: "ETHUSDC", Side: "BUY", TimeInForce: "GTC", Price: 2999.5, Qty: 0.01, PostOnly: true, ClientID: "b1"},
+		{Symbol: "ETHUSDC", Side: "BUY", TimeInForce: "GTC", Price: 3000.01, Qty: 0.01, PostOnly: true, ClientID: "b-cross"},
+		{Symbol: "ETHUSDC", Side: "SELL", TimeInForce: "GTC", Price: 3001, Qty: 0.01, PostOnly: true, ClientID: "a1"},
+	}
+	results, err := client.PlaceBatch(orders)
+	if err != nil {
+		t.Fatalf("place batch: %v", err)
+	}
+	if results[0].Err != nil || results[2].Err != nil {
+		t.Fatalf("unexpected item errors %+v", results)
+	}
+	if results[1].Err == nil || !strings.Contains(results[1].Err.Error(), "-5022") {
+		t.Fatalf("expected post-only reject for crossing item, got %+v", results[1])
+	}
+	if open := ex.OpenOrders("ETHUSDC"); len(open) != 2 {
+		t.Fatalf("expected 2 resting orders, got %+v", open)
+	}
+
+	cancels, err := client.CancelBatch("ETHUSDC", []string{results[0].OrderID, results[2].OrderID, "999999"})
+	if err != nil {
+		t.Fatalf("cancel batch: %v", err)
+	}
+	if cancels[0].Err != nil || cancels[1].Err != nil || cancels[2].Err == nil {
+		t.Fatalf("unexpected cancel results %+v", cancels)
+	}
+	if open := ex.OpenOrders("ETHUSDC"); len(open) != 0 {
+		t.Fatalf("expected book cleared, got %+v", open)
+	}
+}
+
+func TestExchangeListenKeyLifecycle(t *testing.T) {
+	ex := newTestExchange(t)
+	lk := &gateway.ListenKeyClient{BaseURL: ex.URL(), APIKey: testKey, HTTPClient: gateway.NewListenKeyHTTPClient()}
+	key, err := lk.NewListenKey()
+	if err != nil {
+		t.Fatalf("new listenKey: %v", err)
+	}
+	again, err := lk.NewListenKey()
+	if err != nil || again != key {
+		t.Fatalf("expected same active listenKey, got %s err=%v", again, err)
+	}
+	if err := lk.KeepAlive(key); err != nil {
+		t.Fatalf("keepalive: %v", err)
+	}
+	if err := lk.CloseListenKey(key); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if err := lk.KeepAlive(key); err == nil {
+		t.Fatalf("expected keepalive on closed key to fail")
+	}
+}
+
+func TestExchangeStreamsDepthAndUserData(t *testing.T) {
+	ex := newTestExchange(t)
+	client := newTestREST(ex, testSecret)
+	lk := &gateway.ListenKeyClient{BaseURL: ex.URL(), APIKey: testKey, HTTPClient: gateway.NewListenKeyHTTPClient()}
+	key, err := lk.NewListenKey()
+	if err != nil {
+		t.Fatalf("listenKey: %v", err)
+	}
+
+	book := market.NewOrderBook()
+	depth := gateway.NewDepthSynchronizer("ETHUSDC", book, client)
+	var mu sync.Mutex
+	var updates []gateway.OrderUpdate
+	var accounts []gateway.AccountUpdate
+	user := &gateway.BinanceUserHandler{
+		OnOrderUpdate: func(o gateway.OrderUpdate) {
+			mu.Lock()
+			updates = append(updates, o)
+			mu.Unlock()
+		},
+		OnAccountUpdate: func(a gateway.AccountUpdate) {
+			mu.Lock()
+			accounts = append(accounts, a)
+			mu.Unlock()
+		},
+	}
+	pub := market.NewPublisher()
+	var trades []market.Trade
+	pub.SubscribeTradeFunc(func(tr market.Trade) {
+		mu.Lock()
+		trades = append(trades, tr)
+		mu.Unlock()
+	})
+	trade := &gateway.BinanceTradeHandler{Svc: market.NewService(pub)}
+	connected := make(chan struct{}, 1)
+	ws := gateway.NewBinanceWSReal()
+	ws.BaseEndpoint = ex.WSEndpoint()
+	ws.MaxRetries = 0
+	ws.OnConnect(func() { connected <- struct{}{} })
+	_ = ws.SubscribeDepth("ETHUSDC")
+	_ = ws.SubscribeTrade("ETHUSDC")
+	_ = ws.SubscribeUserData(key)
+	go func() { _ = ws.Run(muxHandler{depth, user, trade}) }()
+	select {
+	case <-connected:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("ws not connected")
+	}
+	// 等待服务端完成注册后再产生事件
+	waitFor(t, func() bool {
+		ex.wsMu.Lock()
+		defer ex.wsMu.Unlock()
+		return len(ex.clients) == 1
+	})
+
+	if _, err := client.PlaceLimit("ETHUSDC", "BUY", "GTC", 2999.5, 0.2, false, true, "bid-ws"); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	waitFor(t, func() bool { return depth.Synced() && book.BidVolume(2999.5) == 0.2 })
+
+	ex.Trade("ETHUSDC", 2999.5, 0.2)
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, u := range updates {
+			if u.ClientOrderID == "bid-ws" && u.Status == "FILLED" {
+				return len(accounts) > 0
+			}
+		}
+		return false
+	})
+	waitFor(t, func() bool { return book.BidVolume(2999.5) == 0 })
+
+	mu.Lock()
+	last := accounts[len(accounts)-1]
+	mu.Unlock()
+	if len(last.Positions) != 1 || last.Positions[0].PositionAmt != 0.2 || last.Positions[0].EntryPrice != 2999.5 {
+		t.Fatalf("unexpected account update %+v", last)
+	}
+	if p := ex.Position("ETHUSDC"); p.Amount != 0.2 {
+		t.Fatalf("unexpected exchange position %+v", p)
+	}
+	if depth.SnapshotCount() != 1 {
+		t.Fatalf("expected a single depth snapshot, got %d", depth.SnapshotCount())
+	}
+	// 挂单买方为 maker：aggTrade 主动方为卖方
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(trades) == 1
+	})
+	mu.Lock()
+	tr := trades[0]
+	mu.Unlock()
+	if tr.Symbol != "ETHUSDC" || tr.Price != 2999.5 || tr.Qty != 0.2 || tr.IsBuy {
+		t.Fatalf("unexpected aggTrade %+v", tr)
+	}
+}
+
+type muxHandler struct {
+	depth *gateway.DepthSynchronizer
+	user  *gateway.BinanceUserHandler
+	trade *gateway.BinanceTradeHandler
+}
+
+func (m muxHandler) OnDepth(symbol string, bid, ask float64) {}
+
+func (m muxHandler) OnTrade(symbol string, price, qty float64) {}
+
+func (m muxHandler) OnRawMessage(msg []byte) {
+	m.depth.OnRawMessage(msg)
+	m.user.OnRawMessage(msg)
+	m.trade.OnRawMessage(msg)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("condition not met before timeout")
+}
@@ -0,0 +1,538 @@
+package emulator
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+)
+
+const qtyEpsilon = 1e-12
+
+// outbound 表示撮合后需要推送的 WS 事件：kind 为 "depth" 或 "user"。
+type outbound struct {
+	kind   string
+	symbol string
+	data   []byte
+}
+
+// apiError 对应 Binance 的 {"code":...,"msg":...} 错误体。
+type apiError struct {
+	HTTPStatus int    `json:"-"`
+	Code       int    `json:"code"`
+	Msg        string `json:"msg"`
+}
+
+func (e *apiError) Error() string {
+	return e.Msg
+}
+
+var (
+	errPostOnlyReject = &apiError{HTTPStatus: 400, Code: -5022, Msg: "Due to the order could not be executed as maker, the Post Only order will be rejected. The order will not be recorded in the order history"}
+	errReduceOnly     = &apiError{HTTPStatus: 400, Code: -2022, Msg: "ReduceOnly Order is rejected."}
+	errUnknownOrder   = &apiError{HTTPStatus: 400, Code: -2011, Msg: "Unknown order sent."}
+	errOrderNotExist  = &apiError{HTTPStatus: 400, Code: -2013, Msg: "Order does not exist."}
+	errInvalidSymbol  = &apiError{HTTPStatus: 400, Code: -1121, Msg: "Invalid symbol."}
+	errDuplicateCID   = &apiError{HTTPStatus: 400, Code: -4116, Msg: "ClientOrderId is duplicated."}
+	errTickSize       = &apiError{HTTPStatus: 400, Code: -4014, Msg: "Price not increased by tick size."}
+	errStepSize       = &apiError{HTTPStatus: 400, Code: -1111, Msg: "Precision is over the maximum defined for this asset."}
+	errModifyQty      = &apiError{HTTPStatus: 400, Code: -4028, Msg: "Quantity less than or equal to executed quantity."}
+)
+
+// placeLocked 校验并撮合新订单；taker 部分与外部流动性成交，剩余按 TIF 挂单或过期。
+func (e *Exchange) placeLocked(st *symbolState, o *Order) ([]outbound, *apiError) {
+	spec := st.spec
+	if o.Type == "LIMIT" {
+		if !onGrid(o.Price, spec.TickSize) {
+			return nil, errTickSize
+		}
+	}
+	if !onGrid(o.OrigQty, spec.StepSize) {
+		return nil, errStepSize
+	}
+	if o.ClientOrderID != "" {
+		for _, other := range e.orders {
+			if other.ClientOrderID == o.ClientOrderID && isOpen(other.Status) {
+				return nil, errDuplicateCID
+			}
+		}
+	}
+	if o.ReduceOnly {
+		pos := st.position.Amount
+		if (o.Side == "BUY" && pos >= 0) || (o.Side == "SELL" && pos <= 0) {
+			return nil, errReduceOnly
+		}
+		if o.OrigQty > math.Abs(pos) {
+			o.OrigQty = math.Abs(pos)
+		}
+	}
+	if spec.MinNotional > 0 && !o.ReduceOnly {
+		ref := o.Price
+		if o.Type == "MARKET" {
+			ref = e.bestOppositeLocked(st, o.Side)
+		}
+		if ref > 0 && ref*o.OrigQty < spec.MinNotional-qtyEpsilon {
+			return nil, &apiError{HTTPStatus: 400, Code: -4164, Msg: "Order's notional must be no smaller than " + fmtFloat(spec.MinNotional) + " (unless you choose reduce only)."}
+		}
+	}
+
+	crossable := e.crossableQtyLocked(st, o)
+	if o.TimeInForce == "GTX" && crossable > 0 {
+		return nil, errPostOnlyReject
+	}
+	if o.TimeInForce == "FOK" && crossable+qtyEpsilon < o.OrigQty {
+		e.assignIDLocked(o)
+		o.Status = "EXPIRED"
+		o.UpdateTime = e.nowMillis()
+		e.orders[o.OrderID] = o
+		return []outbound{e.orderEventLocked(o, "EXPIRED", 0, 0, 0, 0, false, 0)}, nil
+	}
+
+	e.assignIDLocked(o)
+	o.Status = "NEW"
+	o.UpdateTime = e.nowMillis()
+	e.orders[o.OrderID] = o
+	out := []outbound{e.orderEventLocked(o, "NEW", 0, 0, 0, 0, false, 0)}
+
+	out = append(out, e.takeLocked(st, o)...)
+	if o.Remaining() > qtyEpsilon && (o.Type == "MARKET" || o.TimeInForce == "IOC") {
+		o.Status = "EXPIRED"
+		o.UpdateTime = e.nowMillis()
+		out = append(out, e.orderEventLocked(o, "EXPIRED", 0, 0, 0, 0, false, 0))
+	}
+	out = append(out, e.depthDiffLocked(st)...)
+	return out, nil
+}
+
+// takeLocked 订单的 taker 部分：按价格优先吃外部流动性。
+func (e *Exchange) takeLocked(st *symbolState, o *Order) []outbound {
+	var out []outbound
+	book, ascending := st.asks, true
+	if o.Side == "SELL" {
+		book, ascending = st.bids, false
+	}
+	for _, px := range sortedPrices(book, ascending) {
+		if o.Remaining() <= qtyEpsilon {
+			break
+		}
+		if o.Type == "LIMIT" && ((o.Side == "BUY" && px > o.Price) || (o.Side == "SELL" && px < o.Price)) {
+			break
+		}
+		fill := minFloat(book[px], o.Remaining())
+		book[px] -= fill
+		if book[px] <= qtyEpsilon {
+			delete(book, px)
+		}
+		out = append(out, e.fillLocked(st, o, px, fill, false)...)
+	}
+	return out
+}
+
+// modifyLocked 原地修改限价挂单的价格与数量（保留 orderId），推送 AMENDMENT 回报；
+// GTX 改价后会穿价时拒绝，其余 TIF 穿价部分按 taker 成交。
+func (e *Exchange) modifyLocked(st *symbolState, o *Order, price, qty float64) ([]outbound, *apiError) {
+	if o.Type != "LIMIT" {
+		return nil, errOrderNotExist
+	}
+	if !onGrid(price, st.spec.TickSize) {
+		return nil, errTickSize
+	}
+	if !onGrid(qty, st.spec.StepSize) {
+		return nil, errStepSize
+	}
+	if qty <= o.ExecutedQty+qtyEpsilon {
+		return nil, errModifyQty
+	}
+	prevPrice, prevQty := o.Price, o.OrigQty
+	o.Price, o.OrigQty = price, qty
+	if o.TimeInForce == "GTX" && e.crossableQtyLocked(st, o) > 0 {
+		o.Price, o.OrigQty = prevPrice, prevQty
+		return nil, errPostOnlyReject
+	}
+	o.UpdateTime = e.nowMillis()
+	out := []outbound{e.orderEventLocked(o, "AMENDMENT", 0, 0, 0, 0, false, 0)}
+	out = append(out, e.takeLocked(st, o)...)
+	return append(out, e.depthDiffLocked(st)...), nil
+}
+
+// assignIDLocked 分配 orderId；未指定 newClientOrderId 时按 orderId 生成。
+func (e *Exchange) assignIDLocked(o *Order) {
+	o.OrderID = e.nextOrderID
+	e.nextOrderID++
+	if o.ClientOrderID == "" {
+		o.ClientOrderID = "emu-" + strconv.FormatInt(o.OrderID, 10)
+	}
+}
+
+// cancelLocked 撤销挂单并推送 CANCELED 回报。
+func (e *Exchange) cancelLocked(st *symbolState, o *Order) []outbound {
+	o.Status = "CANCELED"
+	o.UpdateTime = e.nowMillis()
+	out := []outbound{e.orderEventLocked(o, "CANCELED", 0, 0, 0, 0, false, 0)}
+	return append(out, e.depthDiffLocked(st)...)
+}
+
+// matchRestingLocked 外部流动性穿过挂单时，挂单按自身价格以 maker 身份成交。
+func (e *Exchange) matchRestingLocked(st *symbolState) []outbound {
+	var out []outbound
+	for _, side := range []string{"BUY", "SELL"} {
+		book, ascending := st.asks, true
+		if side == "SELL" {
+			book, ascending = st.bids, false
+		}
+		for _, o := range e.restingLocked(st.spec.Symbol, side) {
+			for _, px := range sortedPrices(book, ascending) {
+				if o.Remaining() <= qtyEpsilon {
+					break
+				}
+				if (side == "BUY" && px > o.Price) || (side == "SELL" && px < o.Price) {
+					break
+				}
+				fill := minFloat(book[px], o.Remaining())
+				book[px] -= fill
+				if book[px] <= qtyEpsilon {
+					delete(book, px)
+				}
+				out = append(out, e.fillLocked(st, o, o.Price, fill, true)...)
+			}
+		}
+	}
+	return out
+}
+
+// fillLocked 记录一笔成交：更新订单、仓位与钱包，生成 ORDER_TRADE_UPDATE 与 ACCOUNT_UPDATE。
+func (e *Exchange) fillLocked(st *symbolState, o *Order, price, qty float64, maker bool) []outbound {
+	if qty <= qtyEpsilon {
+		return nil
+	}
+	o.ExecutedQty += qty
+	o.CumQuote += price * qty
+	if o.Remaining() <= qtyEpsilon {
+		o.Status = "FILLED"
+	} else {
+		o.Status = "PARTIALLY_FILLED"
+	}
+	o.UpdateTime = e.nowMillis()
+	st.lastTrade = price
+
+	signed := qty
+	if o.Side == "SELL" {
+		signed = -qty
+	}
+	pos := &st.position
+	realized := 0.0
+	switch {
+	case pos.Amount == 0 || (pos.Amount > 0) == (signed > 0):
+		total := math.Abs(pos.Amount) + qty
+		pos.EntryPrice = (pos.EntryPrice*math.Abs(pos.Amount) + price*qty) / total
+		pos.Amount += signed
+	default:
+		closeQty := minFloat(qty, math.Abs(pos.Amount))
+		dir := 1.0
+		if pos.Amount < 0 {
+			dir = -1
+		}
+		realized = closeQty * (price - pos.EntryPrice) * dir
+		pos.Amount += signed
+		if math.Abs(pos.Amount) <= qtyEpsilon {
+			pos.Amount = 0
+			pos.EntryPrice = 0
+		} else if qty > closeQty {
+			pos.EntryPrice = price
+		}
+	}
+	pos.RealizedPnL += realized
+	rate := e.cfg.TakerFeeRate
+	if maker {
+		rate = e.cfg.MakerFeeRate
+	}
+	commission := price * qty * rate
+	e.wallet += realized - commission
+
+	tradeID := e.nextTradeID
+	e.nextTradeID++
+	return []outbound{
+		e.orderEventLocked(o, "TRADE", qty, price, realized, commission, maker, tradeID),
+		e.accountEventLocked(st),
+		e.aggTradeEventLocked(o, price, qty, maker, tradeID),
+	}
+}
+
+// aggTradeEventLocked 生成公开成交流 aggTrade；m 表示买方是否为挂单方。
+func (e *Exchange) aggTradeEventLocked(o *Order, price, qty float64, maker bool, tradeID int64) outbound {
+	now := e.nowMillis()
+	data, _ := json.Marshal(map[string]interface{}{
+		"e": "aggTrade",
+		"E": now,
+		"s": o.Symbol,
+		"a": tradeID,
+		"p": fmtFloat(price),
+		"q": fmtFloat(qty),
+		"f": tradeID,
+		"l": tradeID,
+		"T": now,
+		"m": (o.Side == "BUY") == maker,
+	})
+	return outbound{kind: "aggTrade", symbol: o.Symbol, data: data}
+}
+
+// depthDiffLocked 计算聚合深度（外部流动性 + 挂单剩余量）相对上次推送的变化，生成 depthUpdate。
+func (e *Exchange) depthDiffLocked(st *symbolState) []outbound {
+	bids, asks := e.aggregatedLocked(st)
+	bidDiff := diffLevels(st.pubBids, bids)
+	askDiff := diffLevels(st.pubAsks, asks)
+	if len(bidDiff) == 0 && len(askDiff) == 0 {
+		return nil
+	}
+	st.pubBids, st.pubAsks = bids, asks
+	prev := st.updateID
+	st.updateID++
+	now := e.nowMillis()
+	payload := map[string]interface{}{
+		"e":  "depthUpdate",
+		"E":  now,
+		"T":  now,
+		"s":  st.spec.Symbol,
+		"U":  st.updateID,
+		"u":  st.updateID,
+		"pu": prev,
+		"b":  bidDiff,
+		"a":  askDiff,
+	}
+	raw, _ := json.Marshal(payload)
+	return []outbound{{kind: "depth", symbol: st.spec.Symbol, data: raw}}
+}
+
+func (e *Exchange) aggregatedLocked(st *symbolState) (map[float64]float64, map[float64]float64) {
+	bids := make(map[float64]float64, len(st.bids))
+	asks := make(map[float64]float64, len(st.asks))
+	for p, q := range st.bids {
+		bids[p] = q
+	}
+	for p, q := range st.asks {
+		asks[p] = q
+	}
+	for _, o := range e.orders {
+		if o.Symbol != st.spec.Symbol || !isOpen(o.Status) || o.Type != "LIMIT" {
+			continue
+		}
+		if o.Side == "BUY" {
+			bids[o.Price] += o.Remaining()
+		} else {
+			asks[o.Price] += o.Remaining()
+		}
+	}
+	return bids, asks
+}
+
+func (e *Exchange) orderEventLocked(o *Order, execType string, lastQty, lastPrice, realized, commission float64, maker bool, tradeID int64) outbound {
+	now := e.nowMillis()
+	payload := map[string]interface{}{
+		"e": "ORDER_TRADE_UPDATE",
+		"E": now,
+		"T": now,
+		"o": map[string]interface{}{
+			"s":  o.Symbol,
+			"c":  o.ClientOrderID,
+			"S":  o.Side,
+			"o":  o.Type,
+			"f":  o.TimeInForce,
+			"q":  fmtFloat(o.OrigQty),
+			"p":  fmtFloat(o.Price),
+			"ap": fmtFloat(o.AvgPrice()),
+			"sp": "0",
+			"x":  execType,
+			"X":  o.Status,
+			"i":  o.OrderID,
+			"l":  fmtFloat(lastQty),
+			"z":  fmtFloat(o.ExecutedQty),
+			"L":  fmtFloat(lastPrice),
+			"N":  e.cfg.Asset,
+			"n":  fmtFloat(commission),
+			"T":  now,
+			"t":  tradeID,
+			"b":  "0",
+			"a":  "0",
+			"m":  maker,
+			"R":  o.ReduceOnly,
+			"wt": "CONTRACT_PRICE",
+			"ot": o.Type,
+			"ps": "BOTH",
+			"cp": false,
+			"rp": fmtFloat(realized),
+			"pP": false,
+			"si": 0,
+			"ss": 0,
+		},
+	}
+	raw, _ := json.Marshal(payload)
+	return outbound{kind: "user", symbol: o.Symbol, data: raw}
+}
+
+func (e *Exchange) accountEventLocked(st *symbolState) outbound {
+	now := e.nowMillis()
+	pos := st.position
+	payload := map[string]interface{}{
+		"e": "ACCOUNT_UPDATE",
+		"E": now,
+		"T": now,
+		"a": map[string]interface{}{
+			"m": "ORDER",
+			"B": []map[string]interface{}{{
+				"a":  e.cfg.Asset,
+				"wb": fmtFloat(e.wallet),
+				"cw": fmtFloat(e.wallet),
+				"bc": "0",
+			}},
+			"P": []map[string]interface{}{{
+				"s":  pos.Symbol,
+				"pa": fmtFloat(pos.Amount),
+				"ep": fmtFloat(pos.EntryPrice),
+				"cr": fmtFloat(pos.RealizedPnL),
+				"up": fmtFloat(e.unrealizedLocked(st)),
+				"mt": "cross",
+				"iw": "0",
+				"ps": "BOTH",
+			}},
+		},
+	}
+	raw, _ := json.Marshal(payload)
+	return outbound{kind: "user", symbol: pos.Symbol, data: raw}
+}
+
+// crossableQtyLocked 返回新订单可立即与外部流动性成交的数量。
+func (e *Exchange) crossableQtyLocked(st *symbolState, o *Order) float64 {
+	total := 0.0
+	if o.Side == "BUY" {
+		for p, q := range st.asks {
+			if o.Type == "MARKET" || p <= o.Price {
+				total += q
+			}
+		}
+	} else {
+		for p, q := range st.bids {
+			if o.Type == "MARKET" || p >= o.Price {
+				total += q
+			}
+		}
+	}
+	return total
+}
+
+func (e *Exchange) bestOppositeLocked(st *symbolState, side string) float64 {
+	if side == "BUY" {
+		if ps := sortedPrices(st.asks, true); len(ps) > 0 {
+			return ps[0]
+		}
+		return 0
+	}
+	if ps := sortedPrices(st.bids, false); len(ps) > 0 {
+		return ps[0]
+	}
+	return 0
+}
+
+// markPriceLocked 以外部盘口中间价作为标记价格，缺失时退化为最近成交价。
+func (e *Exchange) markPriceLocked(st *symbolState) float64 {
+	bid := e.bestOppositeLocked(st, "SELL")
+	ask := e.bestOppositeLocked(st, "BUY")
+	if bid > 0 && ask > 0 {
+		return (bid + ask) / 2
+	}
+	return st.lastTrade
+}
+
+func (e *Exchange) unrealizedLocked(st *symbolState) float64 {
+	mark := e.markPriceLocked(st)
+	if mark <= 0 || st.position.Amount == 0 {
+		return 0
+	}
+	return (mark - st.position.EntryPrice) * st.position.Amount
+}
+
+// restingLocked 返回某方向的挂单，按价格优先、时间优先排序。
+func (e *Exchange) restingLocked(symbol, side string) []*Order {
+	var out []*Order
+	for _, o := range e.orders {
+		if o.Symbol == symbol && o.Side == side && o.Type == "LIMIT" && isOpen(o.Status) {
+			out = append(out, o)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Price != out[j].Price {
+			if side == "BUY" {
+				return out[i].Price > out[j].Price
+			}
+			return out[i].Price < out[j].Price
+		}
+		return out[i].OrderID < out[j].OrderID
+	})
+	return out
+}
+
+func (e *Exchange) sortedOrdersLocked() []*Order {
+	out := make([]*Order, 0, len(e.orders))
+	for _, o := range e.orders {
+		out = append(out, o)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].OrderID < out[j].OrderID })
+	return out
+}
+
+func (e *Exchange) findOrderLocked(symbol string, orderID int64, clientID string) *Order {
+	for _, o := range e.orders {
+		if o.Symbol != symbol {
+			continue
+		}
+		if orderID != 0 && o.OrderID == orderID {
+			return o
+		}
+		if orderID == 0 && clientID != "" && o.ClientOrderID == clientID {
+			return o
+		}
+	}
+	return nil
+}
+
+func diffLevels(prev, next map[float64]float64) [][]string {
+	out := [][]string{}
+	for _, p := range sortedPrices(next, true) {
+		if q := next[p]; prev[p] != q {
+			out = append(out, []string{fmtFloat(p), fmtFloat(q)})
+		}
+	}
+	for _, p := range sortedPrices(prev, true) {
+		if _, ok := next[p]; !ok {
+			out = append(out, []string{fmtFloat(p), "0"})
+		}
+	}
+	return out
+}
+
+func sortedPrices(levels map[float64]float64, ascending bool) []float64 {
+	prices := make([]float64, 0, len(levels))
+	for p := range levels {
+		prices = append(prices, p)
+	}
+	if ascending {
+		sort.Float64s(prices)
+	} else {
+		sort.Sort(sort.Reverse(sort.Float64Slice(prices)))
+	}
+	return prices
+}
+
+// onGrid 判断 v 是否为 step 的整数倍（允许浮点误差）。
+func onGrid(v, step float64) bool {
+	if step <= 0 {
+		return true
+	}
+	n := v / step
+	return math.Abs(n-math.Round(n)) < 1e-6
+}
+
+// fmtFloat 输出十进制字符串，先抹掉累加产生的浮点尾差。
+func fmtFloat(v float64) string {
+	v = math.Round(v*1e10) / 1e10
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
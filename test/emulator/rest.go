@@ -0,0 +1,573 @@
+package emulator
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"market-maker-go/gateway"
+)
+
+var (
+	errInvalidAPIKey   = &apiError{HTTPStatus: 401, Code: -2015, Msg: "Invalid API-key, IP, or permissions for action."}
+	errSignature       = &apiError{HTTPStatus: 400, Code: -1022, Msg: "Signature for this request is not valid."}
+	errRecvWindow      = &apiError{HTTPStatus: 400, Code: -1021, Msg: "Timestamp for this request is outside of the recvWindow."}
+	errListenKeyAbsent = &apiError{HTTPStatus: 400, Code: -1125, Msg: "This listenKey does not exist."}
+	errMethod          = &apiError{HTTPStatus: 405, Code: -1000, Msg: "Method not allowed."}
+)
+
+func errMandatory(name string) *apiError {
+	return &apiError{HTTPStatus: 400, Code: -1102, Msg: fmt.Sprintf("Mandatory parameter '%s' was not sent, was empty/null, or malformed.", name)}
+}
+
+// authenticate 校验 API key；signed 为 true 时额外用 gateway.SignParams 复算签名并检查 recvWindow。
+func (e *Exchange) authenticate(w http.ResponseWriter, r *http.Request, signed bool) (map[string]string, bool) {
+	if r.Header.Get("X-MBX-APIKEY") != e.cfg.APIKey {
+		writeError(w, errInvalidAPIKey)
+		return nil, false
+	}
+	if err := r.ParseForm(); err != nil {
+		writeError(w, &apiError{HTTPStatus: 400, Code: -1100, Msg: err.Error()})
+		return nil, false
+	}
+	params := make(map[string]string, len(r.Form))
+	for k, v := range r.Form {
+		if len(v) > 0 {
+			params[k] = v[0]
+		}
+	}
+	if !signed {
+		return params, true
+	}
+	if apiErr := e.verifySignature(params); apiErr != nil {
+		writeError(w, apiErr)
+		return nil, false
+	}
+	return params, true
+}
+
+// verifySignature 用 gateway.SignParams 复算 HMAC 签名并校验 timestamp/recvWindow；通过后移除 signature。
+func (e *Exchange) verifySignature(params map[string]string) *apiError {
+	sig := params["signature"]
+	delete(params, "signature")
+	if sig == "" {
+		return errMandatory("signature")
+	}
+	tsRaw, ok := params["timestamp"]
+	if !ok {
+		return errMandatory("timestamp")
+	}
+	signParams := make(map[string]string, len(params))
+	for k, v := range params {
+		signParams[k] = v
+	}
+	_, expected := gateway.SignParams(signParams, e.cfg.Secret)
+	if !hmac.Equal([]byte(expected), []byte(sig)) {
+		return errSignature
+	}
+	return e.checkTimestamp(tsRaw, params["recvWindow"])
+}
+
+func (e *Exchange) checkTimestamp(tsRaw, recvWindowRaw string) *apiError {
+	ts, err := strconv.ParseInt(tsRaw, 10, 64)
+	if err != nil {
+		return errMandatory("timestamp")
+	}
+	recvWindow := int64(5000)
+	if n, err := strconv.ParseInt(recvWindowRaw, 10, 64); err == nil && n > 0 {
+		recvWindow = n
+	}
+	now := e.nowMillis()
+	if ts > now+1000 || now-ts > recvWindow {
+		return errRecvWindow
+	}
+	return nil
+}
+
+func (e *Exchange) handleOrder(w http.ResponseWriter, r *http.Request) {
+	params, ok := e.authenticate(w, r, true)
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		e.placeOrder(w, params)
+	case http.MethodPut:
+		e.modifyOrder(w, params)
+	case http.MethodDelete:
+		e.cancelOrder(w, params)
+	case http.MethodGet:
+		e.queryOrder(w, params)
+	default:
+		writeError(w, errMethod)
+	}
+}
+
+func (e *Exchange) placeOrder(w http.ResponseWriter, params map[string]string) {
+	resp, apiErr := e.doPlace(params)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (e *Exchange) cancelOrder(w http.ResponseWriter, params map[string]string) {
+	resp, apiErr := e.doCancel(params)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (e *Exchange) modifyOrder(w http.ResponseWriter, params map[string]string) {
+	resp, apiErr := e.doModify(params)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (e *Exchange) queryOrder(w http.ResponseWriter, params map[string]string) {
+	symbol := strings.ToUpper(params["symbol"])
+	orderID, _ := strconv.ParseInt(params["orderId"], 10, 64)
+	clientID := params["origClientOrderId"]
+	e.mu.Lock()
+	o := e.findOrderLocked(symbol, orderID, clientID)
+	var resp map[string]interface{}
+	if o != nil {
+		resp = orderJSON(o)
+	}
+	e.mu.Unlock()
+	if resp == nil {
+		writeError(w, errOrderNotExist)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// doPlace 解析下单参数并撮合（REST 与 WS API 共用）。
+func (e *Exchange) doPlace(params map[string]string) (map[string]interface{}, *apiError) {
+	o := &Order{
+		Symbol:        strings.ToUpper(params["symbol"]),
+		Side:          strings.ToUpper(params["side"]),
+		Type:          strings.ToUpper(params["type"]),
+		TimeInForce:   strings.ToUpper(params["timeInForce"]),
+		ClientOrderID: params["newClientOrderId"],
+		ReduceOnly:    params["reduceOnly"] == "true",
+	}
+	if o.Side != "BUY" && o.Side != "SELL" {
+		return nil, errMandatory("side")
+	}
+	qty, err := strconv.ParseFloat(params["quantity"], 64)
+	if err != nil || qty <= 0 {
+		return nil, errMandatory("quantity")
+	}
+	o.OrigQty = qty
+	switch o.Type {
+	case "LIMIT":
+		price, err := strconv.ParseFloat(params["price"], 64)
+		if err != nil || price <= 0 {
+			return nil, errMandatory("price")
+		}
+		o.Price = price
+		switch o.TimeInForce {
+		case "GTC", "IOC", "FOK", "GTX":
+		default:
+			return nil, errMandatory("timeInForce")
+		}
+	case "MARKET":
+		o.TimeInForce = ""
+	default:
+		return nil, errMandatory("type")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st := e.symbols[o.Symbol]
+	if st == nil {
+		return nil, errInvalidSymbol
+	}
+	out, apiErr := e.placeLocked(st, o)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	e.broadcastLocked(out)
+	return orderJSON(o), nil
+}
+
+// doCancel 按 orderId 或 origClientOrderId 撤单（REST 与 WS API 共用）。
+func (e *Exchange) doCancel(params map[string]string) (map[string]interface{}, *apiError) {
+	symbol := strings.ToUpper(params["symbol"])
+	orderID, _ := strconv.ParseInt(params["orderId"], 10, 64)
+	clientID := params["origClientOrderId"]
+	if orderID == 0 && clientID == "" {
+		return nil, errMandatory("orderId")
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st := e.symbols[symbol]
+	if st == nil {
+		return nil, errInvalidSymbol
+	}
+	o := e.findOrderLocked(symbol, orderID, clientID)
+	if o == nil || !isOpen(o.Status) {
+		return nil, errUnknownOrder
+	}
+	e.broadcastLocked(e.cancelLocked(st, o))
+	return orderJSON(o), nil
+}
+
+// doModify 修改挂单价格/数量，保留 orderId（PUT /fapi/v1/order 与 WS API order.modify）。
+func (e *Exchange) doModify(params map[string]string) (map[string]interface{}, *apiError) {
+	symbol := strings.ToUpper(params["symbol"])
+	orderID, _ := strconv.ParseInt(params["orderId"], 10, 64)
+	clientID := params["origClientOrderId"]
+	if orderID == 0 && clientID == "" {
+		return nil, errMandatory("orderId")
+	}
+	price, err := strconv.ParseFloat(params["price"], 64)
+	if err != nil || price <= 0 {
+		return nil, errMandatory("price")
+	}
+	qty, err := strconv.ParseFloat(params["quantity"], 64)
+	if err != nil || qty <= 0 {
+		return nil, errMandatory("quantity")
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st := e.symbols[symbol]
+	if st == nil {
+		return nil, errInvalidSymbol
+	}
+	o := e.findOrderLocked(symbol, orderID, clientID)
+	if o == nil || !isOpen(o.Status) {
+		return nil, errOrderNotExist
+	}
+	if side := strings.ToUpper(params["side"]); side != "" && side != o.Side {
+		return nil, errMandatory("side")
+	}
+	out, apiErr := e.modifyLocked(st, o, price, qty)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	e.broadcastLocked(out)
+	return orderJSON(o), nil
+}
+
+// handleBatchOrders 处理 /fapi/v1/batchOrders：POST batchOrders（≤5）逐笔下单，
+// DELETE orderIdList/origClientOrderIdList（≤10）逐笔撤单；响应数组中失败项为 {"code","msg"}。
+func (e *Exchange) handleBatchOrders(w http.ResponseWriter, r *http.Request) {
+	params, ok := e.authenticate(w, r, true)
+	if !ok {
+		return
+	}
+	var results []interface{}
+	switch r.Method {
+	case http.MethodPost:
+		var items []map[string]string
+		if err := json.Unmarshal([]byte(params["batchOrders"]), &items); err != nil || len(items) == 0 || len(items) > 5 {
+			writeError(w, errBatchParam("batchOrders"))
+			return
+		}
+		for _, item := range items {
+			resp, apiErr := e.doPlace(item)
+			results = append(results, batchItem(resp, apiErr))
+		}
+	case http.MethodDelete:
+		var orderIDs []int64
+		var clientIDs []string
+		if raw := params["orderIdList"]; raw != "" {
+			if err := json.Unmarshal([]byte(raw), &orderIDs); err != nil || len(orderIDs) > 10 {
+				writeError(w, errBatchParam("orderIdList"))
+				return
+			}
+		} else if err := json.Unmarshal([]byte(params["origClientOrderIdList"]), &clientIDs); err != nil || len(clientIDs) > 10 {
+			writeError(w, errBatchParam("origClientOrderIdList"))
+			return
+		}
+		for _, id := range orderIDs {
+			resp, apiErr := e.doCancel(map[string]string{"symbol": params["symbol"], "orderId": strconv.FormatInt(id, 10)})
+			results = append(results, batchItem(resp, apiErr))
+		}
+		for _, cid := range clientIDs {
+			resp, apiErr := e.doCancel(map[string]string{"symbol": params["symbol"], "origClientOrderId": cid})
+			results = append(results, batchItem(resp, apiErr))
+		}
+	default:
+		writeError(w, errMethod)
+		return
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+func errBatchParam(name string) *apiError {
+	return &apiError{HTTPStatus: 400, Code: -1130, Msg: fmt.Sprintf("Data sent for parameter '%s' is not valid.", name)}
+}
+
+func batchItem(resp map[string]interface{}, apiErr *apiError) interface{} {
+	if apiErr != nil {
+		return map[string]interface{}{"code": apiErr.Code, "msg": apiErr.Msg}
+	}
+	return resp
+}
+
+func (e *Exchange) handleOpenOrders(w http.ResponseWriter, r *http.Request) {
+	params, ok := e.authenticate(w, r, true)
+	if !ok {
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, errMethod)
+		return
+	}
+	symbol := strings.ToUpper(params["symbol"])
+	e.mu.Lock()
+	out := make([]map[string]interface{}, 0)
+	for _, o := range e.sortedOrdersLocked() {
+		if isOpen(o.Status) && (symbol == "" || o.Symbol == symbol) {
+			out = append(out, orderJSON(o))
+		}
+	}
+	e.mu.Unlock()
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (e *Exchange) handleCancelAll(w http.ResponseWriter, r *http.Request) {
+	params, ok := e.authenticate(w, r, true)
+	if !ok {
+		return
+	}
+	if r.Method != http.MethodDelete {
+		writeError(w, errMethod)
+		return
+	}
+	symbol := strings.ToUpper(params["symbol"])
+	e.mu.Lock()
+	st := e.symbols[symbol]
+	if st == nil {
+		e.mu.Unlock()
+		writeError(w, errInvalidSymbol)
+		return
+	}
+	var out []outbound
+	for _, o := range e.sortedOrdersLocked() {
+		if o.Symbol == symbol && isOpen(o.Status) {
+			out = append(out, e.cancelLocked(st, o)...)
+		}
+	}
+	e.broadcastLocked(out)
+	e.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"code": 200, "msg": "The operation of cancel all open order is done."})
+}
+
+// handleTime 返回模拟器时钟（Config.Now），供客户端校时。
+func (e *Exchange) handleTime(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, errMethod)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"serverTime": e.nowMillis()})
+}
+
+func (e *Exchange) handleDepth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, errMethod)
+		return
+	}
+	symbol := strings.ToUpper(r.URL.Query().Get("symbol"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = 500
+	}
+	e.mu.Lock()
+	st := e.symbols[symbol]
+	if st == nil {
+		e.mu.Unlock()
+		writeError(w, errInvalidSymbol)
+		return
+	}
+	now := e.nowMillis()
+	resp := map[string]interface{}{
+		"lastUpdateId": st.updateID,
+		"E":            now,
+		"T":            now,
+		"bids":         levelsJSON(st.pubBids, false, limit),
+		"asks":         levelsJSON(st.pubAsks, true, limit),
+	}
+	e.mu.Unlock()
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (e *Exchange) handleListenKey(w http.ResponseWriter, r *http.Request) {
+	params, ok := e.authenticate(w, r, false)
+	if !ok {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	switch r.Method {
+	case http.MethodPost:
+		// 与 Binance 一致：已有有效 listenKey 时直接返回并延长有效期
+		for key, active := range e.listenKeys {
+			if active {
+				writeJSON(w, http.StatusOK, map[string]string{"listenKey": key})
+				return
+			}
+		}
+		e.nextKey++
+		key := fmt.Sprintf("emulistenkey%04d", e.nextKey)
+		e.listenKeys[key] = true
+		writeJSON(w, http.StatusOK, map[string]string{"listenKey": key})
+	case http.MethodPut:
+		key := params["listenKey"]
+		if key != "" && !e.listenKeys[key] {
+			writeError(w, errListenKeyAbsent)
+			return
+		}
+		if key == "" && !e.hasListenKeyLocked() {
+			writeError(w, errListenKeyAbsent)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{})
+	case http.MethodDelete:
+		for key := range e.listenKeys {
+			e.listenKeys[key] = false
+		}
+		writeJSON(w, http.StatusOK, map[string]string{})
+	default:
+		writeError(w, errMethod)
+	}
+}
+
+func (e *Exchange) hasListenKeyLocked() bool {
+	for _, active := range e.listenKeys {
+		if active {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Exchange) handleAccount(w http.ResponseWriter, r *http.Request) {
+	if _, ok := e.authenticate(w, r, true); !ok {
+		return
+	}
+	e.mu.Lock()
+	totalUnreal := 0.0
+	positions := make([]map[string]interface{}, 0, len(e.symbols))
+	for _, sym := range e.symbolNamesLocked() {
+		st := e.symbols[sym]
+		unreal := e.unrealizedLocked(st)
+		totalUnreal += unreal
+		positions = append(positions, map[string]interface{}{
+			"symbol":           sym,
+			"leverage":         strconv.Itoa(st.spec.Leverage),
+			"entryPrice":       fmtFloat(st.position.EntryPrice),
+			"positionAmt":      fmtFloat(st.position.Amount),
+			"unrealizedProfit": fmtFloat(unreal),
+			"positionSide":     "BOTH",
+		})
+	}
+	wallet := e.wallet
+	e.mu.Unlock()
+	margin := wallet + totalUnreal
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"totalWalletBalance":    fmtFloat(wallet),
+		"totalUnrealizedProfit": fmtFloat(totalUnreal),
+		"availableBalance":      fmtFloat(margin),
+		"assets": []map[string]interface{}{{
+			"asset":             e.cfg.Asset,
+			"walletBalance":     fmtFloat(wallet),
+			"availableBalance":  fmtFloat(margin),
+			"marginBalance":     fmtFloat(margin),
+			"maxWithdrawAmount": fmtFloat(margin),
+		}},
+		"positions": positions,
+	})
+}
+
+func (e *Exchange) handlePositionRisk(w http.ResponseWriter, r *http.Request) {
+	params, ok := e.authenticate(w, r, true)
+	if !ok {
+		return
+	}
+	symbol := strings.ToUpper(params["symbol"])
+	e.mu.Lock()
+	out := make([]map[string]interface{}, 0, len(e.symbols))
+	for _, sym := range e.symbolNamesLocked() {
+		if symbol != "" && sym != symbol {
+			continue
+		}
+		st := e.symbols[sym]
+		out = append(out, map[string]interface{}{
+			"symbol":           sym,
+			"positionAmt":      fmtFloat(st.position.Amount),
+			"entryPrice":       fmtFloat(st.position.EntryPrice),
+			"markPrice":        fmtFloat(e.markPriceLocked(st)),
+			"unRealizedProfit": fmtFloat(e.unrealizedLocked(st)),
+			"marginType":       "cross",
+			"positionSide":     "BOTH",
+			"leverage":         strconv.Itoa(st.spec.Leverage),
+		})
+	}
+	e.mu.Unlock()
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (e *Exchange) symbolNamesLocked() []string {
+	names := make([]string, 0, len(e.symbols))
+	for sym := range e.symbols {
+		names = append(names, sym)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func orderJSON(o *Order) map[string]interface{} {
+	return map[string]interface{}{
+		"orderId":       o.OrderID,
+		"clientOrderId": o.ClientOrderID,
+		"symbol":        o.Symbol,
+		"side":          o.Side,
+		"type":          o.Type,
+		"origType":      o.Type,
+		"timeInForce":   o.TimeInForce,
+		"price":         fmtFloat(o.Price),
+		"origQty":       fmtFloat(o.OrigQty),
+		"executedQty":   fmtFloat(o.ExecutedQty),
+		"cumQuote":      fmtFloat(o.CumQuote),
+		"avgPrice":      fmtFloat(o.AvgPrice()),
+		"reduceOnly":    o.ReduceOnly,
+		"positionSide":  "BOTH",
+		"status":        o.Status,
+		"updateTime":    o.UpdateTime,
+	}
+}
+
+func levelsJSON(levels map[float64]float64, ascending bool, limit int) [][]string {
+	out := [][]string{}
+	for _, p := range sortedPrices(levels, ascending) {
+		if len(out) >= limit {
+			break
+		}
+		out = append(out, []string{fmtFloat(p), fmtFloat(levels[p])})
+	}
+	return out
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err *apiError) {
+	writeJSON(w, err.HTTPStatus, err)
+}
@@ -0,0 +1,127 @@
+package emulator
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// wsClient 对应一条 combined stream 连接。
+type wsClient struct {
+	conn    *websocket.Conn
+	streams []string
+	send    chan []byte
+	once    sync.Once
+	done    chan struct{}
+}
+
+func (c *wsClient) close() {
+	c.once.Do(func() {
+		close(c.done)
+		_ = c.conn.Close()
+	})
+}
+
+func (c *wsClient) writeLoop() {
+	for {
+		select {
+		case msg := <-c.send:
+			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				c.close()
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// handleStream 处理 /stream?streams=a/b/c，支持 <symbol>@depth[@100ms]、<symbol>@aggTrade 与 listenKey 用户流。
+func (e *Exchange) handleStream(w http.ResponseWriter, r *http.Request) {
+	raw := r.URL.Query().Get("streams")
+	if raw == "" {
+		writeError(w, errMandatory("streams"))
+		return
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := &wsClient{
+		conn:    conn,
+		streams: strings.Split(raw, "/"),
+		send:    make(chan []byte, 1024),
+		done:    make(chan struct{}),
+	}
+	e.wsMu.Lock()
+	e.clients[c] = struct{}{}
+	e.wsMu.Unlock()
+	go c.writeLoop()
+	go func() {
+		defer func() {
+			c.close()
+			e.wsMu.Lock()
+			delete(e.clients, c)
+			e.wsMu.Unlock()
+		}()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+// broadcastLocked 将事件按订阅推送给各连接；需持有 e.mu 以保证各连接收到的 update ID 有序。
+// 发送缓冲满的慢消费者会被断开（与 Binance 行为一致）。
+func (e *Exchange) broadcastLocked(events []outbound) {
+	if len(events) == 0 {
+		return
+	}
+	e.wsMu.Lock()
+	defer e.wsMu.Unlock()
+	for c := range e.clients {
+		for _, ev := range events {
+			stream := e.matchStreamLocked(c, ev)
+			if stream == "" {
+				continue
+			}
+			msg, _ := json.Marshal(struct {
+				Stream string          `json:"stream"`
+				Data   json.RawMessage `json:"data"`
+			}{Stream: stream, Data: ev.data})
+			select {
+			case c.send <- msg:
+			default:
+				go c.close()
+			}
+		}
+	}
+}
+
+func (e *Exchange) matchStreamLocked(c *wsClient, ev outbound) string {
+	for _, name := range c.streams {
+		switch ev.kind {
+		case "depth":
+			if strings.HasPrefix(name, strings.ToLower(ev.symbol)+"@depth") {
+				return name
+			}
+		case "aggTrade":
+			if name == strings.ToLower(ev.symbol)+"@aggTrade" {
+				return name
+			}
+		case "user":
+			if e.listenKeys[name] {
+				return name
+			}
+		}
+	}
+	return ""
+}
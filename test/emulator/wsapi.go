@@ -0,0 +1,113 @@
+package emulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+type wsAPIRequest struct {
+	ID     string                 `json:"id"`
+	Method string                 `json:"method"`
+	Params map[string]interface{} `json:"params"`
+}
+
+// handleWSAPI 处理 /ws-fapi/v1 交易 API：order.place / order.cancel / order.modify。
+// 仅支持逐条 HMAC 签名（params 内携带 apiKey/timestamp/signature）；session.logon 需 Ed25519 key，模拟器不支持。
+func (e *Exchange) handleWSAPI(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := &wsClient{
+		conn: conn,
+		send: make(chan []byte, 1024),
+		done: make(chan struct{}),
+	}
+	e.wsMu.Lock()
+	e.apiClients[c] = struct{}{}
+	e.wsMu.Unlock()
+	go c.writeLoop()
+	defer func() {
+		c.close()
+		e.wsMu.Lock()
+		delete(e.apiClients, c)
+		e.wsMu.Unlock()
+	}()
+	// 请求按到达顺序串行处理
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		resp := e.serveWSAPI(msg)
+		select {
+		case c.send <- resp:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (e *Exchange) serveWSAPI(msg []byte) []byte {
+	var req wsAPIRequest
+	dec := json.NewDecoder(bytes.NewReader(msg))
+	dec.UseNumber()
+	if err := dec.Decode(&req); err != nil {
+		return wsAPIReply("", nil, &apiError{HTTPStatus: 400, Code: -1100, Msg: err.Error()})
+	}
+	params := make(map[string]string, len(req.Params))
+	for k, v := range req.Params {
+		params[k] = fmt.Sprint(v)
+	}
+	if req.Method == "session.logon" {
+		return wsAPIReply(req.ID, nil, &apiError{HTTPStatus: 400, Code: -4056, Msg: "HMAC_SHA256 API key is not supported."})
+	}
+	if params["apiKey"] != e.cfg.APIKey {
+		return wsAPIReply(req.ID, nil, errInvalidAPIKey)
+	}
+	if apiErr := e.verifySignature(params); apiErr != nil {
+		return wsAPIReply(req.ID, nil, apiErr)
+	}
+	var (
+		result map[string]interface{}
+		apiErr *apiError
+	)
+	switch req.Method {
+	case "order.place":
+		result, apiErr = e.doPlace(params)
+	case "order.cancel":
+		result, apiErr = e.doCancel(params)
+	case "order.modify":
+		result, apiErr = e.doModify(params)
+	default:
+		apiErr = &apiError{HTTPStatus: 400, Code: -1020, Msg: "Unsupported method " + req.Method + "."}
+	}
+	return wsAPIReply(req.ID, result, apiErr)
+}
+
+func wsAPIReply(id string, result map[string]interface{}, apiErr *apiError) []byte {
+	resp := map[string]interface{}{"id": id, "status": http.StatusOK}
+	if apiErr != nil {
+		resp["status"] = apiErr.HTTPStatus
+		resp["error"] = map[string]interface{}{"code": apiErr.Code, "msg": apiErr.Msg}
+	} else {
+		resp["result"] = result
+	}
+	body, _ := json.Marshal(resp)
+	return body
+}
+
+// DisconnectWSAPI 断开所有 WS API 连接，用于测试下单通道回退与重连。
+func (e *Exchange) DisconnectWSAPI() {
+	e.wsMu.Lock()
+	clients := make([]*wsClient, 0, len(e.apiClients))
+	for c := range e.apiClients {
+		clients = append(clients, c)
+	}
+	e.wsMu.Unlock()
+	for _, c := range clients {
+		c.close()
+	}
+}
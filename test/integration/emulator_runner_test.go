@@ -0,0 +1,195 @@
+package integration
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"market-maker-go/gateway"
+	"market-maker-go/inventory"
+	"market-maker-go/market"
+	"market-maker-go/order"
+	"market-maker-go/sim"
+	"market-maker-go/strategy"
+	"market-maker-go/test/emulator"
+)
+
+// emulatorOrderGateway 与 cmd/runner 的 restOrderGateway 一致走 BinanceRESTClient，
+// 但以 order.Manager 的订单 ID 作为 newClientOrderId，便于用户流回报直接回写状态。
+type emulatorOrderGateway struct {
+	client *gateway.BinanceRESTClient
+	mu     sync.Mutex
+	byID   map[string]string // clientOrderId -> exchange orderId
+}
+
+func (g *emulatorOrderGateway) Place(o order.Order) (string, error) {
+	tif := o.TimeInForce
+	if tif == "" {
+		tif = "GTC"
+	}
+	exchangeID, err := g.client.PlaceLimit(o.Symbol, o.Side, tif, o.Price, o.Quantity, o.ReduceOnly, o.PostOnly, o.ID)
+	if err != nil {
+		return "", err
+	}
+	g.mu.Lock()
+	g.byID[o.ID] = exchangeID
+	g.mu.Unlock()
+	return exchangeID, nil
+}
+
+func (g *emulatorOrderGateway) Cancel(orderID string) error {
+	g.mu.Lock()
+	exchangeID := g.byID[orderID]
+	g.mu.Unlock()
+	return g.client.CancelOrder("ETHUSDC", exchangeID)
+}
+
+type emulatorWSMux struct {
+	depth *gateway.DepthSynchronizer
+	user  *gateway.BinanceUserHandler
+}
+
+func (m *emulatorWSMux) OnDepth(symbol string, bid, ask float64) {}
+
+func (m *emulatorWSMux) OnTrade(symbol string, price, qty float64) {}
+
+func (m *emulatorWSMux) OnRawMessage(msg []byte) {
+	m.user.OnRawMessage(msg)
+	m.depth.OnRawMessage(msg)
+}
+
+// TestRunnerAgainstEmulator 使用真实 REST/WS 客户端连接离线交易所模拟器，
+// 覆盖 runner 的报价 -> 下单 -> 深度同步 -> 成交回报 -> 库存更新全链路。
+func TestRunnerAgainstEmulator(t *testing.T) {
+	const symbol = "ETHUSDC"
+	ex := emulator.New(emulator.Config{
+		APIKey:  "itest-key",
+		Secret:  "itest-secret",
+		Symbols: []emulator.SymbolSpec{{Symbol: symbol, TickSize: 0.01, StepSize: 0.001, MinNotional: 5}},
+	}).Start()
+	defer ex.Close()
+	ex.SetBook(symbol,
+		[]gateway.DepthLevel{{Price: 2999.99, Qty: 3}, {Price: 2999.50, Qty: 5}},
+		[]gateway.DepthLevel{{Price: 3000.01, Qty: 3}, {Price: 3000.50, Qty: 5}},
+	)
+
+	rest := &gateway.BinanceRESTClient{
+		BaseURL:      ex.URL(),
+		APIKey:       "itest-key",
+		Secret:       "itest-secret",
+		HTTPClient:   gateway.NewDefaultHTTPClient(),
+		RecvWindowMs: 5000,
+	}
+	lk := &gateway.ListenKeyClient{BaseURL: ex.URL(), APIKey: "itest-key", HTTPClient: gateway.NewListenKeyHTTPClient()}
+	listenKey, err := lk.NewListenKey()
+	if err != nil {
+		t.Fatalf("listenKey: %v", err)
+	}
+
+	gw := &emulatorOrderGateway{client: rest, byID: make(map[string]string)}
+	mgr := order.NewManager(gw)
+	constraints := order.SymbolConstraints{TickSize: 0.01, StepSize: 0.001, MinNotional: 5}
+	mgr.SetConstraints(map[string]order.SymbolConstraints{symbol: constraints})
+	inv := &inventory.Tracker{}
+	book := market.NewOrderBook()
+
+	depthSync := gateway.NewDepthSynchronizer(symbol, book, rest)
+	userHandler := &gateway.BinanceUserHandler{
+		OnOrderUpdate: func(o gateway.OrderUpdate) {
+			switch o.Status {
+			case "FILLED":
+				_ = mgr.Update(o.ClientOrderID, order.StatusFilled)
+			case "PARTIALLY_FILLED":
+				_ = mgr.Update(o.ClientOrderID, order.StatusPartial)
+			case "CANCELED":
+				_ = mgr.Update(o.ClientOrderID, order.StatusCanceled)
+			}
+		},
+		OnAccountUpdate: func(a gateway.AccountUpdate) {
+			for _, p := range a.Positions {
+				if strings.EqualFold(p.Symbol, symbol) {
+					inv.SetExposure(p.PositionAmt, p.EntryPrice)
+				}
+			}
+		},
+	}
+	ws := gateway.NewBinanceWSReal()
+	ws.BaseEndpoint = ex.WSEndpoint()
+	ws.MaxRetries = 0
+	connected := make(chan struct{}, 1)
+	ws.OnConnect(func() { connected <- struct{}{} })
+	_ = ws.SubscribeDepth(symbol)
+	_ = ws.SubscribeUserData(listenKey)
+	go func() { _ = ws.Run(&emulatorWSMux{depth: depthSync, user: userHandler}) }()
+	select {
+	case <-connected:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("ws not connected")
+	}
+	// 首次 depth 事件触发快照同步；用一次外部盘口变化驱动
+	time.Sleep(50 * time.Millisecond)
+	ex.SetBook(symbol,
+		[]gateway.DepthLevel{{Price: 2999.99, Qty: 4}, {Price: 2999.50, Qty: 5}},
+		[]gateway.DepthLevel{{Price: 3000.01, Qty: 4}, {Price: 3000.50, Qty: 5}},
+	)
+	waitUntil(t, func() bool { return depthSync.Synced() && book.Mid() > 0 })
+
+	engine, err := strategy.NewEngine(strategy.EngineConfig{MinSpread: 0.0004, BaseSize: 0.01, MaxDrift: 1})
+	if err != nil {
+		t.Fatalf("engine: %v", err)
+	}
+	runner := &sim.Runner{
+		Symbol:      symbol,
+		Engine:      engine,
+		Inv:         inv,
+		OrderMgr:    mgr,
+		Book:        book,
+		Constraints: constraints,
+	}
+	if err := runner.OnTick(book.Mid()); err != nil {
+		t.Fatalf("runner tick: %v", err)
+	}
+
+	open := ex.OpenOrders(symbol)
+	var bestBid *emulator.Order
+	var sells int
+	for i := range open {
+		o := open[i]
+		if _, ok := mgr.Status(o.ClientOrderID); !ok {
+			t.Fatalf("exchange order %s not tracked by manager", o.ClientOrderID)
+		}
+		if o.Side == "BUY" && (bestBid == nil || o.Price > bestBid.Price) {
+			bestBid = &open[i]
+		}
+		if o.Side == "SELL" {
+			sells++
+		}
+	}
+	if bestBid == nil || sells == 0 {
+		t.Fatalf("expected two-sided quotes on exchange, got %+v", open)
+	}
+	waitUntil(t, func() bool { return book.BidVolume(bestBid.Price) >= bestBid.OrigQty })
+
+	ex.Trade(symbol, bestBid.Price, bestBid.OrigQty)
+	waitUntil(t, func() bool {
+		st, ok := mgr.Status(bestBid.ClientOrderID)
+		return ok && st == order.StatusFilled
+	})
+	waitUntil(t, func() bool { return inv.NetExposure() == bestBid.OrigQty })
+	if pos := ex.Position(symbol); pos.Amount != bestBid.OrigQty || pos.EntryPrice != bestBid.Price {
+		t.Fatalf("unexpected exchange position %+v", pos)
+	}
+}
+
+func waitUntil(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("condition not met before timeout")
+}